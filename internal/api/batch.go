@@ -0,0 +1,230 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// MaxBatchSize is the largest number of requests sent in one batch.
+const MaxBatchSize = 50
+
+// ErrBatchUnsupported is returned by Batch when the endpoint rejects batch
+// requests as a whole. None of the requests were applied; send them one
+// by one instead.
+var ErrBatchUnsupported = errors.New("batch requests are not supported by this endpoint")
+
+// BatchRequest is one API request to be sent as part of a batch.
+type BatchRequest struct {
+	Method string
+	// Path is the resource path relative to BaseURL, as for Get or Post.
+	Path   string
+	Params url.Values
+	// Body, if not nil, is marshaled as the JSON request body.
+	Body interface{}
+}
+
+// BatchResult is the outcome of one request of a batch.
+type BatchResult struct {
+	Body json.RawMessage
+	Err  error
+}
+
+// Send sends a single request outside of a batch.
+func (c *Client) Send(ctx context.Context, r BatchRequest) (json.RawMessage, error) {
+	if r.Body == nil {
+		return c.do(ctx, r.Method, r.Path, r.Params, nil, "")
+	}
+	jsonBody, err := json.Marshal(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request body: %w", err)
+	}
+	return c.do(ctx, r.Method, r.Path, r.Params, bytes.NewReader(jsonBody), "application/json")
+}
+
+// Batch sends up to MaxBatchSize requests in one HTTP round trip to the
+// batch endpoint and returns their results in order. The returned error is
+// only set when the batch as a whole failed, in which case none of the
+// results are valid; ErrBatchUnsupported means it may be sent again
+// without batching. The batch itself is never retried, since a failed
+// attempt may have applied some of its requests. When a RetryBudget is
+// attached, requests whose own result shows they were not applied are sent
+// again on their own: those rejected with 429, and idempotent ones that
+// failed with 5xx.
+// POST /batch (multipart/mixed)
+func (c *Client) Batch(ctx context.Context, reqs []BatchRequest) ([]BatchResult, error) {
+	if len(reqs) > MaxBatchSize {
+		return nil, fmt.Errorf("batch of %d requests exceeds the limit of %d", len(reqs), MaxBatchSize)
+	}
+
+	base, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %w", err)
+	}
+
+	body, contentType, err := encodeBatch(base.Path, reqs)
+	if err != nil {
+		return nil, err
+	}
+	if c.Verbose {
+		for _, r := range reqs {
+			log.Printf(">>   %s %s\n", r.Method, r.Path)
+		}
+	}
+
+	// The batch endpoint is at the root of the API host, next to the
+	// version path (e.g. https://chat.googleapis.com/batch).
+	batchURL := *base
+	batchURL.Path = path.Join(path.Dir(base.Path), "batch")
+	respBody, header, err := c.roundTrip(ctx, http.MethodPost, batchURL.String(), bytes.NewReader(body), contentType, false)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && batchRejected(apiErr.Code) {
+		return nil, ErrBatchUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := decodeBatch(header.Get("Content-Type"), respBody, len(reqs))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range reqs {
+		if c.Retry != nil && resendable(r, results[i].Err) {
+			body, err := c.Send(ctx, r)
+			results[i] = BatchResult{Body: body, Err: err}
+			continue
+		}
+		if c.AfterRequest != nil {
+			c.AfterRequest(r.Method, r.Path, results[i].Body, results[i].Err)
+		}
+	}
+	return results, nil
+}
+
+// resendable reports whether r, which failed in a batch with err, can be
+// sent again without risk of applying it twice: it was rejected with 429,
+// or it is idempotent and failed with a retryable status.
+func resendable(r BatchRequest, err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusTooManyRequests ||
+		(apiErr.Retryable() && idempotent(r.Method, r.Params))
+}
+
+// batchRejected reports whether a batch request failed with a status that
+// means the endpoint does not accept batches at all.
+func batchRejected(code int) bool {
+	switch code {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// encodeBatch builds the multipart/mixed body of a batch request. Each part
+// is an HTTP request whose path starts with versionPath (e.g. "/v1"), with
+// the part's index as its Content-ID.
+func encodeBatch(versionPath string, reqs []BatchRequest) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for i, r := range reqs {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "application/http")
+		header.Set("Content-ID", "<"+strconv.Itoa(i)+">")
+		part, err := writer.CreatePart(header)
+		if err != nil {
+			return nil, "", fmt.Errorf("creating batch part: %w", err)
+		}
+
+		target := versionPath + "/" + strings.TrimLeft(r.Path, "/")
+		if len(r.Params) > 0 {
+			target += "?" + r.Params.Encode()
+		}
+		fmt.Fprintf(part, "%s %s HTTP/1.1\r\n", r.Method, target)
+		if r.Body != nil {
+			jsonBody, err := json.Marshal(r.Body)
+			if err != nil {
+				return nil, "", fmt.Errorf("marshaling request body: %w", err)
+			}
+			fmt.Fprintf(part, "Content-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(jsonBody), jsonBody)
+		} else {
+			fmt.Fprint(part, "\r\n")
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("closing multipart writer: %w", err)
+	}
+	return buf.Bytes(), "multipart/mixed; boundary=" + writer.Boundary(), nil
+}
+
+// decodeBatch parses the multipart/mixed response of a batch of n requests.
+// Parts are matched to requests by their Content-ID ("<response-{i}>").
+// Requests without a part in the response get an error result.
+func decodeBatch(contentType string, body []byte, n int) ([]BatchResult, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "multipart/mixed" {
+		return nil, fmt.Errorf("unexpected batch response type %q", contentType)
+	}
+
+	results := make([]BatchResult, n)
+	seen := make([]bool, n)
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading batch response: %w", err)
+		}
+
+		id := strings.Trim(part.Header.Get("Content-ID"), "<>")
+		i, err := strconv.Atoi(strings.TrimPrefix(id, "response-"))
+		if err != nil || i < 0 || i >= n {
+			return nil, fmt.Errorf("unexpected batch response part %q", id)
+		}
+
+		resp, err := http.ReadResponse(bufio.NewReader(part), nil)
+		if err != nil {
+			return nil, fmt.Errorf("reading batch response part %d: %w", i, err)
+		}
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading batch response part %d: %w", i, err)
+		}
+
+		seen[i] = true
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			results[i].Err = responseError(resp.StatusCode, respBody)
+		} else {
+			results[i].Body = json.RawMessage(respBody)
+		}
+	}
+
+	for i, ok := range seen {
+		if !ok {
+			results[i].Err = errors.New("the batch response has no result for this request")
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// batchResponse builds a multipart/mixed batch response body from parts,
+// each the Content-ID and the HTTP response of one part.
+func batchResponse(parts ...[2]string) string {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString("--batch_x\r\nContent-Type: application/http\r\nContent-ID: <" + p[0] + ">\r\n\r\n")
+		b.WriteString(p[1])
+		b.WriteString("\r\n")
+	}
+	b.WriteString("--batch_x--\r\n")
+	return b.String()
+}
+
+func TestDecodeBatch(t *testing.T) {
+	const contentType = "multipart/mixed; boundary=batch_x"
+	ok := func(body string) string {
+		return "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n\r\n" + body
+	}
+	failed := "HTTP/1.1 403 Forbidden\r\nContent-Type: application/json\r\n\r\n" +
+		`{"error":{"code":403,"message":"denied","status":"PERMISSION_DENIED"}}`
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		n           int
+		wantErr     bool
+		// want are the bodies of the results, or "error" for failed ones.
+		want []string
+	}{
+		{
+			name:        "in order",
+			contentType: contentType,
+			body:        batchResponse([2]string{"response-0", ok(`{"name":"a"}`)}, [2]string{"response-1", ok(`{"name":"b"}`)}),
+			n:           2,
+			want:        []string{`{"name":"a"}`, `{"name":"b"}`},
+		},
+		{
+			name:        "out of order",
+			contentType: contentType,
+			body:        batchResponse([2]string{"response-1", ok(`{"name":"b"}`)}, [2]string{"response-0", ok(`{"name":"a"}`)}),
+			n:           2,
+			want:        []string{`{"name":"a"}`, `{"name":"b"}`},
+		},
+		{
+			name:        "failed part",
+			contentType: contentType,
+			body:        batchResponse([2]string{"response-0", failed}, [2]string{"response-1", ok(`{}`)}),
+			n:           2,
+			want:        []string{"error", `{}`},
+		},
+		{
+			name:        "missing part",
+			contentType: contentType,
+			body:        batchResponse([2]string{"response-0", ok(`{}`)}),
+			n:           2,
+			want:        []string{`{}`, "error"},
+		},
+		{
+			name:        "unknown part",
+			contentType: contentType,
+			body:        batchResponse([2]string{"response-5", ok(`{}`)}),
+			n:           2,
+			wantErr:     true,
+		},
+		{
+			name:        "not multipart",
+			contentType: "application/json",
+			body:        `{}`,
+			n:           1,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := decodeBatch(tt.contentType, []byte(tt.body), tt.n)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeBatch() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeBatch() error: %v", err)
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("decodeBatch() returned %d results, want %d", len(results), len(tt.want))
+			}
+			for i, want := range tt.want {
+				switch {
+				case want == "error" && results[i].Err == nil:
+					t.Errorf("result %d: no error, want one", i)
+				case want != "error" && results[i].Err != nil:
+					t.Errorf("result %d: error %v", i, results[i].Err)
+				case want != "error" && string(results[i].Body) != want:
+					t.Errorf("result %d: body %s, want %s", i, results[i].Body, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeBatchAPIError(t *testing.T) {
+	body := batchResponse([2]string{"response-0", "HTTP/1.1 429 Too Many Requests\r\n\r\n" +
+		`{"error":{"code":429,"message":"slow down","status":"RESOURCE_EXHAUSTED"}}`})
+	results, err := decodeBatch("multipart/mixed; boundary=batch_x", []byte(body), 1)
+	if err != nil {
+		t.Fatalf("decodeBatch() error: %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(results[0].Err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		t.Errorf("result error = %v, want an APIError with code 429", results[0].Err)
+	}
+}
+
+func TestResendable(t *testing.T) {
+	apiErr := func(code int) error { return &APIError{Code: code} }
+
+	tests := []struct {
+		name string
+		req  BatchRequest
+		err  error
+		want bool
+	}{
+		{"POST 429", BatchRequest{Method: http.MethodPost}, apiErr(429), true},
+		{"POST 503", BatchRequest{Method: http.MethodPost}, apiErr(503), false},
+		{"POST 503 with requestId", BatchRequest{Method: http.MethodPost, Params: url.Values{"requestId": {"x"}}}, apiErr(503), true},
+		{"DELETE 500", BatchRequest{Method: http.MethodDelete}, apiErr(500), true},
+		{"GET 404", BatchRequest{Method: http.MethodGet}, apiErr(404), false},
+		{"not an API error", BatchRequest{Method: http.MethodGet}, errors.New("no result"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resendable(tt.req, tt.err); got != tt.want {
+				t.Errorf("resendable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
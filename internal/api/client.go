@@ -4,23 +4,66 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
+	"path"
+	"regexp"
 	"strconv"
 	"strings"
+
+	"golang.org/x/oauth2"
 )
 
+// DefaultVersion is the stable version of the Google Chat API.
+const DefaultVersion = "v1"
+
 // BaseURL is the default Google Chat API endpoint.
-const BaseURL = "https://chat.googleapis.com/v1"
+const BaseURL = "https://chat.googleapis.com/" + DefaultVersion
+
+// versionPattern matches API versions: v1, v2, v1beta, v1alpha1, ...
+var versionPattern = regexp.MustCompile(`^v[1-9][0-9]*((alpha|beta)[0-9]*)?$`)
+
+// ValidVersion reports whether version is a well-formed API version.
+func ValidVersion(version string) bool {
+	return versionPattern.MatchString(version)
+}
+
+// PreviewVersion reports whether version is a pre-release API version
+// (e.g. v1beta or v1alpha1).
+func PreviewVersion(version string) bool {
+	return strings.Contains(version, "alpha") || strings.Contains(version, "beta")
+}
 
 // Client is the base HTTP client for the Google Chat API.
 type Client struct {
 	HTTPClient *http.Client
 	BaseURL    string
 	Verbose    bool
+
+	// UserAgent, if set, overrides the User-Agent header of every request.
+	UserAgent string
+	// Headers are extra headers added to every request
+	// (e.g. X-Goog-Request-Reason for audit attribution).
+	Headers http.Header
+
+	// Retry, if set, enables retries of 429/5xx responses drawn from a
+	// budget shared by every request made through this client.
+	Retry *RetryBudget
+
+	// Reauthenticate, if set, is called when a request fails because the
+	// stored credentials are no longer valid: a 401 response, or a token
+	// refresh rejected by Google. It reports whether fresh credentials are
+	// now in place, in which case the request is retried once.
+	Reauthenticate func(ctx context.Context) bool
+
+	// AfterRequest, if set, is called once for every JSON request with its
+	// method, resource path, and outcome (the response body or the error),
+	// e.g. to keep an audit log.
+	AfterRequest func(method, path string, resp json.RawMessage, err error)
 }
 
 // NewClient creates a new API client with the default BaseURL.
@@ -31,6 +74,19 @@ func NewClient(httpClient *http.Client) *Client {
 	}
 }
 
+// SetVersion points the client at another version of the API (e.g.
+// v1beta) by replacing the version segment of BaseURL, the last element
+// of its path.
+func (c *Client) SetVersion(version string) error {
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return fmt.Errorf("parsing base URL: %w", err)
+	}
+	u.Path = path.Join(path.Dir(u.Path), version)
+	c.BaseURL = u.String()
+	return nil
+}
+
 // ErrorLink represents a help link in a Google API error detail.
 type ErrorLink struct {
 	Description string `json:"description"`
@@ -61,6 +117,12 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error %d (%s): %s", e.Code, e.Status, e.Message)
 }
 
+// Retryable reports whether the request may succeed if sent again later:
+// it was rate limited or failed on the server.
+func (e *APIError) Retryable() bool {
+	return isRetryable(e.Code)
+}
+
 // HelpLinks returns all help URLs from the error details.
 func (e *APIError) HelpLinks() []ErrorLink {
 	var links []ErrorLink
@@ -117,10 +179,41 @@ func (c *Client) Delete(ctx context.Context, path string, params url.Values) (js
 	return c.do(ctx, http.MethodDelete, path, params, nil, "")
 }
 
-// Upload performs an HTTP POST request with arbitrary content (e.g. multipart upload)
-// and returns the raw JSON response.
-func (c *Client) Upload(ctx context.Context, path string, params url.Values, body io.Reader, contentType string) (json.RawMessage, error) {
-	return c.do(ctx, http.MethodPost, path, params, body, contentType)
+// UploadBody is the content of an upload request. It is streamed rather
+// than buffered, so uploading a large file does not hold it in memory.
+type UploadBody struct {
+	// Reader is the content. If nil, Reopen provides it.
+	Reader io.Reader
+	// Length is the content length in bytes, or -1 if unknown.
+	Length      int64
+	ContentType string
+	// Reopen, if set, returns the content from its start again, so that
+	// failed attempts can be retried. Without it, uploads are not retried.
+	Reopen func() (io.ReadCloser, error)
+}
+
+// uploadReader is the request body of an upload; roundTrip uses it to set
+// the content length and to rewind the body for retries.
+type uploadReader struct {
+	io.Reader
+	UploadBody
+}
+
+// Upload performs an HTTP POST request streaming body (e.g. a multipart
+// upload) and returns the raw JSON response.
+func (c *Client) Upload(ctx context.Context, path string, params url.Values, body UploadBody) (json.RawMessage, error) {
+	r := body.Reader
+	if r == nil {
+		rc, err := body.Reopen()
+		if err != nil {
+			return nil, fmt.Errorf("opening upload content: %w", err)
+		}
+		// The transport closes the body once sent; this covers requests
+		// that fail before that.
+		defer rc.Close()
+		r = rc
+	}
+	return c.do(ctx, http.MethodPost, path, params, &uploadReader{Reader: r, UploadBody: body}, body.ContentType)
 }
 
 // Download performs an HTTP GET and returns the response body as a ReadCloser,
@@ -132,6 +225,7 @@ func (c *Client) Download(ctx context.Context, path string) (io.ReadCloser, stri
 	if err != nil {
 		return nil, "", fmt.Errorf("creating request: %w", err)
 	}
+	c.applyHeaders(req)
 
 	if c.Verbose {
 		log.Printf(">> %s %s\n", req.Method, req.URL.String())
@@ -160,50 +254,177 @@ func (c *Client) Download(ctx context.Context, path string) (io.ReadCloser, stri
 }
 
 // do is the internal helper that executes an HTTP request, checks the status code,
-// and returns the response body as raw JSON or an error.
+// and returns the response body as raw JSON or an error. When a RetryBudget is
+// attached, retryable failures (429, 5xx, transport errors) are retried with
+// backoff while the budget allows, where canRetry finds it safe.
 func (c *Client) do(ctx context.Context, method, path string, params url.Values, body io.Reader, contentType string) (json.RawMessage, error) {
-	reqURL := c.buildURL(path, params)
+	resp, _, err := c.roundTrip(ctx, method, c.buildURL(path, params), body, contentType, true)
+	if c.AfterRequest != nil {
+		c.AfterRequest(method, path, resp, err)
+	}
+	return resp, err
+}
 
+// roundTrip sends a request, retrying (if retry is set) and reauthenticating
+// as configured, and returns the response body and headers of a 2xx
+// response.
+func (c *Client) roundTrip(ctx context.Context, method, reqURL string, body io.Reader, contentType string, retry bool) (json.RawMessage, http.Header, error) {
 	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, nil, fmt.Errorf("creating request: %w", err)
+	}
+	if u, ok := body.(*uploadReader); ok {
+		req.ContentLength = u.Length
+		if u.Reopen != nil {
+			req.GetBody = u.Reopen
+		}
 	}
 
+	c.applyHeaders(req)
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
 
-	if c.Verbose {
-		log.Printf(">> %s %s\n", req.Method, req.URL.String())
+	reauthenticated := false
+	for attempt := 0; ; attempt++ {
+		if c.Retry != nil {
+			if err := c.Retry.wait(ctx); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if attempt > 0 && req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, nil, fmt.Errorf("rewinding request body: %w", err)
+			}
+		}
+
+		if c.Verbose {
+			log.Printf(">> %s %s\n", req.Method, req.URL.String())
+		}
+
+		respBody, resp, err := c.send(req)
+
+		if !reauthenticated && c.Reauthenticate != nil && isAuthFailure(err, resp) && rewindable(req) {
+			reauthenticated = true
+			if c.Reauthenticate(ctx) {
+				if c.Verbose {
+					log.Printf("<< retrying with new credentials\n")
+				}
+				continue
+			}
+		}
+
+		retryable := err != nil || isRetryable(resp.StatusCode)
+
+		if retryable && retry && c.canRetry(req, attempt, resp, err) {
+			c.Retry.recordFailure()
+			if !c.Retry.take() {
+				if err != nil {
+					return nil, nil, fmt.Errorf("%w: executing request: %w", ErrRetryBudgetExhausted, err)
+				}
+				return nil, nil, fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, responseError(resp.StatusCode, respBody))
+			}
+			if c.Verbose {
+				log.Printf("<< retrying (attempt %d of %d)\n", attempt+2, maxAttempts)
+			}
+			if err := sleepCtx(ctx, retryDelay(attempt, resp)); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+
+		if retryable && c.Retry != nil {
+			c.Retry.recordFailure()
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("executing request: %w", err)
+		}
+
+		if c.Verbose {
+			log.Printf("<< %d %s\n", resp.StatusCode, resp.Status)
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			if c.Verbose {
+				log.Printf("<< Response body:\n%s\n", string(respBody))
+			}
+			return nil, nil, responseError(resp.StatusCode, respBody)
+		}
+
+		if c.Retry != nil {
+			c.Retry.recordSuccess()
+		}
+		return json.RawMessage(respBody), resp.Header, nil
 	}
+}
 
+// send executes req and reads the full response body.
+func (c *Client) send(req *http.Request) ([]byte, *http.Response, error) {
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
+		return nil, nil, fmt.Errorf("reading response body: %w", err)
 	}
+	return respBody, resp, nil
+}
 
-	if c.Verbose {
-		log.Printf("<< %d %s\n", resp.StatusCode, resp.Status)
+// canRetry reports whether another attempt of req, which failed with resp
+// or err, is possible and safe: a budget is attached, attempts remain, the
+// body (if any) can be rewound, and sending req again cannot apply it twice.
+// Requests that are not idempotent are only retried when the failed attempt
+// certainly did not reach the server.
+func (c *Client) canRetry(req *http.Request, attempt int, resp *http.Response, err error) bool {
+	if c.Retry == nil || attempt+1 >= maxAttempts || !rewindable(req) {
+		return false
 	}
+	return idempotent(req.Method, req.URL.Query()) || notApplied(resp, err)
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if c.Verbose {
-			log.Printf("<< Response body:\n%s\n", string(respBody))
-		}
-		apiErr := parseAPIErrorFromBody(resp.StatusCode, respBody)
-		if apiErr != nil {
-			return nil, apiErr
-		}
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(respBody))
+// rewindable reports whether req can be sent again: it has no body, or the
+// body can be recreated.
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// isAuthFailure reports whether a request failed because the credentials
+// are no longer valid: the API answered 401, or refreshing the access token
+// was rejected (e.g. the refresh token was revoked or expired).
+func isAuthFailure(err error, resp *http.Response) bool {
+	if err != nil {
+		// Server errors from the token endpoint are transient, not a sign of
+		// dead credentials.
+		var retrieveErr *oauth2.RetrieveError
+		return errors.As(err, &retrieveErr) &&
+			(retrieveErr.Response == nil || retrieveErr.Response.StatusCode < 500)
 	}
+	return resp.StatusCode == http.StatusUnauthorized
+}
 
-	return json.RawMessage(respBody), nil
+// responseError converts a non-2xx response into an error.
+func responseError(statusCode int, body []byte) error {
+	if apiErr := parseAPIErrorFromBody(statusCode, body); apiErr != nil {
+		return apiErr
+	}
+	return fmt.Errorf("unexpected status %d: %s", statusCode, string(body))
+}
+
+// applyHeaders sets the configured User-Agent and extra headers on req.
+func (c *Client) applyHeaders(req *http.Request) {
+	for key, values := range c.Headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
 }
 
 // buildURL constructs the full request URL from the base URL, path, and query parameters.
@@ -255,6 +476,39 @@ func parseAPIErrorFromBody(statusCode int, body []byte) *APIError {
 	return nil
 }
 
+// NormalizeUser expands the "me" shorthand and bare user IDs into a
+// users/{user} resource name.
+// E.g. NormalizeUser("me") → "users/me"
+// E.g. NormalizeUser("123456") → "users/123456"
+func NormalizeUser(user string) string {
+	if user == "me" {
+		return "users/me"
+	}
+	return NormalizeName(user, "users/")
+}
+
+// UserScopedName builds a per-user resource name such as
+// users/{user}/spaces/{space}/spaceReadState from shorthand. Full names are
+// returned unchanged, "me/spaces/..." gets the users/ prefix, and a bare
+// space or thread (with or without "spaces/") is taken as the calling
+// user's. The suffix is appended when missing.
+// E.g. UserScopedName("AAAA", "spaceReadState") → "users/me/spaces/AAAA/spaceReadState"
+// E.g. UserScopedName("spaces/AAAA/threads/T1", "threadReadState") → "users/me/spaces/AAAA/threads/T1/threadReadState"
+func UserScopedName(ref, suffix string) string {
+	ref = strings.Trim(strings.TrimSpace(ref), "/")
+	switch {
+	case strings.HasPrefix(ref, "users/"):
+	case strings.HasPrefix(ref, "me/"):
+		ref = "users/" + ref
+	default:
+		ref = "users/me/" + NormalizeName(ref, "spaces/")
+	}
+	if !strings.HasSuffix(ref, "/"+suffix) {
+		ref += "/" + suffix
+	}
+	return ref
+}
+
 // NormalizeName ensures name starts with the given prefix.
 // E.g. NormalizeName("AAAA", "spaces/") → "spaces/AAAA"
 // E.g. NormalizeName("spaces/AAAA", "spaces/") → "spaces/AAAA"
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DriveBaseURL is the Google Drive API endpoint used for Drive-backed
+// attachments.
+const DriveBaseURL = "https://www.googleapis.com/drive/v3"
+
+// DriveUploadURL is the Google Drive API endpoint for file content uploads.
+const DriveUploadURL = "https://www.googleapis.com/upload/drive/v3"
+
+// DriveService provides the subset of the Google Drive API that gogchat needs
+// to attach Drive files to messages.
+type DriveService struct {
+	client *Client
+}
+
+// NewDriveService creates a new DriveService that shares the given client's
+// HTTP transport and credentials but talks to the Drive API endpoint.
+func NewDriveService(client *Client) *DriveService {
+	driveClient := *client
+	driveClient.BaseURL = DriveBaseURL
+	return &DriveService{client: &driveClient}
+}
+
+// Get returns metadata for a Drive file.
+// GET /drive/v3/files/{fileId}
+func (s *DriveService) Get(ctx context.Context, fileID string) (json.RawMessage, error) {
+	params := url.Values{}
+	params.Set("fields", "id,name,mimeType,webViewLink")
+	params.Set("supportsAllDrives", "true")
+
+	return s.client.Get(ctx, "files/"+url.PathEscape(fileID), params)
+}
+
+// CreatePermission grants a permission on a Drive file.
+// POST /drive/v3/files/{fileId}/permissions
+func (s *DriveService) CreatePermission(ctx context.Context, fileID string, permission map[string]interface{}) (json.RawMessage, error) {
+	params := url.Values{}
+	params.Set("supportsAllDrives", "true")
+	params.Set("sendNotificationEmail", "false")
+
+	return s.client.Post(ctx, fmt.Sprintf("files/%s/permissions", url.PathEscape(fileID)), params, permission)
+}
+
+// Upload uploads a local file to the caller's My Drive and returns the new
+// file's metadata. It uses a resumable upload session so the file is
+// streamed from disk instead of being buffered in memory.
+// POST /upload/drive/v3/files?uploadType=resumable, then PUT {session}
+func (s *DriveService) Upload(ctx context.Context, filePath string) (json.RawMessage, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("opening file %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("checking file %s: %w", filePath, err)
+	}
+
+	contentType, err := detectContentType(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", filePath, err)
+	}
+
+	// Start the upload session; Drive returns its URL in the Location header.
+	params := url.Values{}
+	params.Set("uploadType", "resumable")
+	params.Set("supportsAllDrives", "true")
+	params.Set("fields", "id,name,mimeType,webViewLink,size")
+	metadata, err := json.Marshal(map[string]string{"name": filepath.Base(filePath)})
+	if err != nil {
+		return nil, fmt.Errorf("marshaling file metadata: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, DriveUploadURL+"/files?"+params.Encode(), bytes.NewReader(metadata))
+	if err != nil {
+		return nil, fmt.Errorf("creating upload session request: %w", err)
+	}
+	s.client.applyHeaders(req)
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Type", contentType)
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(info.Size(), 10))
+
+	body, resp, err := s.sendLogged(req)
+	if err != nil {
+		return nil, fmt.Errorf("starting Drive upload: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, responseError(resp.StatusCode, body)
+	}
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return nil, fmt.Errorf("starting Drive upload: no session URL in response")
+	}
+
+	// Send the whole file in a single request to the session URL.
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, session, f)
+	if err != nil {
+		return nil, fmt.Errorf("creating upload request: %w", err)
+	}
+	s.client.applyHeaders(req)
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", contentType)
+
+	body, resp, err = s.sendLogged(req)
+	if err != nil {
+		return nil, fmt.Errorf("uploading to Drive: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, responseError(resp.StatusCode, body)
+	}
+	return json.RawMessage(body), nil
+}
+
+// sendLogged executes req without retries, logging it in verbose mode, and
+// returns the full response body.
+func (s *DriveService) sendLogged(req *http.Request) ([]byte, *http.Response, error) {
+	if s.client.Verbose {
+		log.Printf(">> %s %s\n", req.Method, req.URL.String())
+	}
+	resp, err := s.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if s.client.Verbose {
+		log.Printf("<< %d %s\n", resp.StatusCode, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading response body: %w", err)
+	}
+	return body, resp, nil
+}
+
+// driveURLPatterns match the file ID in the common Drive and Docs URL shapes.
+var driveURLPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`/d/([A-Za-z0-9_-]+)`),
+	regexp.MustCompile(`/folders/([A-Za-z0-9_-]+)`),
+	regexp.MustCompile(`[?&]id=([A-Za-z0-9_-]+)`),
+}
+
+// ParseDriveFileID extracts a Drive file ID from a bare ID or a Drive/Docs URL.
+// E.g. ParseDriveFileID("https://docs.google.com/document/d/abc123/edit") → "abc123"
+func ParseDriveFileID(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("empty Drive file reference")
+	}
+
+	if !strings.Contains(ref, "/") && !strings.Contains(ref, "?") {
+		return ref, nil
+	}
+
+	for _, re := range driveURLPatterns {
+		if m := re.FindStringSubmatch(ref); m != nil {
+			return m[1], nil
+		}
+	}
+	return "", fmt.Errorf("could not find a Drive file ID in %q", ref)
+}
@@ -9,8 +9,11 @@ import (
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // MediaService handles media upload and download operations on the Google Chat API.
@@ -28,49 +31,169 @@ func NewMediaService(client *Client) *MediaService {
 func (s *MediaService) Upload(ctx context.Context, parent string, filePath string) (json.RawMessage, error) {
 	parent = NormalizeName(parent, "spaces/")
 
-	f, err := os.Open(filePath)
+	body, err := multipartFile(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("opening file %s: %w", filePath, err)
+		return nil, err
 	}
-	defer f.Close()
 
-	// Detect the content type from the file extension, falling back to
-	// application/octet-stream.
-	contentType := mime.TypeByExtension(filepath.Ext(filePath))
-	if contentType == "" {
-		contentType = "application/octet-stream"
+	path := parent + "/attachments:upload"
+	return s.client.Upload(ctx, path, nil, body)
+}
+
+// multipartFile returns a multipart/form-data body with the file at
+// filePath as its "file" part, followed by a "filename" field. Only the
+// parts around the file's content are built in memory; the content is
+// streamed from disk, and reopened for retries.
+func multipartFile(filePath string) (UploadBody, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return UploadBody{}, fmt.Errorf("opening file %s: %w", filePath, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return UploadBody{}, fmt.Errorf("checking file %s: %w", filePath, err)
+	}
+	contentType, err := detectContentType(f)
+	f.Close()
+	if err != nil {
+		return UploadBody{}, fmt.Errorf("reading file %s: %w", filePath, err)
 	}
 
-	// Build a multipart request body containing the file.
 	var buf bytes.Buffer
 	writer := multipart.NewWriter(&buf)
 
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
-	if err != nil {
-		return nil, fmt.Errorf("creating multipart form file: %w", err)
-	}
-
-	if _, err := io.Copy(part, f); err != nil {
-		return nil, fmt.Errorf("copying file data: %w", err)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{
+		"name":     "file",
+		"filename": filepath.Base(filePath),
+	}))
+	header.Set("Content-Type", contentType)
+	if _, err := writer.CreatePart(header); err != nil {
+		return UploadBody{}, fmt.Errorf("creating multipart form file: %w", err)
 	}
+	head := bytes.Clone(buf.Bytes())
+	buf.Reset()
 
 	// Add the filename metadata field.
 	if err := writer.WriteField("filename", filepath.Base(filePath)); err != nil {
-		return nil, fmt.Errorf("writing filename field: %w", err)
+		return UploadBody{}, fmt.Errorf("writing filename field: %w", err)
 	}
-
 	if err := writer.Close(); err != nil {
-		return nil, fmt.Errorf("closing multipart writer: %w", err)
+		return UploadBody{}, fmt.Errorf("closing multipart writer: %w", err)
+	}
+	tail := bytes.Clone(buf.Bytes())
+
+	return UploadBody{
+		Length:      int64(len(head)) + info.Size() + int64(len(tail)),
+		ContentType: writer.FormDataContentType(),
+		Reopen: func() (io.ReadCloser, error) {
+			f, err := os.Open(filePath)
+			if err != nil {
+				return nil, err
+			}
+			return struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(head), f, bytes.NewReader(tail)), f}, nil
+		},
+	}, nil
+}
+
+// sniffLen is the number of leading bytes http.DetectContentType considers.
+const sniffLen = 512
+
+// detectContentType returns the content type of f from its file extension,
+// or, for unknown extensions, by sniffing its first bytes. Files that match
+// nothing are application/octet-stream. f is rewound to the start.
+func detectContentType(f *os.File) (string, error) {
+	if ct := mime.TypeByExtension(filepath.Ext(f.Name())); ct != "" {
+		return ct, nil
 	}
 
-	path := parent + "/attachments:upload"
-	return s.client.Upload(ctx, path, nil, &buf, writer.FormDataContentType())
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "application/octet-stream", nil
+	}
+	// DetectContentType falls back to application/octet-stream itself.
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// MediaInfo describes a media resource from the headers of its download
+// response.
+type MediaInfo struct {
+	ContentType string `json:"contentType"`
+	// Size is the length of the content in bytes, or -1 if unknown.
+	Size int64 `json:"size"`
+	// FileName is the file name suggested by the Content-Disposition
+	// header, unsanitized, or "" if there is none.
+	FileName string `json:"fileName,omitempty"`
+}
+
+// mediaInfo extracts the MediaInfo of a download response.
+func mediaInfo(resp *http.Response) MediaInfo {
+	info := MediaInfo{
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        resp.ContentLength,
+	}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		info.FileName = params["filename"]
+	}
+	return info
 }
 
 // Download downloads media content by resource name.
 // GET /v1/media/{resourceName}?alt=media
-// Returns the response body as a ReadCloser, the Content-Type header, and any error.
-func (s *MediaService) Download(ctx context.Context, resourceName string) (io.ReadCloser, string, error) {
+// Returns the response body as a ReadCloser, the media's content type,
+// size, and suggested file name, and any error.
+func (s *MediaService) Download(ctx context.Context, resourceName string) (io.ReadCloser, MediaInfo, error) {
+	resp, err := s.get(ctx, resourceName, nil)
+	if err != nil {
+		return nil, MediaInfo{}, err
+	}
+	return resp.Body, mediaInfo(resp), nil
+}
+
+// Stat returns the content type, size, and suggested file name of a media
+// resource without downloading it. It requests only the first byte and
+// reads the total size from the Content-Range header.
+// GET /v1/media/{resourceName}?alt=media with Range: bytes=0-0
+func (s *MediaService) Stat(ctx context.Context, resourceName string) (MediaInfo, error) {
+	header := http.Header{}
+	header.Set("Range", "bytes=0-0")
+	// A compressed response would hide the length of the content.
+	header.Set("Accept-Encoding", "identity")
+	resp, err := s.get(ctx, resourceName, header)
+	if err != nil {
+		return MediaInfo{}, err
+	}
+	// Servers that ignore the range send the whole content; the body is
+	// closed unread, so it is not transferred beyond the first packets.
+	resp.Body.Close()
+
+	info := mediaInfo(resp)
+	if resp.StatusCode == http.StatusPartialContent {
+		info.Size = -1
+		// Content-Range: bytes 0-0/{total}
+		if _, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/"); ok {
+			if n, err := strconv.ParseInt(total, 10, 64); err == nil {
+				info.Size = n
+			}
+		}
+	}
+	return info, nil
+}
+
+// get sends a media request with the given extra headers and returns the
+// response, or the API error of a non-2xx response.
+func (s *MediaService) get(ctx context.Context, resourceName string, header http.Header) (*http.Response, error) {
 	path := "media/" + resourceName
 	// The Download method on Client builds the full URL. We need to append
 	// the alt=media query parameter. Since Client.Download does not accept
@@ -82,12 +205,16 @@ func (s *MediaService) Download(ctx context.Context, resourceName string) (io.Re
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		return nil, "", fmt.Errorf("creating download request: %w", err)
+		return nil, fmt.Errorf("creating download request: %w", err)
+	}
+	s.client.applyHeaders(req)
+	for name, values := range header {
+		req.Header[name] = values
 	}
 
 	resp, err := s.client.HTTPClient.Do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("executing download request: %w", err)
+		return nil, fmt.Errorf("executing download request: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -95,11 +222,9 @@ func (s *MediaService) Download(ctx context.Context, resourceName string) (io.Re
 		body, _ := io.ReadAll(resp.Body)
 		apiErr := parseAPIErrorFromBody(resp.StatusCode, body)
 		if apiErr != nil {
-			return nil, "", apiErr
+			return nil, apiErr
 		}
-		return nil, "", fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
 	}
-
-	ct := resp.Header.Get("Content-Type")
-	return resp.Body, ct, nil
+	return resp, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 )
 
@@ -47,13 +48,21 @@ func (s *MembersService) Get(ctx context.Context, name string, useAdminAccess bo
 // parent is the space resource name (e.g. "spaces/AAAA" or just "AAAA").
 // membership is the membership resource body to create.
 func (s *MembersService) Create(ctx context.Context, parent string, membership map[string]interface{}, useAdminAccess bool) (json.RawMessage, error) {
-	parent = NormalizeName(parent, "spaces/")
-	path := fmt.Sprintf("%s/members", parent)
+	return s.client.Send(ctx, s.CreateRequest(parent, membership, useAdminAccess))
+}
 
+// CreateRequest returns the request of Create, e.g. for Client.Batch.
+func (s *MembersService) CreateRequest(parent string, membership map[string]interface{}, useAdminAccess bool) BatchRequest {
+	parent = NormalizeName(parent, "spaces/")
 	params := url.Values{}
 	AddQueryParamBool(params, "useAdminAccess", useAdminAccess)
 
-	return s.client.Post(ctx, path, params, membership)
+	return BatchRequest{
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("%s/members", parent),
+		Params: params,
+		Body:   membership,
+	}
 }
 
 // Patch updates an existing membership.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 )
 
@@ -38,7 +39,12 @@ func (s *MessagesService) List(ctx context.Context, parent string, pageSize int,
 // GET /v1/{name}
 // Name format: spaces/{space}/messages/{message}
 func (s *MessagesService) Get(ctx context.Context, name string) (json.RawMessage, error) {
-	return s.client.Get(ctx, name, nil)
+	return s.client.Send(ctx, s.GetRequest(name))
+}
+
+// GetRequest returns the request of Get, e.g. for Client.Batch.
+func (s *MessagesService) GetRequest(name string) BatchRequest {
+	return BatchRequest{Method: http.MethodGet, Path: name}
 }
 
 // Create sends a new message to a space.
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+// PeopleBaseURL is the Google People API endpoint used to search the
+// Workspace directory.
+const PeopleBaseURL = "https://people.googleapis.com/v1"
+
+// PeopleService provides the subset of the Google People API that gogchat
+// needs to look up colleagues in the Workspace directory.
+type PeopleService struct {
+	client *Client
+}
+
+// NewPeopleService creates a new PeopleService that shares the given
+// client's HTTP transport and credentials but talks to the People API
+// endpoint.
+func NewPeopleService(client *Client) *PeopleService {
+	peopleClient := *client
+	peopleClient.BaseURL = PeopleBaseURL
+	return &PeopleService{client: &peopleClient}
+}
+
+// SearchDirectory searches the domain directory for people whose name or
+// email matches query.
+// GET /v1/people:searchDirectoryPeople
+func (s *PeopleService) SearchDirectory(ctx context.Context, query string, pageSize int) (json.RawMessage, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("readMask", "names,emailAddresses")
+	params.Add("sources", "DIRECTORY_SOURCE_TYPE_DOMAIN_PROFILE")
+	params.Add("sources", "DIRECTORY_SOURCE_TYPE_DOMAIN_CONTACT")
+	AddQueryParamInt(params, "pageSize", pageSize)
+
+	return s.client.Get(ctx, "people:searchDirectoryPeople", params)
+}
+
+// BatchGet returns the person resources (people/{id}) with the given
+// fields, up to 200 per call.
+// GET /v1/people:batchGet
+func (s *PeopleService) BatchGet(ctx context.Context, resourceNames []string, personFields string) (json.RawMessage, error) {
+	params := url.Values{}
+	for _, name := range resourceNames {
+		params.Add("resourceNames", name)
+	}
+	params.Set("personFields", personFields)
+
+	return s.client.Get(ctx, "people:batchGet", params)
+}
+
+// GetMe returns the authenticated user's person resource with the given
+// fields.
+// GET /v1/people/me
+func (s *PeopleService) GetMe(ctx context.Context, personFields string) (json.RawMessage, error) {
+	params := url.Values{}
+	params.Set("personFields", personFields)
+
+	return s.client.Get(ctx, "people/me", params)
+}
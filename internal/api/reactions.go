@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
 )
 
@@ -36,10 +37,17 @@ func (s *ReactionsService) List(ctx context.Context, parent string, pageSize int
 // parent is the message resource name, e.g. "spaces/{space}/messages/{message}".
 // reaction is the request body describing the reaction to create.
 func (s *ReactionsService) Create(ctx context.Context, parent string, reaction map[string]interface{}) (json.RawMessage, error) {
-	parent = NormalizeName(parent, "spaces/")
-	path := fmt.Sprintf("%s/reactions", parent)
+	return s.client.Send(ctx, s.CreateRequest(parent, reaction))
+}
 
-	return s.client.Post(ctx, path, nil, reaction)
+// CreateRequest returns the request of Create, e.g. for Client.Batch.
+func (s *ReactionsService) CreateRequest(parent string, reaction map[string]interface{}) BatchRequest {
+	parent = NormalizeName(parent, "spaces/")
+	return BatchRequest{
+		Method: http.MethodPost,
+		Path:   fmt.Sprintf("%s/reactions", parent),
+		Body:   reaction,
+	}
 }
 
 // Delete removes a reaction.
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxAttempts is the maximum number of attempts for a single request when
+	// a RetryBudget is attached to the client.
+	maxAttempts = 5
+	// baseBackoff is the initial delay between retries; it doubles per attempt.
+	baseBackoff = time.Second
+	// maxBackoff caps the delay between retries.
+	maxBackoff = 30 * time.Second
+)
+
+// ErrRetryBudgetExhausted is returned when a request fails with a retryable
+// error but the shared retry budget has no retries left.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget is a retry allowance and circuit breaker shared by every request
+// of a bulk operation. Each retry consumes one unit of the budget; a budget
+// made with NewRefillingRetryBudget regains units over time, for daemons
+// that run for days. After
+// Threshold consecutive retryable failures (429 or 5xx) the breaker trips and
+// all requests pause for Cooldown before trying again, instead of burning
+// through quota retrying every item.
+type RetryBudget struct {
+	// Threshold is the number of consecutive retryable failures that trips
+	// the breaker.
+	Threshold int
+	// Cooldown is how long requests pause after the breaker trips.
+	Cooldown time.Duration
+	// OnPause, if set, is called once each time the breaker trips.
+	OnPause func(time.Duration)
+
+	mu          sync.Mutex
+	remaining   int
+	consecutive int
+	openUntil   time.Time
+	// capacity and refill are the most retries the budget holds and how
+	// often it regains one, if it refills; refilled is when it last did.
+	capacity int
+	refill   time.Duration
+	refilled time.Time
+}
+
+// NewRetryBudget creates a RetryBudget allowing retries retries in total.
+func NewRetryBudget(retries, threshold int, cooldown time.Duration) *RetryBudget {
+	return &RetryBudget{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		remaining: retries,
+	}
+}
+
+// NewRefillingRetryBudget creates a RetryBudget holding up to retries
+// retries that regains one retry every refill, so that a long-running
+// process keeps retrying after a past outage used up its budget.
+func NewRefillingRetryBudget(retries, threshold int, cooldown, refill time.Duration) *RetryBudget {
+	b := NewRetryBudget(retries, threshold, cooldown)
+	b.capacity = retries
+	b.refill = refill
+	b.refilled = time.Now()
+	return b
+}
+
+// Remaining returns the number of retries left in the budget.
+func (b *RetryBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillAt(time.Now())
+	return b.remaining
+}
+
+// refillAt adds the retries regained by now. b.mu must be held.
+func (b *RetryBudget) refillAt(now time.Time) {
+	if b.refill <= 0 {
+		return
+	}
+	if b.remaining >= b.capacity {
+		b.refilled = now
+		return
+	}
+	n := int(now.Sub(b.refilled) / b.refill)
+	if n <= 0 {
+		return
+	}
+	b.remaining = min(b.capacity, b.remaining+n)
+	b.refilled = b.refilled.Add(time.Duration(n) * b.refill)
+}
+
+// wait blocks while the breaker is open.
+func (b *RetryBudget) wait(ctx context.Context) error {
+	b.mu.Lock()
+	delay := time.Until(b.openUntil)
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	return sleepCtx(ctx, delay)
+}
+
+// take consumes one retry, reporting whether one was available.
+func (b *RetryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillAt(time.Now())
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// recordSuccess resets the consecutive failure count.
+func (b *RetryBudget) recordSuccess() {
+	b.mu.Lock()
+	b.consecutive = 0
+	b.mu.Unlock()
+}
+
+// recordFailure counts a retryable failure and trips the breaker once the
+// threshold is reached.
+func (b *RetryBudget) recordFailure() {
+	b.mu.Lock()
+	b.consecutive++
+	tripped := b.Threshold > 0 && b.consecutive >= b.Threshold && time.Now().After(b.openUntil)
+	if tripped {
+		b.consecutive = 0
+		b.openUntil = time.Now().Add(b.Cooldown)
+	}
+	onPause := b.OnPause
+	b.mu.Unlock()
+
+	if tripped && onPause != nil {
+		onPause(b.Cooldown)
+	}
+}
+
+// isRetryable reports whether a response status is worth retrying.
+func isRetryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// idempotent reports whether a request with the given method and query can
+// be sent twice with the effect of sending it once: GET, HEAD, PUT, PATCH,
+// and DELETE requests, and POSTs that carry an idempotency key (requestId,
+// or a client-assigned messageId) so the API recognises a repeat.
+func idempotent(method string, query url.Values) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return query.Get("requestId") != "" || query.Get("messageId") != ""
+	}
+	return false
+}
+
+// notApplied reports whether a failed attempt certainly was not applied by
+// the server: it was rejected with 429, or the connection could not be
+// established (e.g. it was refused), so the request was never sent. A
+// timeout or 5xx gives no such guarantee.
+func notApplied(resp *http.Response, err error) bool {
+	if err != nil {
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	return resp.StatusCode == http.StatusTooManyRequests
+}
+
+// retryDelay returns how long to wait before the given retry attempt,
+// honouring a Retry-After header (in seconds) when present.
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+			return min(time.Duration(secs)*time.Second, maxBackoff)
+		}
+	}
+	return min(baseBackoff<<attempt, maxBackoff)
+}
+
+// sleepCtx sleeps for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	withRetryAfter := func(v string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{v}}}
+	}
+
+	tests := []struct {
+		name    string
+		attempt int
+		resp    *http.Response
+		want    time.Duration
+	}{
+		{"first retry", 0, nil, time.Second},
+		{"doubles per attempt", 3, nil, 8 * time.Second},
+		{"capped", 10, nil, maxBackoff},
+		{"no Retry-After", 1, &http.Response{Header: http.Header{}}, 2 * time.Second},
+		{"Retry-After seconds", 0, withRetryAfter("7"), 7 * time.Second},
+		{"Retry-After capped", 0, withRetryAfter("3600"), maxBackoff},
+		{"Retry-After zero ignored", 2, withRetryAfter("0"), 4 * time.Second},
+		{"Retry-After date ignored", 1, withRetryAfter("Wed, 21 Oct 2026 07:28:00 GMT"), 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryDelay(tt.attempt, tt.resp); got != tt.want {
+				t.Errorf("retryDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	tests := []struct {
+		method string
+		query  url.Values
+		want   bool
+	}{
+		{http.MethodGet, nil, true},
+		{http.MethodHead, nil, true},
+		{http.MethodPut, nil, true},
+		{http.MethodPatch, nil, true},
+		{http.MethodDelete, nil, true},
+		{http.MethodPost, nil, false},
+		{http.MethodPost, url.Values{"requestId": {"abc"}}, true},
+		{http.MethodPost, url.Values{"messageId": {"client-1"}}, true},
+		{http.MethodPost, url.Values{"requestId": {""}}, false},
+		{http.MethodPost, url.Values{"useAdminAccess": {"true"}}, false},
+	}
+	for _, tt := range tests {
+		if got := idempotent(tt.method, tt.query); got != tt.want {
+			t.Errorf("idempotent(%s, %v) = %v, want %v", tt.method, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestNotApplied(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"429", &http.Response{StatusCode: http.StatusTooManyRequests}, nil, true},
+		{"500", &http.Response{StatusCode: http.StatusInternalServerError}, nil, false},
+		{"503", &http.Response{StatusCode: http.StatusServiceUnavailable}, nil, false},
+		{"dial error", nil, &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"read error", nil, &net.OpError{Op: "read", Err: errors.New("connection reset")}, false},
+		{"other error", nil, errors.New("timeout"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := notApplied(tt.resp, tt.err); got != tt.want {
+				t.Errorf("notApplied() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanRetry(t *testing.T) {
+	budget := NewRetryBudget(10, 5, time.Minute)
+	request := func(method, target, body string) *http.Request {
+		var req *http.Request
+		if body == "" {
+			req, _ = http.NewRequest(method, target, nil)
+		} else {
+			req, _ = http.NewRequest(method, target, strings.NewReader(body))
+		}
+		return req
+	}
+	unrewindable := request(http.MethodPatch, "https://chat.googleapis.com/v1/spaces/a", "{}")
+	unrewindable.GetBody = nil
+	dialErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	resp := func(code int) *http.Response { return &http.Response{StatusCode: code} }
+
+	tests := []struct {
+		name    string
+		retry   *RetryBudget
+		req     *http.Request
+		attempt int
+		resp    *http.Response
+		err     error
+		want    bool
+	}{
+		{"GET 503", budget, request(http.MethodGet, "https://chat.googleapis.com/v1/spaces", ""), 0, resp(503), nil, true},
+		{"no budget", nil, request(http.MethodGet, "https://chat.googleapis.com/v1/spaces", ""), 0, resp(503), nil, false},
+		{"last attempt", budget, request(http.MethodGet, "https://chat.googleapis.com/v1/spaces", ""), maxAttempts - 1, resp(503), nil, false},
+		{"DELETE 500", budget, request(http.MethodDelete, "https://chat.googleapis.com/v1/spaces/a", ""), 1, resp(500), nil, true},
+		{"POST 503", budget, request(http.MethodPost, "https://chat.googleapis.com/v1/spaces/a/messages", "{}"), 0, resp(503), nil, false},
+		{"POST 429", budget, request(http.MethodPost, "https://chat.googleapis.com/v1/spaces/a/messages", "{}"), 0, resp(429), nil, true},
+		{"POST dial error", budget, request(http.MethodPost, "https://chat.googleapis.com/v1/spaces/a/messages", "{}"), 0, nil, dialErr, true},
+		{"POST with requestId", budget, request(http.MethodPost, "https://chat.googleapis.com/v1/spaces/a/messages?requestId=x", "{}"), 0, resp(503), nil, true},
+		{"body cannot be rewound", budget, unrewindable, 0, resp(503), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{Retry: tt.retry}
+			if got := c.canRetry(tt.req, tt.attempt, tt.resp, tt.err); got != tt.want {
+				t.Errorf("canRetry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefillingRetryBudget(t *testing.T) {
+	start := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		capacity  int
+		remaining int
+		elapsed   time.Duration
+		want      int
+	}{
+		{"nothing elapsed", 10, 0, 0, 0},
+		{"less than one refill", 10, 0, 59 * time.Second, 0},
+		{"one refill", 10, 0, time.Minute, 1},
+		{"several refills", 10, 1, 5*time.Minute + 30*time.Second, 6},
+		{"capped", 3, 2, time.Hour, 3},
+		{"already full", 3, 3, time.Hour, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewRefillingRetryBudget(tt.capacity, 5, time.Minute, time.Minute)
+			b.remaining = tt.remaining
+			b.refilled = start
+			b.refillAt(start.Add(tt.elapsed))
+			if b.remaining != tt.want {
+				t.Errorf("remaining after %v = %d, want %d", tt.elapsed, b.remaining, tt.want)
+			}
+		})
+	}
+
+	t.Run("one-shot budget does not refill", func(t *testing.T) {
+		b := NewRetryBudget(1, 5, time.Minute)
+		if !b.take() || b.take() {
+			t.Fatalf("take() should succeed once")
+		}
+		b.refillAt(time.Now().Add(time.Hour))
+		if b.Remaining() != 0 {
+			t.Errorf("Remaining() = %d, want 0", b.Remaining())
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions tune the connections of the HTTP transport used for API
+// requests. Zero values keep the defaults of http.DefaultTransport.
+type TransportOptions struct {
+	// MaxIdleConns is the maximum number of idle (keep-alive) connections
+	// kept across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept
+	// per host. The Go default of 2 is too low for parallel requests.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept open.
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes.
+	KeepAlive time.Duration
+}
+
+// NewTransport returns a copy of http.DefaultTransport with opts applied.
+func NewTransport(opts TransportOptions) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.MaxIdleConns > 0 {
+		t.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.KeepAlive != 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: opts.KeepAlive}
+		t.DialContext = dialer.DialContext
+	}
+	return t
+}
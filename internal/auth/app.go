@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// AppScope is the OAuth2 scope used when calling the Chat API as a Chat app
+// (service account authentication).
+const AppScope = "https://www.googleapis.com/auth/chat.bot"
+
+// AppMembershipsScope lets a Chat app manage the members of spaces. It
+// needs the one-time approval of a Workspace administrator.
+const AppMembershipsScope = "https://www.googleapis.com/auth/chat.app.memberships"
+
+// ErrMissingServiceAccount is returned when app authentication is requested
+// but no service account key file is configured.
+var ErrMissingServiceAccount = errors.New(`app authentication requires a service account key.
+
+Set the path to the JSON key of your Chat app's service account:
+
+  Option 1: In ~/.config/gogchat/config.yaml:
+    service_account_file: /path/to/key.json
+
+  Option 2: As an environment variable:
+    export GOGCHAT_SERVICE_ACCOUNT_FILE=/path/to/key.json
+
+To create a service account key, visit:
+  https://console.cloud.google.com/iam-admin/serviceaccounts`)
+
+// AppHTTPClient returns an *http.Client that authenticates as the Chat app
+// using the service account key stored at keyFile, sending requests through
+// base (http.DefaultTransport if nil). extraScopes are requested besides
+// AppScope, e.g. AppMembershipsScope.
+func AppHTTPClient(keyFile string, base http.RoundTripper, extraScopes ...string) (*http.Client, error) {
+	if keyFile == "" {
+		return nil, ErrMissingServiceAccount
+	}
+
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading service account key %s: %w", keyFile, err)
+	}
+
+	cfg, err := google.JWTConfigFromJSON(data, append([]string{AppScope}, extraScopes...)...)
+	if err != nil {
+		return nil, fmt.Errorf("parsing service account key %s: %w", keyFile, err)
+	}
+
+	ctx := context.Background()
+	if base != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: base})
+	}
+	return cfg.Client(ctx), nil
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChatIssuer is the issuer of the bearer tokens that Google Chat sends with
+// the events of HTTP endpoint apps.
+const ChatIssuer = "chat@system.gserviceaccount.com"
+
+// ChatCertsURL is the endpoint with the certificates that sign the bearer
+// tokens of Chat events, by key ID.
+var ChatCertsURL = "https://www.googleapis.com/service_accounts/v1/metadata/x509/" + ChatIssuer
+
+// ChatVerifier checks that requests to an HTTP endpoint app come from
+// Google Chat: their bearer token must be signed by ChatIssuer for the
+// project number of the app.
+type ChatVerifier struct {
+	// Audience is the project number of the Chat app.
+	Audience string
+	// Client fetches the certificates.
+	Client *http.Client
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PublicKey
+	expires time.Time
+}
+
+// Verify checks the Authorization header of a Chat event.
+func (v *ChatVerifier) Verify(ctx context.Context, authorization string) error {
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return fmt.Errorf("no bearer token")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return err
+	}
+	if header.Alg != "RS256" {
+		return fmt.Errorf("unexpected token algorithm %q", header.Alg)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return fmt.Errorf("malformed token signature: %w", err)
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig); err != nil {
+		return fmt.Errorf("invalid token signature")
+	}
+
+	var claims struct {
+		Iss string `json:"iss"`
+		Aud string `json:"aud"`
+		Exp int64  `json:"exp"`
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return err
+	}
+	if claims.Iss != ChatIssuer {
+		return fmt.Errorf("token issued by %q, not Chat", claims.Iss)
+	}
+	if claims.Aud != v.Audience {
+		return fmt.Errorf("token for audience %q, not %q", claims.Aud, v.Audience)
+	}
+	// Allow for some clock skew.
+	if time.Now().After(time.Unix(claims.Exp, 0).Add(time.Minute)) {
+		return fmt.Errorf("token expired")
+	}
+	return nil
+}
+
+// key returns the public key with the ID kid, fetching the certificates
+// again when they are stale or the key is unknown (keys are rotated).
+func (v *ChatVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if key, ok := v.keys[kid]; ok && time.Now().Before(v.expires) {
+		return key, nil
+	}
+	if err := v.fetchKeys(ctx); err != nil {
+		return nil, err
+	}
+	key, ok := v.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown token key %q", kid)
+	}
+	return key, nil
+}
+
+// fetchKeys loads the certificates of ChatCertsURL. The caller holds v.mu.
+func (v *ChatVerifier) fetchKeys(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ChatCertsURL, nil)
+	if err != nil {
+		return err
+	}
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching Chat certificates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching Chat certificates: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("fetching Chat certificates: %w", err)
+	}
+
+	var certs map[string]string
+	if err := json.Unmarshal(body, &certs); err != nil {
+		return fmt.Errorf("parsing Chat certificates: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for kid, data := range certs {
+		block, _ := pem.Decode([]byte(data))
+		if block == nil {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			keys[kid] = key
+		}
+	}
+	v.keys = keys
+	v.expires = time.Now().Add(time.Hour)
+	return nil
+}
+
+// decodeJWTPart decodes a base64url JSON part of a JWT into v.
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+	return nil
+}
@@ -6,8 +6,13 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
+	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -33,6 +38,7 @@ var Scopes = []string{
 	"https://www.googleapis.com/auth/chat.users.readstate",
 	"https://www.googleapis.com/auth/chat.users.readstate.readonly",
 	"https://www.googleapis.com/auth/chat.users.spacesettings",
+	"https://www.googleapis.com/auth/userinfo.profile",
 }
 
 // RestrictedScopes contains scopes that require special access such as
@@ -49,6 +55,15 @@ var RestrictedScopes = []string{
 	"https://www.googleapis.com/auth/chat.import",
 }
 
+// OptionalScopes contains scopes that only some features need, such as
+// access to Drive or the Workspace directory. They are not requested during
+// normal user login.
+var OptionalScopes = []string{
+	"https://www.googleapis.com/auth/drive.file",
+	"https://www.googleapis.com/auth/drive",
+	"https://www.googleapis.com/auth/directory.readonly",
+}
+
 // DefaultClientID is the OAuth2 client ID for the gogchat CLI.
 // This is set at build time via -ldflags:
 //
@@ -72,7 +87,8 @@ var ErrMissingCredentials = errors.New(`OAuth2 credentials are not configured.
 
 If you installed gogchat via Homebrew or a release binary, this is a bug — please report it.
 
-If you built from source, you need to supply your own Google OAuth2 credentials:
+If you built from source, you need to supply your own Google OAuth2 credentials.
+Run gogchat without arguments in a terminal for a guided setup, or:
 
   Option 1: Build with credentials baked in:
     go build -ldflags "-X 'github.com/cipher-shad0w/gogchat/internal/auth.DefaultClientID=YOUR_ID' \
@@ -86,6 +102,9 @@ If you built from source, you need to supply your own Google OAuth2 credentials:
     export GOGCHAT_CLIENT_ID=YOUR_ID
     export GOGCHAT_CLIENT_SECRET=YOUR_SECRET
 
+  Option 4: Save them in the config file:
+    gogchat config init --client-id YOUR_ID --client-secret YOUR_SECRET
+
 To create OAuth2 credentials, visit:
   https://console.cloud.google.com/apis/credentials`)
 
@@ -98,8 +117,12 @@ func ValidateCredentials(clientID, clientSecret string) error {
 	return nil
 }
 
-// redirectURI is the local callback address used during the OAuth2 flow.
-const redirectURI = "http://localhost:8085"
+// defaultRedirectPort is the port of the local callback address used during
+// the OAuth2 flow when no other is configured.
+const defaultRedirectPort = 8085
+
+// redirectURI is the default local callback address of the OAuth2 flow.
+var redirectURI = fmt.Sprintf("http://localhost:%d", defaultRedirectPort)
 
 // GetOAuthConfig creates an OAuth2 configuration for the Google Chat API
 // using the provided client credentials.
@@ -113,12 +136,85 @@ func GetOAuthConfig(clientID, clientSecret string) *oauth2.Config {
 	}
 }
 
+// LoginOptions customize the OAuth2 callback of Login. The zero value
+// listens on localhost:8085 and redirects there.
+type LoginOptions struct {
+	// RedirectPort is the localhost port the callback server listens on.
+	// Zero means the port of RedirectURI if it points at localhost, else
+	// 8085.
+	RedirectPort int
+	// RedirectURI is the redirect URI sent to Google. It must be allowed
+	// by the OAuth client and lead to the callback server, e.g. through a
+	// port forward. Empty means http://localhost:{RedirectPort}.
+	RedirectURI string
+	// SuccessMessage is shown on the page the browser lands on after
+	// signing in, e.g. with the organization's name. Empty means
+	// DefaultSuccessMessage.
+	SuccessMessage string
+	// Timeout is how long to wait for the user to finish signing in in the
+	// browser. Zero means DefaultLoginTimeout.
+	Timeout time.Duration
+	// Scopes are requested in addition to the default Scopes, e.g. the
+	// RestrictedScopes needed by admin commands. Short names such as
+	// "chat.import" may be used.
+	Scopes []string
+}
+
+// DefaultLoginTimeout is how long Login waits for the browser by default.
+const DefaultLoginTimeout = 5 * time.Minute
+
+// ErrLoginTimeout is returned by Login when the browser flow was not
+// completed in time.
+var ErrLoginTimeout = errors.New("timed out waiting for the browser sign-in")
+
+// callback returns the redirect URI and the listen address of the callback
+// server for opts.
+func (opts LoginOptions) callback() (string, string, error) {
+	port := opts.RedirectPort
+	uri := opts.RedirectURI
+	if uri != "" {
+		u, err := url.Parse(uri)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", "", fmt.Errorf("invalid redirect URI %q: expected an absolute http:// or https:// URL", uri)
+		}
+		if port == 0 && isLoopback(u.Hostname()) && u.Port() != "" {
+			port, _ = strconv.Atoi(u.Port())
+		}
+	}
+	if port == 0 {
+		port = defaultRedirectPort
+	}
+	if port < 1 || port > 65535 {
+		return "", "", fmt.Errorf("invalid redirect port %d", port)
+	}
+	if uri == "" {
+		uri = fmt.Sprintf("http://localhost:%d", port)
+	}
+	return uri, fmt.Sprintf("localhost:%d", port), nil
+}
+
+// isLoopback reports whether host names the local machine.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // Login performs the full interactive OAuth2 authorization-code flow.
-// It starts a local HTTP server on localhost:8085 to receive the callback,
-// opens the user's browser to the consent screen, waits for the authorization
-// code, exchanges it for a token, and returns the resulting token.
-func Login(clientID, clientSecret string) (*oauth2.Token, error) {
+// It starts a local HTTP server (on localhost:8085 unless opts say
+// otherwise) to receive the callback, opens the user's browser to the
+// consent screen, waits for the authorization code, exchanges it for a
+// token, and returns the resulting token.
+func Login(clientID, clientSecret string, opts LoginOptions) (*oauth2.Token, error) {
+	redirect, addr, err := opts.callback()
+	if err != nil {
+		return nil, err
+	}
 	cfg := GetOAuthConfig(clientID, clientSecret)
+	cfg.RedirectURL = redirect
+	cfg.Scopes = loginScopes(opts.Scopes)
 
 	// Generate the authorization URL requesting offline access so that a
 	// refresh token is included in the response.
@@ -133,7 +229,22 @@ func Login(clientID, clientSecret string) (*oauth2.Token, error) {
 	resultCh := make(chan callbackResult, 1)
 
 	// Set up a temporary HTTP server to handle the OAuth2 redirect.
+	// Only the first callback counts; later ones (a reload of the page)
+	// must not block.
+	deliver := func(res callbackResult) {
+		select {
+		case resultCh <- res:
+		default:
+		}
+	}
+
+	successMessage := opts.SuccessMessage
+	if successMessage == "" {
+		successMessage = DefaultSuccessMessage
+	}
+
 	mux := http.NewServeMux()
+	mux.HandleFunc("/favicon.ico", http.NotFound)
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
 		if code == "" {
@@ -141,42 +252,59 @@ func Login(clientID, clientSecret string) (*oauth2.Token, error) {
 			if errMsg == "" {
 				errMsg = "no authorization code received"
 			}
-			http.Error(w, "Authentication failed: "+errMsg, http.StatusBadRequest)
-			resultCh <- callbackResult{err: fmt.Errorf("OAuth callback error: %s", errMsg)}
+			writeCallbackPage(w, http.StatusBadRequest, false, "Authentication failed",
+				"Google reported: "+errMsg+". Return to the terminal and run gogchat auth login again.")
+			deliver(callbackResult{err: fmt.Errorf("OAuth callback error: %s", errMsg)})
 			return
 		}
 
-		// Show a success page in the browser.
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		fmt.Fprint(w, "<html><body><h1>Authentication successful!</h1><p>You may close this window and return to the terminal.</p></body></html>")
-		resultCh <- callbackResult{code: code}
+		writeCallbackPage(w, http.StatusOK, true, "Authentication successful", successMessage)
+		deliver(callbackResult{code: code})
 	})
 
 	// Bind the listener before opening the browser so we know the port is
 	// available.
-	listener, err := net.Listen("tcp", "localhost:8085")
+	listener, err := net.Listen("tcp", addr)
 	if err != nil {
-		return nil, fmt.Errorf("starting local HTTP server: %w", err)
+		return nil, fmt.Errorf("starting local HTTP server on %s: %w", addr, err)
 	}
 
-	server := &http.Server{Handler: mux}
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
 	go func() {
 		_ = server.Serve(listener)
 	}()
 
-	// Open the authorization URL in the user's default browser.
-	fmt.Println("Opening browser for authentication...")
-	fmt.Printf("If the browser does not open automatically, visit:\n%s\n", authURL)
-	if err := openBrowser(authURL); err != nil {
-		fmt.Printf("Warning: could not open browser automatically: %v\n", err)
+	// Open the authorization URL in the user's default browser. Instructions
+	// go to stderr so they are not mixed into the output of a command that
+	// logs in again mid-run.
+	fmt.Fprintln(os.Stderr, "Opening browser for authentication...")
+	fmt.Fprintf(os.Stderr, "If the browser does not open automatically, visit:\n%s\n", authURL)
+	if redirect != redirectURI {
+		fmt.Fprintf(os.Stderr, "Waiting for the redirect to %s on %s...\n", redirect, addr)
+	}
+	if err := OpenBrowser(authURL); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not open browser automatically: %v\n", err)
 	}
 
-	// Block until the callback delivers a result.
-	res := <-resultCh
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultLoginTimeout
+	}
 
-	// Shut down the temporary server; ignore errors since we only care about
-	// the token exchange at this point.
-	_ = server.Shutdown(context.Background())
+	// Block until the callback delivers a result or the user gives up.
+	var res callbackResult
+	select {
+	case res = <-resultCh:
+	case <-time.After(timeout):
+		res.err = fmt.Errorf("%w after %s; run the login again to retry", ErrLoginTimeout, timeout)
+	}
+
+	// Shut down the temporary server, letting the success page finish
+	// loading; ignore errors since we only care about the token exchange
+	// at this point.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_ = server.Shutdown(ctx)
+	cancel()
 
 	if res.err != nil {
 		return nil, res.err
@@ -218,8 +346,46 @@ func HTTPClient(clientID, clientSecret string, token *oauth2.Token) *http.Client
 	return cfg.Client(context.Background(), token)
 }
 
-// openBrowser attempts to open the given URL in the user's default browser.
-func openBrowser(url string) error {
+// SwappableTokenSource is an oauth2.TokenSource whose underlying source can
+// be replaced while requests are in flight, e.g. after the user logs in
+// again because the refresh token was revoked.
+type SwappableTokenSource struct {
+	mu  sync.Mutex
+	src oauth2.TokenSource
+}
+
+// NewSwappableTokenSource returns a SwappableTokenSource that refreshes token
+// with the given client credentials.
+func NewSwappableTokenSource(clientID, clientSecret string, token *oauth2.Token) *SwappableTokenSource {
+	return &SwappableTokenSource{src: TokenSource(clientID, clientSecret, token)}
+}
+
+// Token returns a valid token from the current underlying source.
+func (s *SwappableTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	src := s.src
+	s.mu.Unlock()
+	return src.Token()
+}
+
+// Swap replaces the underlying source with one that refreshes token.
+func (s *SwappableTokenSource) Swap(clientID, clientSecret string, token *oauth2.Token) {
+	src := TokenSource(clientID, clientSecret, token)
+	s.mu.Lock()
+	s.src = src
+	s.mu.Unlock()
+}
+
+// SourceHTTPClient returns an *http.Client that authorizes every request
+// with a token from src and sends it through base (http.DefaultTransport if
+// nil). Unlike HTTPClient, no token is cached outside src, so swapping the
+// source of a SwappableTokenSource takes effect immediately.
+func SourceHTTPClient(src oauth2.TokenSource, base http.RoundTripper) *http.Client {
+	return &http.Client{Transport: &oauth2.Transport{Source: src, Base: base}}
+}
+
+// OpenBrowser attempts to open the given URL in the user's default browser.
+func OpenBrowser(url string) error {
 	var cmd *exec.Cmd
 
 	switch runtime.GOOS {
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// DefaultSuccessMessage is shown on the login callback page when no custom
+// message is configured.
+const DefaultSuccessMessage = "You are now signed in to gogchat. You can close this window and return to the terminal."
+
+// callbackPage is the page the browser lands on after the OAuth2 redirect.
+// On success it tries to close itself after a few seconds; browsers only
+// allow that for windows opened by a script, so the text says what to do
+// otherwise.
+var callbackPage = template.Must(template.New("callback").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>gogchat – {{.Title}}</title>
+<style>
+  body { margin: 0; min-height: 100vh; display: flex; align-items: center; justify-content: center;
+         font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, sans-serif;
+         background: #f1f3f4; color: #202124; }
+  .card { max-width: 28rem; padding: 2.5rem; border-radius: 12px; background: #fff;
+          box-shadow: 0 1px 3px rgba(60, 64, 67, .3), 0 4px 8px rgba(60, 64, 67, .15); text-align: center; }
+  .icon { font-size: 3rem; line-height: 1; color: {{if .OK}}#188038{{else}}#d93025{{end}}; }
+  h1 { font-size: 1.5rem; font-weight: 500; margin: 1rem 0 .5rem; }
+  p { line-height: 1.5; color: #5f6368; }
+  .hint { font-size: .875rem; }
+  @media (prefers-color-scheme: dark) {
+    body { background: #202124; color: #e8eaed; }
+    .card { background: #303134; box-shadow: none; }
+    p { color: #bdc1c6; }
+  }
+</style>
+</head>
+<body>
+<div class="card">
+  <div class="icon">{{if .OK}}✓{{else}}✗{{end}}</div>
+  <h1>{{.Title}}</h1>
+  <p>{{.Message}}</p>
+  {{if .OK}}<p class="hint" id="hint">This window closes automatically.</p>{{end}}
+</div>
+{{if .OK}}<script>
+  setTimeout(function () {
+    window.close();
+    document.getElementById("hint").textContent = "You can close this window now.";
+  }, 3000);
+</script>{{end}}
+</body>
+</html>
+`))
+
+// writeCallbackPage renders the callback page with the given outcome.
+func writeCallbackPage(w http.ResponseWriter, status int, ok bool, title, message string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_ = callbackPage.Execute(w, struct {
+		OK             bool
+		Title, Message string
+	}{ok, title, message})
+}
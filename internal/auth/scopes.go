@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// scopePrefix is the common prefix of Google OAuth2 scope URLs.
+const scopePrefix = "https://www.googleapis.com/auth/"
+
+// TokenInfoURL is the endpoint that describes an access token.
+var TokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// ScopeURL returns the full URL of scope, which may be given by its short
+// name such as "chat.import".
+func ScopeURL(scope string) string {
+	if strings.Contains(scope, "://") {
+		return scope
+	}
+	return scopePrefix + scope
+}
+
+// ShortScope returns the short name of a scope URL, e.g. "chat.import".
+// Scopes outside https://www.googleapis.com/auth/ are returned unchanged.
+func ShortScope(scope string) string {
+	return strings.TrimPrefix(scope, scopePrefix)
+}
+
+// loginScopes returns the default Scopes followed by extra, without
+// duplicates.
+func loginScopes(extra []string) []string {
+	scopes := append([]string(nil), Scopes...)
+	seen := make(map[string]bool, len(scopes))
+	for _, s := range scopes {
+		seen[s] = true
+	}
+	for _, s := range extra {
+		s = ScopeURL(strings.TrimSpace(s))
+		if s == scopePrefix || seen[s] {
+			continue
+		}
+		seen[s] = true
+		scopes = append(scopes, s)
+	}
+	return scopes
+}
+
+// TokenScopes asks Google which scopes accessToken was granted. The
+// returned scopes are full URLs.
+func TokenScopes(ctx context.Context, client *http.Client, accessToken string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, TokenInfoURL+"?access_token="+url.QueryEscape(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading token info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("reading token info: %w", err)
+	}
+	var info struct {
+		Scope            string `json:"scope"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, fmt.Errorf("parsing token info: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if info.ErrorDescription == "" {
+			info.ErrorDescription = resp.Status
+		}
+		return nil, fmt.Errorf("reading token info: %s", info.ErrorDescription)
+	}
+	return strings.Fields(info.Scope), nil
+}
@@ -0,0 +1,266 @@
+// Package cache keeps the results of lookups (space display names, user
+// IDs, directory searches, and custom emoji) in a bbolt database so that
+// later commands do not repeat them. Each kind of entry has its own time to
+// live.
+//
+// The database is opened for each operation and closed right after, so
+// that concurrent gogchat processes (e.g. shell completion while a
+// long-running command is active) only wait for each other briefly. When
+// the cache cannot be used, lookups simply go to the API.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Kinds of cached entries. Each kind is stored in its own bucket.
+const (
+	// Spaces maps space resource names to display names.
+	Spaces = "spaces"
+	// Users maps email addresses to users/{id} resource names.
+	Users = "users"
+	// Directory maps directory search queries, per login, to their
+	// matches.
+	Directory = "directory"
+	// Emoji maps custom emoji shortcodes (":name:"), per login, to their
+	// UIDs.
+	Emoji = "emoji"
+	// Scopes maps logins (hashed refresh tokens) to their granted scopes.
+	Scopes = "scopes"
+)
+
+// Kinds lists every kind of entry.
+var Kinds = []string{Spaces, Users, Directory, Emoji, Scopes}
+
+// lockTimeout is how long an operation waits for another process that
+// holds the database.
+const lockTimeout = time.Second
+
+// Cache is an on-disk cache of lookup results.
+type Cache struct {
+	path string
+	ttl  map[string]time.Duration
+}
+
+// New returns a cache stored at path. ttl holds the time to live of each
+// kind; kinds without a positive TTL are not cached.
+func New(path string, ttl map[string]time.Duration) *Cache {
+	return &Cache{path: path, ttl: ttl}
+}
+
+// Path returns the location of the database.
+func (c *Cache) Path() string {
+	return c.path
+}
+
+// entry is the stored form of a cached value.
+type entry struct {
+	Time  time.Time       `json:"time"`
+	Value json.RawMessage `json:"value"`
+}
+
+// expired reports whether e is older than ttl.
+func (e entry) expired(ttl time.Duration) bool {
+	return time.Since(e.Time) >= ttl
+}
+
+// Get loads the entry of kind for key into v. It reports false if there is
+// no such entry, it has expired, or the cache cannot be read.
+func (c *Cache) Get(kind, key string, v interface{}) bool {
+	ttl := c.ttl[kind]
+	if ttl <= 0 {
+		return false
+	}
+	if _, err := os.Stat(c.path); err != nil {
+		return false
+	}
+
+	var e entry
+	found := false
+	err := c.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(kind))
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = json.Unmarshal(data, &e) == nil
+		return nil
+	})
+	if err != nil || !found || e.expired(ttl) {
+		return false
+	}
+	return json.Unmarshal(e.Value, v) == nil
+}
+
+// Put stores v as the entry of kind for key. Nothing is stored for kinds
+// that are not cached.
+func (c *Cache) Put(kind, key string, v interface{}) error {
+	return c.PutAll(kind, map[string]interface{}{key: v})
+}
+
+// PutAll stores several entries of kind in one write.
+func (c *Cache) PutAll(kind string, values map[string]interface{}) error {
+	if c.ttl[kind] <= 0 || len(values) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	encoded := make(map[string][]byte, len(values))
+	for key, v := range values {
+		value, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(entry{Time: now, Value: value})
+		if err != nil {
+			return err
+		}
+		encoded[key] = data
+	}
+
+	return c.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(kind))
+		if err != nil {
+			return err
+		}
+		for key, data := range encoded {
+			if err := b.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Clear removes the entries of the given kinds, or of every kind if none
+// are given, and returns how many were removed. With expiredOnly, only
+// entries past their TTL (and entries of kinds no longer cached) are
+// removed.
+func (c *Cache) Clear(expiredOnly bool, kinds ...string) (int, error) {
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+	if _, err := os.Stat(c.path); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	removed := 0
+	err := c.update(func(tx *bolt.Tx) error {
+		for _, kind := range kinds {
+			b := tx.Bucket([]byte(kind))
+			if b == nil {
+				continue
+			}
+			if !expiredOnly || c.ttl[kind] <= 0 {
+				removed += b.Stats().KeyN
+				if err := tx.DeleteBucket([]byte(kind)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var stale [][]byte
+			err := b.ForEach(func(k, v []byte) error {
+				var e entry
+				if json.Unmarshal(v, &e) != nil || e.expired(c.ttl[kind]) {
+					stale = append(stale, k)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, k := range stale {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			removed += len(stale)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// Stats describes the entries of one kind.
+type Stats struct {
+	Kind    string        `json:"kind"`
+	TTL     time.Duration `json:"ttl"`
+	Entries int           `json:"entries"`
+	Expired int           `json:"expired"`
+	// Bytes is the size of the stored entries.
+	Bytes int `json:"bytes"`
+	// Oldest is when the oldest entry was stored.
+	Oldest time.Time `json:"oldest,omitzero"`
+}
+
+// Stats returns the statistics of every kind, in the order of Kinds.
+func (c *Cache) Stats() ([]Stats, error) {
+	stats := make([]Stats, len(Kinds))
+	for i, kind := range Kinds {
+		stats[i] = Stats{Kind: kind, TTL: c.ttl[kind]}
+	}
+	if _, err := os.Stat(c.path); os.IsNotExist(err) {
+		return stats, nil
+	}
+
+	err := c.view(func(tx *bolt.Tx) error {
+		for i := range stats {
+			s := &stats[i]
+			b := tx.Bucket([]byte(s.Kind))
+			if b == nil {
+				continue
+			}
+			err := b.ForEach(func(k, v []byte) error {
+				s.Entries++
+				s.Bytes += len(k) + len(v)
+				var e entry
+				if json.Unmarshal(v, &e) != nil {
+					s.Expired++
+					return nil
+				}
+				if s.TTL <= 0 || e.expired(s.TTL) {
+					s.Expired++
+				}
+				if s.Oldest.IsZero() || e.Time.Before(s.Oldest) {
+					s.Oldest = e.Time
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// view runs fn in a read-only transaction.
+func (c *Cache) view(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(c.path, 0o600, &bolt.Options{Timeout: lockTimeout, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("opening cache %s: %w", c.path, err)
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// update runs fn in a read-write transaction, creating the database if
+// needed.
+func (c *Cache) update(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(c.path, 0o600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return fmt.Errorf("opening cache %s: %w", c.path, err)
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
@@ -0,0 +1,385 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// allAccounts is the --account value that runs a command against every
+// configured profile.
+const allAccounts = "all"
+
+// fanOutCommand is a read-only command that can run against several
+// accounts. Instead of printing, it returns the API resources it found, so
+// that the results of all accounts can be merged.
+type fanOutCommand struct {
+	// list says whether the command lists resources; otherwise it returns
+	// a single one.
+	list bool
+	// unsupported are the flags of the command that only make sense for
+	// one account (--page-token always is).
+	unsupported []string
+	// columns and row render the resources as table rows.
+	columns []string
+	row     func(item map[string]interface{}) []string
+	// fetch returns the resources, as raw JSON, using client.
+	fetch func(ctx context.Context, client *api.Client, cmd *cobra.Command, args []string) ([]json.RawMessage, error)
+}
+
+// fanOutCommands are the commands that may run against several accounts at
+// once, by path below the root command.
+var fanOutCommands = map[string]fanOutCommand{
+	"spaces list": {
+		list:        true,
+		unsupported: []string{"sort-by", "reverse", "group-by", "expand"},
+		columns:     []string{"NAME", "DISPLAY_NAME", "TYPE", "MEMBER_COUNT", "LAST_ACTIVE", "CREATE_TIME"},
+		row:         fanOutSpaceRow,
+		fetch: func(ctx context.Context, client *api.Client, cmd *cobra.Command, args []string) ([]json.RawMessage, error) {
+			svc := api.NewSpacesService(client)
+			filter, _ := cmd.Flags().GetString("filter")
+			pageSize := getPageSize(cmd)
+			return fetchPages(cmd, "listing spaces", "spaces", func(pageToken string) (json.RawMessage, error) {
+				return svc.List(ctx, filter, pageSize, pageToken)
+			})
+		},
+	},
+	"spaces search": {
+		list:    true,
+		columns: []string{"NAME", "DISPLAY_NAME", "TYPE", "MEMBER_COUNT", "LAST_ACTIVE", "CREATE_TIME"},
+		row:     fanOutSpaceRow,
+		fetch: func(ctx context.Context, client *api.Client, cmd *cobra.Command, args []string) ([]json.RawMessage, error) {
+			svc := api.NewSpacesService(client)
+			admin, _ := cmd.Flags().GetBool("admin")
+			pageSize := getPageSize(cmd)
+			orderBy, err := resolveOrderBy(cmd, spaceSearchSortFields)
+			if err != nil {
+				return nil, err
+			}
+			query, err := buildSpaceSearchQuery(cmd)
+			if err != nil {
+				return nil, err
+			}
+			return fetchPages(cmd, "searching spaces", "spaces", func(pageToken string) (json.RawMessage, error) {
+				return svc.Search(ctx, query, pageSize, pageToken, orderBy, admin)
+			})
+		},
+	},
+	"spaces get": {
+		columns: []string{"NAME", "DISPLAY_NAME", "TYPE", "MEMBER_COUNT", "LAST_ACTIVE", "CREATE_TIME"},
+		row:     fanOutSpaceRow,
+		fetch: func(ctx context.Context, client *api.Client, cmd *cobra.Command, args []string) ([]json.RawMessage, error) {
+			admin, _ := cmd.Flags().GetBool("admin")
+			raw, err := api.NewSpacesService(client).Get(ctx, args[0], admin)
+			if err != nil {
+				return nil, fmt.Errorf("getting space: %w", err)
+			}
+			return []json.RawMessage{raw}, nil
+		},
+	},
+	"spaces find-dm": {
+		columns: []string{"NAME", "DISPLAY_NAME", "TYPE", "MEMBER_COUNT", "LAST_ACTIVE", "CREATE_TIME"},
+		row:     fanOutSpaceRow,
+		fetch: func(ctx context.Context, client *api.Client, cmd *cobra.Command, args []string) ([]json.RawMessage, error) {
+			user, _ := cmd.Flags().GetString("user")
+			switch {
+			case len(args) == 1 && user != "":
+				return nil, fmt.Errorf("give the user either as an argument or with --user, not both")
+			case len(args) == 1:
+				user = args[0]
+			case user == "":
+				return nil, fmt.Errorf("a user is required: gogchat spaces find-dm alice@example.com")
+			}
+			user, err := resolveUser(ctx, client, user)
+			if err != nil {
+				return nil, err
+			}
+			raw, err := api.NewSpacesService(client).FindDirectMessage(ctx, user)
+			if err != nil {
+				return nil, fmt.Errorf("finding direct message: %w", err)
+			}
+			return []json.RawMessage{raw}, nil
+		},
+	},
+	"members list": {
+		list:    true,
+		columns: []string{"NAME", "MEMBER_NAME", "DISPLAY_NAME", "ROLE", "TYPE", "STATE"},
+		row: func(m map[string]interface{}) []string {
+			return []string{
+				spaceMapStr(m, "name"),
+				spaceExtractNested(m, "member.name"),
+				spaceExtractNested(m, "member.displayName"),
+				spaceMapStr(m, "role"),
+				spaceExtractNested(m, "member.type"),
+				formatMemberState(m["state"]),
+			}
+		},
+		fetch: func(ctx context.Context, client *api.Client, cmd *cobra.Command, args []string) ([]json.RawMessage, error) {
+			svc := api.NewMembersService(client)
+			pageSize := getPageSize(cmd)
+			filter, _ := cmd.Flags().GetString("filter")
+			showInvited, _ := cmd.Flags().GetBool("show-invited")
+			showGroups, _ := cmd.Flags().GetBool("show-groups")
+			admin, _ := cmd.Flags().GetBool("admin")
+			return fetchPages(cmd, "listing members", "memberships", func(pageToken string) (json.RawMessage, error) {
+				return svc.List(ctx, args[0], pageSize, pageToken, filter, showInvited, showGroups, admin)
+			})
+		},
+	},
+	"messages list": {
+		list:        true,
+		unsupported: []string{"translate", "render"},
+		columns:     []string{"NAME", "SENDER", "TEXT", "CREATE_TIME"},
+		row:         fanOutMessageRow,
+		fetch: func(ctx context.Context, client *api.Client, cmd *cobra.Command, args []string) ([]json.RawMessage, error) {
+			svc := api.NewMessagesService(client)
+			pageSize := getPageSize(cmd)
+			filter, _ := cmd.Flags().GetString("filter")
+			showDeleted, _ := cmd.Flags().GetBool("show-deleted")
+			filter, err := timeRangeFilter(cmd, filter, "createTime")
+			if err != nil {
+				return nil, err
+			}
+			orderBy, err := resolveOrderBy(cmd, messageSortFields)
+			if err != nil {
+				return nil, err
+			}
+			return fetchPages(cmd, "listing messages", "messages", func(pageToken string) (json.RawMessage, error) {
+				return svc.List(ctx, args[0], pageSize, pageToken, filter, orderBy, showDeleted)
+			})
+		},
+	},
+	"messages get": {
+		columns: []string{"NAME", "SENDER", "TEXT", "CREATE_TIME"},
+		row:     fanOutMessageRow,
+		fetch: func(ctx context.Context, client *api.Client, cmd *cobra.Command, args []string) ([]json.RawMessage, error) {
+			raw, err := api.NewMessagesService(client).Get(ctx, args[0])
+			if err != nil {
+				return nil, fmt.Errorf("getting message: %w", err)
+			}
+			return []json.RawMessage{raw}, nil
+		},
+	},
+}
+
+// fanOutSpaceRow renders a space as a table row.
+func fanOutSpaceRow(sp map[string]interface{}) []string {
+	return []string{
+		spaceMapStr(sp, "name"),
+		spaceMapStr(sp, "displayName"),
+		spaceMapStr(sp, "spaceType"),
+		spaceMapStr(sp, "membershipCount"),
+		output.FormatTime(spaceMapStr(sp, "lastActiveTime")),
+		output.FormatTime(spaceMapStr(sp, "createTime")),
+	}
+}
+
+// fanOutMessageRow renders a message as a table row.
+func fanOutMessageRow(msg map[string]interface{}) []string {
+	sender := spaceExtractNested(msg, "sender.displayName")
+	if sender == "" {
+		sender = spaceExtractNested(msg, "sender.name")
+	}
+	return []string{
+		spaceMapStr(msg, "name"),
+		sender,
+		output.Truncate(spaceMapStr(msg, "text"), 60),
+		output.FormatTime(spaceMapStr(msg, "createTime")),
+	}
+}
+
+// fetchPages returns the items in the field key of the pages returned by
+// list: the first page, or every page with --all. what describes the
+// listing in errors.
+func fetchPages(cmd *cobra.Command, what, key string, list func(pageToken string) (json.RawMessage, error)) ([]json.RawMessage, error) {
+	all, _ := cmd.Flags().GetBool("all")
+	var items []json.RawMessage
+	pageToken := ""
+	for {
+		raw, err := list(pageToken)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", what, err)
+		}
+		var page map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		var pageItems []json.RawMessage
+		if data, ok := page[key]; ok {
+			if err := json.Unmarshal(data, &pageItems); err != nil {
+				return nil, fmt.Errorf("parsing response: %w", err)
+			}
+		}
+		items = append(items, pageItems...)
+
+		pageToken = ""
+		if data, ok := page["nextPageToken"]; ok {
+			_ = json.Unmarshal(data, &pageToken)
+		}
+		if !all || pageToken == "" {
+			return items, nil
+		}
+	}
+}
+
+// fanOutCommandNames returns the paths of the commands that can run against
+// several accounts, sorted.
+func fanOutCommandNames() []string {
+	names := make([]string, 0, len(fanOutCommands))
+	for name := range fanOutCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyAccount applies --account and --profiles. A single --account selects
+// that profile's token and credentials for the command; --account all or
+// --profiles a,b makes cmd run once per profile with the results merged.
+func applyAccount(cmd *cobra.Command) error {
+	account := viper.GetString("account")
+	profiles, _ := cmd.Flags().GetStringSlice("profiles")
+
+	if len(profiles) == 0 && account != allAccounts {
+		if account == "" {
+			return nil
+		}
+		cfg, err := Cfg.WithProfile(account)
+		if err != nil {
+			return err
+		}
+		Cfg = cfg
+		return nil
+	}
+
+	if len(profiles) > 0 && account != "" {
+		return fmt.Errorf("--account and --profiles cannot be combined")
+	}
+	if len(profiles) == 0 {
+		profiles = Cfg.ProfileNames()
+		if len(profiles) == 0 {
+			return fmt.Errorf("--account all needs at least one profile under profiles in the config file")
+		}
+	}
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	spec, ok := fanOutCommands[path]
+	if !ok {
+		return fmt.Errorf("%q cannot run against several accounts (supported: %s); pick one with --account NAME",
+			cmd.CommandPath(), strings.Join(fanOutCommandNames(), ", "))
+	}
+	if viper.GetBool("as_app") {
+		return fmt.Errorf("--as-app cannot be combined with --account all or --profiles")
+	}
+	var unsupported []string
+	cmd.Flags().Visit(func(fl *pflag.Flag) {
+		if fl.Name == "page-token" || containsString(spec.unsupported, fl.Name) {
+			unsupported = append(unsupported, "--"+fl.Name)
+		}
+	})
+	if len(unsupported) > 0 {
+		return fmt.Errorf("%s cannot be used with several accounts", strings.Join(unsupported, ", "))
+	}
+
+	configs := make([]*config.Config, len(profiles))
+	for i, name := range profiles {
+		cfg, err := Cfg.WithProfile(name)
+		if err != nil {
+			return err
+		}
+		configs[i] = cfg
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return runFanOut(cmd, args, spec, profiles, configs)
+	}
+	return nil
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// runFanOut runs spec once per account, each with a client for the
+// account's config, and prints the merged resources with the account they
+// came from. A failing account does not stop the others; its error is
+// reported and the command fails once every account has run.
+func runFanOut(cmd *cobra.Command, args []string, spec fanOutCommand, accounts []string, configs []*config.Config) error {
+	// The fan-out replaces the RunE of useDefaultSpace, so the default
+	// space and the check it puts off are applied here.
+	args, err := defaultSpaceArgs(cmd, args)
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+	ctx := cmd.Context()
+
+	var items []interface{}
+	table := output.NewTable(append([]string{"ACCOUNT"}, spec.columns...)...)
+	failed := 0
+	for i, account := range accounts {
+		resources, err := fanOutAccount(ctx, cmd, args, spec, configs[i])
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "✗ %s: %v\n", account, err)
+			continue
+		}
+		for _, raw := range resources {
+			var item map[string]interface{}
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return fmt.Errorf("parsing response: %w", err)
+			}
+			table.AddRow(append([]string{account}, spec.row(item)...)...)
+			item["account"] = account
+			items = append(items, item)
+		}
+	}
+
+	switch {
+	case f.IsJSON() && spec.list:
+		if err := printList(f, items, ""); err != nil {
+			return err
+		}
+	case f.IsJSON():
+		if items == nil {
+			items = []interface{}{}
+		}
+		if err := f.Print(items); err != nil {
+			return err
+		}
+	case len(items) == 0:
+		f.PrintMessage("No results.")
+	default:
+		fmt.Print(table.Render())
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d accounts failed", failed, len(accounts))
+	}
+	return nil
+}
+
+// fanOutAccount runs spec for the account whose config is cfg.
+func fanOutAccount(ctx context.Context, cmd *cobra.Command, args []string, spec fanOutCommand, cfg *config.Config) ([]json.RawMessage, error) {
+	client, err := newAccountClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return spec.fetch(ctx, client, cmd, args)
+}
@@ -0,0 +1,471 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// alertmanagerMaxAlerts is the number of alerts shown in one message; the
+// rest of a group are counted.
+const alertmanagerMaxAlerts = 10
+
+// alertGroup is the webhook payload of Alertmanager (version 4).
+type alertGroup struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []alert           `json:"alerts"`
+}
+
+// alert is one alert of an alertGroup.
+type alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// severityStyles are the color and marker of alert severities, by the
+// severity label; resolved alerts are always green.
+var severityStyles = map[string]struct{ color, marker string }{
+	"critical": {"#d93025", "🔴"},
+	"page":     {"#d93025", "🔴"},
+	"error":    {"#d93025", "🔴"},
+	"warning":  {"#e37400", "🟠"},
+	"info":     {"#1a73e8", "🔵"},
+	"resolved": {"#188038", "🟢"},
+	"":         {"#5f6368", "⚪"},
+}
+
+// style returns the color and marker of a.
+func (a alert) style() (string, string) {
+	key := strings.ToLower(a.Labels["severity"])
+	if a.Status == "resolved" {
+		key = "resolved"
+	}
+	s, ok := severityStyles[key]
+	if !ok {
+		s = severityStyles[""]
+	}
+	return s.color, s.marker
+}
+
+// summary returns the one-line description of a.
+func (a alert) summary() string {
+	for _, key := range []string{"summary", "description", "message"} {
+		if s := a.Annotations[key]; s != "" {
+			return s
+		}
+	}
+	return a.Labels["alertname"]
+}
+
+// silenceURL returns the Alertmanager page for silencing the alert with
+// labels.
+func silenceURL(externalURL string, labels map[string]string) string {
+	if externalURL == "" {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	matchers := make([]string, len(names))
+	for i, name := range names {
+		matchers[i] = fmt.Sprintf("%s=%q", name, labels[name])
+	}
+	filter := "{" + strings.Join(matchers, ", ") + "}"
+	return strings.TrimRight(externalURL, "/") + "/#/silences/new?filter=" + strings.ReplaceAll(url.QueryEscape(filter), "+", "%20")
+}
+
+// title returns the heading of g, e.g. "[FIRING:2] HighLatency".
+func (g alertGroup) title() string {
+	firing := 0
+	for _, a := range g.Alerts {
+		if a.Status == "firing" {
+			firing++
+		}
+	}
+	name := g.GroupLabels["alertname"]
+	if name == "" {
+		name = g.CommonLabels["alertname"]
+	}
+	if name == "" {
+		name = g.Receiver
+	}
+	if g.Status == "resolved" {
+		return fmt.Sprintf("[RESOLVED] %s", name)
+	}
+	return fmt.Sprintf("[FIRING:%d] %s", firing, name)
+}
+
+// labelsText formats the group labels other than alertname.
+func (g alertGroup) labelsText() string {
+	var parts []string
+	for name, value := range g.GroupLabels {
+		if name != "alertname" {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// shown returns the alerts to show and the number left out.
+func (g alertGroup) shown() ([]alert, int) {
+	alerts := g.Alerts
+	if len(alerts) > alertmanagerMaxAlerts {
+		alerts = alerts[:alertmanagerMaxAlerts]
+	}
+	return alerts, len(g.Alerts) - len(alerts) + g.TruncatedAlerts
+}
+
+// card renders g as a cardsV2 message.
+func (g alertGroup) card() map[string]interface{} {
+	alerts, more := g.shown()
+	var sections []interface{}
+	for _, a := range alerts {
+		color, _ := a.style()
+		label := strings.ToUpper(a.Status)
+		if severity := a.Labels["severity"]; severity != "" {
+			label += " · " + severity
+		}
+		widgets := []interface{}{
+			map[string]interface{}{"decoratedText": map[string]interface{}{
+				"topLabel":    label,
+				"text":        fmt.Sprintf(`<font color="%s"><b>%s</b></font>`, color, html.EscapeString(a.summary())),
+				"bottomLabel": "Since " + output.FormatTime(a.StartsAt.Format(time.RFC3339)),
+				"wrapText":    true,
+			}},
+		}
+		if d := a.Annotations["description"]; d != "" && d != a.summary() {
+			widgets = append(widgets, map[string]interface{}{"textParagraph": map[string]interface{}{"text": html.EscapeString(d)}})
+		}
+		var buttons []interface{}
+		addButton := func(text, link string) {
+			if link != "" {
+				buttons = append(buttons, map[string]interface{}{"text": text, "onClick": map[string]interface{}{"openLink": map[string]interface{}{"url": link}}})
+			}
+		}
+		if a.Status == "firing" {
+			addButton("Silence", silenceURL(g.ExternalURL, a.Labels))
+		}
+		addButton("Runbook", a.Annotations["runbook_url"])
+		addButton("Source", a.GeneratorURL)
+		if len(buttons) > 0 {
+			widgets = append(widgets, map[string]interface{}{"buttonList": map[string]interface{}{"buttons": buttons}})
+		}
+		sections = append(sections, map[string]interface{}{"widgets": widgets})
+	}
+	if more > 0 {
+		sections = append(sections, map[string]interface{}{"widgets": []interface{}{
+			map[string]interface{}{"textParagraph": map[string]interface{}{"text": fmt.Sprintf("<i>... and %d more</i>", more)}},
+		}})
+	}
+
+	header := map[string]interface{}{"title": g.title()}
+	if labels := g.labelsText(); labels != "" {
+		header["subtitle"] = labels
+	}
+	return map[string]interface{}{
+		"text": g.title(),
+		"cardsV2": []interface{}{map[string]interface{}{
+			"cardId": "alertmanager",
+			"card":   map[string]interface{}{"header": header, "sections": sections},
+		}},
+	}
+}
+
+// text renders g as a text message, for user authentication, which cannot
+// post cards.
+func (g alertGroup) text() map[string]interface{} {
+	alerts, more := g.shown()
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*", g.title())
+	if labels := g.labelsText(); labels != "" {
+		fmt.Fprintf(&b, " (%s)", labels)
+	}
+	for _, a := range alerts {
+		_, marker := a.style()
+		fmt.Fprintf(&b, "\n%s %s", marker, a.summary())
+		if severity := a.Labels["severity"]; severity != "" {
+			fmt.Fprintf(&b, " _%s_", severity)
+		}
+		var links []string
+		if a.Status == "firing" {
+			if u := silenceURL(g.ExternalURL, a.Labels); u != "" {
+				links = append(links, "<"+u+"|Silence>")
+			}
+		}
+		if u := a.Annotations["runbook_url"]; u != "" {
+			links = append(links, "<"+u+"|Runbook>")
+		}
+		if a.GeneratorURL != "" {
+			links = append(links, "<"+a.GeneratorURL+"|Source>")
+		}
+		if len(links) > 0 {
+			b.WriteString(" · " + strings.Join(links, " · "))
+		}
+	}
+	if more > 0 {
+		fmt.Fprintf(&b, "\n_... and %d more_", more)
+	}
+	return map[string]interface{}{"text": b.String()}
+}
+
+// threadKey returns the thread key of g: its alerts, firing and resolved,
+// share a thread.
+func (g alertGroup) threadKey() string {
+	sum := sha256.Sum256([]byte(g.GroupKey))
+	return "alertmanager:" + hex.EncodeToString(sum[:8])
+}
+
+// newBridgeAlertmanagerCmd creates the "bridge alertmanager" subcommand.
+func newBridgeAlertmanagerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alertmanager",
+		Short: "Post Prometheus Alertmanager notifications",
+		Long: `Receive notifications from Prometheus Alertmanager over HTTP and post
+them to a space, one message per notification.
+
+Add a webhook receiver pointing at the bridge to alertmanager.yml:
+
+  receivers:
+    - name: chat
+      webhook_configs:
+        - url: http://localhost:9097/
+          send_resolved: true
+
+Each alert group gets a thread, in which its firing and resolved
+notifications follow each other. Messages are cards with the alerts colored
+by their severity label, and Silence, Runbook (runbook_url annotation), and
+Source buttons. Cards can only be posted by Chat apps, so they are used
+with --as-app or --webhook (a webhook saved with "webhooks add"); with user
+authentication, the same content is posted as text.
+
+Past --rate messages per minute, notifications are refused with HTTP 429
+and Alertmanager sends them again later. Notifications that cannot be
+posted are kept in the dead-letter queue (see "gogchat dlq") and answered
+with HTTP 200, so that they are not posted twice once retried; only if they
+cannot be kept either are they answered with HTTP 502 for Alertmanager to
+retry. With --bearer-token-file,
+requests must carry the token, as set with http_config.authorization in
+the webhook config.`,
+		Example: `  gogchat bridge alertmanager --listen :9097 --space spaces/AAAABBBBcccc --as-app
+  gogchat bridge alertmanager --listen 127.0.0.1:9097 --webhook oncall
+  gogchat bridge alertmanager --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: runBridgeAlertmanager,
+	}
+
+	flags := cmd.Flags()
+	flags.String("listen", ":9097", "Address to receive Alertmanager webhooks on")
+	flags.String("webhook", "", "Post through this saved webhook instead of the API")
+	flags.String("bearer-token-file", "", "Only accept requests with the bearer token in this file")
+	addBridgeFlags(cmd)
+	cmd.MarkFlagsMutuallyExclusive("webhook", "space")
+
+	return cmd
+}
+
+func runBridgeAlertmanager(cmd *cobra.Command, args []string) error {
+	listen, _ := cmd.Flags().GetString("listen")
+	webhookName, _ := cmd.Flags().GetString("webhook")
+	tokenFile, _ := cmd.Flags().GetString("bearer-token-file")
+	rate, _ := cmd.Flags().GetInt("rate")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	f := getFormatter()
+	if rate < 1 {
+		return fmt.Errorf("--rate must be at least 1")
+	}
+
+	var token string
+	if tokenFile != "" {
+		data, err := os.ReadFile(expandHome(tokenFile))
+		if err != nil {
+			return fmt.Errorf("reading bearer token: %w", err)
+		}
+		if token = strings.TrimSpace(string(data)); token == "" {
+			return fmt.Errorf("%s is empty", tokenFile)
+		}
+	}
+
+	// post sends a message into the thread with the key, and cards says
+	// whether it may contain cards.
+	var post func(ctx context.Context, body map[string]interface{}, key string) (json.RawMessage, error)
+	var target, space string
+	cards := true
+	switch {
+	case dryRun:
+		target = "stdout"
+		post = func(ctx context.Context, body map[string]interface{}, key string) (json.RawMessage, error) {
+			data, err := json.MarshalIndent(body, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("--- thread %s\n%s\n", key, data)
+			return nil, nil
+		}
+	case webhookName != "":
+		w, err := findWebhook(webhookName)
+		if err != nil {
+			return err
+		}
+		target = w.Space + " (webhook " + w.Name + ")"
+		space = w.Space
+		post = func(ctx context.Context, body map[string]interface{}, key string) (json.RawMessage, error) {
+			return postWebhook(ctx, w, body, key)
+		}
+	default:
+		var err error
+		if space, err = bridgeSpace(cmd); err != nil {
+			return err
+		}
+		client, err := newAPIClient()
+		if err != nil {
+			return err
+		}
+		enableDaemonRetries(client)
+		svc := api.NewMessagesService(client)
+		target = space
+		cards = viper.GetBool("as_app")
+		if !cards {
+			fmt.Fprintln(os.Stderr, "⚠ Only Chat apps can post cards; alerts are posted as text. Use --as-app or --webhook for cards.")
+		}
+		post = func(ctx context.Context, body map[string]interface{}, key string) (json.RawMessage, error) {
+			threadKey, replyOption := applyThreadKey(space, key, body, "")
+			raw, err := svc.Create(ctx, space, body, threadKey, "", "", replyOption)
+			if err == nil {
+				recordThreadKey(space, key, raw)
+			}
+			return raw, err
+		}
+	}
+
+	var gov *postGovernor
+	if !dryRun {
+		var err error
+		if gov, err = getPostGovernor(); err != nil {
+			return err
+		}
+		defer gov.printSummary()
+	}
+
+	limit := &rateLimit{n: rate}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var g alertGroup
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&g); err != nil {
+			http.Error(w, "invalid Alertmanager payload: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(g.Alerts) == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if !limit.allow(time.Now()) {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "rate limit reached", http.StatusTooManyRequests)
+			fmt.Fprintf(os.Stderr, "⚠ Rate limit reached; deferred %s\n", g.title())
+			return
+		}
+		// Past the daily cap, notifications are accepted and dropped, as
+		// Alertmanager would otherwise resend them all day.
+		if err := gov.acquireOrDefer(space); errors.Is(err, errPostRateLimited) {
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, "posting limit reached", http.StatusTooManyRequests)
+			fmt.Fprintf(os.Stderr, "⚠ posting.max_per_minute reached; deferred %s\n", g.title())
+			return
+		} else if err != nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		render := g.text
+		if cards {
+			render = g.card
+		}
+		raw, err := post(r.Context(), render(), g.threadKey())
+		gov.record(space, err)
+		if err != nil {
+			// post may have pointed the body at a thread by name; the dead
+			// letter finds the thread again by key.
+			if !keepDeadLetter(deadLetter{
+				Source:    "bridge alertmanager",
+				Space:     space,
+				Webhook:   webhookName,
+				AsApp:     webhookName == "" && viper.GetBool("as_app"),
+				ThreadKey: g.threadKey(),
+				Body:      render(),
+				Summary:   g.title(),
+			}, err) {
+				http.Error(w, "posting to Chat failed", http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		switch {
+		case dryRun:
+		case f.IsJSON():
+			_ = f.PrintRaw(raw)
+		default:
+			f.PrintSuccess(fmt.Sprintf("Posted %s (%s)", g.title(), jsonField(raw, "name")))
+		}
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{Addr: listen, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Receiving Alertmanager webhooks on %s for %s (Ctrl-C to stop)...\n", listen, target)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("listening on %s: %w", listen, err)
+	}
+	return nil
+}
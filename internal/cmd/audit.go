@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+)
+
+// auditEntry is one line of the audit log: a command that sent requests
+// changing Chat, with the resources it touched.
+type auditEntry struct {
+	Time    time.Time     `json:"time"`
+	User    string        `json:"user"`
+	Host    string        `json:"host,omitempty"`
+	Account string        `json:"account,omitempty"`
+	AsApp   bool          `json:"asApp,omitempty"`
+	Command string        `json:"command"`
+	Args    []string      `json:"args,omitempty"`
+	Targets []auditTarget `json:"targets"`
+	// Result is "ok", "partial" (some items of a bulk command failed), or
+	// "error".
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// auditTarget is one mutating request of a command.
+type auditTarget struct {
+	Method   string `json:"method"`
+	Resource string `json:"resource"`
+	Status   int    `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// auditTrail collects the mutating requests of the running command.
+type auditTrail struct {
+	mu      sync.Mutex
+	targets []auditTarget
+}
+
+// audit is the trail of the running command.
+var audit auditTrail
+
+// record is an api.Client AfterRequest hook. Reads are not recorded. A
+// created resource is recorded by the name in the response.
+func (a *auditTrail) record(method, path string, resp json.RawMessage, err error) {
+	if method == http.MethodGet {
+		return
+	}
+
+	resource, _, _ := strings.Cut(strings.TrimLeft(path, "/"), "?")
+	t := auditTarget{Method: method, Resource: resource, Status: http.StatusOK}
+	var apiErr *api.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		t.Status = apiErr.Code
+		t.Error = err.Error()
+	case err != nil:
+		t.Status = 0
+		t.Error = err.Error()
+	case method == http.MethodPost:
+		if name := jsonField(resp, "name"); name != "" {
+			t.Resource = name
+		}
+	}
+
+	a.mu.Lock()
+	a.targets = append(a.targets, t)
+	a.mu.Unlock()
+}
+
+// writeAuditLog appends the entry of cmd to the audit_log file if the
+// command sent any mutating request. Failing to write the log is reported
+// but does not fail the command.
+func writeAuditLog(cmd *cobra.Command, cmdErr error) {
+	if Cfg == nil || Cfg.AuditLog == "" || cmd == nil {
+		return
+	}
+	audit.mu.Lock()
+	targets := audit.targets
+	audit.mu.Unlock()
+	if len(targets) == 0 {
+		return
+	}
+
+	entry := auditEntry{
+		Time:    time.Now().UTC(),
+		User:    currentOSUser(),
+		Account: viper.GetString("account"),
+		AsApp:   viper.GetBool("as_app"),
+		Command: cmd.CommandPath(),
+		Args:    cmd.Flags().Args(),
+		Targets: targets,
+		Result:  "ok",
+	}
+	entry.Host, _ = os.Hostname()
+	var be *bulkError
+	switch {
+	case errors.As(cmdErr, &be):
+		entry.Result = "partial"
+		entry.Error = cmdErr.Error()
+	case cmdErr != nil:
+		entry.Result = "error"
+		entry.Error = cmdErr.Error()
+	}
+
+	if err := appendAuditEntry(expandHome(Cfg.AuditLog), entry); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not write the audit log: %v\n", err)
+	}
+}
+
+// appendAuditEntry appends entry as one JSON line to the file at path.
+func appendAuditEntry(path string, entry auditEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// currentOSUser returns the login name of the user running gogchat.
+func currentOSUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// expandHome replaces a leading "~/" in path with the home directory.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
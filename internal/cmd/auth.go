@@ -1,18 +1,21 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/cipher-shad0w/gogchat/internal/auth"
-	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/cache"
+	"github.com/cipher-shad0w/gogchat/internal/output"
 )
 
-// NewAuthCmd creates the top-level "auth" command with login, logout, and
-// status subcommands.
+// NewAuthCmd creates the top-level "auth" command with login, logout,
+// status, and rotate subcommands.
 func NewAuthCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "auth",
@@ -24,6 +27,7 @@ func NewAuthCmd() *cobra.Command {
 		newLoginCmd(),
 		newLogoutCmd(),
 		newStatusCmd(),
+		newAuthRotateCmd(),
 	)
 
 	return cmd
@@ -36,12 +40,13 @@ func resolveCredentials(cmd *cobra.Command) (string, string, error) {
 	clientID, _ := cmd.Flags().GetString("client-id")
 	clientSecret, _ := cmd.Flags().GetString("client-secret")
 
-	// Fall back to viper (config file / env vars) when flags are empty.
+	// Fall back to the config file / env vars (or the --account profile)
+	// when flags are empty.
 	if clientID == "" {
-		clientID = viper.GetString("client_id")
+		clientID = Cfg.ClientID
 	}
 	if clientSecret == "" {
-		clientSecret = viper.GetString("client_secret")
+		clientSecret = Cfg.ClientSecret
 	}
 
 	// Fall back to the built-in defaults shipped with the binary.
@@ -59,14 +64,26 @@ func resolveCredentials(cmd *cobra.Command) (string, string, error) {
 	return clientID, clientSecret, nil
 }
 
-// tokenPath returns the path to the token file from the loaded config or the
-// default location.
+// tokenPath returns the path to the token file from the loaded config (or
+// the --account profile) or the default location.
 func tokenPath() string {
-	cfg, err := config.Load()
-	if err != nil || cfg.TokenFile == "" {
+	if Cfg == nil || Cfg.TokenFile == "" {
 		return auth.DefaultTokenPath()
 	}
-	return cfg.TokenFile
+	return Cfg.TokenFile
+}
+
+// loginOptions returns the options of the browser login flow from the
+// config.
+func loginOptions() auth.LoginOptions {
+	if Cfg == nil {
+		return auth.LoginOptions{}
+	}
+	return auth.LoginOptions{
+		SuccessMessage: Cfg.LoginSuccessMessage,
+		Timeout:        Cfg.LoginTimeout,
+		Scopes:         Cfg.Scopes,
+	}
 }
 
 // newLoginCmd creates the "auth login" subcommand.
@@ -74,12 +91,45 @@ func newLoginCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "login",
 		Short: "Authenticate with Google Chat API via OAuth2",
-		Long:  "Run the interactive OAuth2 authorization flow, open a browser for consent, and save the resulting token locally.",
+		Long: `Run the interactive OAuth2 authorization flow, open a browser for consent, and save the resulting token locally.
+
+The browser is redirected to a callback server on localhost:8085. If your
+OAuth client only allows other redirect URIs, pass --redirect-port and/or
+--redirect-uri. When logging in on a remote host, forward the port from the
+machine running the browser (e.g. ssh -L 8765:localhost:8765 host) and use
+--redirect-port 8765.
+
+The login gives up if the browser sign-in is not completed within --timeout
+(default 5m, or login_timeout in the config). The text of the page shown
+after signing in can be set with login_success_message.
+
+Admin and import commands need scopes beyond the default ones, such as
+chat.admin.spaces.readonly for "spaces search" or chat.import for "import
+begin"; request them with --scopes, or list them under scopes in the config
+file so that every login asks for them. "auth status" shows which commands
+the current login cannot run.`,
+		Example: `  # Log in with the scope needed by "spaces search"
+  $ gogchat auth login --scopes chat.admin.spaces.readonly`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			clientID, clientSecret, err := resolveCredentials(cmd)
+			if errors.Is(err, auth.ErrMissingCredentials) && firstUse() && canPrompt() {
+				return runOnboarding()
+			}
 			if err != nil {
 				return err
 			}
+			opts := loginOptions()
+			opts.RedirectPort, _ = cmd.Flags().GetInt("redirect-port")
+			opts.RedirectURI, _ = cmd.Flags().GetString("redirect-uri")
+			if cmd.Flags().Changed("timeout") {
+				opts.Timeout, _ = cmd.Flags().GetDuration("timeout")
+			}
+			scopes, _ := cmd.Flags().GetStringSlice("scopes")
+			opts.Scopes = append(opts.Scopes, scopes...)
+
+			if err := requireInteractive("auth login", "log in on a workstation and point GOGCHAT_TOKEN_FILE at a copy of its token file, or use a service account with --as-app"); err != nil {
+				return err
+			}
 
 			path := tokenPath()
 
@@ -96,7 +146,7 @@ func newLoginCmd() *cobra.Command {
 				}
 			}
 
-			token, err := auth.Login(clientID, clientSecret)
+			token, err := auth.Login(clientID, clientSecret, opts)
 			if err != nil {
 				return fmt.Errorf("login failed: %w", err)
 			}
@@ -104,6 +154,8 @@ func newLoginCmd() *cobra.Command {
 			if err := auth.SaveToken(path, token); err != nil {
 				return fmt.Errorf("saving token: %w", err)
 			}
+			// The new login may have other scopes than the cached ones.
+			_, _ = getCache().Clear(false, cache.Scopes)
 
 			fmt.Println("✓ Successfully logged in!")
 			fmt.Printf("  Token saved to: %s\n", path)
@@ -113,6 +165,18 @@ func newLoginCmd() *cobra.Command {
 
 	cmd.Flags().String("client-id", "", "Google OAuth2 client ID")
 	cmd.Flags().String("client-secret", "", "Google OAuth2 client secret")
+	cmd.Flags().Int("redirect-port", 0, "Localhost port of the OAuth callback server (default 8085, or the port of --redirect-uri)")
+	cmd.Flags().String("redirect-uri", "", "Redirect URI registered for the OAuth client (default http://localhost:{port})")
+	cmd.Flags().Duration("timeout", auth.DefaultLoginTimeout, "How long to wait for the browser sign-in")
+	cmd.Flags().StringSlice("scopes", nil, "Extra OAuth scopes to request, e.g. chat.admin.spaces.readonly (comma-separated)")
+	_ = cmd.RegisterFlagCompletionFunc("scopes", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		var names []string
+		for _, s := range slices.Concat(auth.RestrictedScopes, auth.OptionalScopes) {
+			names = append(names, auth.ShortScope(s))
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+	disablePager(cmd)
 
 	return cmd
 }
@@ -146,7 +210,11 @@ func newStatusCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Show current authentication status",
-		Long:  "Check whether a valid OAuth2 token exists and display its expiry information.",
+		Long: `Check whether a valid OAuth2 token exists and display its expiry information.
+
+The scopes granted to the login are looked up with Google and cached (see
+cache.scopes in the config). Scopes beyond the default ones are listed,
+followed by the commands that need a scope the login lacks.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			path := tokenPath()
 
@@ -170,14 +238,17 @@ func newStatusCmd() *cobra.Command {
 				fmt.Printf("  Token file: %s\n", path)
 			} else if token.Expiry.Before(time.Now()) {
 				fmt.Println("✓ Logged in (token expired — will refresh on next use)")
-				fmt.Printf("  Token expired: %s\n", token.Expiry.UTC().Format("2006-01-02 15:04:05 UTC"))
+				fmt.Printf("  Token expired: %s\n", output.FormatTime(token.Expiry.Format(time.RFC3339Nano)))
 				fmt.Printf("  Token file: %s\n", path)
 			} else {
 				fmt.Println("✓ Logged in")
-				fmt.Printf("  Token expires: %s\n", token.Expiry.UTC().Format("2006-01-02 15:04:05 UTC"))
+				fmt.Printf("  Token expires: %s\n", output.FormatTime(token.Expiry.Format(time.RFC3339Nano)))
 				fmt.Printf("  Token file: %s\n", path)
 			}
 
+			if !viper.GetBool("as_app") {
+				printScopeStatus()
+			}
 			return nil
 		},
 	}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// autoRespondFile is the structure of a --rules file.
+type autoRespondFile struct {
+	Rules []autoRespondRule `yaml:"rules"`
+}
+
+// autoRespondRule is one declared rule: when a new message matches When,
+// Reply is posted in its thread.
+type autoRespondRule struct {
+	When  string `yaml:"when"`
+	Reply string `yaml:"reply"`
+	// Cooldown is the minimum time between two replies of this rule in
+	// the same thread, e.g. "10m".
+	Cooldown string `yaml:"cooldown"`
+}
+
+// autoRespondData is the data available to reply templates.
+type autoRespondData struct {
+	Message    string
+	Space      string
+	Thread     string
+	Sender     string
+	SenderName string
+	Text       string
+}
+
+// compiledRule is an autoRespondRule ready to be evaluated.
+type compiledRule struct {
+	when     string
+	match    func(msg tailMessage) bool
+	reply    *template.Template
+	cooldown time.Duration
+}
+
+// responder posts the replies of the first matching rule for each new
+// message seen by "messages tail --rules".
+type responder struct {
+	rules []compiledRule
+	// sent holds the names of the replies posted by the responder, so that
+	// they never trigger rules themselves.
+	sent map[string]bool
+	// lastReply records when each rule last replied in each thread, keyed
+	// by rule index and thread name.
+	lastReply map[string]time.Time
+	// gov limits the replies per space; replies past its limits are
+	// dropped rather than delaying the tail.
+	gov *postGovernor
+}
+
+// bareEnvRef matches {{env NAME}} with an unquoted variable name, which is
+// accepted as shorthand for {{env "NAME"}}.
+var bareEnvRef = regexp.MustCompile(`\{\{\s*env\s+([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// loadResponder reads and compiles the rules in path.
+func loadResponder(path string) (*responder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading rules: %w", err)
+	}
+	var file autoRespondFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing rules %s: %w", path, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("rules file %s declares no rules", path)
+	}
+
+	r := &responder{sent: map[string]bool{}, lastReply: map[string]time.Time{}}
+	for i, rule := range file.Rules {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+	return r, nil
+}
+
+// compileRule parses the condition, reply template, and cooldown of rule.
+func compileRule(rule autoRespondRule) (compiledRule, error) {
+	if rule.Reply == "" {
+		return compiledRule{}, fmt.Errorf("reply is required")
+	}
+	match, err := parseRuleCondition(rule.When)
+	if err != nil {
+		return compiledRule{}, err
+	}
+
+	source := bareEnvRef.ReplaceAllString(rule.Reply, `{{env "$1"}}`)
+	reply, err := template.New("reply").Funcs(template.FuncMap{"env": os.Getenv}).Parse(source)
+	if err != nil {
+		return compiledRule{}, fmt.Errorf("invalid reply template: %w", err)
+	}
+
+	var cooldown time.Duration
+	if rule.Cooldown != "" {
+		if cooldown, err = time.ParseDuration(rule.Cooldown); err != nil {
+			return compiledRule{}, fmt.Errorf("invalid cooldown %q: %w", rule.Cooldown, err)
+		}
+	}
+
+	return compiledRule{when: rule.When, match: match, reply: reply, cooldown: cooldown}, nil
+}
+
+// parseRuleCondition parses a "when" condition:
+//
+//	contains:TEXT   message text contains TEXT (case-insensitive)
+//	equals:TEXT     message text is TEXT (case-insensitive, trimmed)
+//	regex:PATTERN   message text matches the regular expression
+//	from:USER       sender resource name or display name is USER
+func parseRuleCondition(when string) (func(tailMessage) bool, error) {
+	kind, arg, ok := strings.Cut(when, ":")
+	if !ok || arg == "" {
+		return nil, fmt.Errorf("invalid when %q; expected contains:, equals:, regex:, or from: followed by a value", when)
+	}
+
+	switch strings.TrimSpace(kind) {
+	case "contains":
+		needle := strings.ToLower(arg)
+		return func(msg tailMessage) bool {
+			return strings.Contains(strings.ToLower(msg.Text), needle)
+		}, nil
+	case "equals":
+		want := strings.TrimSpace(arg)
+		return func(msg tailMessage) bool {
+			return strings.EqualFold(strings.TrimSpace(msg.Text), want)
+		}, nil
+	case "regex":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in when %q: %w", when, err)
+		}
+		return func(msg tailMessage) bool {
+			return re.MatchString(msg.Text)
+		}, nil
+	case "from":
+		user := strings.TrimSpace(arg)
+		return func(msg tailMessage) bool {
+			return msg.Sender.Name == api.NormalizeUser(user) || strings.EqualFold(msg.Sender.DisplayName, user)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown condition %q in when %q; use contains, equals, regex, or from", kind, when)
+	}
+}
+
+// handle replies to msg with the first matching rule, unless msg was sent
+// by the responder itself or by a bot, or the rule is cooling down in the
+// thread. Failures are reported without stopping the tail.
+func (r *responder) handle(ctx context.Context, f *output.Formatter, svc *api.MessagesService, space string, msg tailMessage) {
+	if r.sent[msg.Name] || msg.Sender.Type == "BOT" {
+		return
+	}
+
+	for i, rule := range r.rules {
+		if !rule.match(msg) {
+			continue
+		}
+
+		key := fmt.Sprintf("%d/%s", i, msg.Thread.Name)
+		if last, ok := r.lastReply[key]; ok && time.Since(last) < rule.cooldown {
+			return
+		}
+
+		var text bytes.Buffer
+		data := autoRespondData{
+			Message:    msg.Name,
+			Space:      space,
+			Thread:     msg.Thread.Name,
+			Sender:     msg.Sender.Name,
+			SenderName: msg.Sender.DisplayName,
+			Text:       msg.Text,
+		}
+		if err := rule.reply.Execute(&text, data); err != nil {
+			f.PrintError(fmt.Sprintf("⚠ Rule %q failed for %s: %v", rule.when, msg.Name, err))
+			return
+		}
+
+		if r.gov.acquireNow(space) != nil {
+			return
+		}
+		body := map[string]interface{}{"text": text.String()}
+		if msg.Thread.Name != "" {
+			body["thread"] = map[string]interface{}{"name": msg.Thread.Name}
+		}
+		raw, err := svc.Create(ctx, space, body, "", "", "", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+		r.gov.record(space, err)
+		if err != nil {
+			f.PrintError(fmt.Sprintf("⚠ Auto-reply to %s failed: %v", msg.Name, err))
+			return
+		}
+
+		var sent struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(raw, &sent) == nil {
+			r.sent[sent.Name] = true
+		}
+		r.lastReply[key] = time.Now()
+		if !f.IsJSON() {
+			fmt.Fprintf(os.Stderr, "↳ Rule %q replied to %s\n", rule.when, msg.Name)
+		}
+		return
+	}
+}
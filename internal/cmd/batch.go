@@ -0,0 +1,379 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/dedup"
+	"github.com/cipher-shad0w/gogchat/internal/undo"
+)
+
+// NewBatchCmd creates the top-level "batch" command for running files of
+// declared operations.
+func NewBatchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "batch",
+		Short: "Run batches of operations from a file",
+		Long:  "Execute a list of operations declared in a YAML (or JSON) file, e.g. for migration scripts.",
+	}
+
+	cmd.AddCommand(newBatchRunCmd())
+
+	return cmd
+}
+
+// batchFile is the top-level structure of an operations file.
+type batchFile struct {
+	// Parallel runs operations concurrently (see --concurrency) instead of
+	// one after another.
+	Parallel bool `yaml:"parallel"`
+	// ContinueOnError keeps going after a failed operation. Otherwise the
+	// remaining operations are skipped.
+	ContinueOnError bool      `yaml:"continue_on_error"`
+	Operations      []batchOp `yaml:"operations"`
+}
+
+// batchOp is a single declared operation. Which fields are used depends on
+// Op; Body, when set, replaces the request body built from the other fields.
+type batchOp struct {
+	ID      string                 `yaml:"id"`
+	Op      string                 `yaml:"op"`
+	Space   string                 `yaml:"space"`
+	Message string                 `yaml:"message"`
+	Member  string                 `yaml:"member"`
+	User    string                 `yaml:"user"`
+	Role    string                 `yaml:"role"`
+	Text    string                 `yaml:"text"`
+	Thread  string                 `yaml:"thread_key"`
+	Emoji   string                 `yaml:"emoji"`
+	Force   bool                   `yaml:"force"`
+	Admin   bool                   `yaml:"admin"`
+	Body    map[string]interface{} `yaml:"body"`
+}
+
+// batchResult is the outcome of one operation in the results report.
+type batchResult struct {
+	Index     int             `json:"index"`
+	ID        string          `json:"id,omitempty"`
+	Op        string          `json:"op"`
+	Status    string          `json:"status"`
+	Code      string          `json:"code,omitempty"`
+	Retryable bool            `json:"retryable,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	Result    json.RawMessage `json:"result,omitempty"`
+}
+
+// batchReport is the machine-readable report produced by "batch run".
+type batchReport struct {
+	bulkSummary
+	Results []batchResult `json:"results"`
+}
+
+// batchEnv is the state shared by the operations of one batch run.
+type batchEnv struct {
+	client *api.Client
+	undo   *undoRecorder
+	guard  *spaceGuard
+	// ledger, if set, skips messages.send operations that repeat a
+	// recently posted message.
+	ledger *dedup.Ledger
+}
+
+// batchOps maps each supported operation name to its implementation.
+var batchOps = map[string]func(ctx context.Context, env *batchEnv, op batchOp) (json.RawMessage, error){
+	"messages.send":   batchMessagesSend,
+	"messages.delete": batchMessagesDelete,
+	"members.add":     batchMembersAdd,
+	"members.remove":  batchMembersRemove,
+	"reactions.add":   batchReactionsAdd,
+}
+
+// batchSpaceOps are the operations that act on a space, which they need
+// even when the request is given as a raw body.
+var batchSpaceOps = map[string]bool{
+	"messages.send": true,
+	"members.add":   true,
+}
+
+// batchOpNames returns the supported operation names, sorted.
+func batchOpNames() []string {
+	names := make([]string, 0, len(batchOps))
+	for name := range batchOps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newBatchRunCmd creates the "batch run" subcommand.
+func newBatchRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run FILE",
+		Short: "Run the operations declared in a file",
+		Long: fmt.Sprintf(`Run the operations declared in FILE, in order or in parallel, and report the
+outcome of each one.
+
+Supported operations: %s.
+
+Example file:
+
+  parallel: false
+  continue_on_error: true
+  operations:
+    - id: welcome
+      op: messages.send
+      space: spaces/AAAA
+      text: Welcome to the new space!
+    - op: members.add
+      space: spaces/AAAA
+      user: users/123456
+      role: ROLE_MANAGER
+    - op: reactions.add
+      message: spaces/AAAA/messages/BBBB
+      emoji: "👍"`, strings.Join(batchOpNames(), ", ")),
+		Args: cobra.ExactArgs(1),
+		RunE: runBatchRun,
+	}
+
+	cmd.Flags().String("report", "", "Write the JSON results report to this file")
+	addProtectionFlag(cmd)
+	addDedupFlag(cmd)
+	disablePager(cmd)
+
+	return cmd
+}
+
+func runBatchRun(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("reading operations file: %w", err)
+	}
+
+	var file batchFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parsing operations file %s: %w", args[0], err)
+	}
+	if len(file.Operations) == 0 {
+		return fmt.Errorf("%s declares no operations", args[0])
+	}
+	for i, op := range file.Operations {
+		if _, ok := batchOps[op.Op]; !ok {
+			return fmt.Errorf("operation %d: unknown op %q (supported: %s)", i+1, op.Op, strings.Join(batchOpNames(), ", "))
+		}
+		if batchSpaceOps[op.Op] {
+			if err := requireOpFields(op, map[string]string{"space": strings.TrimSpace(op.Space)}); err != nil {
+				return fmt.Errorf("operation %d: %w", i+1, err)
+			}
+		}
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+	reportPath, _ := cmd.Flags().GetString("report")
+
+	workers := 1
+	if file.Parallel {
+		workers = getConcurrency()
+		enableBulkRetries(client)
+	}
+
+	// Items are labelled "#N op" so the worker pool can report them; the
+	// label maps back to the operation's index.
+	labels := make([]string, len(file.Operations))
+	index := make(map[string]int, len(file.Operations))
+	results := make([]batchResult, len(file.Operations))
+	for i, op := range file.Operations {
+		labels[i] = fmt.Sprintf("#%d %s", i+1, op.Op)
+		if op.ID != "" {
+			labels[i] += " (" + op.ID + ")"
+		}
+		index[labels[i]] = i
+		results[i] = batchResult{Index: i + 1, ID: op.ID, Op: op.Op, Status: "skipped"}
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+
+	env := &batchEnv{
+		client: client,
+		undo:   newUndoRecorder(cmd),
+		guard:  newSpaceGuard(cmd, client),
+		ledger: messageLedger(cmd),
+	}
+	defer env.undo.save()
+
+	var mu sync.Mutex
+	summary := runBulkWorkers(ctx, f, workers, labels, func(ctx context.Context, label string) (string, error) {
+		i := index[label]
+		raw, err := batchOps[file.Operations[i].Op](ctx, env, file.Operations[i])
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			results[i].Status = "failed"
+			results[i].Error = err.Error()
+			if !file.ContinueOnError {
+				cancel()
+			}
+			return "", err
+		}
+		results[i].Status = "ok"
+		results[i].Result = raw
+		return label, nil
+	})
+
+	// The per-operation results carry the item statuses of the summary.
+	for i, item := range summary.Items {
+		results[i].Code, results[i].Retryable = item.Code, item.Retryable
+	}
+	if !f.IsJSON() {
+		printBulkFailures(f, summary)
+	}
+	summary.Items = nil
+	report := batchReport{bulkSummary: summary, Results: results}
+	if reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encoding report: %w", err)
+		}
+		if err := os.WriteFile(reportPath, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("writing report %s: %w", reportPath, err)
+		}
+	}
+
+	if f.IsJSON() {
+		if err := f.Print(report); err != nil {
+			return err
+		}
+	} else {
+		f.PrintMessage(fmt.Sprintf("%d of %d operations succeeded, %d failed, %d skipped.",
+			summary.Succeeded, summary.Total, summary.Failed, summary.Skipped))
+	}
+
+	if summary.Failed+summary.Skipped > 0 {
+		return &bulkError{fmt.Sprintf("%d of %d operations did not succeed", summary.Failed+summary.Skipped, summary.Total)}
+	}
+	return nil
+}
+
+// requireOpFields returns an error naming the first empty required field.
+func requireOpFields(op batchOp, fields map[string]string) error {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if fields[name] == "" {
+			return fmt.Errorf("%s requires %q", op.Op, name)
+		}
+	}
+	return nil
+}
+
+func batchMessagesSend(ctx context.Context, env *batchEnv, op batchOp) (json.RawMessage, error) {
+	op.Space = strings.TrimSpace(op.Space)
+	if err := requireOpFields(op, map[string]string{"space": op.Space}); err != nil {
+		return nil, err
+	}
+	op.Space = api.NormalizeName(op.Space, "spaces/")
+	body := op.Body
+	if body == nil {
+		if err := requireOpFields(op, map[string]string{"text": op.Text}); err != nil {
+			return nil, err
+		}
+		body = map[string]interface{}{"text": op.Text}
+	}
+	threadKey, replyOption := applyThreadKey(op.Space, op.Thread, body, "")
+	raw, dup, err := postOnce(env.ledger, op.Space, body, threadKey, func() (json.RawMessage, error) {
+		return api.NewMessagesService(env.client).Create(ctx, op.Space, body, threadKey, "", "", replyOption)
+	})
+	if dup != nil {
+		return json.Marshal(dup)
+	}
+	recordSent(op.Space, op.Thread, body, raw, err)
+	if err == nil {
+		recordThreadKey(op.Space, op.Thread, raw)
+	}
+	return raw, err
+}
+
+func batchMessagesDelete(ctx context.Context, env *batchEnv, op batchOp) (json.RawMessage, error) {
+	if err := requireOpFields(op, map[string]string{"message": op.Message}); err != nil {
+		return nil, err
+	}
+	if err := env.guard.check(ctx, op.Message); err != nil {
+		return nil, err
+	}
+	saved := env.undo.snapshot(ctx, env.client, undo.KindMessage, op.Message, false)
+	raw, err := api.NewMessagesService(env.client).Delete(ctx, op.Message, op.Force)
+	if err == nil {
+		env.undo.add(undo.KindMessage, op.Message, saved)
+	}
+	return raw, err
+}
+
+func batchMembersAdd(ctx context.Context, env *batchEnv, op batchOp) (json.RawMessage, error) {
+	op.Space = strings.TrimSpace(op.Space)
+	if err := requireOpFields(op, map[string]string{"space": op.Space}); err != nil {
+		return nil, err
+	}
+	op.Space = api.NormalizeName(op.Space, "spaces/")
+	body := op.Body
+	if body == nil {
+		if err := requireOpFields(op, map[string]string{"user": op.User}); err != nil {
+			return nil, err
+		}
+		role := op.Role
+		if role == "" {
+			role = "ROLE_MEMBER"
+		}
+		body = newHumanMembership(op.User, role)
+	}
+	return api.NewMembersService(env.client).Create(ctx, op.Space, body, op.Admin)
+}
+
+func batchMembersRemove(ctx context.Context, env *batchEnv, op batchOp) (json.RawMessage, error) {
+	if err := requireOpFields(op, map[string]string{"member": op.Member}); err != nil {
+		return nil, err
+	}
+	if err := env.guard.check(ctx, op.Member); err != nil {
+		return nil, err
+	}
+	saved := env.undo.snapshot(ctx, env.client, undo.KindMembership, op.Member, op.Admin)
+	raw, err := api.NewMembersService(env.client).Delete(ctx, op.Member, op.Admin)
+	if err == nil {
+		env.undo.add(undo.KindMembership, op.Member, saved)
+	}
+	return raw, err
+}
+
+func batchReactionsAdd(ctx context.Context, env *batchEnv, op batchOp) (json.RawMessage, error) {
+	if err := requireOpFields(op, map[string]string{"message": op.Message}); err != nil {
+		return nil, err
+	}
+	body := op.Body
+	if body == nil {
+		if err := requireOpFields(op, map[string]string{"emoji": op.Emoji}); err != nil {
+			return nil, err
+		}
+		var err error
+		body, err = reactionBody(ctx, env.client, op.Emoji)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return api.NewReactionsService(env.client).Create(ctx, op.Message, body)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRequireOpFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  map[string]string
+		wantErr string
+	}{
+		{"all set", map[string]string{"space": "spaces/a", "text": "hi"}, ""},
+		{"no fields", nil, ""},
+		{"one missing", map[string]string{"space": "spaces/a", "text": ""}, `messages.send requires "text"`},
+		{"first missing by name", map[string]string{"text": "", "space": ""}, `messages.send requires "space"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := requireOpFields(batchOp{Op: "messages.send"}, tt.fields)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("requireOpFields() error: %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("requireOpFields() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBatchOpValidation(t *testing.T) {
+	body := map[string]interface{}{"text": "hi"}
+	tests := []struct {
+		name    string
+		op      batchOp
+		wantErr string
+	}{
+		{"send without space", batchOp{Op: "messages.send", Text: "hi"}, `messages.send requires "space"`},
+		{"send with blank space", batchOp{Op: "messages.send", Space: "  ", Body: body}, `messages.send requires "space"`},
+		{"send without text", batchOp{Op: "messages.send", Space: "spaces/a"}, `messages.send requires "text"`},
+		{"delete without message", batchOp{Op: "messages.delete"}, `messages.delete requires "message"`},
+		{"add member without space", batchOp{Op: "members.add", User: "users/1"}, `members.add requires "space"`},
+		{"add member without user", batchOp{Op: "members.add", Space: "spaces/a"}, `members.add requires "user"`},
+		{"remove member without member", batchOp{Op: "members.remove"}, `members.remove requires "member"`},
+		{"react without message", batchOp{Op: "reactions.add", Emoji: "👍"}, `reactions.add requires "message"`},
+		{"react with body without message", batchOp{Op: "reactions.add", Body: map[string]interface{}{"emoji": map[string]interface{}{"unicode": "👍"}}}, `reactions.add requires "message"`},
+		{"react without emoji", batchOp{Op: "reactions.add", Message: "spaces/a/messages/b"}, `reactions.add requires "emoji"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The operations are rejected before the API client is used.
+			_, err := batchOps[tt.op.Op](context.Background(), &batchEnv{}, tt.op)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("%s error = %v, want %q", tt.op.Op, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// addBodyFlag registers the --body flag on a create/patch/update command.
+// A body given with --body replaces the one built from the other flags, so
+// advanced users can send fields the CLI does not wrap yet.
+func addBodyFlag(cmd *cobra.Command) {
+	cmd.Flags().String("body", "", "Full request body as JSON: @file.json, - for stdin, or inline JSON (overrides other body flags)")
+}
+
+// readBody returns the request body supplied with --body, or nil if the flag
+// was not set. The value may be "@path" to read a file, "-" to read stdin, or
+// an inline JSON object.
+func readBody(cmd *cobra.Command) (map[string]interface{}, error) {
+	spec, _ := cmd.Flags().GetString("body")
+	if spec == "" {
+		return nil, nil
+	}
+
+	var data []byte
+	var err error
+	switch {
+	case spec == "-":
+		data, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("reading body from stdin: %w", err)
+		}
+	case strings.HasPrefix(spec, "@"):
+		path := strings.TrimPrefix(spec, "@")
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading body file %s: %w", path, err)
+		}
+	default:
+		data = []byte(spec)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, fmt.Errorf("parsing --body: expected a JSON object: %w", err)
+	}
+	return body, nil
+}
+
+// requireFlags returns an error naming any of the given flags that were not
+// set. It replaces cobra's MarkFlagRequired for flags that become optional
+// when --body is used.
+func requireFlags(cmd *cobra.Command, names ...string) error {
+	var missing []string
+	for _, name := range names {
+		if !cmd.Flags().Changed(name) {
+			missing = append(missing, fmt.Sprintf("%q", name))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required flag(s) %s not set (or pass the full payload with --body)", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// maskExpandedFields are body fields whose sub-fields are listed individually
+// in a computed update mask, because the API accepts (or requires) masks such
+// as "accessSettings.audience" rather than the whole object.
+var maskExpandedFields = map[string]bool{
+	"spaceDetails":       true,
+	"accessSettings":     true,
+	"permissionSettings": true,
+}
+
+// addMaskFlag registers --mask on a patch command. The older --update-mask
+// spelling is kept as a deprecated alias.
+func addMaskFlag(cmd *cobra.Command) {
+	cmd.Flags().String("mask", "", "Comma-separated update mask (computed from the body if not set)")
+	cmd.Flags().String("update-mask", "", "Comma-separated update mask")
+	_ = cmd.Flags().MarkDeprecated("update-mask", "use --mask instead")
+}
+
+// resolveUpdateMask returns the explicit --mask (or --update-mask) value, or
+// computes the mask from the fields present in body.
+func resolveUpdateMask(cmd *cobra.Command, body map[string]interface{}) (string, error) {
+	for _, name := range []string{"mask", "update-mask"} {
+		if mask, _ := cmd.Flags().GetString(name); mask != "" {
+			return mask, nil
+		}
+	}
+
+	mask := computeUpdateMask(body)
+	if mask == "" {
+		return "", fmt.Errorf("no fields to update; set a field flag, --body, or --mask")
+	}
+	return mask, nil
+}
+
+// computeUpdateMask builds an update mask from the keys present in body.
+// E.g. {"displayName": "x", "accessSettings": {"audience": "y"}} → "accessSettings.audience,displayName"
+func computeUpdateMask(body map[string]interface{}) string {
+	var paths []string
+	for key, value := range body {
+		nested, ok := value.(map[string]interface{})
+		if ok && maskExpandedFields[key] && len(nested) > 0 {
+			for sub := range nested {
+				paths = append(paths, key+"."+sub)
+			}
+			continue
+		}
+		paths = append(paths, key)
+	}
+	sort.Strings(paths)
+	return strings.Join(paths, ",")
+}
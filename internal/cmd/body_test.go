@@ -0,0 +1,27 @@
+package cmd
+
+import "testing"
+
+func TestComputeUpdateMask(t *testing.T) {
+	type obj = map[string]interface{}
+	tests := []struct {
+		name string
+		body obj
+		want string
+	}{
+		{"empty", obj{}, ""},
+		{"top-level fields sorted", obj{"text": "x", "cardsV2": []interface{}{}}, "cardsV2,text"},
+		{"expanded object", obj{"displayName": "x", "accessSettings": obj{"audience": "y"}}, "accessSettings.audience,displayName"},
+		{"several sub-fields", obj{"spaceDetails": obj{"guidelines": "g", "description": "d"}}, "spaceDetails.description,spaceDetails.guidelines"},
+		{"empty expanded object", obj{"permissionSettings": obj{}}, "permissionSettings"},
+		{"other objects kept whole", obj{"attachment": obj{"name": "a"}}, "attachment"},
+		{"expanded field that is not an object", obj{"accessSettings": nil}, "accessSettings"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeUpdateMask(tt.body); got != tt.want {
+				t.Errorf("computeUpdateMask() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/auth"
+)
+
+// chatEvent is an interaction event that Chat sends to an HTTP endpoint
+// app.
+type chatEvent struct {
+	Type string `json:"type"`
+	User struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	Space struct {
+		Name string `json:"name"`
+	} `json:"space"`
+	Common struct {
+		InvokedFunction string            `json:"invokedFunction"`
+		Parameters      map[string]string `json:"parameters"`
+	} `json:"common"`
+	Action struct {
+		ActionMethodName string `json:"actionMethodName"`
+		Parameters       []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"parameters"`
+	} `json:"action"`
+}
+
+// function returns the function of the button that was clicked and its
+// parameters.
+func (e chatEvent) function() (string, map[string]string) {
+	if e.Common.InvokedFunction != "" {
+		return e.Common.InvokedFunction, e.Common.Parameters
+	}
+	params := make(map[string]string, len(e.Action.Parameters))
+	for _, p := range e.Action.Parameters {
+		params[p.Key] = p.Value
+	}
+	return e.Action.ActionMethodName, params
+}
+
+// botFunctions handle the clicks on card buttons, by the function of the
+// button. They return the response to the event, e.g. an update of the
+// card, and a line describing what they did.
+var botFunctions = map[string]func(ctx context.Context, client *api.Client, e chatEvent, params map[string]string) (map[string]interface{}, string, error){
+	joinRequestApprove: handleJoinRequest,
+	joinRequestDeny:    handleJoinRequest,
+}
+
+// NewBotCmd returns the top-level "bot" command.
+func NewBotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bot",
+		Short: "Run gogchat as a Chat app",
+		Long: `Run gogchat as the HTTP endpoint of a Chat app, to handle the buttons of the
+cards that gogchat posts with --as-app.`,
+	}
+
+	cmd.AddCommand(newBotServeCmd())
+
+	return cmd
+}
+
+// newBotServeCmd creates the "bot serve" subcommand.
+func newBotServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Handle interaction events of a Chat app",
+		Long: `Receive the interaction events of a Chat app over HTTP, until interrupted
+with Ctrl-C, and act on the clicks on the buttons of gogchat's cards:
+
+  Approve, Deny   join requests of "spaces request-join --as-app": Approve
+                  adds the user to the space, if the manager who clicks
+                  still manages it; both update the card.
+
+Set the app's HTTP endpoint URL in the Chat API configuration of the Google
+Cloud project to the address of the server (Chat requires HTTPS, e.g. behind
+a reverse proxy), with "Project Number" as the authentication audience, and
+pass the project number with --audience. Requests whose bearer token was not
+signed by Chat for that project are refused.
+
+The server acts as the app, so it needs --as-app and the service account
+of the app. Adding members needs the chat.app.memberships scope, which a
+Workspace administrator must approve for the app once. Other events, such
+as messages sent to the app, are answered with an empty response.`,
+		Example: `  gogchat bot serve --as-app --audience 123456789012
+  gogchat bot serve --as-app --audience 123456789012 --listen 127.0.0.1:8080`,
+		Args: cobra.NoArgs,
+		RunE: runBotServe,
+	}
+
+	cmd.Flags().String("listen", ":8080", "Address to receive events on")
+	cmd.Flags().String("audience", "", "Project number of the Chat app, the audience of the events' tokens")
+	disablePager(cmd)
+
+	return cmd
+}
+
+func runBotServe(cmd *cobra.Command, args []string) error {
+	listen, _ := cmd.Flags().GetString("listen")
+	audience, _ := cmd.Flags().GetString("audience")
+	if !viper.GetBool("as_app") {
+		return fmt.Errorf("bot serve acts as the Chat app; run it with --as-app")
+	}
+	if audience == "" {
+		return fmt.Errorf("--audience is required: the project number of the Chat app")
+	}
+
+	// Adding members needs a scope beyond the one of other app commands.
+	httpClient, err := auth.AppHTTPClient(Cfg.ServiceAccountFile, getTransport(), auth.AppMembershipsScope)
+	if err != nil {
+		return err
+	}
+	client, err := configureClient(api.NewClient(httpClient))
+	if err != nil {
+		return err
+	}
+	enableDaemonRetries(client)
+	f := getFormatter()
+	verifier := &auth.ChatVerifier{
+		Audience: audience,
+		Client:   &http.Client{Transport: getTransport(), Timeout: 30 * time.Second},
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := verifier.Verify(r.Context(), r.Header.Get("Authorization")); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Refused request from %s: %v\n", r.RemoteAddr, err)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var e chatEvent
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&e); err != nil {
+			http.Error(w, "invalid event: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp := map[string]interface{}{}
+		if e.Type == "CARD_CLICKED" {
+			function, params := e.function()
+			handle, ok := botFunctions[function]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "⚠ Unknown function %q clicked by %s\n", function, e.User.Name)
+			} else {
+				var done string
+				var err error
+				resp, done, err = handle(r.Context(), client, e, params)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "⚠ %s by %s: %v\n", function, e.User.Name, err)
+					resp = map[string]interface{}{
+						"actionResponse": map[string]interface{}{"type": "NEW_MESSAGE"},
+						"text":           "⚠ " + err.Error(),
+					}
+				} else if done != "" {
+					if f.IsJSON() {
+						_ = f.Print(map[string]any{"function": function, "user": e.User.Name, "parameters": params, "result": done})
+					} else {
+						f.PrintSuccess(done)
+					}
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server := &http.Server{Addr: listen, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Receiving Chat app events on %s (Ctrl-C to stop)...\n", listen)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("listening on %s: %w", listen, err)
+	}
+	return nil
+}
+
+// handleJoinRequest handles the Approve and Deny buttons of a join request
+// card: it checks that the user who clicked manages the space, adds the
+// requester on approval, and replaces the buttons with the outcome.
+func handleJoinRequest(ctx context.Context, client *api.Client, e chatEvent, params map[string]string) (map[string]interface{}, string, error) {
+	req := joinRequest{
+		Space:       params["space"],
+		DisplayName: params["displayName"],
+		User:        params["user"],
+		Note:        params["note"],
+	}
+	if req.Space == "" || req.User == "" {
+		return nil, "", fmt.Errorf("the join request has no space or user")
+	}
+	managers, err := spaceManagers(ctx, client, req.Space)
+	if err != nil {
+		return nil, "", err
+	}
+	if !managers[e.User.Name] {
+		return nil, "", fmt.Errorf("only managers of %s can answer the join request", req.DisplayName)
+	}
+
+	who := e.User.DisplayName
+	if who == "" {
+		who = e.User.Name
+	}
+	var status, done string
+	function, _ := e.function()
+	if function == joinRequestDeny {
+		status = "Denied by " + who
+		done = fmt.Sprintf("%s denied %s joining %s", e.User.Name, req.User, req.Space)
+	} else {
+		membership := map[string]interface{}{"member": map[string]interface{}{"name": req.User, "type": "HUMAN"}}
+		_, err := api.NewMembersService(client).Create(ctx, req.Space, membership, false)
+		var apiErr *api.APIError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict:
+			status = "Already a member"
+			done = fmt.Sprintf("%s is already a member of %s", req.User, req.Space)
+		case err != nil:
+			return nil, "", fmt.Errorf("adding %s to %s: %w", req.User, req.Space, err)
+		default:
+			status = "Approved by " + who
+			done = fmt.Sprintf("%s approved %s joining %s", e.User.Name, req.User, req.Space)
+		}
+	}
+
+	resp := req.card(status)
+	resp["actionResponse"] = map[string]interface{}{"type": "UPDATE_MESSAGE"}
+	return resp, done, nil
+}
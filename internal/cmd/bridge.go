@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewBridgeCmd creates the top-level "bridge" command.
+func NewBridgeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bridge",
+		Short: "Forward events from other systems into a space",
+		Long: `Forward events from other systems into a space, until interrupted with
+Ctrl-C.
+
+Bridges post each source (e.g. a systemd unit or an alert group) into a
+thread of its own, and post at most --rate messages per minute.`,
+	}
+
+	cmd.AddCommand(
+		newBridgeJournalCmd(),
+		newBridgeAlertmanagerCmd(),
+	)
+
+	return cmd
+}
+
+// addBridgeFlags registers the flags shared by all bridges.
+func addBridgeFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	flags.String("space", "", "Space to post to (default: default_space)")
+	flags.Int("rate", 6, "Maximum number of messages posted per minute")
+	flags.Bool("dry-run", false, "Print the messages instead of posting them")
+	disablePager(cmd)
+}
+
+// bridgeSpace returns the --space of a bridge, or default_space.
+func bridgeSpace(cmd *cobra.Command) (string, error) {
+	space, _ := cmd.Flags().GetString("space")
+	if space == "" && Cfg != nil {
+		space = Cfg.DefaultSpace
+	}
+	if space == "" {
+		return "", fmt.Errorf("--space is required (or set default_space)")
+	}
+	return api.NormalizeName(space, "spaces/"), nil
+}
+
+// rateLimit allows at most n events per minute.
+type rateLimit struct {
+	n      int
+	mu     sync.Mutex
+	recent []time.Time
+}
+
+// allow reports whether an event may happen now without exceeding the
+// limit, and if so counts it.
+func (r *rateLimit) allow(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.recent[:0]
+	for _, t := range r.recent {
+		if now.Sub(t) < time.Minute {
+			kept = append(kept, t)
+		}
+	}
+	r.recent = kept
+	if len(r.recent) >= r.n {
+		return false
+	}
+	r.recent = append(r.recent, now)
+	return true
+}
+
+// bridgeEvent is one event to forward, e.g. a journal entry.
+type bridgeEvent struct {
+	// key identifies the source; its events share a thread.
+	key string
+	// title heads the messages of the source.
+	title string
+	line  string
+}
+
+// bridgeBurst is the events of one source waiting to be posted.
+type bridgeBurst struct {
+	key     string
+	title   string
+	lines   []string
+	count   int
+	started time.Time
+}
+
+// bridgePoster posts the events of a bridge to a space, collapsing bursts
+// and keeping to a rate limit.
+type bridgePoster struct {
+	f        *output.Formatter
+	svc      *api.MessagesService
+	space    string
+	limit    *rateLimit
+	window   time.Duration
+	maxLines int
+	dryRun   bool
+	// keyPrefix namespaces the thread keys of the bridge.
+	keyPrefix string
+	gov       *postGovernor
+	// source names the bridge in dead letters, e.g. "bridge journal".
+	source string
+
+	pending map[string]*bridgeBurst
+	order   []string
+}
+
+// newBridgePoster reads the bridge flags of cmd, including --burst-window
+// and --max-lines.
+func newBridgePoster(cmd *cobra.Command, keyPrefix string) (*bridgePoster, error) {
+	rate, _ := cmd.Flags().GetInt("rate")
+	window, _ := cmd.Flags().GetDuration("burst-window")
+	maxLines, _ := cmd.Flags().GetInt("max-lines")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	space, err := bridgeSpace(cmd)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case rate < 1:
+		return nil, fmt.Errorf("--rate must be at least 1")
+	case window < 0:
+		return nil, fmt.Errorf("--burst-window must not be negative")
+	case maxLines < 1:
+		return nil, fmt.Errorf("--max-lines must be at least 1")
+	}
+
+	p := &bridgePoster{
+		f:         getFormatter(),
+		space:     space,
+		limit:     &rateLimit{n: rate},
+		window:    window,
+		maxLines:  maxLines,
+		dryRun:    dryRun,
+		keyPrefix: keyPrefix,
+		source:    strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		pending:   map[string]*bridgeBurst{},
+	}
+	if !dryRun {
+		client, err := newAPIClient()
+		if err != nil {
+			return nil, err
+		}
+		enableDaemonRetries(client)
+		p.svc = api.NewMessagesService(client)
+		if p.gov, err = getPostGovernor(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// run posts the events read from events until it is closed or ctx is
+// cancelled. Events still pending then are posted before returning.
+func (p *bridgePoster) run(ctx context.Context, events <-chan bridgeEvent) {
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	// Post what is pending even when interrupted.
+	defer p.gov.printSummary()
+	defer p.flush(context.Background(), true)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			p.add(e)
+		case <-tick.C:
+		}
+		p.flush(ctx, false)
+	}
+}
+
+// add queues an event.
+func (p *bridgePoster) add(e bridgeEvent) {
+	b, ok := p.pending[e.key]
+	if !ok {
+		b = &bridgeBurst{key: e.key, title: e.title, started: time.Now()}
+		p.pending[e.key] = b
+		p.order = append(p.order, e.key)
+	}
+	if len(b.lines) < p.maxLines {
+		b.lines = append(b.lines, e.line)
+	}
+	b.count++
+}
+
+// flush posts the bursts whose window has passed, oldest first, as far as
+// the rate limit allows; with all, every pending burst is posted.
+func (p *bridgePoster) flush(ctx context.Context, all bool) {
+	now := time.Now()
+	kept := p.order[:0]
+	for _, key := range p.order {
+		b := p.pending[key]
+		if !all && (now.Sub(b.started) < p.window || !p.limit.allow(now)) {
+			kept = append(kept, key)
+			continue
+		}
+		p.post(ctx, b, all)
+		delete(p.pending, key)
+	}
+	p.order = kept
+}
+
+// text formats a burst as a message.
+func (b *bridgeBurst) text() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "*%s*", b.title)
+	if b.count > 1 {
+		fmt.Fprintf(&s, " (%d %s)", b.count, plural(b.count, "event", "events"))
+	}
+	s.WriteString("\n```\n")
+	for _, line := range b.lines {
+		s.WriteString(strings.TrimRight(line, "\n"))
+		s.WriteString("\n")
+	}
+	s.WriteString("```")
+	if more := b.count - len(b.lines); more > 0 {
+		fmt.Fprintf(&s, "\n_... and %d more_", more)
+	}
+	return s.String()
+}
+
+// post sends a burst into the thread of its source, once the posting
+// governor allows it; when stopping, it does not wait for room. Failures
+// are kept in the dead-letter queue and do not stop the bridge.
+func (p *bridgePoster) post(ctx context.Context, b *bridgeBurst, stopping bool) {
+	text := b.text()
+	if p.dryRun {
+		fmt.Printf("--- %s (thread %s)\n%s\n", p.space, p.keyPrefix+b.key, text)
+		return
+	}
+	var err error
+	if stopping {
+		err = p.gov.acquireNow(p.space)
+	} else {
+		err = p.gov.acquire(ctx, p.space)
+	}
+	if err != nil {
+		return
+	}
+
+	body := map[string]interface{}{"text": text}
+	key := p.keyPrefix + b.key
+	threadKey, replyOption := applyThreadKey(p.space, key, body, "")
+	raw, err := p.svc.Create(ctx, p.space, body, threadKey, "", "", replyOption)
+	p.gov.record(p.space, err)
+	if err != nil {
+		// The body may have been pointed at a thread by name; the dead
+		// letter keeps the text only and finds the thread again by key.
+		keepDeadLetter(deadLetter{
+			Source:    p.source,
+			Space:     p.space,
+			AsApp:     viper.GetBool("as_app"),
+			ThreadKey: key,
+			Body:      map[string]interface{}{"text": text},
+			Summary:   fmt.Sprintf("%d %s of %s", b.count, plural(b.count, "event", "events"), b.title),
+		}, err)
+		return
+	}
+	recordThreadKey(p.space, key, raw)
+	if p.f.IsJSON() {
+		_ = p.f.PrintRaw(raw)
+		return
+	}
+	p.f.PrintSuccess(fmt.Sprintf("Posted %d %s of %s (%s)", b.count, plural(b.count, "event", "events"), b.title, jsonField(raw, "name")))
+}
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// defaultConcurrency is the number of workers used by bulk operations when
+// neither --concurrency nor the concurrency config key is set.
+const defaultConcurrency = 4
+
+// exitPartialFailure is the exit status of a bulk operation in which some
+// items failed or were skipped.
+const exitPartialFailure = 6
+
+// errBulkSkipped marks items that were never attempted because the bulk
+// operation was stopped early.
+var errBulkSkipped = errors.New("skipped")
+
+// Statuses of the items of a bulk operation.
+const (
+	bulkSucceeded = "succeeded"
+	bulkFailed    = "failed"
+	bulkSkipped   = "skipped"
+)
+
+// bulkItem is the outcome of a single item of a bulk operation.
+type bulkItem struct {
+	Item   string `json:"item"`
+	Status string `json:"status"`
+	// Code is the API status (e.g. "PERMISSION_DENIED") or a client-side
+	// code such as "CANCELLED" of a failed or skipped item.
+	Code       string `json:"code,omitempty"`
+	HTTPStatus int    `json:"httpStatus,omitempty"`
+	// Retryable reports whether running the item again may succeed.
+	Retryable bool   `json:"retryable,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// bulkSummary is the aggregated outcome of a bulk operation. Items are in
+// the order they were given, whatever order they completed in.
+type bulkSummary struct {
+	Total     int        `json:"total"`
+	Succeeded int        `json:"succeeded"`
+	Failed    int        `json:"failed"`
+	Skipped   int        `json:"skipped"`
+	Items     []bulkItem `json:"items,omitempty"`
+}
+
+// firstError returns the error of the first failed item, or "" if none.
+func (s bulkSummary) firstError() string {
+	for _, item := range s.Items {
+		if item.Status == bulkFailed {
+			return item.Error
+		}
+	}
+	return ""
+}
+
+// bulkError is returned when some items of a bulk operation did not
+// succeed. It makes the command exit with exitPartialFailure.
+type bulkError struct {
+	msg string
+}
+
+func (e *bulkError) Error() string { return e.msg }
+
+// exitCode returns the exit status for a command error.
+func exitCode(err error) int {
+	var be *bulkError
+	if errors.As(err, &be) {
+		return exitPartialFailure
+	}
+	var redoErr *redoExitError
+	if errors.As(err, &redoErr) {
+		return redoErr.code
+	}
+	return 1
+}
+
+// classifyBulkError returns the code, HTTP status, and retryability of the
+// error of a bulk item.
+func classifyBulkError(err error) (code string, httpStatus int, retryable bool) {
+	var apiErr *api.APIError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errBulkSkipped):
+		return "SKIPPED", 0, true
+	case errors.As(err, &apiErr):
+		code = apiErr.Status
+		if code == "" {
+			code = fmt.Sprintf("HTTP_%d", apiErr.Code)
+		}
+		return code, apiErr.Code, apiErr.Retryable()
+	case errors.Is(err, context.Canceled):
+		return "CANCELLED", 0, true
+	case errors.Is(err, context.DeadlineExceeded):
+		return "DEADLINE_EXCEEDED", 0, true
+	case errors.As(err, &netErr):
+		return "UNAVAILABLE", 0, true
+	}
+	return "UNKNOWN", 0, false
+}
+
+// getConcurrency returns the number of workers to use for bulk operations,
+// from --concurrency or the concurrency config key.
+func getConcurrency() int {
+	n := viper.GetInt("concurrency")
+	if n < 1 {
+		return defaultConcurrency
+	}
+	return n
+}
+
+// runBulk calls fn for every item using a pool of getConcurrency() workers.
+// fn returns a short success message for the item. Successes are printed as
+// they complete; failures are recorded in the returned summary and reported
+// by finishBulk. Once the shared retry budget is exhausted, items not yet
+// started are skipped.
+func runBulk(ctx context.Context, f *output.Formatter, items []string, fn func(ctx context.Context, item string) (string, error)) bulkSummary {
+	return runBulkWorkers(ctx, f, getConcurrency(), items, fn)
+}
+
+// runBulkWorkers is runBulk with an explicit number of workers. With one
+// worker, items are processed in order. Cancelling ctx skips the items not
+// yet started.
+func runBulkWorkers(ctx context.Context, f *output.Formatter, workers int, items []string, fn func(ctx context.Context, item string) (string, error)) bulkSummary {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers = max(1, min(workers, len(items)))
+	jobs := make(chan int)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		summary = bulkSummary{Total: len(items), Items: make([]bulkItem, len(items))}
+	)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				item := items[i]
+				var msg string
+				err := ctx.Err()
+				if err == nil {
+					msg, err = fn(ctx, item)
+				} else {
+					err = errBulkSkipped
+				}
+
+				mu.Lock()
+				if summary.record(f, i, item, msg, err) {
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range items {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return summary
+}
+
+// runBulkBatched is runBulk for items that take a single request each,
+// built by build. With http.batch, the requests are combined into batch
+// requests (see api.Client.Batch) spread over getConcurrency() workers, so
+// hundreds of items take a handful of round trips. done turns the response
+// of an item into its success message. When http.batch is off (the
+// default) or the endpoint rejects batches, the items are sent one by one
+// instead.
+func runBulkBatched(ctx context.Context, f *output.Formatter, client *api.Client, items []string,
+	build func(item string) api.BatchRequest, done func(item string, resp json.RawMessage) (string, error)) bulkSummary {
+	send := func(ctx context.Context, item string) (string, error) {
+		resp, err := client.Send(ctx, build(item))
+		if err != nil {
+			return "", err
+		}
+		return done(item, resp)
+	}
+	if !Cfg.HTTP.Batch || len(items) < 2 {
+		return runBulk(ctx, f, items, send)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Spread the items evenly over the workers, so that small bulks still
+	// run in parallel.
+	workers := max(1, min(getConcurrency(), len(items)))
+	size := min(api.MaxBatchSize, (len(items)+workers-1)/workers)
+	jobs := make(chan int)
+
+	var (
+		mu          sync.Mutex
+		wg          sync.WaitGroup
+		unsupported atomic.Bool
+		summary     = bulkSummary{Total: len(items), Items: make([]bulkItem, len(items))}
+	)
+	record := func(i int, msg string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if summary.record(f, i, items[i], msg, err) {
+			cancel()
+		}
+	}
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range jobs {
+				end := min(start+size, len(items))
+
+				if ctx.Err() == nil && !unsupported.Load() {
+					reqs := make([]api.BatchRequest, 0, end-start)
+					for _, item := range items[start:end] {
+						reqs = append(reqs, build(item))
+					}
+					results, err := client.Batch(ctx, reqs)
+					if !errors.Is(err, api.ErrBatchUnsupported) {
+						for i := start; i < end; i++ {
+							var msg string
+							itemErr := err
+							if itemErr == nil {
+								itemErr = results[i-start].Err
+							}
+							if itemErr == nil {
+								msg, itemErr = done(items[i], results[i-start].Body)
+							}
+							record(i, msg, itemErr)
+						}
+						continue
+					}
+					unsupported.Store(true)
+				}
+
+				for i := start; i < end; i++ {
+					var msg string
+					err := ctx.Err()
+					if err == nil {
+						msg, err = send(ctx, items[i])
+					} else {
+						err = errBulkSkipped
+					}
+					record(i, msg, err)
+				}
+			}
+		}()
+	}
+
+	for start := 0; start < len(items); start += size {
+		jobs <- start
+	}
+	close(jobs)
+	wg.Wait()
+
+	return summary
+}
+
+// record stores the outcome of item i, printing the success message msg
+// unless the output is JSON. It reports whether the remaining items should
+// be skipped because the retry budget is exhausted.
+func (s *bulkSummary) record(f *output.Formatter, i int, item, msg string, err error) (stop bool) {
+	result := bulkItem{Item: item, Status: bulkSucceeded}
+	if err != nil {
+		result.Code, result.HTTPStatus, result.Retryable = classifyBulkError(err)
+	}
+	switch {
+	case errors.Is(err, errBulkSkipped):
+		s.Skipped++
+		result.Status = bulkSkipped
+	case err != nil:
+		s.Failed++
+		result.Status = bulkFailed
+		result.Error = err.Error()
+		stop = errors.Is(err, api.ErrRetryBudgetExhausted)
+	default:
+		s.Succeeded++
+		if !f.IsJSON() {
+			f.PrintSuccess(msg)
+		}
+	}
+	s.Items[i] = result
+	return stop
+}
+
+// finishBulk prints the final report of a bulk operation and returns a
+// *bulkError if any item failed or was skipped. With --json the report is
+// the summary with the status of every item; otherwise the failures are
+// listed after the successes. noun names the items in plural, e.g.
+// "messages".
+func finishBulk(f *output.Formatter, summary bulkSummary, noun string) error {
+	if f.IsJSON() {
+		if err := f.Print(summary); err != nil {
+			return err
+		}
+	} else {
+		printBulkFailures(f, summary)
+		if summary.Skipped > 0 {
+			f.PrintError(fmt.Sprintf("Stopped early; %d %s skipped.", summary.Skipped, noun))
+		}
+		f.PrintMessage(fmt.Sprintf("%d of %d %s succeeded, %d failed, %d skipped.",
+			summary.Succeeded, summary.Total, noun, summary.Failed, summary.Skipped))
+	}
+
+	if summary.Failed+summary.Skipped > 0 {
+		return &bulkError{fmt.Sprintf("%d of %d %s failed", summary.Failed+summary.Skipped, summary.Total, noun)}
+	}
+	return nil
+}
+
+// printBulkFailures lists the failed items of a bulk operation with their
+// error code and whether they can be retried.
+func printBulkFailures(f *output.Formatter, summary bulkSummary) {
+	for _, item := range summary.Items {
+		if item.Status != bulkFailed {
+			continue
+		}
+		retry := ""
+		if item.Retryable {
+			retry = ", retryable"
+		}
+		f.PrintError(fmt.Sprintf("✗ %s [%s%s]: %s", item.Item, item.Code, retry, item.Error))
+	}
+}
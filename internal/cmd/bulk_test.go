@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+func TestRunBulkWorkersExitStatus(t *testing.T) {
+	// fn fails the items named "fail", and runs out of retries on the item
+	// named "exhausted"; the others succeed.
+	fn := func(ctx context.Context, item string) (string, error) {
+		switch item {
+		case "fail":
+			return "", &api.APIError{Code: 404, Status: "NOT_FOUND"}
+		case "exhausted":
+			return "", fmt.Errorf("sending: %w", api.ErrRetryBudgetExhausted)
+		}
+		return item + " done", nil
+	}
+
+	tests := []struct {
+		name                       string
+		workers                    int
+		items                      []string
+		succeeded, failed, skipped int
+		wantExit                   int
+	}{
+		{"all succeed", 4, []string{"a", "b", "c"}, 3, 0, 0, 0},
+		{"no items", 4, nil, 0, 0, 0, 0},
+		{"one fails", 2, []string{"a", "fail", "c"}, 2, 1, 0, exitPartialFailure},
+		{"all fail", 1, []string{"fail", "fail"}, 0, 2, 0, exitPartialFailure},
+		{"budget exhausted skips the rest", 1, []string{"a", "exhausted", "b", "c"}, 1, 1, 2, exitPartialFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := output.NewFormatter(false, true)
+			summary := runBulkWorkers(context.Background(), f, tt.workers, tt.items, fn)
+			if summary.Total != len(tt.items) || summary.Succeeded != tt.succeeded ||
+				summary.Failed != tt.failed || summary.Skipped != tt.skipped {
+				t.Errorf("summary = %d total, %d succeeded, %d failed, %d skipped; want %d, %d, %d, %d",
+					summary.Total, summary.Succeeded, summary.Failed, summary.Skipped,
+					len(tt.items), tt.succeeded, tt.failed, tt.skipped)
+			}
+
+			err := finishBulk(f, summary, "items")
+			code := 0
+			if err != nil {
+				code = exitCode(err)
+			}
+			if code != tt.wantExit {
+				t.Errorf("exit status = %d (%v), want %d", code, err, tt.wantExit)
+			}
+		})
+	}
+}
+
+func TestRunBulkWorkersCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	summary := runBulkWorkers(ctx, output.NewFormatter(false, true), 2, []string{"a", "b"}, func(context.Context, string) (string, error) {
+		called = true
+		return "", nil
+	})
+	if called {
+		t.Error("fn was called after the context was cancelled")
+	}
+	if summary.Skipped != 2 {
+		t.Errorf("skipped = %d, want 2", summary.Skipped)
+	}
+	if err := finishBulk(output.NewFormatter(false, true), summary, "items"); exitCode(err) != exitPartialFailure {
+		t.Errorf("exit status = %d, want %d", exitCode(err), exitPartialFailure)
+	}
+	for _, item := range summary.Items {
+		if item.Status != bulkSkipped {
+			t.Errorf("item %s status = %s, want %s", item.Item, item.Status, bulkSkipped)
+		}
+	}
+}
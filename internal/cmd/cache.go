@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/auth"
+	"github.com/cipher-shad0w/gogchat/internal/cache"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewCacheCmd creates the top-level "cache" command for inspecting and
+// clearing the lookup cache.
+func NewCacheCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and clear the lookup cache",
+		Long: `Inspect and clear the local cache of lookups.
+
+Space display names, the user IDs of email addresses, directory searches
+for shell completion, custom emoji shortcodes, and the scopes granted to a
+login are cached in ~/.config/gogchat/cache.db so that later commands do not ask the API again.
+How long each kind is kept is set under cache in the config file.`,
+	}
+
+	cmd.AddCommand(
+		newCacheStatsCmd(),
+		newCacheClearCmd(),
+	)
+
+	return cmd
+}
+
+var (
+	lookupCacheOnce sync.Once
+	lookupCache     *cache.Cache
+)
+
+// getCache returns the lookup cache configured by the cache config keys.
+func getCache() *cache.Cache {
+	lookupCacheOnce.Do(func() {
+		ttl := config.CacheConfig{}
+		if Cfg != nil {
+			ttl = Cfg.Cache
+		}
+		lookupCache = cache.New(filepath.Join(config.ConfigDir(), "cache.db"), map[string]time.Duration{
+			cache.Spaces:    ttl.Spaces,
+			cache.Users:     ttl.Users,
+			cache.Directory: ttl.Directory,
+			cache.Emoji:     ttl.Emoji,
+			cache.Scopes:    ttl.Scopes,
+		})
+	})
+	return lookupCache
+}
+
+// accountCacheKey returns the cache key of a lookup whose result depends on
+// the Workspace domain of the login, such as directory searches and custom
+// emoji, so that the result is not served to another account. The key is
+// scoped to the token file of the user login, which differs per profile, or
+// to the service account key with --as-app.
+func accountCacheKey(key string) string {
+	login := "user\x00" + Cfg.TokenFile
+	switch {
+	case viper.GetBool("as_app"):
+		login = "app\x00" + Cfg.ServiceAccountFile
+	case Cfg.TokenFile == "":
+		login = "user\x00" + auth.DefaultTokenPath()
+	}
+	return login + "\x00" + key
+}
+
+// newCacheStatsCmd creates the "cache stats" subcommand.
+func newCacheStatsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Show what the cache holds",
+		Long:  "Show the number of entries, expired entries, size, and age of each kind of cached lookup, with its TTL.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			c := getCache()
+			stats, err := c.Stats()
+			if err != nil {
+				return err
+			}
+
+			if f.IsJSON() {
+				return printList(f, stats, "")
+			}
+
+			table := output.NewTable("KIND", "TTL", "ENTRIES", "EXPIRED", "SIZE", "OLDEST")
+			for _, s := range stats {
+				ttl, oldest := "off", ""
+				if s.TTL > 0 {
+					ttl = s.TTL.String()
+				}
+				if !s.Oldest.IsZero() {
+					oldest = output.FormatTime(s.Oldest.Format(time.RFC3339))
+				}
+				table.AddRow(s.Kind, ttl, fmt.Sprint(s.Entries), fmt.Sprint(s.Expired), formatByteSize(int64(s.Bytes)), oldest)
+			}
+			fmt.Print(table.Render())
+			f.PrintMessage("Cache: " + c.Path())
+			return nil
+		},
+	}
+}
+
+// newCacheClearCmd creates the "cache clear" subcommand.
+func newCacheClearCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clear [KIND...]",
+		Short: "Remove cached lookups",
+		Long: fmt.Sprintf(`Remove cached lookups, e.g. after renaming a space or when a lookup is
+stale. Without KIND every entry is removed.
+
+Kinds: %s.`, strings.Join(cache.Kinds, ", ")),
+		ValidArgs: cache.Kinds,
+		Args:      cobra.OnlyValidArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			expired, _ := cmd.Flags().GetBool("expired")
+
+			kinds := slices.Compact(slices.Sorted(slices.Values(args)))
+			removed, err := getCache().Clear(expired, kinds...)
+			if err != nil {
+				return err
+			}
+
+			if f.IsJSON() {
+				return f.Print(map[string]interface{}{"removed": removed})
+			}
+			f.PrintSuccess(fmt.Sprintf("Removed %d cached entries.", removed))
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("expired", false, "Only remove entries past their TTL")
+
+	return cmd
+}
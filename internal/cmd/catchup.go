@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewCatchupCmd creates the top-level "catchup" command.
+func NewCatchupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "catchup SPACE",
+		Short: "Show the messages in a space since you last read it",
+		Long: `Show the messages posted in a space since your read marker
+(spaceReadState.lastReadTime), oldest first.
+
+With --mark-read, the read marker is then advanced to the last message shown,
+so running catchup again continues where this run stopped. When more than
+--limit messages are unread, only the oldest --limit are shown.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runCatchup,
+	}
+
+	cmd.Flags().Bool("mark-read", false, "Advance the read marker to the last message shown")
+	cmd.Flags().Int("limit", 200, "Maximum number of messages to show")
+	addRenderFlag(cmd)
+
+	return cmd
+}
+
+func runCatchup(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+	ctx := cmd.Context()
+
+	space := api.NormalizeName(args[0], "spaces/")
+	markRead, _ := cmd.Flags().GetBool("mark-read")
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit < 1 {
+		return fmt.Errorf("--limit must be at least 1")
+	}
+
+	readState := api.NewReadStateService(client)
+	stateName := api.UserScopedName(space, "spaceReadState")
+	raw, err := readState.GetSpaceReadState(ctx, stateName)
+	if err != nil {
+		return fmt.Errorf("getting space read state: %w", err)
+	}
+	var state struct {
+		LastReadTime string `json:"lastReadTime"`
+	}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+
+	messages, more, err := listUnreadMessages(ctx, api.NewMessagesService(client), space, state.LastReadTime, limit)
+	if err != nil {
+		return fmt.Errorf("listing messages: %w", err)
+	}
+
+	// The marker is advanced to the last message shown rather than to now,
+	// so messages posted while reading are not skipped.
+	markedAt := ""
+	if markRead && len(messages) > 0 {
+		var last tailMessage
+		if err := json.Unmarshal(messages[len(messages)-1], &last); err != nil {
+			return fmt.Errorf("parsing response: %w", err)
+		}
+		body := map[string]interface{}{"lastReadTime": last.CreateTime}
+		if _, err := readState.UpdateSpaceReadState(ctx, stateName, body, "lastReadTime"); err != nil {
+			return fmt.Errorf("updating space read state: %w", err)
+		}
+		markedAt = last.CreateTime
+	}
+
+	if f.IsJSON() {
+		result := map[string]interface{}{
+			"space":        space,
+			"lastReadTime": state.LastReadTime,
+			"messages":     messages,
+			"more":         more,
+		}
+		if messages == nil {
+			result["messages"] = []json.RawMessage{}
+		}
+		if markedAt != "" {
+			result["markedReadAt"] = markedAt
+		}
+		return f.Print(result)
+	}
+
+	if len(messages) == 0 {
+		f.PrintMessage(fmt.Sprintf("You're all caught up in %s.", space))
+		return nil
+	}
+
+	since := "the beginning"
+	if state.LastReadTime != "" {
+		since = output.FormatTime(state.LastReadTime)
+	}
+	f.PrintMessage(fmt.Sprintf("%d new messages in %s since %s:\n", len(messages), space, since))
+
+	render := shouldRender(cmd)
+	for _, raw := range messages {
+		var msg tailMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+		printTailMessage(f, raw, msg, render, "")
+	}
+
+	if more {
+		f.PrintMessage(fmt.Sprintf("\nShowing the oldest %d unread messages; run catchup again for more.", limit))
+	}
+	if markedAt != "" {
+		f.PrintSuccess(fmt.Sprintf("Marked read up to %s.", output.FormatTime(markedAt)))
+	}
+	return nil
+}
+
+// listUnreadMessages returns up to limit messages in space created after
+// lastReadTime, oldest first, and whether more remain. An empty
+// lastReadTime returns the oldest messages of the space.
+func listUnreadMessages(ctx context.Context, svc *api.MessagesService, space, lastReadTime string, limit int) ([]json.RawMessage, bool, error) {
+	filter := ""
+	if lastReadTime != "" {
+		filter = fmt.Sprintf("createTime > %q", lastReadTime)
+	}
+
+	var messages []json.RawMessage
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, space, min(limit-len(messages), 1000), pageToken, filter, "createTime asc", false)
+		if err != nil {
+			return nil, false, err
+		}
+		var resp struct {
+			Messages      []json.RawMessage `json:"messages"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, false, fmt.Errorf("parsing response: %w", err)
+		}
+		messages = append(messages, resp.Messages...)
+		if resp.NextPageToken == "" {
+			return messages, false, nil
+		}
+		if len(messages) >= limit {
+			return messages[:limit], true, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
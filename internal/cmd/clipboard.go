@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// stdoutCapture tees everything written to os.Stdout into a buffer so that
+// --copy can place the command's output on the system clipboard.
+type stdoutCapture struct {
+	orig *os.File
+	w    *os.File
+	done chan struct{}
+	buf  bytes.Buffer
+}
+
+// activeCapture is the capture started for --copy, if any.
+var activeCapture *stdoutCapture
+
+// startCopy redirects os.Stdout through a pipe that copies output to both
+// the terminal and an in-memory buffer.
+func startCopy() error {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("capturing output for --copy: %w", err)
+	}
+
+	c := &stdoutCapture{orig: os.Stdout, w: w, done: make(chan struct{})}
+	go func() {
+		_, _ = io.Copy(io.MultiWriter(c.orig, &c.buf), r)
+		r.Close()
+		close(c.done)
+	}()
+
+	os.Stdout = w
+	activeCapture = c
+	return nil
+}
+
+// finishCopy restores os.Stdout and, if copy is true, writes the captured
+// output to the clipboard. It is a no-op when --copy was not given.
+func finishCopy(copy bool) error {
+	c := activeCapture
+	if c == nil {
+		return nil
+	}
+	activeCapture = nil
+
+	c.w.Close()
+	<-c.done
+	os.Stdout = c.orig
+
+	if !copy {
+		return nil
+	}
+
+	text := strings.TrimSpace(c.buf.String())
+	if text == "" {
+		fmt.Fprintln(os.Stderr, "⚠ Nothing to copy: the command printed no output.")
+		return nil
+	}
+	if err := clipboard.WriteAll(text); err != nil {
+		return fmt.Errorf("copying output to clipboard: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "✓ Copied output to clipboard.")
+	return nil
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/cache"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+)
+
+const (
+	// directoryMinQuery is the number of characters typed before the
+	// directory is searched.
+	directoryMinQuery = 3
+	// directoryPageSize is the number of matches fetched per search.
+	directoryPageSize = 50
+	// directoryTimeout bounds a directory search so completion never hangs
+	// the shell.
+	directoryTimeout = 3 * time.Second
+)
+
+// directoryPerson is a cached directory search result.
+type directoryPerson struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// completeDirectoryUsers completes user arguments from the Workspace
+// directory. Suggestions are "users/{email}", which the Chat API accepts
+// in place of a numeric user ID, with the person's name as description.
+func completeDirectoryUsers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	query := strings.TrimPrefix(toComplete, "users/")
+	if len(query) < directoryMinQuery {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	people := searchDirectoryCached(query)
+	completions := make([]string, 0, len(people))
+	for _, p := range people {
+		completions = append(completions, "users/"+p.Email+"\t"+p.Name)
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// searchDirectoryCached returns directory matches for query, reusing the
+// results of an earlier search for query or a prefix of it from the lookup
+// cache. Errors yield no suggestions rather than breaking completion.
+func searchDirectoryCached(query string) []directoryPerson {
+	query = strings.ToLower(query)
+	if err := loadConfigOnce(); err != nil {
+		return nil
+	}
+
+	// A complete (not truncated) cached search for a prefix of the query
+	// already contains every match, so filter it locally instead of asking
+	// the API again.
+	for i := len(query); i >= directoryMinQuery; i-- {
+		var people []directoryPerson
+		if !getCache().Get(cache.Directory, accountCacheKey(query[:i]), &people) {
+			continue
+		}
+		if i == len(query) || len(people) < directoryPageSize {
+			return filterPeople(people, query)
+		}
+	}
+
+	people, err := searchDirectory(query)
+	if err != nil {
+		return nil
+	}
+	_ = getCache().Put(cache.Directory, accountCacheKey(query), people)
+	return people
+}
+
+// loadConfigOnce loads the configuration if the command did not, as when
+// completing arguments.
+func loadConfigOnce() error {
+	if Cfg != nil {
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	Cfg = cfg
+	return nil
+}
+
+// searchDirectory queries the People API directory search.
+func searchDirectory(query string) ([]directoryPerson, error) {
+	client, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), directoryTimeout)
+	defer cancel()
+
+	raw, err := api.NewPeopleService(client).SearchDirectory(ctx, query, directoryPageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		People []struct {
+			Names []struct {
+				DisplayName string `json:"displayName"`
+			} `json:"names"`
+			EmailAddresses []struct {
+				Value string `json:"value"`
+			} `json:"emailAddresses"`
+		} `json:"people"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, err
+	}
+
+	var people []directoryPerson
+	for _, p := range resp.People {
+		name := ""
+		if len(p.Names) > 0 {
+			name = p.Names[0].DisplayName
+		}
+		for _, e := range p.EmailAddresses {
+			people = append(people, directoryPerson{Email: e.Value, Name: name})
+		}
+	}
+	return people, nil
+}
+
+// filterPeople returns the people whose email or name contains query.
+func filterPeople(people []directoryPerson, query string) []directoryPerson {
+	var out []directoryPerson
+	for _, p := range people {
+		if strings.Contains(strings.ToLower(p.Email), query) || strings.Contains(strings.ToLower(p.Name), query) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
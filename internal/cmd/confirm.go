@@ -0,0 +1,295 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// messageCountLimit is the number of messages counted when summarising a
+// space before deletion; larger spaces are shown as "1000+".
+const messageCountLimit = 1000
+
+// previewPageSize is the number of rows shown per page when previewing the
+// items a bulk command is about to destroy.
+const previewPageSize = 20
+
+// stdinReader is shared by all prompts so that answers piped on stdin are
+// not lost in a discarded buffer between prompts.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// nonInteractive reports whether prompts are disabled: --non-interactive
+// (or GOGCHAT_NON_INTERACTIVE, or non_interactive in the config) is set, or
+// was left unset and stdin is not a terminal, as in CI pipelines and cron.
+// Unlike isTerminal, this does not take /dev/null for a terminal.
+func nonInteractive() bool {
+	if viper.IsSet("non_interactive") {
+		return viper.GetBool("non_interactive")
+	}
+	return !term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// addConfirmFlags registers --yes (-y) and its older alias --force on a
+// destructive command.
+func addConfirmFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	cmd.Flags().Bool("force", false, "Skip confirmation prompt (same as --yes)")
+	// A pager would compete with the prompt for the terminal.
+	disablePager(cmd)
+}
+
+// skipConfirm reports whether --yes or --force was given.
+func skipConfirm(cmd *cobra.Command) bool {
+	yes, _ := cmd.Flags().GetBool("yes")
+	force, _ := cmd.Flags().GetBool("force")
+	return yes || force
+}
+
+// confirm prints a summary of what is about to be destroyed followed by
+// question, and reports whether the user answered yes. Prompts go to stderr
+// so they do not mix with --json output.
+func confirm(question string, summary []string) (bool, error) {
+	for _, line := range summary {
+		fmt.Fprintf(os.Stderr, "  %s\n", line)
+	}
+	if nonInteractive() {
+		return false, fmt.Errorf("%q needs confirmation, but prompts are disabled (non-interactive mode); pass --yes to proceed without asking", question)
+	}
+	fmt.Fprintf(os.Stderr, "%s [y/N]: ", question)
+
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("reading confirmation: %w", err)
+	}
+	if errors.Is(err, io.EOF) && strings.TrimSpace(answer) == "" {
+		fmt.Fprintln(os.Stderr)
+		return false, fmt.Errorf("no confirmation received; pass --yes to skip the prompt")
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// confirmPreview shows rows as a table, previewPageSize rows at a time, and
+// then asks question. Between pages the user can press Enter to see more or
+// q to go straight to the question.
+func confirmPreview(question string, headers []string, rows [][]string) (bool, error) {
+	if nonInteractive() {
+		return confirm(question, nil)
+	}
+	for start := 0; start < len(rows); start += previewPageSize {
+		end := min(start+previewPageSize, len(rows))
+		table := output.NewTable(headers...)
+		for _, row := range rows[start:end] {
+			table.AddRow(row...)
+		}
+		fmt.Fprint(os.Stderr, table.Render())
+
+		if end == len(rows) {
+			break
+		}
+		fmt.Fprintf(os.Stderr, "-- %d more; Enter to show the next page, q to stop listing -- ", len(rows)-end)
+		answer, err := stdinReader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+			return false, fmt.Errorf("no confirmation received; pass --yes to skip the prompt")
+		}
+		if strings.EqualFold(strings.TrimSpace(answer), "q") {
+			break
+		}
+	}
+	return confirm(question, nil)
+}
+
+// previewMessages looks up messages for a bulk deletion preview and returns
+// one row per message: name, sender, time, and the first line of text. It
+// fails if any message cannot be found, so typos are caught before anything
+// is deleted.
+func previewMessages(ctx context.Context, client *api.Client, names []string) ([][]string, error) {
+	svc := api.NewMessagesService(client)
+	rows := make([][]string, len(names))
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+
+	quiet := output.NewFormatter(false, true)
+	summary := runBulkBatched(ctx, quiet, client, names, svc.GetRequest, func(name string, raw json.RawMessage) (string, error) {
+		var msg struct {
+			Text       string `json:"text"`
+			CreateTime string `json:"createTime"`
+			Sender     struct {
+				DisplayName string `json:"displayName"`
+				Name        string `json:"name"`
+			} `json:"sender"`
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return "", fmt.Errorf("parsing response: %w", err)
+		}
+		sender := msg.Sender.DisplayName
+		if sender == "" {
+			sender = msg.Sender.Name
+		}
+		firstLine, _, _ := strings.Cut(msg.Text, "\n")
+		rows[index[name]] = []string{name, sender, output.FormatTime(msg.CreateTime), output.Truncate(firstLine, 50)}
+		return name, nil
+	})
+	if summary.Failed+summary.Skipped > 0 {
+		return nil, fmt.Errorf("%d of %d messages could not be looked up", summary.Failed+summary.Skipped, summary.Total)
+	}
+	return rows, nil
+}
+
+// summarizeSpace describes a space for a deletion prompt: its name, display
+// name, type, member count, and (up to messageCountLimit) message count.
+// Looking the space up first also catches mistyped space IDs.
+func summarizeSpace(ctx context.Context, client *api.Client, name string, admin bool) ([]string, error) {
+	raw, err := api.NewSpacesService(client).Get(ctx, name, admin)
+	if err != nil {
+		return nil, fmt.Errorf("looking up space %s: %w", name, err)
+	}
+
+	var sp struct {
+		DisplayName     string `json:"displayName"`
+		SpaceType       string `json:"spaceType"`
+		MembershipCount struct {
+			JoinedDirectHumanUserCount int `json:"joinedDirectHumanUserCount"`
+			JoinedGroupCount           int `json:"joinedGroupCount"`
+		} `json:"membershipCount"`
+	}
+	if err := json.Unmarshal(raw, &sp); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	members := fmt.Sprintf("%d", sp.MembershipCount.JoinedDirectHumanUserCount)
+	if sp.MembershipCount.JoinedGroupCount > 0 {
+		members += fmt.Sprintf(" (+%d groups)", sp.MembershipCount.JoinedGroupCount)
+	}
+
+	return []string{
+		fmt.Sprintf("Space:        %s", name),
+		fmt.Sprintf("Display Name: %s", sp.DisplayName),
+		fmt.Sprintf("Type:         %s", sp.SpaceType),
+		fmt.Sprintf("Members:      %s", members),
+		fmt.Sprintf("Messages:     %s", countSpaceMessages(ctx, client, name)),
+	}, nil
+}
+
+// countSpaceMessages returns the number of messages in a space as text,
+// "1000+" past messageCountLimit, or "unknown" if they cannot be listed.
+func countSpaceMessages(ctx context.Context, client *api.Client, name string) string {
+	raw, err := api.NewMessagesService(client).List(ctx, name, messageCountLimit, "", "", "", false)
+	if err != nil {
+		return "unknown"
+	}
+	var resp struct {
+		Messages      []json.RawMessage `json:"messages"`
+		NextPageToken string            `json:"nextPageToken"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return "unknown"
+	}
+	if resp.NextPageToken != "" {
+		return fmt.Sprintf("%d+", len(resp.Messages))
+	}
+	return fmt.Sprintf("%d", len(resp.Messages))
+}
+
+// summarizeMessage describes a message for a deletion prompt.
+func summarizeMessage(ctx context.Context, client *api.Client, name string) ([]string, error) {
+	raw, err := api.NewMessagesService(client).Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("looking up message %s: %w", name, err)
+	}
+
+	var msg struct {
+		Text   string `json:"text"`
+		Sender struct {
+			DisplayName string `json:"displayName"`
+			Name        string `json:"name"`
+		} `json:"sender"`
+		CreateTime string `json:"createTime"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	sender := msg.Sender.DisplayName
+	if sender == "" {
+		sender = msg.Sender.Name
+	}
+	return []string{
+		fmt.Sprintf("Message: %s", name),
+		fmt.Sprintf("Sender:  %s", sender),
+		fmt.Sprintf("Created: %s", output.FormatTime(msg.CreateTime)),
+		fmt.Sprintf("Text:    %s", output.Truncate(msg.Text, 60)),
+	}, nil
+}
+
+// summarizeMembership describes a membership for a removal prompt.
+func summarizeMembership(ctx context.Context, client *api.Client, name string, admin bool) ([]string, error) {
+	raw, err := api.NewMembersService(client).Get(ctx, name, admin)
+	if err != nil {
+		return nil, fmt.Errorf("looking up member %s: %w", name, err)
+	}
+
+	var m struct {
+		Role   string `json:"role"`
+		Member struct {
+			Name        string `json:"name"`
+			DisplayName string `json:"displayName"`
+		} `json:"member"`
+		GroupMember struct {
+			Name string `json:"name"`
+		} `json:"groupMember"`
+	}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	who := m.Member.DisplayName
+	if who == "" {
+		who = m.Member.Name
+	}
+	if who == "" {
+		who = m.GroupMember.Name
+	}
+	return []string{
+		fmt.Sprintf("Membership: %s", name),
+		fmt.Sprintf("Member:     %s", who),
+		fmt.Sprintf("Role:       %s", m.Role),
+	}, nil
+}
+
+// summarizeEmoji describes a custom emoji for a deletion prompt.
+func summarizeEmoji(ctx context.Context, client *api.Client, name string) ([]string, error) {
+	raw, err := api.NewEmojiService(client).Get(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("looking up emoji %s: %w", name, err)
+	}
+
+	var e struct {
+		Name      string `json:"name"`
+		EmojiName string `json:"emojiName"`
+	}
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return []string{
+		fmt.Sprintf("Emoji:      %s", e.Name),
+		fmt.Sprintf("Emoji Name: %s", e.EmojiName),
+	}, nil
+}
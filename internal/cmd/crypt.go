@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+)
+
+// The armor lines around age ciphertext.
+const (
+	ageArmorBegin = "-----BEGIN AGE ENCRYPTED FILE-----"
+	ageArmorEnd   = "-----END AGE ENCRYPTED FILE-----"
+)
+
+// runAge runs the age binary with args and input on stdin, and returns its
+// output.
+func runAge(ctx context.Context, input string, args ...string) (string, error) {
+	path, err := exec.LookPath("age")
+	if err != nil {
+		return "", fmt.Errorf("encryption needs the age command (https://age-encryption.org) on your PATH")
+	}
+	c := exec.CommandContext(ctx, path, args...)
+	c.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// encryptText encrypts text to the age recipients listed in
+// recipientsFile and returns it as a code block of armored ciphertext.
+func encryptText(ctx context.Context, text, recipientsFile string) (string, error) {
+	armor, err := runAge(ctx, text, "--encrypt", "--armor", "--recipients-file", recipientsFile)
+	if err != nil {
+		return "", fmt.Errorf("encrypting message: %w", err)
+	}
+	return "```\n" + strings.TrimRight(armor, "\n") + "\n```", nil
+}
+
+// extractAgeArmor returns the armored age ciphertext in a message text.
+func extractAgeArmor(text string) (string, bool) {
+	begin := strings.Index(text, ageArmorBegin)
+	if begin < 0 {
+		return "", false
+	}
+	end := strings.Index(text[begin:], ageArmorEnd)
+	if end < 0 {
+		return "", false
+	}
+	return text[begin:begin+end+len(ageArmorEnd)] + "\n", true
+}
+
+// newMessagesDecryptCmd creates the "messages decrypt" subcommand.
+func newMessagesDecryptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "decrypt MESSAGE",
+		Short: "Decrypt a message sent with --encrypt-for",
+		Long: `Decrypt a message sent with "messages send --encrypt-for" and print its
+text. The message is decrypted locally with the age command, using the
+private keys in the --identity files; nothing is sent anywhere.`,
+		Example: `  gogchat messages decrypt spaces/AAAABBBBcccc/messages/DDDDeeeeFFFF --identity ~/.config/age/keys.txt`,
+		Args:    cobra.ExactArgs(1),
+		RunE:    runMessagesDecrypt,
+	}
+
+	cmd.Flags().StringArray("identity", nil, "age identity file with your private key (repeatable, required)")
+	_ = cmd.MarkFlagRequired("identity")
+
+	return cmd
+}
+
+func runMessagesDecrypt(cmd *cobra.Command, args []string) error {
+	identities, _ := cmd.Flags().GetStringArray("identity")
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+	name := args[0]
+
+	raw, err := api.NewMessagesService(client).Get(cmd.Context(), name)
+	if err != nil {
+		return fmt.Errorf("getting message: %w", err)
+	}
+	armor, ok := extractAgeArmor(jsonField(raw, "text"))
+	if !ok {
+		return fmt.Errorf("%s is not an encrypted message", name)
+	}
+
+	ageArgs := []string{"--decrypt"}
+	for _, identity := range identities {
+		ageArgs = append(ageArgs, "--identity", expandHome(identity))
+	}
+	text, err := runAge(cmd.Context(), armor, ageArgs...)
+	if err != nil {
+		return fmt.Errorf("decrypting message: %w", err)
+	}
+
+	if f.IsJSON() {
+		return f.Print(map[string]string{"name": name, "text": text})
+	}
+	fmt.Println(strings.TrimRight(text, "\n"))
+	return nil
+}
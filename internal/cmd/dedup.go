@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/dedup"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// addDedupFlag registers --dedup-window on a command that posts messages.
+func addDedupFlag(cmd *cobra.Command) {
+	cmd.Flags().Duration("dedup-window", 0, "Skip messages identical to one posted to the same space within this window (default: dedup_window config key)")
+}
+
+// messageLedger returns the ledger of posted messages used to skip
+// duplicates, or nil if deduplication is off for cmd.
+func messageLedger(cmd *cobra.Command) *dedup.Ledger {
+	window := Cfg.DedupWindow
+	if cmd.Flags().Changed("dedup-window") {
+		window, _ = cmd.Flags().GetDuration("dedup-window")
+	}
+	if window <= 0 {
+		return nil
+	}
+	return dedup.New(filepath.Join(config.ConfigDir(), "dedup.db"), window)
+}
+
+// duplicateMessage describes a message that was not posted because an
+// identical one already was.
+type duplicateMessage struct {
+	Skipped     bool   `json:"skipped"`
+	Space       string `json:"space"`
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+	PostedAt    string `json:"postedAt"`
+}
+
+// String returns a one-line description for human-readable output.
+func (d duplicateMessage) String() string {
+	s := fmt.Sprintf("Skipped: an identical message was posted to %s at %s", d.Space, output.FormatTime(d.PostedAt))
+	if d.DuplicateOf != "" {
+		s += " (" + d.DuplicateOf + ")"
+	}
+	return s
+}
+
+// postOnce calls send to post body to space unless ledger holds an
+// identical message (same body and thread key) posted within its window,
+// in which case the earlier message is returned as a duplicate instead. A
+// nil ledger always sends.
+func postOnce(ledger *dedup.Ledger, space string, body map[string]interface{}, threadKey string,
+	send func() (json.RawMessage, error)) (json.RawMessage, *duplicateMessage, error) {
+	if ledger == nil {
+		raw, err := send()
+		return raw, nil, err
+	}
+
+	space = api.NormalizeName(space, "spaces/")
+	hash, err := dedup.Hash(body, threadKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, claimed, err := ledger.Claim(space, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !claimed {
+		return nil, &duplicateMessage{
+			Skipped:     true,
+			Space:       space,
+			DuplicateOf: entry.Message,
+			PostedAt:    entry.Time.UTC().Format(time.RFC3339),
+		}, nil
+	}
+
+	raw, err := send()
+	if err != nil {
+		// Give a retry the chance to post it.
+		_ = ledger.Release(space, hash)
+		return nil, nil, err
+	}
+	if err := ledger.Record(space, hash, jsonField(raw, "name")); err != nil {
+		// The message is out; failing now would only invite a retry.
+		fmt.Fprintf(os.Stderr, "⚠ Could not record the message in the dedup ledger: %v\n", err)
+	}
+	return raw, nil, nil
+}
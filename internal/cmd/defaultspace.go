@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultSpaceCommands are the commands that fall back to the default
+// space (default_space, GOGCHAT_DEFAULT_SPACE, or GOGCHAT_SPACE) when no
+// SPACE argument is given. Commands that change or delete the space itself
+// are deliberately left out.
+var defaultSpaceCommands = map[string]bool{
+	"analytics export": true,
+	"catchup":          true,
+	"events list":      true,
+	"import status":    true,
+	"media upload":     true,
+	"members add":      true,
+	"members list":     true,
+	"messages list":    true,
+	"messages send":    true,
+	"messages tail":    true,
+	"spaces get":       true,
+	"spaces snapshot":  true,
+}
+
+// applyDefaultSpace makes the commands in defaultSpaceCommands below root
+// use the default space when they are run without arguments.
+func applyDefaultSpace(root *cobra.Command) {
+	for _, c := range root.Commands() {
+		applyDefaultSpace(c)
+		path := strings.TrimPrefix(c.CommandPath(), root.Root().Name()+" ")
+		if defaultSpaceCommands[path] {
+			useDefaultSpace(c)
+		}
+	}
+}
+
+// defaultSpaceChecks are the original argument checks of the commands
+// wrapped by useDefaultSpace, whose check of an empty argument list is put
+// off until the command runs.
+var defaultSpaceChecks = map[*cobra.Command]cobra.PositionalArgs{}
+
+// useDefaultSpace wraps the argument check and RunE of c so that the
+// default space is passed as the SPACE argument when none is given.
+// Arguments are checked before the config file is read, so the check of
+// an empty argument list is put off until the command runs.
+func useDefaultSpace(c *cobra.Command) {
+	check, run := c.Args, c.RunE
+	defaultSpaceChecks[c] = check
+
+	c.Args = func(cmd *cobra.Command, args []string) error {
+		if wantsDefaultSpace(cmd, args) || check == nil {
+			return nil
+		}
+		return check(cmd, args)
+	}
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		args, err := defaultSpaceArgs(cmd, args)
+		if err != nil {
+			return err
+		}
+		return run(cmd, args)
+	}
+}
+
+// wantsDefaultSpace reports whether cmd, run with args, is to use the
+// default space.
+func wantsDefaultSpace(cmd *cobra.Command, args []string) bool {
+	if len(args) > 0 {
+		return false
+	}
+	all, _ := cmd.Flags().GetBool("all-spaces")
+	return !all
+}
+
+// defaultSpaceArgs returns the arguments that a command wrapped by
+// useDefaultSpace runs with: the default space if it needs one, after the
+// deferred argument check if there is no default space. Other commands
+// keep their arguments.
+func defaultSpaceArgs(cmd *cobra.Command, args []string) ([]string, error) {
+	check, ok := defaultSpaceChecks[cmd]
+	if !ok || !wantsDefaultSpace(cmd, args) {
+		return args, nil
+	}
+	if Cfg.DefaultSpace != "" {
+		noteResolved("", Cfg.DefaultSpace)
+		return []string{Cfg.DefaultSpace}, nil
+	}
+	if check != nil {
+		if err := check(cmd, args); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,435 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewDigestCmd creates the top-level "digest" command.
+func NewDigestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "digest",
+		Short: "Summarize recent activity across spaces as markdown",
+		Long: `Summarize the activity in your spaces since --since as a markdown digest:
+per space, the number of messages and people, the most active threads, the
+most reacted messages, and the messages that mention you. Spaces without
+activity are listed at the end.
+
+The digest covers every space you are a member of unless --spaces is given.
+It is printed to stdout, ready to be emailed or posted to yourself, e.g.
+
+  gogchat messages send DM_SPACE --text "$(gogchat digest)"
+
+At most limits.max_fetch messages (default 5000) are read per space unless
+--all is given.`,
+		Args: cobra.NoArgs,
+		RunE: runDigest,
+	}
+
+	cmd.Flags().String("since", "24h", `Start of the digest period (e.g. "24h", "yesterday 9am", RFC 3339)`)
+	cmd.Flags().StringSlice("spaces", nil, "Spaces to include (comma-separated; default all your spaces)")
+	cmd.Flags().Int("top", 3, "Number of threads and messages listed per section")
+	addFetchAllFlag(cmd)
+
+	return cmd
+}
+
+// digestMessage is the part of a message used by the digest.
+type digestMessage struct {
+	Name       string `json:"name"`
+	Text       string `json:"text"`
+	CreateTime string `json:"createTime"`
+	Sender     struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"sender"`
+	Thread struct {
+		Name string `json:"name"`
+	} `json:"thread"`
+	Annotations []struct {
+		Type        string `json:"type"`
+		UserMention struct {
+			User struct {
+				Name string `json:"name"`
+			} `json:"user"`
+		} `json:"userMention"`
+	} `json:"annotations"`
+	EmojiReactionSummaries []struct {
+		Emoji struct {
+			Unicode     string `json:"unicode"`
+			CustomEmoji struct {
+				UID       string `json:"uid"`
+				EmojiName string `json:"emojiName"`
+			} `json:"customEmoji"`
+		} `json:"emoji"`
+		ReactionCount int `json:"reactionCount"`
+	} `json:"emojiReactionSummaries"`
+}
+
+// senderName returns the sender's display name, or its resource name.
+func (m digestMessage) senderName() string {
+	if m.Sender.DisplayName != "" {
+		return m.Sender.DisplayName
+	}
+	return m.Sender.Name
+}
+
+// mentions reports whether the message @-mentions user.
+func (m digestMessage) mentions(user string) bool {
+	for _, a := range m.Annotations {
+		if a.Type == "USER_MENTION" && a.UserMention.User.Name == user {
+			return true
+		}
+	}
+	return false
+}
+
+// reactionCount returns the total number of reactions on the message.
+func (m digestMessage) reactionCount() int {
+	n := 0
+	for _, r := range m.EmojiReactionSummaries {
+		n += r.ReactionCount
+	}
+	return n
+}
+
+// reactions formats the reaction summary, e.g. "👍 4 🎉 1".
+func (m digestMessage) reactions() string {
+	parts := make([]string, 0, len(m.EmojiReactionSummaries))
+	for _, r := range m.EmojiReactionSummaries {
+		emoji := r.Emoji.Unicode
+		if emoji == "" {
+			emoji = r.Emoji.CustomEmoji.EmojiName
+		}
+		parts = append(parts, fmt.Sprintf("%s %d", emoji, r.ReactionCount))
+	}
+	return strings.Join(parts, " ")
+}
+
+// digestItem is a message listed in a digest section.
+type digestItem struct {
+	Message   string `json:"message"`
+	Sender    string `json:"sender"`
+	Text      string `json:"text"`
+	Link      string `json:"link"`
+	Reactions string `json:"reactions,omitempty"`
+}
+
+// digestThread is an active thread in a digest.
+type digestThread struct {
+	Thread   string `json:"thread"`
+	Messages int    `json:"messages"`
+	Text     string `json:"text"`
+	Link     string `json:"link"`
+}
+
+// spaceDigest is the digest of one space.
+type spaceDigest struct {
+	Space         string         `json:"space"`
+	DisplayName   string         `json:"displayName"`
+	Messages      int            `json:"messages"`
+	People        int            `json:"people"`
+	ActiveThreads []digestThread `json:"activeThreads"`
+	TopReacted    []digestItem   `json:"topReacted"`
+	Mentions      []digestItem   `json:"mentions"`
+}
+
+func runDigest(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	enableBulkRetries(client)
+	f := getFormatter()
+	ctx := cmd.Context()
+
+	spaceArgs, _ := cmd.Flags().GetStringSlice("spaces")
+	top, _ := cmd.Flags().GetInt("top")
+	if top < 1 {
+		return fmt.Errorf("--top must be at least 1")
+	}
+	since, err := parseTimeFlag(cmd, "since")
+	if err != nil {
+		return err
+	}
+	if since.IsZero() {
+		return fmt.Errorf("--since is required")
+	}
+
+	names, err := digestSpaces(ctx, client, spaceArgs)
+	if err != nil {
+		return err
+	}
+
+	// Mentions need the caller's user ID; without it the digest is still
+	// useful, so the section is left out.
+	me := ""
+	if !viper.GetBool("as_app") {
+		if me, err = currentUser(ctx, client); err != nil {
+			f.PrintError(fmt.Sprintf("⚠ Mentions are left out: %v", err))
+		}
+	}
+
+	var mu sync.Mutex
+	var digests []spaceDigest
+	svc := api.NewMessagesService(client)
+	filter := fmt.Sprintf("createTime > %q", formatFilterTime(since))
+	quiet := output.NewFormatter(false, true)
+	limit := fetchLimit(cmd)
+	summary := runBulk(ctx, quiet, names.order, func(ctx context.Context, space string) (string, error) {
+		raws, more, err := listMessagesUpTo(ctx, svc, space, filter, "createTime asc", limit)
+		if err != nil {
+			return "", err
+		}
+		if more {
+			warnFetchLimit(space, limit)
+		}
+		d := buildSpaceDigest(space, names.display[space], raws, me, top)
+		mu.Lock()
+		digests = append(digests, d)
+		mu.Unlock()
+		return space, nil
+	})
+	if summary.Succeeded == 0 && summary.Total > 0 {
+		return fmt.Errorf("could not read any space: %s", summary.firstError())
+	}
+
+	// Busiest spaces first.
+	sort.SliceStable(digests, func(i, j int) bool {
+		if digests[i].Messages != digests[j].Messages {
+			return digests[i].Messages > digests[j].Messages
+		}
+		return digests[i].DisplayName < digests[j].DisplayName
+	})
+
+	if f.IsJSON() {
+		if digests == nil {
+			digests = []spaceDigest{}
+		}
+		return f.Print(map[string]interface{}{
+			"since":  formatFilterTime(since),
+			"spaces": digests,
+		})
+	}
+	fmt.Print(renderDigest(digests, since, me != ""))
+	return nil
+}
+
+// digestSpaceNames are the spaces covered by a digest, in order, with their
+// display names.
+type digestSpaceNames struct {
+	order   []string
+	display map[string]string
+}
+
+// digestSpaces resolves --spaces, or every space of the caller when it is
+// empty, along with the spaces' display names.
+func digestSpaces(ctx context.Context, client *api.Client, args []string) (digestSpaceNames, error) {
+	names := digestSpaceNames{display: map[string]string{}}
+	add := func(raw json.RawMessage) {
+		var sp struct {
+			Name        string `json:"name"`
+			DisplayName string `json:"displayName"`
+		}
+		if json.Unmarshal(raw, &sp) == nil && sp.Name != "" {
+			names.order = append(names.order, sp.Name)
+			names.display[sp.Name] = sp.DisplayName
+		}
+	}
+
+	if len(args) == 0 {
+		raws, err := listAllSpaces(ctx, client, "")
+		if err != nil {
+			return names, err
+		}
+		for _, raw := range raws {
+			add(raw)
+		}
+		if len(names.order) == 0 {
+			return names, fmt.Errorf("you are not a member of any space")
+		}
+		return names, nil
+	}
+
+	svc := api.NewSpacesService(client)
+	for _, arg := range args {
+		space := api.NormalizeName(arg, "spaces/")
+		if _, ok := names.display[space]; ok {
+			continue
+		}
+		raw, err := svc.Get(ctx, space, false)
+		if err != nil {
+			return names, fmt.Errorf("getting %s: %w", space, err)
+		}
+		add(raw)
+	}
+	return names, nil
+}
+
+// buildSpaceDigest summarizes the messages of one space.
+func buildSpaceDigest(space, displayName string, raws []json.RawMessage, me string, top int) spaceDigest {
+	d := spaceDigest{
+		Space:         space,
+		DisplayName:   displayName,
+		ActiveThreads: []digestThread{},
+		TopReacted:    []digestItem{},
+		Mentions:      []digestItem{},
+	}
+	if d.DisplayName == "" {
+		d.DisplayName = space
+	}
+
+	people := map[string]bool{}
+	threads := map[string]*digestThread{}
+	var reacted []digestMessage
+	for _, raw := range raws {
+		var msg digestMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+		d.Messages++
+		people[msg.Sender.Name] = true
+
+		if t := msg.Thread.Name; t != "" {
+			if threads[t] == nil {
+				threads[t] = &digestThread{Thread: t, Text: snippet(msg.Text), Link: messageLink(msg.Name, t)}
+			}
+			threads[t].Messages++
+		}
+		if msg.reactionCount() > 0 {
+			reacted = append(reacted, msg)
+		}
+		if me != "" && msg.mentions(me) {
+			d.Mentions = append(d.Mentions, digestItem{
+				Message: msg.Name,
+				Sender:  msg.senderName(),
+				Text:    snippet(msg.Text),
+				Link:    messageLink(msg.Name, msg.Thread.Name),
+			})
+		}
+	}
+	d.People = len(people)
+
+	// A thread is active when it got more than one message in the period.
+	for _, t := range threads {
+		if t.Messages > 1 {
+			d.ActiveThreads = append(d.ActiveThreads, *t)
+		}
+	}
+	sort.SliceStable(d.ActiveThreads, func(i, j int) bool {
+		if d.ActiveThreads[i].Messages != d.ActiveThreads[j].Messages {
+			return d.ActiveThreads[i].Messages > d.ActiveThreads[j].Messages
+		}
+		return d.ActiveThreads[i].Thread < d.ActiveThreads[j].Thread
+	})
+	if len(d.ActiveThreads) > top {
+		d.ActiveThreads = d.ActiveThreads[:top]
+	}
+
+	sort.SliceStable(reacted, func(i, j int) bool {
+		return reacted[i].reactionCount() > reacted[j].reactionCount()
+	})
+	for _, msg := range reacted[:min(top, len(reacted))] {
+		d.TopReacted = append(d.TopReacted, digestItem{
+			Message:   msg.Name,
+			Sender:    msg.senderName(),
+			Text:      snippet(msg.Text),
+			Link:      messageLink(msg.Name, msg.Thread.Name),
+			Reactions: msg.reactions(),
+		})
+	}
+	return d
+}
+
+// renderDigest formats digests as markdown. withMentions is false when the
+// caller's mentions could not be looked up.
+func renderDigest(digests []spaceDigest, since time.Time, withMentions bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Chat digest since %s\n\n", output.FormatTime(since.Format(time.RFC3339)))
+
+	var quiet []string
+	for _, d := range digests {
+		if d.Messages == 0 {
+			quiet = append(quiet, d.DisplayName)
+			continue
+		}
+
+		fmt.Fprintf(&b, "## %s\n\n", d.DisplayName)
+		fmt.Fprintf(&b, "%d %s from %d %s\n\n", d.Messages, plural(d.Messages, "message", "messages"), d.People, plural(d.People, "person", "people"))
+
+		if len(d.ActiveThreads) > 0 {
+			b.WriteString("**Active threads**\n\n")
+			for _, t := range d.ActiveThreads {
+				fmt.Fprintf(&b, "- %s (%d messages) [link](%s)\n", quoteSnippet(t.Text), t.Messages, t.Link)
+			}
+			b.WriteString("\n")
+		}
+		if len(d.TopReacted) > 0 {
+			b.WriteString("**Most reacted**\n\n")
+			for _, m := range d.TopReacted {
+				fmt.Fprintf(&b, "- %s: %s %s [link](%s)\n", m.Sender, quoteSnippet(m.Text), m.Reactions, m.Link)
+			}
+			b.WriteString("\n")
+		}
+		if withMentions && len(d.Mentions) > 0 {
+			b.WriteString("**Mentions of you**\n\n")
+			for _, m := range d.Mentions {
+				fmt.Fprintf(&b, "- %s: %s [link](%s)\n", m.Sender, quoteSnippet(m.Text), m.Link)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(digests) == len(quiet) {
+		b.WriteString("No new messages.\n\n")
+	}
+	if len(quiet) > 0 && len(quiet) < len(digests) {
+		sort.Strings(quiet)
+		fmt.Fprintf(&b, "_No activity in: %s_\n", strings.Join(quiet, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// plural returns one when n is 1, otherwise many.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// snippet shortens message text to one line for summaries.
+func snippet(text string) string {
+	return output.Truncate(strings.Join(strings.Fields(text), " "), 80)
+}
+
+// quoteSnippet quotes a snippet, or describes a message without text.
+func quoteSnippet(s string) string {
+	if s == "" {
+		return "(no text)"
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// messageLink returns the Chat web link of a message
+// (spaces/{space}/messages/{message}) in thread (spaces/{space}/threads/{thread}).
+func messageLink(message, thread string) string {
+	space := strings.TrimPrefix(spaceOf(message), "spaces/")
+	_, id, _ := strings.Cut(message, "/messages/")
+	_, threadID, _ := strings.Cut(thread, "/threads/")
+	if threadID == "" {
+		threadID = id
+	}
+	return fmt.Sprintf("https://chat.google.com/room/%s/%s/%s", space, threadID, id)
+}
@@ -0,0 +1,459 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/filelock"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// deadLetter is a message that a bridge could not post, even after
+// retrying, kept to be retried with "dlq retry".
+type deadLetter struct {
+	ID     int    `json:"id"`
+	Source string `json:"source"`
+	Space  string `json:"space"`
+	// Webhook is the saved webhook the message was posted through, if not
+	// the API.
+	Webhook string `json:"webhook,omitempty"`
+	// AsApp is set when the message was posted with --as-app; its body
+	// may contain cards, which only Chat apps can post.
+	AsApp bool `json:"asApp,omitempty"`
+	// Account is the profile (--account) the message was posted as, if
+	// not the default login.
+	Account   string                 `json:"account,omitempty"`
+	ThreadKey string                 `json:"threadKey,omitempty"`
+	Body      map[string]interface{} `json:"body"`
+	// Summary describes the message, e.g. "[FIRING:2] HighLatency".
+	Summary       string    `json:"summary"`
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"firstFailedAt"`
+	LastFailedAt  time.Time `json:"lastFailedAt"`
+}
+
+// deadLetterStore is the content of the dead-letter store.
+type deadLetterStore struct {
+	// LastID is the last ID given to a letter, kept so that the IDs of
+	// retried or purged letters are not given again.
+	LastID  int          `json:"lastId"`
+	Letters []deadLetter `json:"letters"`
+}
+
+// deadLettersMu serialises updates of the dead-letter store by a bridge
+// and its HTTP handlers; other processes are kept out by a file lock.
+var deadLettersMu sync.Mutex
+
+// deadLettersPath returns the location of the dead-letter store.
+func deadLettersPath() string {
+	return filepath.Join(config.ConfigDir(), "dead-letters.json")
+}
+
+// loadDeadLetters returns the stored dead letters.
+func loadDeadLetters() ([]deadLetter, error) {
+	store, err := loadDeadLetterStore()
+	return store.Letters, err
+}
+
+// loadDeadLetterStore reads the dead-letter store; a missing store is
+// empty. Stores written before LastID was kept are a bare list of letters.
+func loadDeadLetterStore() (deadLetterStore, error) {
+	var store deadLetterStore
+	data, err := os.ReadFile(deadLettersPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return store, fmt.Errorf("reading dead letters: %w", err)
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		if err := json.Unmarshal(data, &store.Letters); err != nil {
+			return store, fmt.Errorf("parsing %s: %w", deadLettersPath(), err)
+		}
+	}
+	for _, d := range store.Letters {
+		store.LastID = max(store.LastID, d.ID)
+	}
+	return store, nil
+}
+
+// saveDeadLetterStore writes the dead-letter store.
+func saveDeadLetterStore(store deadLetterStore) error {
+	if store.Letters == nil {
+		store.Letters = []deadLetter{}
+	}
+	data, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.ConfigDir(), 0o700); err != nil {
+		return fmt.Errorf("saving dead letters: %w", err)
+	}
+	if err := writeFileAtomic(deadLettersPath(), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("saving dead letters: %w", err)
+	}
+	return nil
+}
+
+// changeDeadLetterStore applies change to the dead-letter store while no
+// other goroutine or gogchat process can change it, so that a bridge and
+// "dlq retry" do not overwrite each other's letters.
+func changeDeadLetterStore(change func(*deadLetterStore)) error {
+	deadLettersMu.Lock()
+	defer deadLettersMu.Unlock()
+	lock, err := filelock.Acquire(deadLettersPath())
+	if err != nil {
+		return fmt.Errorf("saving dead letters: %w", err)
+	}
+	defer lock.Unlock()
+
+	store, err := loadDeadLetterStore()
+	if err != nil {
+		return err
+	}
+	change(&store)
+	return saveDeadLetterStore(store)
+}
+
+// addDeadLetter stores d, which failed with cause, under a new ID and
+// returns the ID. Messages posted through the API are kept with the
+// account they were posted as.
+func addDeadLetter(d deadLetter, cause error) (int, error) {
+	if d.Webhook == "" {
+		d.Account = viper.GetString("account")
+	}
+	d.Error = cause.Error()
+	d.Attempts = 1
+	d.FirstFailedAt = time.Now().UTC()
+	d.LastFailedAt = d.FirstFailedAt
+	err := changeDeadLetterStore(func(store *deadLetterStore) {
+		store.LastID++
+		d.ID = store.LastID
+		store.Letters = append(store.Letters, d)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return d.ID, nil
+}
+
+// keepDeadLetter stores a message that failed to post and warns about it,
+// pointing at "dlq retry". It reports whether the message was stored.
+func keepDeadLetter(d deadLetter, cause error) bool {
+	id, err := addDeadLetter(d, cause)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not post %s: %v\n⚠ It is lost, as it could not be kept in the dead-letter queue: %v\n", d.Summary, cause, err)
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "⚠ Could not post %s: %v\n  Kept as dead letter %d; retry with: gogchat dlq retry %d\n", d.Summary, cause, id, id)
+	return true
+}
+
+// NewDLQCmd creates the top-level "dlq" command.
+func NewDLQCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dlq",
+		Short: "Manage messages that bridges failed to post",
+		Long: `Manage the dead-letter queue: the messages that bridges could not post, even
+after retrying, e.g. during an API outage. They are kept in
+~/.config/gogchat/dead-letters.json until they are retried successfully or
+purged, so that alerts are not silently lost.`,
+	}
+
+	cmd.AddCommand(
+		newDLQListCmd(),
+		newDLQRetryCmd(),
+		newDLQPurgeCmd(),
+	)
+
+	return cmd
+}
+
+// newDLQListCmd creates the "dlq list" subcommand.
+func newDLQListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List dead letters",
+		Long:  "List the messages in the dead-letter queue, oldest first, with the error of their last attempt.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			letters, err := loadDeadLetters()
+			if err != nil {
+				return err
+			}
+
+			if f.IsJSON() {
+				return printList(f, letters, "")
+			}
+			if len(letters) == 0 {
+				f.PrintMessage("No dead letters.")
+				return nil
+			}
+
+			table := output.NewTable("ID", "FAILED", "SOURCE", "TARGET", "ATTEMPTS", "SUMMARY", "ERROR")
+			for _, d := range letters {
+				table.AddRow(strconv.Itoa(d.ID),
+					output.FormatTime(d.LastFailedAt.Format(time.RFC3339)),
+					d.Source, d.target(), strconv.Itoa(d.Attempts),
+					output.Truncate(d.Summary, 40), output.Truncate(d.Error, 60))
+			}
+			fmt.Print(table.Render())
+			return nil
+		},
+	}
+}
+
+// target describes where d is posted.
+func (d deadLetter) target() string {
+	switch {
+	case d.Webhook != "":
+		return d.Space + " (webhook " + d.Webhook + ")"
+	case d.Account != "":
+		return d.Space + " (account " + d.Account + ")"
+	}
+	return d.Space
+}
+
+// newDLQRetryCmd creates the "dlq retry" subcommand.
+func newDLQRetryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retry [ID...]",
+		Short: "Post dead letters again",
+		Long: `Post dead letters again, oldest first: those with the given IDs, or all of
+them. Delivered messages are removed from the queue; the others stay with
+the error of this attempt.
+
+Messages go to the thread they were meant for, through the webhook they
+were meant to be posted with, if any. Messages that a bridge posted with
+--as-app can only be retried with --as-app, and the others only without
+it. Likewise, messages are only retried with the --account they were
+posted as. The posting limits of the config apply.`,
+		Example: `  gogchat dlq retry
+  gogchat dlq retry 3 4 --as-app`,
+		RunE: runDLQRetry,
+	}
+	disablePager(cmd)
+	return cmd
+}
+
+func runDLQRetry(cmd *cobra.Command, args []string) error {
+	f := getFormatter()
+	letters, err := loadDeadLetters()
+	if err != nil {
+		return err
+	}
+	letters, err = selectDeadLetters(letters, args)
+	if err != nil {
+		return err
+	}
+	if len(letters) == 0 {
+		f.PrintMessage("No dead letters.")
+		return nil
+	}
+	gov, err := getPostGovernor()
+	if err != nil {
+		return err
+	}
+	defer gov.printSummary()
+
+	byID := make(map[string]deadLetter, len(letters))
+	ids := make([]string, len(letters))
+	for i, d := range letters {
+		ids[i] = strconv.Itoa(d.ID)
+		byID[ids[i]] = d
+	}
+
+	var svc *api.MessagesService
+	ctx := cmd.Context()
+	// One worker keeps the messages in the order they failed in.
+	summary := runBulkWorkers(ctx, f, 1, ids, func(ctx context.Context, id string) (string, error) {
+		d := byID[id]
+		raw, err := func() (json.RawMessage, error) {
+			if d.Webhook != "" {
+				w, err := findWebhook(d.Webhook)
+				if err != nil {
+					return nil, err
+				}
+				if err := gov.acquire(ctx, d.Space); err != nil {
+					return nil, err
+				}
+				raw, err := postWebhook(ctx, w, d.Body, d.ThreadKey)
+				gov.record(d.Space, err)
+				return raw, err
+			}
+			if asApp := viper.GetBool("as_app"); d.AsApp != asApp {
+				if d.AsApp {
+					return nil, fmt.Errorf("posted with --as-app; retry it with --as-app")
+				}
+				return nil, fmt.Errorf("posted with user authentication; retry it without --as-app")
+			}
+			if account := viper.GetString("account"); d.Account != account {
+				if d.Account == "" {
+					return nil, fmt.Errorf("posted with the default account; retry it without --account")
+				}
+				return nil, fmt.Errorf("posted with --account %s; retry it with --account %s", d.Account, d.Account)
+			}
+			if svc == nil {
+				client, err := newAPIClient()
+				if err != nil {
+					return nil, err
+				}
+				enableDaemonRetries(client)
+				svc = api.NewMessagesService(client)
+			}
+			if err := gov.acquire(ctx, d.Space); err != nil {
+				return nil, err
+			}
+			threadKey, replyOption := applyThreadKey(d.Space, d.ThreadKey, d.Body, "")
+			raw, err := svc.Create(ctx, d.Space, d.Body, threadKey, "", "", replyOption)
+			gov.record(d.Space, err)
+			if err == nil {
+				recordThreadKey(d.Space, d.ThreadKey, raw)
+			}
+			return raw, err
+		}()
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				_ = updateDeadLetters(func(letters []deadLetter) []deadLetter {
+					for i := range letters {
+						if letters[i].ID == d.ID {
+							letters[i].Attempts++
+							letters[i].Error = err.Error()
+							letters[i].LastFailedAt = time.Now().UTC()
+						}
+					}
+					return letters
+				})
+			}
+			return "", err
+		}
+		if err := removeDeadLetters(map[int]bool{d.ID: true}); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Dead letter %d was delivered but stays in the queue: %v\n", d.ID, err)
+		}
+		msg := fmt.Sprintf("Delivered dead letter %d: %s", d.ID, d.Summary)
+		if name := jsonField(raw, "name"); name != "" {
+			msg += " (" + name + ")"
+		}
+		return msg, nil
+	})
+	return finishBulk(f, summary, "dead letters")
+}
+
+// selectDeadLetters returns the letters with the given IDs, or all letters
+// if there are none.
+func selectDeadLetters(letters []deadLetter, args []string) ([]deadLetter, error) {
+	if len(args) == 0 {
+		return letters, nil
+	}
+	wanted := map[int]bool{}
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dead letter ID %q", arg)
+		}
+		wanted[id] = true
+	}
+	var selected []deadLetter
+	for _, d := range letters {
+		if wanted[d.ID] {
+			selected = append(selected, d)
+			delete(wanted, d.ID)
+		}
+	}
+	for id := range wanted {
+		return nil, fmt.Errorf("no dead letter with ID %d", id)
+	}
+	return selected, nil
+}
+
+// updateDeadLetters replaces the stored letters with the result of change.
+func updateDeadLetters(change func([]deadLetter) []deadLetter) error {
+	return changeDeadLetterStore(func(store *deadLetterStore) {
+		store.Letters = change(store.Letters)
+	})
+}
+
+// removeDeadLetters removes the letters with the given IDs from the store.
+func removeDeadLetters(ids map[int]bool) error {
+	return updateDeadLetters(func(letters []deadLetter) []deadLetter {
+		kept := letters[:0]
+		for _, d := range letters {
+			if !ids[d.ID] {
+				kept = append(kept, d)
+			}
+		}
+		return kept
+	})
+}
+
+// newDLQPurgeCmd creates the "dlq purge" subcommand.
+func newDLQPurgeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "purge {ID... | --all}",
+		Short: "Remove dead letters without posting them",
+		Long:  "Remove dead letters from the queue without posting them: those with the given IDs, or all of them with --all, after confirmation.",
+		Example: `  gogchat dlq purge 3 4
+  gogchat dlq purge --all --yes`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			all, _ := cmd.Flags().GetBool("all")
+			switch {
+			case all && len(args) > 0:
+				return fmt.Errorf("give either IDs or --all")
+			case !all && len(args) == 0:
+				return fmt.Errorf("give the IDs of the dead letters to purge, or --all")
+			}
+
+			letters, err := loadDeadLetters()
+			if err != nil {
+				return err
+			}
+			letters, err = selectDeadLetters(letters, args)
+			if err != nil {
+				return err
+			}
+			if len(letters) == 0 {
+				f.PrintMessage("No dead letters.")
+				return nil
+			}
+			if all && !skipConfirm(cmd) {
+				ok, err := confirm(fmt.Sprintf("Purge all %d dead %s?", len(letters), plural(len(letters), "letter", "letters")), nil)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					f.PrintMessage("Cancelled.")
+					return nil
+				}
+			}
+
+			ids := make(map[int]bool, len(letters))
+			for _, d := range letters {
+				ids[d.ID] = true
+			}
+			if err := removeDeadLetters(ids); err != nil {
+				return err
+			}
+			f.PrintSuccess(fmt.Sprintf("Purged %d dead %s.", len(ids), plural(len(ids), "letter", "letters")))
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("all", false, "Purge all dead letters")
+	addConfirmFlags(cmd)
+	return cmd
+}
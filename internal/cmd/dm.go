@@ -0,0 +1,303 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewDMCmd creates the top-level "dm" command for direct messages.
+func NewDMCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dm",
+		Short: "Send direct messages",
+		Long:  "Send direct messages to people rather than to a space.",
+	}
+
+	cmd.AddCommand(newDMBroadcastCmd())
+
+	return cmd
+}
+
+// dmRecipient is one row of a broadcast's recipient file.
+type dmRecipient struct {
+	// User is the recipient's email address or users/{id}.
+	User string
+	// Fields are the row's values by column name, for the template.
+	Fields map[string]string
+}
+
+// newDMBroadcastCmd creates the "dm broadcast" subcommand.
+func newDMBroadcastCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "broadcast",
+		Short: "Send a personalized direct message to many people",
+		Long: `Send a personalized direct message to every recipient in a CSV file, e.g.
+for HR or IT announcements that should not go to a big space.
+
+The CSV file needs a header row with an "email" (or "user") column naming
+each recipient by email address or users/{id}. --template is a Go template
+in which every column is available by name, e.g. {{.name}}; a column
+missing from the file is an error. The direct message with each recipient
+is found, or created if there is none yet.
+
+Messages are sent one at a time, at most --rate per minute. Recipients
+listed in the --opt-out file (one per line, written as in the CSV file;
+# for comments) are skipped. Use --dry-run to see every message without
+sending anything. Exits with status 6 if any message could not be sent.`,
+		Example: `  gogchat dm broadcast --file recipients.csv --template "Hi {{.name}}, your laptop refresh is on {{.date}}." --dry-run
+  gogchat dm broadcast --file recipients.csv --template-file notice.txt --opt-out opted-out.txt --yes`,
+		Args: cobra.NoArgs,
+		RunE: runDMBroadcast,
+	}
+
+	flags := cmd.Flags()
+	flags.String("file", "", "CSV file of recipients with a header row (required)")
+	flags.String("template", "", "Message text as a Go template over the CSV columns")
+	flags.String("template-file", "", "Read the message template from this file")
+	flags.String("opt-out", "", "File of recipients not to message, one per line")
+	flags.Int("rate", 30, "Maximum number of messages sent per minute")
+	flags.Bool("dry-run", false, "Show the messages without sending them")
+	addConfirmFlags(cmd)
+	_ = cmd.MarkFlagRequired("file")
+
+	return cmd
+}
+
+func runDMBroadcast(cmd *cobra.Command, args []string) error {
+	file, _ := cmd.Flags().GetString("file")
+	source, _ := cmd.Flags().GetString("template")
+	templateFile, _ := cmd.Flags().GetString("template-file")
+	optOutFile, _ := cmd.Flags().GetString("opt-out")
+	rate, _ := cmd.Flags().GetInt("rate")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	switch {
+	case source != "" && templateFile != "":
+		return fmt.Errorf("give either --template or --template-file, not both")
+	case templateFile != "":
+		data, err := os.ReadFile(templateFile)
+		if err != nil {
+			return fmt.Errorf("reading template: %w", err)
+		}
+		source = strings.TrimRight(string(data), "\n")
+	case source == "":
+		return fmt.Errorf("--template or --template-file is required")
+	}
+	if rate < 1 {
+		return fmt.Errorf("--rate must be at least 1")
+	}
+	tmpl, err := template.New("message").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	recipients, err := readDMRecipients(file)
+	if err != nil {
+		return err
+	}
+	optOut, err := readOptOut(optOutFile)
+	if err != nil {
+		return err
+	}
+
+	// Render every message up front so that a template error does not
+	// surface halfway through the broadcast.
+	f := getFormatter()
+	texts := map[string]string{}
+	var users []string
+	optedOut := 0
+	for _, r := range recipients {
+		if optOut[strings.ToLower(r.User)] {
+			optedOut++
+			continue
+		}
+		if _, dup := texts[r.User]; dup {
+			continue
+		}
+		var b bytes.Buffer
+		if err := tmpl.Execute(&b, r.Fields); err != nil {
+			return fmt.Errorf("rendering the message for %s: %w", r.User, err)
+		}
+		texts[r.User] = b.String()
+		users = append(users, r.User)
+	}
+	if optedOut > 0 {
+		f.PrintError(fmt.Sprintf("Skipping %d opted-out recipient(s).", optedOut))
+	}
+	if len(users) == 0 {
+		return fmt.Errorf("no recipients left to message")
+	}
+
+	if dryRun {
+		if f.IsJSON() {
+			preview := make([]map[string]string, 0, len(users))
+			for _, u := range users {
+				preview = append(preview, map[string]string{"recipient": u, "text": texts[u]})
+			}
+			return printList(f, preview, "")
+		}
+		table := output.NewTable("RECIPIENT", "MESSAGE")
+		for _, u := range users {
+			table.AddRow(u, output.Truncate(strings.ReplaceAll(texts[u], "\n", " "), 80))
+		}
+		fmt.Print(table.Render())
+		f.PrintMessage(fmt.Sprintf("Dry run: %d messages would be sent.", len(users)))
+		return nil
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	if !skipConfirm(cmd) {
+		summary := []string{
+			fmt.Sprintf("Recipients: %d (%d opted out)", len(users), optedOut),
+			fmt.Sprintf("Duration:   about %s at %d per minute", (time.Duration(len(users)) * time.Minute / time.Duration(rate)).Round(time.Second), rate),
+			fmt.Sprintf("First:      %s: %s", users[0], output.Truncate(strings.ReplaceAll(texts[users[0]], "\n", " "), 60)),
+		}
+		ok, err := confirm(fmt.Sprintf("Send a direct message to %d people?", len(users)), summary)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			f.PrintMessage("Cancelled.")
+			return nil
+		}
+	}
+
+	enableBulkRetries(client)
+	tick := time.NewTicker(time.Minute / time.Duration(rate))
+	defer tick.Stop()
+	first := true
+	summary := runBulkWorkers(cmd.Context(), f, 1, users, func(ctx context.Context, user string) (string, error) {
+		if !first {
+			select {
+			case <-tick.C:
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		first = false
+
+		space, err := directMessageSpace(ctx, client, user)
+		if err != nil {
+			return "", err
+		}
+		body := map[string]interface{}{"text": texts[user]}
+		raw, err := api.NewMessagesService(client).Create(ctx, space, body, "", "", "", "")
+		recordSent(space, "", body, raw, err)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Sent to %s (%s)", user, jsonField(raw, "name")), nil
+	})
+	return finishBulk(f, summary, "messages")
+}
+
+// directMessageSpace returns the direct message space with user, setting
+// one up if there is none yet.
+func directMessageSpace(ctx context.Context, client *api.Client, user string) (string, error) {
+	name, err := resolveUser(ctx, client, user)
+	if err != nil {
+		return "", err
+	}
+	svc := api.NewSpacesService(client)
+	raw, err := svc.FindDirectMessage(ctx, name)
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+		raw, err = svc.Setup(ctx, map[string]interface{}{
+			"space": map[string]interface{}{"spaceType": "DIRECT_MESSAGE"},
+			"memberships": []map[string]interface{}{
+				{"member": map[string]interface{}{"name": name, "type": "HUMAN"}},
+			},
+		})
+	}
+	if err != nil {
+		return "", fmt.Errorf("finding the direct message with %s: %w", user, err)
+	}
+	return jsonField(raw, "name"), nil
+}
+
+// readDMRecipients reads a broadcast's recipient CSV file.
+func readDMRecipients(path string) ([]dmRecipient, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading recipients: %w", err)
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s has no recipients; it needs a header row and one row per recipient", path)
+	}
+
+	header := records[0]
+	userCol := -1
+	for i, name := range header {
+		header[i] = strings.TrimSpace(name)
+		if userCol < 0 && (strings.EqualFold(header[i], "email") || strings.EqualFold(header[i], "user")) {
+			userCol = i
+		}
+	}
+	if userCol < 0 {
+		return nil, fmt.Errorf(`%s needs an "email" or "user" column`, path)
+	}
+
+	recipients := make([]dmRecipient, 0, len(records)-1)
+	for line, record := range records[1:] {
+		user := strings.TrimSpace(record[userCol])
+		if user == "" {
+			return nil, fmt.Errorf("%s line %d: no recipient in column %q", path, line+2, header[userCol])
+		}
+		fields := make(map[string]string, len(header))
+		for i, name := range header {
+			fields[name] = strings.TrimSpace(record[i])
+		}
+		recipients = append(recipients, dmRecipient{User: user, Fields: fields})
+	}
+	return recipients, nil
+}
+
+// readOptOut reads an opt-out file into a set of lower-cased recipients.
+// No file means no opt-outs.
+func readOptOut(path string) (map[string]bool, error) {
+	optOut := map[string]bool{}
+	if path == "" {
+		return optOut, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading opt-out list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			optOut[strings.ToLower(line)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading opt-out list: %w", err)
+	}
+	return optOut, nil
+}
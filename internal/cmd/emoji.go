@@ -1,15 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/cache"
 	"github.com/cipher-shad0w/gogchat/internal/output"
 )
 
@@ -20,7 +23,7 @@ func NewEmojiCmd() *cobra.Command {
 		Use:     "emoji",
 		Aliases: []string{"emojis", "custom-emoji"},
 		Short:   "Manage custom emojis",
-		Long:    "List, get, create, and delete custom emojis in Google Chat.",
+		Long:    "List, get, create, and delete custom emojis in Google Chat, and report how often emoji are used in reactions.",
 	}
 
 	cmd.AddCommand(
@@ -28,6 +31,8 @@ func NewEmojiCmd() *cobra.Command {
 		newEmojiGetCmd(),
 		newEmojiCreateCmd(),
 		newEmojiDeleteCmd(),
+		newEmojiStatsCmd(),
+		newEmojiDumpCmd(),
 	)
 
 	return cmd
@@ -47,7 +52,7 @@ func newEmojiListCmd() *cobra.Command {
 			formatter := getFormatter()
 			svc := api.NewEmojiService(client)
 
-			pageSize, _ := cmd.Flags().GetInt("page-size")
+			pageSize := getPageSize(cmd)
 			pageToken, _ := cmd.Flags().GetString("page-token")
 			filter, _ := cmd.Flags().GetString("filter")
 			all, _ := cmd.Flags().GetBool("all")
@@ -62,10 +67,6 @@ func newEmojiListCmd() *cobra.Command {
 					return fmt.Errorf("listing emojis: %w", err)
 				}
 
-				if formatter.IsJSON() && !all {
-					return formatter.PrintRaw(raw)
-				}
-
 				var resp struct {
 					CustomEmojis []json.RawMessage `json:"customEmojis"`
 					NextPage     string            `json:"nextPageToken"`
@@ -84,8 +85,7 @@ func newEmojiListCmd() *cobra.Command {
 			}
 
 			if formatter.IsJSON() {
-				// --all + --json: emit collected emojis as a JSON array.
-				return formatter.Print(allEmojis)
+				return printList(formatter, allEmojis, pageToken)
 			}
 
 			if len(allEmojis) == 0 {
@@ -226,20 +226,31 @@ func newEmojiCreateCmd() *cobra.Command {
 			shortName, _ := cmd.Flags().GetString("name")
 			imageFile, _ := cmd.Flags().GetString("image-file")
 
-			// Read the image file and base64-encode it.
-			data, err := os.ReadFile(imageFile)
+			body, err := readBody(cmd)
 			if err != nil {
-				return fmt.Errorf("reading image file %s: %w", imageFile, err)
+				return err
 			}
-			encoded := base64.StdEncoding.EncodeToString(data)
-			filename := filepath.Base(imageFile)
-
-			body := map[string]interface{}{
-				"shortName": shortName,
-				"payload": map[string]interface{}{
-					"fileContent": encoded,
-					"filename":    filename,
-				},
+
+			if body == nil {
+				if err := requireFlags(cmd, "name", "image-file"); err != nil {
+					return err
+				}
+
+				// Read the image file and base64-encode it.
+				data, err := os.ReadFile(imageFile)
+				if err != nil {
+					return fmt.Errorf("reading image file %s: %w", imageFile, err)
+				}
+				encoded := base64.StdEncoding.EncodeToString(data)
+				filename := filepath.Base(imageFile)
+
+				body = map[string]interface{}{
+					"shortName": shortName,
+					"payload": map[string]interface{}{
+						"fileContent": encoded,
+						"filename":    filename,
+					},
+				}
 			}
 
 			raw, err := svc.Create(cmd.Context(), body)
@@ -284,8 +295,7 @@ func newEmojiCreateCmd() *cobra.Command {
 
 	cmd.Flags().String("name", "", "Short name for the custom emoji (required)")
 	cmd.Flags().String("image-file", "", "Path to image file for the emoji (required)")
-	_ = cmd.MarkFlagRequired("name")
-	_ = cmd.MarkFlagRequired("image-file")
+	addBodyFlag(cmd)
 
 	return cmd
 }
@@ -306,13 +316,16 @@ func newEmojiDeleteCmd() *cobra.Command {
 			svc := api.NewEmojiService(client)
 
 			name := args[0]
-			force, _ := cmd.Flags().GetBool("force")
-
-			if !force {
-				fmt.Printf("Delete custom emoji %s? [y/N]: ", name)
-				var answer string
-				fmt.Scanln(&answer)
-				if answer != "y" && answer != "Y" {
+			if !skipConfirm(cmd) {
+				summary, err := summarizeEmoji(cmd.Context(), client, name)
+				if err != nil {
+					return err
+				}
+				ok, err := confirm("Delete this custom emoji?", summary)
+				if err != nil {
+					return err
+				}
+				if !ok {
 					formatter.PrintMessage("Cancelled.")
 					return nil
 				}
@@ -332,7 +345,58 @@ func newEmojiDeleteCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().Bool("force", false, "Skip confirmation prompt")
+	addConfirmFlags(cmd)
 
 	return cmd
 }
+
+// isEmojiShortcode reports whether s names a custom emoji by its
+// shortcode, e.g. ":party-parrot:".
+func isEmojiShortcode(s string) bool {
+	return len(s) > 2 && strings.HasPrefix(s, ":") && strings.HasSuffix(s, ":") && !strings.ContainsAny(s, " \t")
+}
+
+// customEmojiUID returns the UID of the custom emoji with the given
+// shortcode. On a cache miss every custom emoji is listed and cached.
+func customEmojiUID(ctx context.Context, client *api.Client, shortcode string) (string, error) {
+	var uid string
+	if getCache().Get(cache.Emoji, accountCacheKey(shortcode), &uid) {
+		return uid, nil
+	}
+
+	svc := api.NewEmojiService(client)
+	uids := map[string]interface{}{}
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, "", 200, pageToken)
+		if err != nil {
+			return "", fmt.Errorf("looking up custom emoji %s: %w", shortcode, err)
+		}
+		var resp struct {
+			CustomEmojis []struct {
+				UID       string `json:"uid"`
+				EmojiName string `json:"emojiName"`
+			} `json:"customEmojis"`
+			NextPage string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return "", fmt.Errorf("parsing response: %w", err)
+		}
+		for _, e := range resp.CustomEmojis {
+			uids[accountCacheKey(e.EmojiName)] = e.UID
+			if e.EmojiName == shortcode {
+				uid = e.UID
+			}
+		}
+		if resp.NextPage == "" {
+			break
+		}
+		pageToken = resp.NextPage
+	}
+	_ = getCache().PutAll(cache.Emoji, uids)
+
+	if uid == "" {
+		return "", fmt.Errorf("no custom emoji %s found", shortcode)
+	}
+	return uid, nil
+}
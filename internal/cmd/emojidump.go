@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// emojiDumpVersion is the version of the "emoji dump" schema. It changes
+// only when a field is removed or changes meaning.
+const emojiDumpVersion = 1
+
+// emojiEntry is one shortcode of the "emoji dump" table.
+type emojiEntry struct {
+	Shortcode string `json:"shortcode"`
+	// Unicode is the emoji for standard shortcodes.
+	Unicode string `json:"unicode,omitempty"`
+	Custom  bool   `json:"custom"`
+	// UID identifies custom emoji, e.g. for "reactions add --emoji".
+	UID string `json:"uid,omitempty"`
+}
+
+// standardEmoji maps common shortcodes, as used by Chat's emoji picker and
+// most chat tools, to their unicode emoji.
+var standardEmoji = map[string]string{
+	"+1": "👍", "-1": "👎", "100": "💯", "alarm_clock": "⏰", "angry": "😠",
+	"arrow_down": "⬇️", "arrow_left": "⬅️", "arrow_right": "➡️", "arrow_up": "⬆️",
+	"art": "🎨", "astonished": "😲", "baby": "👶", "balloon": "🎈", "bangbang": "‼️",
+	"beer": "🍺", "beers": "🍻", "bell": "🔔", "birthday": "🎂", "blush": "😊",
+	"bomb": "💣", "book": "📖", "bookmark": "🔖", "boom": "💥", "brain": "🧠",
+	"broken_heart": "💔", "bug": "🐛", "bulb": "💡", "calendar": "📆", "camera": "📷",
+	"cat": "🐱", "chart_with_upwards_trend": "📈", "chart_with_downwards_trend": "📉",
+	"clap": "👏", "clipboard": "📋", "clock": "🕐", "cloud": "☁️", "coffee": "☕",
+	"confused": "😕", "construction": "🚧", "cool": "🆒", "crossed_fingers": "🤞",
+	"cry": "😢", "dart": "🎯", "disappointed": "😞", "dog": "🐶", "dollar": "💵",
+	"eyes": "👀", "facepalm": "🤦", "fire": "🔥", "flushed": "😳", "gear": "⚙️",
+	"ghost": "👻", "gift": "🎁", "grimacing": "😬", "grin": "😁", "grinning": "😀",
+	"handshake": "🤝", "heart": "❤️", "heart_eyes": "😍", "hourglass": "⌛",
+	"hugging_face": "🤗", "hushed": "😯", "innocent": "😇", "joy": "😂",
+	"key": "🔑", "kiss": "😘", "laughing": "😆", "link": "🔗", "lock": "🔒",
+	"mag": "🔍", "mask": "😷", "memo": "📝", "moneybag": "💰", "muscle": "💪",
+	"neutral_face": "😐", "no_entry": "⛔", "ok": "🆗", "ok_hand": "👌",
+	"open_mouth": "😮", "package": "📦", "partying_face": "🥳", "pencil2": "✏️",
+	"pensive": "😔", "point_down": "👇", "point_left": "👈", "point_right": "👉",
+	"point_up": "☝️", "pray": "🙏", "pushpin": "📌", "question": "❓",
+	"rage": "😡", "raised_hands": "🙌", "recycle": "♻️", "relaxed": "☺️",
+	"relieved": "😌", "robot": "🤖", "rocket": "🚀", "rofl": "🤣", "rotating_light": "🚨",
+	"scream": "😱", "see_no_evil": "🙈", "shrug": "🤷", "skull": "💀", "sleeping": "😴",
+	"slightly_smiling_face": "🙂", "smile": "😄", "smiley": "😃", "smirk": "😏",
+	"sob": "😭", "sparkles": "✨", "speech_balloon": "💬", "star": "⭐", "star_struck": "🤩",
+	"stuck_out_tongue": "😛", "sun": "☀️", "sunglasses": "😎", "sweat": "😓",
+	"sweat_smile": "😅", "tada": "🎉", "thinking": "🤔", "thumbsdown": "👎",
+	"thumbsup": "👍", "tired_face": "😫", "trophy": "🏆", "unamused": "😒",
+	"upside_down_face": "🙃", "v": "✌️", "warning": "⚠️", "wave": "👋",
+	"weary": "😩", "white_check_mark": "✅", "wink": "😉", "worried": "😟",
+	"x": "❌", "yum": "😋", "zap": "⚡", "zipper_mouth_face": "🤐", "zzz": "💤",
+}
+
+// newEmojiDumpCmd creates the "emoji dump" subcommand.
+func newEmojiDumpCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dump",
+		Short: "Export the emoji shortcode table for completion",
+		Long: `Export a table of emoji shortcodes, for editor plugins and other tools
+that complete ":shortcode:" as you type: the common standard emoji merged
+with your organization's custom emoji, sorted by shortcode.
+
+--format json prints {"version": 1, "emoji": [...]}, where each entry has
+"shortcode" (with colons), "custom", and either "unicode" (standard emoji)
+or "uid" (custom emoji). Fields are only ever added within a version.
+--format tsv prints one "shortcode<TAB>unicode or uid" line per entry.
+
+Use --no-custom to skip the custom emoji, e.g. without network access or
+outside Google Workspace; they are also skipped, with a warning, when they
+cannot be listed.`,
+		Example: `  gogchat emoji dump --format json > ~/.cache/gogchat-emoji.json
+  gogchat emoji dump --format tsv --no-custom`,
+		Args: cobra.NoArgs,
+		RunE: runEmojiDump,
+	}
+
+	cmd.Flags().String("format", "json", "Output format: json or tsv")
+	cmd.Flags().Bool("no-custom", false, "Only export the standard emoji")
+
+	return cmd
+}
+
+func runEmojiDump(cmd *cobra.Command, args []string) error {
+	format, _ := cmd.Flags().GetString("format")
+	noCustom, _ := cmd.Flags().GetBool("no-custom")
+	if format != "json" && format != "tsv" {
+		return fmt.Errorf("unknown --format %q (use json or tsv)", format)
+	}
+
+	entries := make([]emojiEntry, 0, len(standardEmoji))
+	for code, unicode := range standardEmoji {
+		entries = append(entries, emojiEntry{Shortcode: ":" + code + ":", Unicode: unicode})
+	}
+
+	if !noCustom {
+		custom, err := dumpCustomEmoji(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Skipping custom emoji: %v\n", err)
+		}
+		entries = append(entries, custom...)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Shortcode != entries[j].Shortcode {
+			return entries[i].Shortcode < entries[j].Shortcode
+		}
+		return !entries[i].Custom && entries[j].Custom
+	})
+
+	if format == "tsv" {
+		var b strings.Builder
+		for _, e := range entries {
+			value := e.Unicode
+			if e.Custom {
+				value = e.UID
+			}
+			fmt.Fprintf(&b, "%s\t%s\n", e.Shortcode, value)
+		}
+		fmt.Print(b.String())
+		return nil
+	}
+	data, err := json.MarshalIndent(struct {
+		Version int          `json:"version"`
+		Emoji   []emojiEntry `json:"emoji"`
+	}{emojiDumpVersion, entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// dumpCustomEmoji returns the organization's custom emoji as table
+// entries.
+func dumpCustomEmoji(cmd *cobra.Command) ([]emojiEntry, error) {
+	client, err := newAPIClient()
+	if err != nil {
+		return nil, err
+	}
+	custom, err := listAllCustomEmoji(cmd.Context(), client)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]emojiEntry, 0, len(custom))
+	for _, e := range custom {
+		if e.EmojiName != "" {
+			entries = append(entries, emojiEntry{Shortcode: e.EmojiName, Custom: true, UID: e.UID})
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// emojiStat is the reaction usage of one emoji.
+type emojiStat struct {
+	Emoji     string `json:"emoji"`
+	Custom    bool   `json:"custom"`
+	UID       string `json:"uid,omitempty"`
+	Reactions int    `json:"reactions"`
+	Messages  int    `json:"messages"`
+	Spaces    int    `json:"spaces"`
+
+	spaces map[string]bool
+}
+
+// newEmojiStatsCmd creates the "emoji stats" subcommand.
+func newEmojiStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Report how often each emoji is used in reactions",
+		Long: `Report how often each emoji, standard or custom, is used in reactions to
+the messages posted in your spaces since --since, most used first.
+
+Custom emoji that nobody reacted with are listed at the end with zero
+counts, so admins can see which ones are unused before cleaning up. Chat
+has no reaction feed, so every message in the period is read: this covers
+all your spaces unless --spaces narrows them down.`,
+		Example: `  gogchat emoji stats --since 30d
+  gogchat emoji stats --since 90d --spaces all --custom`,
+		Args: cobra.NoArgs,
+		RunE: runEmojiStats,
+	}
+
+	cmd.Flags().String("since", "30d", `Only count reactions to messages created after this time (e.g. "30d", RFC 3339)`)
+	cmd.Flags().StringSlice("spaces", nil, `Spaces to scan (comma-separated; "all" or default: all your spaces)`)
+	cmd.Flags().Bool("custom", false, "Only report custom emoji")
+	addFetchAllFlag(cmd)
+
+	return cmd
+}
+
+func runEmojiStats(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	enableBulkRetries(client)
+	f := getFormatter()
+	ctx := cmd.Context()
+
+	spaceArgs, _ := cmd.Flags().GetStringSlice("spaces")
+	customOnly, _ := cmd.Flags().GetBool("custom")
+	since, err := parseTimeFlag(cmd, "since")
+	if err != nil {
+		return err
+	}
+	if since.IsZero() {
+		return fmt.Errorf("--since is required")
+	}
+	if slices.Contains(spaceArgs, "all") {
+		spaceArgs = nil
+	}
+
+	names, err := digestSpaces(ctx, client, spaceArgs)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	stats := map[string]*emojiStat{}
+	svc := api.NewMessagesService(client)
+	filter := fmt.Sprintf("createTime > %q", formatFilterTime(since))
+	quiet := output.NewFormatter(false, true)
+	limit := fetchLimit(cmd)
+	summary := runBulk(ctx, quiet, names.order, func(ctx context.Context, space string) (string, error) {
+		raws, more, err := listMessagesUpTo(ctx, svc, space, filter, "", limit)
+		if err != nil {
+			return "", err
+		}
+		if more {
+			warnFetchLimit(space, limit)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		for _, raw := range raws {
+			var msg digestMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				continue
+			}
+			for _, r := range msg.EmojiReactionSummaries {
+				stat := emojiStat{Emoji: r.Emoji.Unicode}
+				key := stat.Emoji
+				if custom := r.Emoji.CustomEmoji; custom.UID != "" || custom.EmojiName != "" {
+					stat = emojiStat{Emoji: custom.EmojiName, Custom: true, UID: custom.UID}
+					key = "custom:" + custom.UID
+				}
+				if key == "" || (customOnly && !stat.Custom) {
+					continue
+				}
+				s := stats[key]
+				if s == nil {
+					stat.spaces = map[string]bool{}
+					s = &stat
+					stats[key] = s
+				}
+				s.Reactions += r.ReactionCount
+				s.Messages++
+				s.spaces[space] = true
+			}
+		}
+		return space, nil
+	})
+	if summary.Succeeded == 0 && summary.Total > 0 {
+		return fmt.Errorf("could not read any space: %s", summary.firstError())
+	}
+
+	// Name the custom emoji that were only seen by UID and add the unused
+	// ones. Listing custom emoji can fail (e.g. when they are turned off
+	// for the organization); the usage counts are still worth showing.
+	var unused []emojiStat
+	custom, err := listAllCustomEmoji(ctx, client)
+	if err != nil {
+		f.PrintError(fmt.Sprintf("⚠ Unused custom emoji are left out: %v", err))
+	}
+	for _, e := range custom {
+		if s := stats["custom:"+e.UID]; s != nil {
+			if s.Emoji == "" {
+				s.Emoji = e.EmojiName
+			}
+			continue
+		}
+		unused = append(unused, emojiStat{Emoji: e.EmojiName, Custom: true, UID: e.UID})
+	}
+
+	report := make([]emojiStat, 0, len(stats)+len(unused))
+	for _, s := range stats {
+		s.Spaces = len(s.spaces)
+		if s.Emoji == "" {
+			s.Emoji = s.UID
+		}
+		report = append(report, *s)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Reactions != report[j].Reactions {
+			return report[i].Reactions > report[j].Reactions
+		}
+		return report[i].Emoji < report[j].Emoji
+	})
+	sort.Slice(unused, func(i, j int) bool { return unused[i].Emoji < unused[j].Emoji })
+	report = append(report, unused...)
+
+	if f.IsJSON() {
+		return printList(f, report, "")
+	}
+	if len(report) == 0 {
+		f.PrintMessage("No reactions found.")
+		return nil
+	}
+
+	table := output.NewTable("EMOJI", "TYPE", "REACTIONS", "MESSAGES", "SPACES")
+	for _, s := range report {
+		kind := "standard"
+		if s.Custom {
+			kind = "custom"
+		}
+		table.AddRow(s.Emoji, kind, strconv.Itoa(s.Reactions), strconv.Itoa(s.Messages), strconv.Itoa(s.Spaces))
+	}
+	fmt.Print(table.Render())
+	return nil
+}
+
+// customEmojiInfo identifies a custom emoji.
+type customEmojiInfo struct {
+	UID       string `json:"uid"`
+	EmojiName string `json:"emojiName"`
+}
+
+// listAllCustomEmoji returns every custom emoji of the organization.
+func listAllCustomEmoji(ctx context.Context, client *api.Client) ([]customEmojiInfo, error) {
+	svc := api.NewEmojiService(client)
+	var all []customEmojiInfo
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, "", 200, pageToken)
+		if err != nil {
+			return all, fmt.Errorf("listing custom emoji: %w", err)
+		}
+		var resp struct {
+			CustomEmojis  []customEmojiInfo `json:"customEmojis"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return all, fmt.Errorf("parsing response: %w", err)
+		}
+		all = append(all, resp.CustomEmojis...)
+		if resp.NextPageToken == "" {
+			return all, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// listEnvelope is the JSON output of every list command, whatever the
+// resource, so that tooling can page through results the same way.
+type listEnvelope struct {
+	Items         interface{} `json:"items"`
+	NextPageToken string      `json:"nextPageToken,omitempty"`
+	TotalFetched  int         `json:"totalFetched"`
+}
+
+// printList prints items, a slice, in the list envelope. nextPageToken is
+// the token of the page after the fetched ones, if any.
+func printList(f *output.Formatter, items interface{}, nextPageToken string) error {
+	n := 0
+	if v := reflect.ValueOf(items); v.Kind() == reflect.Slice && !v.IsNil() {
+		n = v.Len()
+	} else {
+		items = []interface{}{}
+	}
+	return f.Print(listEnvelope{Items: items, NextPageToken: nextPageToken, TotalFetched: n})
+}
+
+// printListPage prints one page of an API list response, whose items are in
+// the field key (e.g. "spaces"), in the list envelope.
+func printListPage(f *output.Formatter, raw json.RawMessage, key string) error {
+	var page map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &page); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	var items []json.RawMessage
+	if list, ok := page[key]; ok {
+		if err := json.Unmarshal(list, &items); err != nil {
+			return fmt.Errorf("parsing response: %w", err)
+		}
+	}
+	var next string
+	if token, ok := page["nextPageToken"]; ok {
+		_ = json.Unmarshal(token, &next)
+	}
+	return printList(f, items, next)
+}
@@ -41,8 +41,9 @@ To fix this:
 		hint: `Your access token is missing the required scopes for this operation.
 
 To fix this:
-  1. Run: gogchat auth logout
-  2. Run: gogchat auth login
+  1. Run: gogchat auth status, which lists the commands your login cannot run
+     and the scopes they need
+  2. Run: gogchat auth login --scopes SCOPE
   3. Re-authorize when prompted in your browser`,
 	},
 	{
@@ -85,6 +86,16 @@ Make sure you have the required role in Google Workspace admin console.`,
 	},
 }
 
+// hintError attaches a hint for the situation in which an error occurred,
+// which printRichError shows in place of the generic hint of the error.
+type hintError struct {
+	err  error
+	hint string
+}
+
+func (e *hintError) Error() string { return e.err.Error() }
+func (e *hintError) Unwrap() error { return e.err }
+
 // findHint searches for an actionable hint matching the given API error.
 func findHint(apiErr *api.APIError) string {
 	for _, ke := range knownErrors {
@@ -105,10 +116,16 @@ func findHint(apiErr *api.APIError) string {
 // printRichError prints a detailed, user-friendly error message to stderr.
 // It handles both regular errors and *api.APIError with extended details.
 func printRichError(err error) {
+	var hinted *hintError
+	errors.As(err, &hinted)
+
 	var apiErr *api.APIError
 	if !errors.As(err, &apiErr) {
 		// Not an API error – print as-is.
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if hinted != nil {
+			fmt.Fprintf(os.Stderr, "\n%s\n", hinted.hint)
+		}
 		return
 	}
 
@@ -117,7 +134,11 @@ func printRichError(err error) {
 	fmt.Fprintf(os.Stderr, "  %s\n", apiErr.Message)
 
 	// Check for a known error hint
-	if hint := findHint(apiErr); hint != "" {
+	hint := findHint(apiErr)
+	if hinted != nil {
+		hint = hinted.hint
+	}
+	if hint != "" {
 		fmt.Fprintf(os.Stderr, "\n  Hint:\n")
 		for _, line := range strings.Split(hint, "\n") {
 			fmt.Fprintf(os.Stderr, "  %s\n", line)
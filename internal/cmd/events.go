@@ -3,6 +3,11 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
@@ -16,12 +21,13 @@ func NewEventsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "events",
 		Short: "Manage space events",
-		Long:  "List and retrieve events from Google Chat spaces.",
+		Long:  "List and retrieve events from Google Chat spaces, and replay recorded events through a handler.",
 	}
 
 	cmd.AddCommand(
 		newEventsListCmd(),
 		newEventsGetCmd(),
+		newEventsReplayCmd(),
 	)
 
 	return cmd
@@ -44,7 +50,11 @@ func newEventsListCmd() *cobra.Command {
 
 			parent := args[0]
 			filter, _ := cmd.Flags().GetString("filter")
-			pageSize, _ := cmd.Flags().GetInt("page-size")
+			filter, err = eventTimeFilter(cmd, filter)
+			if err != nil {
+				return err
+			}
+			pageSize := getPageSize(cmd)
 			pageToken, _ := cmd.Flags().GetString("page-token")
 			all, _ := cmd.Flags().GetBool("all")
 
@@ -58,10 +68,6 @@ func newEventsListCmd() *cobra.Command {
 					return fmt.Errorf("listing events: %w", err)
 				}
 
-				if formatter.IsJSON() && !all {
-					return formatter.PrintRaw(raw)
-				}
-
 				var resp struct {
 					SpaceEvents []json.RawMessage `json:"spaceEvents"`
 					NextPage    string            `json:"nextPageToken"`
@@ -80,8 +86,7 @@ func newEventsListCmd() *cobra.Command {
 			}
 
 			if formatter.IsJSON() {
-				// --all + --json: emit collected events as a JSON array.
-				return formatter.Print(allEvents)
+				return printList(formatter, allEvents, pageToken)
 			}
 
 			if len(allEvents) == 0 {
@@ -118,6 +123,7 @@ func newEventsListCmd() *cobra.Command {
 	cmd.Flags().Int("page-size", 0, "Maximum number of events to return per page")
 	cmd.Flags().String("page-token", "", "Page token for pagination")
 	cmd.Flags().Bool("all", false, "Fetch all pages of results")
+	addTimeRangeFlags(cmd)
 
 	return cmd
 }
@@ -214,3 +220,196 @@ func summarizeEventPayload(raw json.RawMessage) string {
 
 	return ""
 }
+
+// newEventsReplayCmd creates the "events replay" subcommand.
+func newEventsReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay FILE --exec COMMAND",
+		Short: "Feed recorded events through a message handler",
+		Long: `Replay previously exported space events through an --exec handler, run the
+same way "messages tail --exec" runs it for live messages, to develop and
+regression-test bot logic against recorded traffic. The API is not called.
+
+FILE (or - for stdin) holds events as JSON lines or the output of
+"events list --json". Events are replayed in file order. For every message
+in a message event (created, updated, deleted, and their batch variants)
+the handler gets the message JSON on stdin and the environment variables of
+"messages tail --exec", plus:
+
+  GOGCHAT_EVENT         Event resource name
+  GOGCHAT_EVENT_TYPE    Event type, e.g. google.workspace.chat.message.v1.created
+  GOGCHAT_EVENT_TIME    Event time (RFC 3339)
+
+Other events are skipped. If any handler run fails, the command exits with
+status 6 after replaying the rest (or at the first failure with
+--stop-on-error).`,
+		Args: cobra.ExactArgs(1),
+		RunE: runEventsReplay,
+	}
+
+	flags := cmd.Flags()
+	flags.String("exec", "", "Shell command to run for each message (required)")
+	flags.StringSlice("type", nil, "Only replay events of these types (repeatable)")
+	flags.Bool("stop-on-error", false, "Stop at the first failing handler run")
+	_ = cmd.MarkFlagRequired("exec")
+
+	return cmd
+}
+
+// messageEventKeys are the payload fields of events about one message;
+// batchMessageEventKeys those of events about several.
+var (
+	messageEventKeys      = []string{"messageCreatedEventData", "messageUpdatedEventData", "messageDeletedEventData"}
+	batchMessageEventKeys = []string{"messageBatchCreatedEventData", "messageBatchUpdatedEventData", "messageBatchDeletedEventData"}
+)
+
+// recordedEvent is a space event read for replay.
+type recordedEvent struct {
+	Name      string `json:"name"`
+	EventType string `json:"eventType"`
+	EventTime string `json:"eventTime"`
+	// Messages are the messages of a message event, in payload order.
+	Messages []json.RawMessage `json:"-"`
+}
+
+func runEventsReplay(cmd *cobra.Command, args []string) error {
+	f := getFormatter()
+	handler, _ := cmd.Flags().GetString("exec")
+	types, _ := cmd.Flags().GetStringSlice("type")
+	stopOnError, _ := cmd.Flags().GetBool("stop-on-error")
+
+	in := os.Stdin
+	if args[0] != "-" {
+		file, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	events, err := readRecordedEvents(in)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", args[0], err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var replayed, skipped, failed int
+	for _, ev := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		if (len(types) > 0 && !slices.Contains(types, ev.EventType)) || len(ev.Messages) == 0 {
+			skipped++
+			continue
+		}
+
+		for _, raw := range ev.Messages {
+			var msg tailMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				return fmt.Errorf("parsing message of event %s: %w", ev.Name, err)
+			}
+			printTailMessage(f, raw, msg, false, "")
+
+			replayed++
+			err := runTailHandler(ctx, handler, raw, msg,
+				"GOGCHAT_EVENT="+ev.Name,
+				"GOGCHAT_EVENT_TYPE="+ev.EventType,
+				"GOGCHAT_EVENT_TIME="+ev.EventTime,
+			)
+			if err == nil {
+				continue
+			}
+			failed++
+			f.PrintError(fmt.Sprintf("⚠ --exec failed for %s (%s): %v", msg.Name, ev.Name, err))
+			if stopOnError {
+				return &bulkError{fmt.Sprintf("handler failed for %s", msg.Name)}
+			}
+		}
+	}
+
+	if !f.IsJSON() {
+		f.PrintMessage(fmt.Sprintf("Replayed %d messages from %d events (%d events skipped), %d handler runs failed.",
+			replayed, len(events)-skipped, skipped, failed))
+	}
+	if failed > 0 {
+		return &bulkError{fmt.Sprintf("%d of %d handler runs failed", failed, replayed)}
+	}
+	return nil
+}
+
+// readRecordedEvents reads the events of r: a stream of JSON values, each
+// an event, an array of events, or a list response ("events list --json"
+// or the API's spaceEvents).
+func readRecordedEvents(r io.Reader) ([]recordedEvent, error) {
+	var events []recordedEvent
+	dec := json.NewDecoder(r)
+	for {
+		var value json.RawMessage
+		err := dec.Decode(&value)
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var items []json.RawMessage
+		var list struct {
+			Items       []json.RawMessage `json:"items"`
+			SpaceEvents []json.RawMessage `json:"spaceEvents"`
+		}
+		switch {
+		case json.Unmarshal(value, &items) == nil:
+		case json.Unmarshal(value, &list) == nil && (list.Items != nil || list.SpaceEvents != nil):
+			items = append(list.Items, list.SpaceEvents...)
+		default:
+			items = []json.RawMessage{value}
+		}
+
+		for _, item := range items {
+			ev, err := parseRecordedEvent(item)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, ev)
+		}
+	}
+}
+
+// parseRecordedEvent parses one space event and extracts its messages.
+func parseRecordedEvent(raw json.RawMessage) (recordedEvent, error) {
+	var ev recordedEvent
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &ev); err != nil {
+		return ev, fmt.Errorf("parsing event: %w", err)
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return ev, fmt.Errorf("parsing event: %w", err)
+	}
+
+	for _, key := range messageEventKeys {
+		var data struct {
+			Message json.RawMessage `json:"message"`
+		}
+		if payload, ok := fields[key]; ok && json.Unmarshal(payload, &data) == nil && data.Message != nil {
+			ev.Messages = append(ev.Messages, data.Message)
+		}
+	}
+	for _, key := range batchMessageEventKeys {
+		var data struct {
+			Messages []struct {
+				Message json.RawMessage `json:"message"`
+			} `json:"messages"`
+		}
+		if payload, ok := fields[key]; ok && json.Unmarshal(payload, &data) == nil {
+			for _, m := range data.Messages {
+				if m.Message != nil {
+					ev.Messages = append(ev.Messages, m.Message)
+				}
+			}
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/cipher-shad0w/gogchat/internal/timeparse"
+)
+
+// memberExpiry is a membership to be removed once it expires.
+type memberExpiry struct {
+	Member    string    `json:"member"`
+	User      string    `json:"user"`
+	AddedAt   time.Time `json:"addedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// expiriesMu serialises updates of the registry by concurrent bulk adds.
+var expiriesMu sync.Mutex
+
+// expiriesPath returns the location of the membership expiry registry.
+func expiriesPath() string {
+	return filepath.Join(config.ConfigDir(), "member-expiries.json")
+}
+
+// loadExpiries reads the membership expiry registry; a missing registry is
+// empty.
+func loadExpiries() ([]memberExpiry, error) {
+	data, err := os.ReadFile(expiriesPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading membership expiries: %w", err)
+	}
+	var expiries []memberExpiry
+	if err := json.Unmarshal(data, &expiries); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", expiriesPath(), err)
+	}
+	return expiries, nil
+}
+
+// saveExpiries writes the membership expiry registry.
+func saveExpiries(expiries []memberExpiry) error {
+	data, err := json.MarshalIndent(expiries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.ConfigDir(), 0o700); err != nil {
+		return fmt.Errorf("saving membership expiries: %w", err)
+	}
+	if err := os.WriteFile(expiriesPath(), data, 0o600); err != nil {
+		return fmt.Errorf("saving membership expiries: %w", err)
+	}
+	return nil
+}
+
+// parseExpiry parses --expires: a period such as "7d" or "2 weeks" from
+// now, or a future point in time such as "2026-03-01 18:00".
+func parseExpiry(s string, now time.Time) (time.Time, error) {
+	if t, err := timeparse.Parse(s+" ago", now); err == nil {
+		return now.Add(now.Sub(t)), nil
+	}
+	t, err := timeparse.Parse(s, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --expires %q (try \"7d\", \"2 weeks\", or a date)", s)
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("--expires %q is in the past", s)
+	}
+	return t, nil
+}
+
+// recordExpiry registers the membership raw, created for user, to expire
+// at expires. Failures are reported but do not fail the add, since the
+// membership exists either way.
+func recordExpiry(user string, raw json.RawMessage, expires time.Time) {
+	member := jsonField(raw, "name")
+	if member == "" {
+		return
+	}
+
+	expiriesMu.Lock()
+	defer expiriesMu.Unlock()
+	expiries, err := loadExpiries()
+	if err == nil {
+		kept := expiries[:0]
+		for _, e := range expiries {
+			if e.Member != member {
+				kept = append(kept, e)
+			}
+		}
+		kept = append(kept, memberExpiry{Member: member, User: user, AddedAt: time.Now().UTC(), ExpiresAt: expires.UTC()})
+		err = saveExpiries(kept)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record the expiry of %s; it will not be removed automatically: %v\n", member, err)
+	}
+}
+
+// newMembersExpiringCmd creates the "members expiring" subcommand.
+func newMembersExpiringCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "expiring [SPACE]",
+		Short: "List temporary memberships and when they expire",
+		Long:  "List the memberships added with --expires, soonest expiry first. With SPACE, only that space's memberships are listed.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			expiries, err := loadExpiries()
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				space := api.NormalizeName(args[0], "spaces/")
+				var kept []memberExpiry
+				for _, e := range expiries {
+					if spaceOf(e.Member) == space {
+						kept = append(kept, e)
+					}
+				}
+				expiries = kept
+			}
+			sort.SliceStable(expiries, func(i, j int) bool { return expiries[i].ExpiresAt.Before(expiries[j].ExpiresAt) })
+
+			if f.IsJSON() {
+				return printList(f, expiries, "")
+			}
+			if len(expiries) == 0 {
+				f.PrintMessage("No temporary memberships.")
+				return nil
+			}
+
+			table := output.NewTable("MEMBER", "USER", "EXPIRES")
+			for _, e := range expiries {
+				expires := output.FormatTime(e.ExpiresAt.Format(time.RFC3339))
+				if !e.ExpiresAt.After(time.Now()) {
+					expires += " (expired)"
+				}
+				table.AddRow(e.Member, e.User, expires)
+			}
+			fmt.Print(table.Render())
+			return nil
+		},
+	}
+}
+
+// newMembersExpireCmd creates the "members expire" subcommand.
+func newMembersExpireCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "expire",
+		Short: "Remove temporary memberships that have expired",
+		Long: `Remove the memberships added with --expires whose time is up, and drop
+them from the registry. Memberships that were already removed are dropped
+too.
+
+Chat has no expiring memberships, so this has to run regularly to enforce
+them, e.g. every few minutes from cron or a systemd timer with --yes.
+Exits with status 6 if any membership could not be removed; it is tried
+again on the next run.`,
+		Example: `  gogchat members expire --dry-run
+  */10 * * * * gogchat members expire --yes`,
+		Args: cobra.NoArgs,
+		RunE: runMembersExpire,
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show the expired memberships without removing them")
+	addConfirmFlags(cmd)
+	addProtectionFlag(cmd)
+
+	return cmd
+}
+
+func runMembersExpire(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	admin, _ := cmd.Flags().GetBool("admin")
+	f := getFormatter()
+
+	expiries, err := loadExpiries()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var due []string
+	users := map[string]string{}
+	for _, e := range expiries {
+		if !e.ExpiresAt.After(now) {
+			due = append(due, e.Member)
+			users[e.Member] = e.User
+		}
+	}
+	if len(due) == 0 {
+		f.PrintMessage("No memberships have expired.")
+		return nil
+	}
+
+	if dryRun || !skipConfirm(cmd) {
+		var summary []string
+		for _, member := range due {
+			summary = append(summary, fmt.Sprintf("%s (%s)", member, users[member]))
+		}
+		if dryRun {
+			if f.IsJSON() {
+				return printList(f, due, "")
+			}
+			f.PrintMessage(fmt.Sprintf("Dry run: %d expired memberships would be removed:", len(due)))
+			for _, line := range summary {
+				f.PrintMessage("  " + line)
+			}
+			return nil
+		}
+		ok, err := confirm(fmt.Sprintf("Remove these %d expired memberships?", len(due)), summary)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			f.PrintMessage("Cancelled.")
+			return nil
+		}
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	enableBulkRetries(client)
+	guard := newSpaceGuard(cmd, client)
+	svc := api.NewMembersService(client)
+
+	var mu sync.Mutex
+	removed := map[string]bool{}
+	summary := runBulk(cmd.Context(), f, due, func(ctx context.Context, member string) (string, error) {
+		if err := guard.check(ctx, member); err != nil {
+			return "", err
+		}
+		_, err := svc.Delete(ctx, member, admin)
+		var apiErr *api.APIError
+		gone := errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound
+		if err != nil && !gone {
+			return "", err
+		}
+		mu.Lock()
+		removed[member] = true
+		mu.Unlock()
+		if gone {
+			return fmt.Sprintf("%s was already removed", member), nil
+		}
+		return fmt.Sprintf("Removed %s (%s)", member, users[member]), nil
+	})
+
+	// Reload in case members were added meanwhile.
+	expiriesMu.Lock()
+	current, err := loadExpiries()
+	if err == nil {
+		kept := current[:0]
+		for _, e := range current {
+			if !removed[e.Member] {
+				kept = append(kept, e)
+			}
+		}
+		err = saveExpiries(kept)
+	}
+	expiriesMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return finishBulk(f, summary, "memberships")
+}
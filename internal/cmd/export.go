@@ -0,0 +1,497 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+)
+
+// exportManifestName is the name of the manifest in the export directory.
+const exportManifestName = "manifest.json"
+
+// exportManifestVersion is the schema version of the manifest. It only
+// changes when fields are renamed or removed.
+const exportManifestVersion = 1
+
+// defaultExportRate is the default of --rate, in requests per second
+// shared by all workers.
+const defaultExportRate = 5
+
+// Statuses of the spaces of an export.
+const (
+	exportPending = "pending"
+	exportDone    = "done"
+	exportFailed  = "failed"
+)
+
+// exportFilterFields maps the lower-case field names of --filter to the
+// space fields they match.
+var exportFilterFields = map[string]string{
+	"displayname": "displayName",
+	"name":        "name",
+	"spacetype":   "spaceType",
+}
+
+// exportManifest records the progress of an export, so that an interrupted
+// export can be resumed.
+type exportManifest struct {
+	Version int      `json:"version"`
+	Filter  []string `json:"filter"`
+	// Since and Until are the time range, fixed when the export started.
+	Since     string         `json:"since,omitempty"`
+	Until     string         `json:"until,omitempty"`
+	StartedAt string         `json:"startedAt"`
+	UpdatedAt string         `json:"updatedAt"`
+	Spaces    []*exportSpace `json:"spaces"`
+}
+
+// exportSpace is the progress of the export of one space.
+type exportSpace struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	SpaceType   string `json:"spaceType"`
+	// File is the JSON Lines file of the messages, relative to the
+	// export directory.
+	File     string `json:"file"`
+	Status   string `json:"status"`
+	Messages int    `json:"messages"`
+	// Bytes and LastCreateTime are the checkpoint: the first Bytes bytes
+	// of File hold the messages up to the one created at LastCreateTime.
+	Bytes          int64  `json:"bytes"`
+	LastCreateTime string `json:"lastCreateTime,omitempty"`
+	Error          string `json:"error,omitempty"`
+	CompletedAt    string `json:"completedAt,omitempty"`
+}
+
+// label returns the display name of the space, or its resource name.
+func (s *exportSpace) label() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Name
+}
+
+// exportFilter is a --filter condition: the glob pattern that a space
+// field must match, regardless of case.
+type exportFilter struct {
+	field   string
+	pattern string
+}
+
+// parseExportFilters parses --filter values of the form FIELD:PATTERN.
+func parseExportFilters(values []string) ([]exportFilter, error) {
+	var filters []exportFilter
+	for _, v := range values {
+		field, pattern, ok := strings.Cut(v, ":")
+		name := exportFilterFields[strings.ToLower(strings.TrimSpace(field))]
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --filter %q; expected FIELD:PATTERN with FIELD one of displayName, name, spaceType", v)
+		}
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if name == "name" && !strings.HasPrefix(pattern, "spaces/") {
+			pattern = "spaces/" + pattern
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid --filter %q: %w", v, err)
+		}
+		filters = append(filters, exportFilter{field: name, pattern: pattern})
+	}
+	return filters, nil
+}
+
+// matchExportFilters reports whether space matches every filter.
+func matchExportFilters(filters []exportFilter, space map[string]interface{}) bool {
+	for _, f := range filters {
+		if ok, _ := path.Match(f.pattern, strings.ToLower(spaceMapStr(space, f.field))); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// NewExportCmd creates the top-level "export" command.
+func NewExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Archive the messages of many spaces",
+		Long: `Export the messages of every space matching --filter into --out, one
+JSON Lines file per space (oldest message first), for archival jobs.
+
+--filter FIELD:PATTERN selects spaces by displayName, name, or spaceType
+with a glob pattern (* and ?, case-insensitive); repeated filters must all
+match. Without --filter every space you are a member of is exported.
+
+Spaces are exported in parallel by --concurrency workers, which share a
+budget of --rate requests per second and of retries, so that a large export
+does not trip the API's rate limits.
+
+Progress is recorded in manifest.json in --out after every page of
+messages. Running the same command again resumes an interrupted or partly
+failed export: finished spaces are skipped and the others continue after
+the last message written. The spaces and the --since/--until range are
+fixed when the export starts; --restart discards the manifest and starts
+over.
+
+With --sink NAME, every message is also written to that sink of the config
+file (see sinks), e.g. a bucket. Messages of a page are written to the
+sinks before the checkpoint, so a resumed export may write the messages of
+the interrupted page again.`,
+		Example: `  # Archive every space whose name starts with "Team"
+  $ gogchat export --filter 'displayName:Team*' --out ./archives
+  Exporting 3 spaces to ./archives...
+  ✓ Team Alpha: 1204 messages → AAAA1111.jsonl
+  ✓ Team Beta: 87 messages → AAAA2222.jsonl
+  ✓ Team Gamma: 5310 messages → AAAA3333.jsonl
+  3 of 3 spaces succeeded, 0 failed, 0 skipped.
+
+  # Last quarter's messages of all named spaces, gently
+  $ gogchat export --filter spaceType:SPACE --since 2026-07-01 --until 2026-10-01 \
+      --out ./q3 --concurrency 2 --rate 2`,
+		Args: cobra.NoArgs,
+		RunE: runExport,
+	}
+
+	flags := cmd.Flags()
+	flags.StringArray("filter", nil, "Only export spaces whose FIELD matches the glob PATTERN, as FIELD:PATTERN (repeatable)")
+	flags.String("out", "", "Directory to write the export and its manifest to (required)")
+	flags.Float64("rate", defaultExportRate, "Maximum API requests per second, shared by all workers (0 for no limit)")
+	flags.Bool("restart", false, "Discard the manifest in --out and export everything again")
+	addTimeRangeFlags(cmd)
+	addSinkFlag(cmd, "every message")
+	_ = cmd.MarkFlagRequired("out")
+	_ = cmd.MarkFlagDirname("out")
+
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	filterValues, _ := cmd.Flags().GetStringArray("filter")
+	filters, err := parseExportFilters(filterValues)
+	if err != nil {
+		return err
+	}
+	dir, _ := cmd.Flags().GetString("out")
+	dir = expandHome(dir)
+	rate, _ := cmd.Flags().GetFloat64("rate")
+	if rate < 0 {
+		return fmt.Errorf("--rate must not be negative")
+	}
+	restart, _ := cmd.Flags().GetBool("restart")
+	since, until, err := timeRange(cmd)
+	if err != nil {
+		return err
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	enableBulkRetries(client)
+	client = rateLimitClient(client, rate)
+	f := getFormatter()
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+	run := &exportRun{dir: dir}
+	if !restart {
+		run.manifest, err = loadExportManifest(dir)
+		if err != nil {
+			return err
+		}
+	}
+	if run.manifest != nil {
+		if !slices.Equal(run.manifest.Filter, filterValues) {
+			what := "without --filter"
+			if len(run.manifest.Filter) > 0 {
+				what = "with --filter " + strings.Join(run.manifest.Filter, " --filter ")
+			}
+			return fmt.Errorf("%s holds an export made %s; run it with the same filters to resume, or pass --restart", dir, what)
+		}
+	} else {
+		spaces, err := listAllSpaces(ctx, client, "")
+		if err != nil {
+			return err
+		}
+		run.manifest = newExportManifest(filterValues, since, until, filters, spaces)
+		if len(run.manifest.Spaces) == 0 {
+			return fmt.Errorf("no spaces match --filter")
+		}
+		if err := run.save(); err != nil {
+			return err
+		}
+	}
+
+	var pending []string
+	bySpace := map[string]*exportSpace{}
+	for _, s := range run.manifest.Spaces {
+		bySpace[s.Name] = s
+		if s.Status != exportDone {
+			pending = append(pending, s.Name)
+		}
+	}
+	if done := len(run.manifest.Spaces) - len(pending); done > 0 {
+		f.PrintMessage(fmt.Sprintf("Resuming: %d of %d %s already exported.", done, len(run.manifest.Spaces), plural(len(run.manifest.Spaces), "space", "spaces")))
+	}
+	if len(pending) == 0 {
+		f.PrintSuccess(fmt.Sprintf("Export in %s is complete.", dir))
+		return nil
+	}
+	f.PrintMessage(fmt.Sprintf("Exporting %d %s to %s...", len(pending), plural(len(pending), "space", "spaces"), dir))
+
+	if run.sinks, err = openSinks(cmd); err != nil {
+		return err
+	}
+	defer run.sinks.close()
+
+	svc := api.NewMessagesService(client)
+	summary := runBulk(ctx, f, pending, func(ctx context.Context, name string) (string, error) {
+		s := bySpace[name]
+		if err := run.exportSpace(ctx, svc, s); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s: %d %s → %s", s.label(), s.Messages, plural(s.Messages, "message", "messages"), s.File), nil
+	})
+	if ctx.Err() != nil && !f.IsJSON() {
+		f.PrintError("Interrupted; run the same command again to resume.")
+	}
+	return finishBulk(f, summary, "spaces")
+}
+
+// newExportManifest returns the manifest of a new export of the spaces
+// matching filters.
+func newExportManifest(filter []string, since, until time.Time, filters []exportFilter, spaces []json.RawMessage) *exportManifest {
+	m := &exportManifest{
+		Version:   exportManifestVersion,
+		Filter:    filter,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+		Spaces:    []*exportSpace{},
+	}
+	if m.Filter == nil {
+		m.Filter = []string{}
+	}
+	if !since.IsZero() {
+		m.Since = formatFilterTime(since)
+	}
+	if !until.IsZero() {
+		m.Until = formatFilterTime(until)
+	}
+	for _, raw := range spaces {
+		var sp map[string]interface{}
+		if err := json.Unmarshal(raw, &sp); err != nil || !matchExportFilters(filters, sp) {
+			continue
+		}
+		name := spaceMapStr(sp, "name")
+		m.Spaces = append(m.Spaces, &exportSpace{
+			Name:        name,
+			DisplayName: spaceMapStr(sp, "displayName"),
+			SpaceType:   spaceMapStr(sp, "spaceType"),
+			File:        strings.TrimPrefix(name, "spaces/") + ".jsonl",
+			Status:      exportPending,
+		})
+	}
+	return m
+}
+
+// loadExportManifest reads the manifest in dir, or returns nil if there is
+// none.
+func loadExportManifest(dir string) (*exportManifest, error) {
+	p := filepath.Join(dir, exportManifestName)
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", p, err)
+	}
+	var m exportManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w (pass --restart to start over)", p, err)
+	}
+	if m.Version != exportManifestVersion {
+		return nil, fmt.Errorf("%s has version %d, expected %d (pass --restart to start over)", p, m.Version, exportManifestVersion)
+	}
+	return &m, nil
+}
+
+// exportRun is an export in progress. Workers update the spaces of its
+// manifest through update, which saves the manifest.
+type exportRun struct {
+	dir   string
+	sinks *sinkSet
+
+	mu       sync.Mutex
+	manifest *exportManifest
+}
+
+// save writes the manifest. The caller holds r.mu or is the only user.
+func (r *exportRun) save() error {
+	r.manifest.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	data, err := json.MarshalIndent(r.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(r.dir, exportManifestName), append(data, '\n'), 0o644)
+}
+
+// update applies change to the manifest and saves it.
+func (r *exportRun) update(change func()) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	change()
+	return r.save()
+}
+
+// exportSpace appends the messages of s after its checkpoint to its file,
+// one page at a time, saving the checkpoint after each page.
+func (r *exportRun) exportSpace(ctx context.Context, svc *api.MessagesService, s *exportSpace) error {
+	p := filepath.Join(r.dir, s.File)
+	file, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	// Drop whatever was written after the last checkpoint.
+	if err := file.Truncate(s.Bytes); err != nil {
+		return err
+	}
+	if _, err := file.Seek(s.Bytes, 0); err != nil {
+		return err
+	}
+
+	var clauses []string
+	if s.LastCreateTime != "" {
+		clauses = append(clauses, fmt.Sprintf("createTime > %q", s.LastCreateTime))
+	} else if r.manifest.Since != "" {
+		clauses = append(clauses, fmt.Sprintf("createTime > %q", r.manifest.Since))
+	}
+	if r.manifest.Until != "" {
+		clauses = append(clauses, fmt.Sprintf("createTime < %q", r.manifest.Until))
+	}
+	filter := andFilter("", clauses...)
+
+	fail := func(err error) error {
+		if ctx.Err() == nil {
+			_ = r.update(func() {
+				s.Status = exportFailed
+				s.Error = err.Error()
+			})
+		}
+		return err
+	}
+
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, s.Name, 1000, pageToken, filter, "createTime asc", false)
+		if err != nil {
+			return fail(fmt.Errorf("listing messages: %w", err))
+		}
+		var resp struct {
+			Messages      []json.RawMessage `json:"messages"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return fail(fmt.Errorf("parsing response: %w", err))
+		}
+
+		var buf bytes.Buffer
+		last := ""
+		for _, msg := range resp.Messages {
+			if err := json.Compact(&buf, msg); err != nil {
+				return fail(fmt.Errorf("parsing response: %w", err))
+			}
+			buf.WriteByte('\n')
+			var m struct {
+				CreateTime string `json:"createTime"`
+			}
+			if json.Unmarshal(msg, &m) == nil && m.CreateTime != "" {
+				last = m.CreateTime
+			}
+		}
+		if buf.Len() > 0 {
+			if _, err := file.Write(buf.Bytes()); err != nil {
+				return fail(err)
+			}
+			if err := file.Sync(); err != nil {
+				return fail(err)
+			}
+		}
+		for _, msg := range resp.Messages {
+			r.sinks.write(msg)
+		}
+
+		done := resp.NextPageToken == ""
+		n, size := len(resp.Messages), int64(buf.Len())
+		if err := r.update(func() {
+			s.Messages += n
+			s.Bytes += size
+			if last != "" {
+				s.LastCreateTime = last
+			}
+			s.Error = ""
+			if done {
+				s.Status = exportDone
+				s.CompletedAt = time.Now().UTC().Format(time.RFC3339)
+			}
+		}); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// rateLimitClient returns a copy of client whose requests are spaced to
+// at most rate per second across all goroutines, or client itself if rate
+// is 0.
+func rateLimitClient(client *api.Client, rate float64) *api.Client {
+	if rate <= 0 {
+		return client
+	}
+	base := client.HTTPClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	limited := *client
+	limited.HTTPClient = &http.Client{Transport: &rateLimitedTransport{
+		base: base,
+		tick: time.NewTicker(time.Duration(float64(time.Second) / rate)).C,
+	}}
+	return &limited
+}
+
+// rateLimitedTransport sends each request on a tick of a shared ticker.
+type rateLimitedTransport struct {
+	base http.RoundTripper
+	tick <-chan time.Time
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	select {
+	case <-t.tick:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	return t.base.RoundTrip(req)
+}
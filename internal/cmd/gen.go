@@ -0,0 +1,345 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completionFiles maps the shells "gen completion" supports to the file
+// names that their completion systems look for.
+var completionFiles = map[string]string{
+	"bash":       "gogchat.bash",
+	"zsh":        "_gogchat",
+	"fish":       "gogchat.fish",
+	"powershell": "gogchat.ps1",
+}
+
+// NewGenCmd creates the "gen" command.
+func NewGenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen",
+		Short: "Generate man pages and completion scripts",
+		Long: `Generate install artifacts from the binary itself, for package maintainers
+(Homebrew, deb, rpm) and for manual installs: man pages for every command
+and completion scripts for every supported shell.`,
+	}
+
+	cmd.AddCommand(
+		newGenManCmd(),
+		newGenCompletionCmd(),
+	)
+
+	return cmd
+}
+
+// newGenManCmd creates the "gen man" subcommand.
+func newGenManCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "man DIR",
+		Short: "Generate man pages",
+		Long: `Generate a man page for every command into DIR: gogchat.1 for the root command
+and gogchat-<command>-<subcommand>.1 for the others, with the command's
+description, flags, examples, and related commands.
+
+The date in the pages is taken from SOURCE_DATE_EPOCH, if set, so that
+package builds are reproducible.`,
+		Example: `  gogchat gen man ./man
+  gogchat gen man --section 1 /usr/local/share/man/man1`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			section, _ := cmd.Flags().GetString("section")
+			dir := expandHome(args[0])
+
+			date, err := manDate()
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("creating %s: %w", dir, err)
+			}
+
+			n := 0
+			var walk func(c *cobra.Command) error
+			walk = func(c *cobra.Command) error {
+				if c.HasParent() && (!c.IsAvailableCommand() || c.IsAdditionalHelpTopicCommand()) {
+					return nil
+				}
+				name := manPageName(c) + "." + section
+				if err := os.WriteFile(filepath.Join(dir, name), genManPage(c, section, date), 0o644); err != nil {
+					return fmt.Errorf("writing %s: %w", name, err)
+				}
+				n++
+				for _, sub := range c.Commands() {
+					if err := walk(sub); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+			if err := walk(cmd.Root()); err != nil {
+				return err
+			}
+
+			if f.IsJSON() {
+				return f.Print(map[string]any{"dir": dir, "pages": n})
+			}
+			f.PrintSuccess(fmt.Sprintf("Wrote %d man %s to %s", n, plural(n, "page", "pages"), dir))
+			return nil
+		},
+	}
+
+	cmd.Flags().String("section", "1", "The manual section of the pages")
+
+	return cmd
+}
+
+// newGenCompletionCmd creates the "gen completion" subcommand.
+func newGenCompletionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion DIR {--shell SHELL...|--all}",
+		Short: "Generate shell completion scripts",
+		Long: `Generate completion scripts into DIR, for the shells given with --shell or
+for all of them with --all. The files are named as the shells' completion
+systems expect:
+
+  bash        gogchat.bash   (e.g. /usr/share/bash-completion/completions/gogchat)
+  zsh         _gogchat       (a directory in $fpath, e.g. /usr/share/zsh/site-functions)
+  fish        gogchat.fish   (e.g. /usr/share/fish/vendor_completions.d)
+  powershell  gogchat.ps1    (dot-source it from your profile)
+
+The scripts are the same as those printed by "gogchat completion SHELL".`,
+		Example: `  gogchat gen completion --all ./completions
+  gogchat gen completion --shell bash --shell zsh ./completions`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			shells, _ := cmd.Flags().GetStringArray("shell")
+			all, _ := cmd.Flags().GetBool("all")
+			dir := expandHome(args[0])
+
+			if all {
+				shells = shells[:0]
+				for shell := range completionFiles {
+					shells = append(shells, shell)
+				}
+				sort.Strings(shells)
+			}
+			if len(shells) == 0 {
+				return fmt.Errorf("specify the shells with --shell, or --all")
+			}
+			for _, shell := range shells {
+				if _, ok := completionFiles[shell]; !ok {
+					return fmt.Errorf("unsupported shell %q (expected bash, zsh, fish, or powershell)", shell)
+				}
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("creating %s: %w", dir, err)
+			}
+
+			root := cmd.Root()
+			var files []string
+			for _, shell := range shells {
+				path := filepath.Join(dir, completionFiles[shell])
+				var err error
+				switch shell {
+				case "bash":
+					err = root.GenBashCompletionFileV2(path, true)
+				case "zsh":
+					err = root.GenZshCompletionFile(path)
+				case "fish":
+					err = root.GenFishCompletionFile(path, true)
+				case "powershell":
+					err = root.GenPowerShellCompletionFileWithDesc(path)
+				}
+				if err != nil {
+					return fmt.Errorf("writing %s completion: %w", shell, err)
+				}
+				files = append(files, path)
+			}
+
+			if f.IsJSON() {
+				return f.Print(map[string]any{"files": files})
+			}
+			for _, file := range files {
+				f.PrintSuccess("Wrote " + file)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArray("shell", nil, "Shell to generate a script for: bash, zsh, fish, or powershell (repeatable)")
+	cmd.Flags().Bool("all", false, "Generate scripts for all supported shells")
+	cmd.MarkFlagsMutuallyExclusive("shell", "all")
+	_ = cmd.RegisterFlagCompletionFunc("shell", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"bash", "zsh", "fish", "powershell"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
+
+// manDate returns the date printed in man pages: SOURCE_DATE_EPOCH if set,
+// otherwise today.
+func manDate() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now(), nil
+	}
+	secs, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
+
+// manPageName returns the name of the man page of c, e.g.
+// "gogchat-messages-send".
+func manPageName(c *cobra.Command) string {
+	return strings.ReplaceAll(c.CommandPath(), " ", "-")
+}
+
+// genManPage renders the man page of c in roff.
+func genManPage(c *cobra.Command, section string, date time.Time) []byte {
+	var b bytes.Buffer
+	name := manPageName(c)
+
+	fmt.Fprintf(&b, ".TH %q %q %q %q %q\n", strings.ToUpper(name), section,
+		date.Format("Jan 2006"), "gogchat "+c.Root().Version, "gogchat Manual")
+	fmt.Fprintf(&b, ".nh\n.ad l\n")
+
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", roffEscape(name), roffEscape(c.Short))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	if c.Runnable() {
+		fmt.Fprintf(&b, "\\fB%s\\fP\n", roffEscape(c.UseLine()))
+	}
+	if c.HasAvailableSubCommands() {
+		if c.Runnable() {
+			b.WriteString(".br\n")
+		}
+		fmt.Fprintf(&b, "\\fB%s\\fP \\fIcommand\\fP [flags]\n", roffEscape(c.CommandPath()))
+	}
+
+	b.WriteString(".SH DESCRIPTION\n")
+	description := c.Long
+	if description == "" {
+		description = c.Short
+	}
+	writeRoffText(&b, description)
+
+	writeRoffFlags(&b, "OPTIONS", c.NonInheritedFlags())
+	writeRoffFlags(&b, "OPTIONS INHERITED FROM PARENT COMMANDS", c.InheritedFlags())
+
+	if c.Example != "" {
+		b.WriteString(".SH EXAMPLE\n")
+		writeRoffBlock(&b, strings.Split(strings.Trim(c.Example, "\n"), "\n"))
+	}
+
+	var related []*cobra.Command
+	if c.HasParent() {
+		related = append(related, c.Parent())
+	}
+	for _, sub := range c.Commands() {
+		if sub.IsAvailableCommand() && !sub.IsAdditionalHelpTopicCommand() {
+			related = append(related, sub)
+		}
+	}
+	if len(related) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		refs := make([]string, len(related))
+		for i, r := range related {
+			refs[i] = fmt.Sprintf("\\fB%s\\fP(%s)", roffEscape(manPageName(r)), section)
+		}
+		b.WriteString(strings.Join(refs, ", ") + "\n")
+	}
+
+	return b.Bytes()
+}
+
+// writeRoffText renders text as paragraphs. Paragraphs whose lines are all
+// indented (lists, tables, commands) are kept as they are; the others are
+// filled.
+func writeRoffText(b *bytes.Buffer, text string) {
+	for _, para := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		lines := strings.Split(strings.Trim(para, "\n"), "\n")
+		indented := true
+		for _, line := range lines {
+			if !strings.HasPrefix(line, " ") {
+				indented = false
+				break
+			}
+		}
+		if indented {
+			writeRoffBlock(b, lines)
+			continue
+		}
+		b.WriteString(".PP\n")
+		for _, line := range lines {
+			b.WriteString(roffLine(strings.TrimSpace(line)) + "\n")
+		}
+	}
+}
+
+// writeRoffBlock renders lines unfilled, as in a code block.
+func writeRoffBlock(b *bytes.Buffer, lines []string) {
+	b.WriteString(".PP\n.RS 4\n.nf\n")
+	for _, line := range lines {
+		b.WriteString(roffLine(line) + "\n")
+	}
+	b.WriteString(".fi\n.RE\n")
+}
+
+// writeRoffFlags renders the visible flags of fs in a section titled title.
+func writeRoffFlags(b *bytes.Buffer, title string, fs *pflag.FlagSet) {
+	var flags []*pflag.Flag
+	fs.VisitAll(func(fl *pflag.Flag) {
+		if !fl.Hidden && fl.Deprecated == "" {
+			flags = append(flags, fl)
+		}
+	})
+	if len(flags) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, ".SH %s\n", title)
+	for _, fl := range flags {
+		varname, usage := pflag.UnquoteUsage(fl)
+		b.WriteString(".TP\n")
+		if fl.Shorthand != "" && fl.ShorthandDeprecated == "" {
+			fmt.Fprintf(b, "\\fB\\-%s\\fP, ", fl.Shorthand)
+		}
+		fmt.Fprintf(b, "\\fB\\-\\-%s\\fP", roffEscape(fl.Name))
+		if varname != "" {
+			fmt.Fprintf(b, " \\fI%s\\fP", roffEscape(varname))
+		}
+		b.WriteString("\n")
+		if def := fl.DefValue; def != "" && def != "false" && def != "0" && def != "[]" {
+			usage += fmt.Sprintf(" (default %s)", def)
+		}
+		b.WriteString(roffLine(usage) + "\n")
+	}
+}
+
+// roffLine escapes a line of text so that roff prints it as it is.
+func roffLine(line string) string {
+	line = roffEscape(line)
+	if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+		line = "\\&" + line
+	}
+	return line
+}
+
+// roffEscape escapes the characters that roff interprets within a line.
+func roffEscape(s string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+}
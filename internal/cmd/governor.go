@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/filelock"
+)
+
+// Overflow modes of postGovernor: what happens to messages past the
+// per-minute limit.
+const (
+	overflowQueue = "queue"
+	overflowDrop  = "drop"
+	// overflowDefer refuses them without dropping, for callers whose
+	// senders retry.
+	overflowDefer = "defer"
+)
+
+// Errors of postGovernor.acquire for messages that must not be posted.
+var (
+	errPostRateLimited = errors.New("posting.max_per_minute reached")
+	errPostDailyCap    = errors.New("posting.max_per_day reached")
+)
+
+// postGovernor enforces the posting limits of the config for every space
+// that bridges and auto-responders post to. Daily counts are kept in
+// ~/.config/gogchat/posting.json, so that restarting a daemon does not
+// reset them, and are shared by the gogchat processes posting at the same
+// time. A nil governor allows everything.
+type postGovernor struct {
+	perMinute int
+	perDay    int
+	overflow  string
+	path      string
+
+	mu     sync.Mutex
+	recent map[string][]time.Time
+	daily  postingCounts
+	// pending counts the messages allowed but not yet recorded, which
+	// count against the daily cap while they are being posted.
+	pending map[string]int
+	// unsaved counts the posts not yet added to posting.json.
+	unsaved map[string]int
+	// Outcomes per space, for the summary.
+	posted   map[string]int
+	delayed  map[string]int
+	deferred map[string]int
+	dropped  map[string]map[error]int
+}
+
+// postingCounts is the content of posting.json: the messages posted to
+// each space on Date.
+type postingCounts struct {
+	Date   string         `json:"date"`
+	Spaces map[string]int `json:"spaces"`
+}
+
+var (
+	postGovernorOnce sync.Once
+	sharedGovernor   *postGovernor
+	postGovernorErr  error
+)
+
+// getPostGovernor returns the governor configured under posting, or nil if
+// no limit is set.
+func getPostGovernor() (*postGovernor, error) {
+	postGovernorOnce.Do(func() {
+		sharedGovernor, postGovernorErr = newPostGovernor(Cfg.Posting)
+	})
+	return sharedGovernor, postGovernorErr
+}
+
+// newPostGovernor creates the governor of cfg.
+func newPostGovernor(cfg config.PostingConfig) (*postGovernor, error) {
+	switch {
+	case cfg.MaxPerMinute < 0 || cfg.MaxPerDay < 0:
+		return nil, fmt.Errorf("posting limits must not be negative")
+	case cfg.Overflow != overflowQueue && cfg.Overflow != overflowDrop:
+		return nil, fmt.Errorf("invalid posting.overflow %q; use queue or drop", cfg.Overflow)
+	case cfg.MaxPerMinute == 0 && cfg.MaxPerDay == 0:
+		return nil, nil
+	}
+
+	g := &postGovernor{
+		perMinute: cfg.MaxPerMinute,
+		perDay:    cfg.MaxPerDay,
+		overflow:  cfg.Overflow,
+		path:      filepath.Join(config.ConfigDir(), "posting.json"),
+		recent:    map[string][]time.Time{},
+		pending:   map[string]int{},
+		unsaved:   map[string]int{},
+		posted:    map[string]int{},
+		delayed:   map[string]int{},
+		deferred:  map[string]int{},
+		dropped:   map[string]map[error]int{},
+	}
+	if data, err := os.ReadFile(g.path); err == nil {
+		_ = json.Unmarshal(data, &g.daily)
+	}
+	return g, nil
+}
+
+// acquire returns nil once a message may be posted to space. Past the
+// per-minute limit, it waits for room or drops the message as set by
+// posting.overflow; past the daily cap, it drops the message. Drops return
+// errPostRateLimited or errPostDailyCap. After acquire returns nil, the
+// outcome of the post must be passed to record.
+func (g *postGovernor) acquire(ctx context.Context, space string) error {
+	if g == nil {
+		return nil
+	}
+	return g.take(ctx, space, g.overflow)
+}
+
+// acquireNow is acquire for callers that cannot wait, such as a bridge
+// that is stopping: messages past the per-minute limit are dropped.
+func (g *postGovernor) acquireNow(space string) error {
+	if g == nil {
+		return nil
+	}
+	return g.take(context.Background(), space, overflowDrop)
+}
+
+// acquireOrDefer is acquireNow for callers that hand messages past the
+// per-minute limit back to their sender to be retried later; those are
+// counted as deferred rather than dropped.
+func (g *postGovernor) acquireOrDefer(space string) error {
+	if g == nil {
+		return nil
+	}
+	return g.take(context.Background(), space, overflowDefer)
+}
+
+// record counts the message that acquire allowed for space once it was
+// posted, or not if err is set, so that failed posts do not use up the
+// daily cap.
+func (g *postGovernor) record(space string, err error) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pending[space] = max(0, g.pending[space]-1)
+	if err != nil {
+		return
+	}
+	g.rollDay(time.Now())
+	g.daily.Spaces[space]++
+	g.unsaved[space]++
+	g.posted[space]++
+	g.save()
+}
+
+// take implements acquire with the given overflow mode.
+func (g *postGovernor) take(ctx context.Context, space, overflow string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	queued := false
+	for {
+		now := time.Now()
+		g.rollDay(now)
+		if g.perDay > 0 && g.daily.Spaces[space]+g.pending[space] >= g.perDay {
+			return g.refuse(space, errPostDailyCap)
+		}
+
+		kept := g.recent[space][:0]
+		for _, t := range g.recent[space] {
+			if now.Sub(t) < time.Minute {
+				kept = append(kept, t)
+			}
+		}
+		g.recent[space] = kept
+		if g.perMinute == 0 || len(kept) < g.perMinute {
+			g.recent[space] = append(kept, now)
+			g.pending[space]++
+			if queued {
+				g.delayed[space]++
+			}
+			return nil
+		}
+
+		if overflow == overflowDefer {
+			g.deferred[space]++
+			return errPostRateLimited
+		}
+		if overflow == overflowDrop {
+			return g.refuse(space, errPostRateLimited)
+		}
+		queued = true
+		delay := time.Minute - now.Sub(kept[0])
+		g.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			g.mu.Lock()
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		g.mu.Lock()
+	}
+}
+
+// refuse counts a dropped message and warns about the first one of each
+// space and reason. The caller holds g.mu.
+func (g *postGovernor) refuse(space string, reason error) error {
+	if g.dropped[space] == nil {
+		g.dropped[space] = map[error]int{}
+	}
+	if g.dropped[space][reason] == 0 {
+		if reason == errPostDailyCap {
+			fmt.Fprintf(os.Stderr, "⚠ %s for %s (%d); messages to it are dropped until tomorrow\n", reason, space, g.perDay)
+		} else {
+			fmt.Fprintf(os.Stderr, "⚠ %s for %s (%d); messages past the limit are dropped\n", reason, space, g.perMinute)
+		}
+	}
+	g.dropped[space][reason]++
+	return reason
+}
+
+// rollDay starts a new daily count when the local date changed. The
+// caller holds g.mu.
+func (g *postGovernor) rollDay(now time.Time) {
+	date := now.Format(time.DateOnly)
+	if g.daily.Date != date || g.daily.Spaces == nil {
+		g.daily = postingCounts{Date: date, Spaces: map[string]int{}}
+		g.unsaved = map[string]int{}
+		for space := range g.dropped {
+			delete(g.dropped[space], errPostDailyCap)
+		}
+	}
+}
+
+// save adds the unsaved posts to the daily counts in posting.json, which
+// other processes may have added to meanwhile, and takes over the merged
+// counts. Failures only cost the counts of a restart and are ignored. The
+// caller holds g.mu.
+func (g *postGovernor) save() {
+	if err := os.MkdirAll(filepath.Dir(g.path), 0o700); err != nil {
+		return
+	}
+	lock, err := filelock.Acquire(g.path)
+	if err != nil {
+		return
+	}
+	defer lock.Unlock()
+
+	var stored postingCounts
+	if data, err := os.ReadFile(g.path); err == nil {
+		_ = json.Unmarshal(data, &stored)
+	}
+	if stored.Date != g.daily.Date || stored.Spaces == nil {
+		stored = postingCounts{Date: g.daily.Date, Spaces: map[string]int{}}
+	}
+	for space, n := range g.unsaved {
+		stored.Spaces[space] += n
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := writeFileAtomic(g.path, append(data, '\n'), 0o600); err != nil {
+		return
+	}
+	g.daily = stored
+	g.unsaved = map[string]int{}
+}
+
+// printSummary reports the messages that were delayed or dropped, if any,
+// once a daemon stops.
+func (g *postGovernor) printSummary() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	spaces := map[string]bool{}
+	for space := range g.delayed {
+		spaces[space] = true
+	}
+	for space := range g.deferred {
+		spaces[space] = true
+	}
+	for space := range g.dropped {
+		spaces[space] = true
+	}
+	var lines []string
+	for space := range spaces {
+		var parts []string
+		if n := g.delayed[space]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d delayed", n))
+		}
+		if n := g.deferred[space]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d handed back for retry", n))
+		}
+		for _, reason := range []error{errPostRateLimited, errPostDailyCap} {
+			if n := g.dropped[space][reason]; n > 0 {
+				parts = append(parts, fmt.Sprintf("%d dropped (%s)", n, reason))
+			}
+		}
+		if len(parts) > 0 {
+			lines = append(lines, fmt.Sprintf("  %s: %d posted, %s", space, g.posted[space], strings.Join(parts, ", ")))
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+	sort.Strings(lines)
+	fmt.Fprintln(os.Stderr, "Posting governor:")
+	fmt.Fprintln(os.Stderr, strings.Join(lines, "\n"))
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testGovernor returns a governor with the given limits whose counts are
+// kept in a temporary directory.
+func testGovernor(t *testing.T, perMinute, perDay int) *postGovernor {
+	return &postGovernor{
+		perMinute: perMinute,
+		perDay:    perDay,
+		overflow:  overflowDrop,
+		path:      filepath.Join(t.TempDir(), "posting.json"),
+		recent:    map[string][]time.Time{},
+		pending:   map[string]int{},
+		unsaved:   map[string]int{},
+		posted:    map[string]int{},
+		delayed:   map[string]int{},
+		deferred:  map[string]int{},
+		dropped:   map[string]map[error]int{},
+	}
+}
+
+func TestPostGovernorTake(t *testing.T) {
+	errPost := errors.New("API error 503")
+
+	// Each step takes a slot for space "a" with overflow, then records the
+	// post as failed if fail is set.
+	type step struct {
+		overflow string
+		fail     bool
+		want     error
+	}
+	tests := []struct {
+		name       string
+		perMinute  int
+		perDay     int
+		steps      []step
+		wantDaily  int
+		wantPosted int
+	}{
+		{
+			name:      "per-minute limit drops",
+			perMinute: 2,
+			steps: []step{
+				{overflowDrop, false, nil},
+				{overflowDrop, false, nil},
+				{overflowDrop, false, errPostRateLimited},
+			},
+			wantDaily:  2,
+			wantPosted: 2,
+		},
+		{
+			name:      "per-minute limit defers",
+			perMinute: 1,
+			steps: []step{
+				{overflowDefer, false, nil},
+				{overflowDefer, false, errPostRateLimited},
+			},
+			wantDaily:  1,
+			wantPosted: 1,
+		},
+		{
+			name:   "daily cap",
+			perDay: 2,
+			steps: []step{
+				{overflowDrop, false, nil},
+				{overflowDrop, false, nil},
+				{overflowDrop, false, errPostDailyCap},
+			},
+			wantDaily:  2,
+			wantPosted: 2,
+		},
+		{
+			name:   "failed posts do not use the daily cap",
+			perDay: 2,
+			steps: []step{
+				{overflowDrop, true, nil},
+				{overflowDrop, true, nil},
+				{overflowDrop, false, nil},
+				{overflowDrop, false, nil},
+				{overflowDrop, false, errPostDailyCap},
+			},
+			wantDaily:  2,
+			wantPosted: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := testGovernor(t, tt.perMinute, tt.perDay)
+			for i, s := range tt.steps {
+				err := g.take(context.Background(), "a", s.overflow)
+				if !errors.Is(err, s.want) {
+					t.Fatalf("step %d: take() = %v, want %v", i, err, s.want)
+				}
+				if err != nil {
+					continue
+				}
+				var postErr error
+				if s.fail {
+					postErr = errPost
+				}
+				g.record("a", postErr)
+			}
+			if got := g.daily.Spaces["a"]; got != tt.wantDaily {
+				t.Errorf("daily count = %d, want %d", got, tt.wantDaily)
+			}
+			if got := g.posted["a"]; got != tt.wantPosted {
+				t.Errorf("posted = %d, want %d", got, tt.wantPosted)
+			}
+		})
+	}
+}
+
+func TestPostGovernorTakeCountsPending(t *testing.T) {
+	// Messages being posted count against the daily cap, so concurrent
+	// posts cannot exceed it.
+	g := testGovernor(t, 0, 1)
+	if err := g.take(context.Background(), "a", overflowDrop); err != nil {
+		t.Fatalf("take() = %v", err)
+	}
+	if err := g.take(context.Background(), "a", overflowDrop); !errors.Is(err, errPostDailyCap) {
+		t.Fatalf("take() while a post is pending = %v, want %v", err, errPostDailyCap)
+	}
+	g.record("a", errors.New("failed"))
+	if err := g.take(context.Background(), "a", overflowDrop); err != nil {
+		t.Fatalf("take() after a failed post = %v", err)
+	}
+}
+
+func TestPostGovernorSaveMerges(t *testing.T) {
+	g := testGovernor(t, 0, 0)
+	if err := g.take(context.Background(), "a", overflowDrop); err != nil {
+		t.Fatal(err)
+	}
+	// Another process posted meanwhile.
+	other := postingCounts{Date: time.Now().Format(time.DateOnly), Spaces: map[string]int{"a": 3, "b": 1}}
+	data, _ := json.Marshal(other)
+	if err := os.WriteFile(g.path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g.record("a", nil)
+
+	data, err := os.ReadFile(g.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved postingCounts
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.Spaces["a"] != 4 || saved.Spaces["b"] != 1 {
+		t.Errorf("saved counts = %v, want a: 4, b: 1", saved.Spaces)
+	}
+	if g.daily.Spaces["a"] != 4 {
+		t.Errorf("daily count = %d, want the merged 4", g.daily.Spaces["a"])
+	}
+}
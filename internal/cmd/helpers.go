@@ -1,19 +1,116 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/cipher-shad0w/gogchat/internal/api"
 	"github.com/cipher-shad0w/gogchat/internal/auth"
+	"github.com/cipher-shad0w/gogchat/internal/cache"
+	"github.com/cipher-shad0w/gogchat/internal/config"
 	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+// The HTTP transport and authorized HTTP clients are shared by every API
+// client of the process, so that long-running commands (tail, watchers) and
+// commands that create several clients (account fan-out, completion) reuse
+// pooled connections and cached access tokens instead of dialing and
+// refreshing again.
+var (
+	transportOnce   sync.Once
+	sharedTransport *http.Transport
+
+	httpClientsMu sync.Mutex
+	httpClients   = map[string]*authorizedClient{}
+)
+
+// authorizedClient is an HTTP client that authorizes its requests, with the
+// token source it draws from (nil for the app's service account).
+type authorizedClient struct {
+	http *http.Client
+	src  *auth.SwappableTokenSource
+
+	// clientID, clientSecret, and tokenPath are the credentials of a user
+	// login.
+	clientID     string
+	clientSecret string
+	tokenPath    string
+}
+
 // newAPIClient creates a new API client using the loaded configuration and
 // stored OAuth2 token. It is shared by all command files in the cmd package.
+// When --as-app is set, the client authenticates with the configured
+// service account instead. The underlying HTTP client is created once per
+// set of credentials and reused by later calls.
 func newAPIClient() (*api.Client, error) {
-	clientID := Cfg.ClientID
-	clientSecret := Cfg.ClientSecret
+	httpClientsMu.Lock()
+	defer httpClientsMu.Unlock()
+
+	if viper.GetBool("as_app") {
+		key := "app\x00" + Cfg.ServiceAccountFile
+		ac, ok := httpClients[key]
+		if !ok {
+			httpClient, err := auth.AppHTTPClient(Cfg.ServiceAccountFile, getTransport())
+			if err != nil {
+				return nil, err
+			}
+			ac = &authorizedClient{http: httpClient}
+			httpClients[key] = ac
+		}
+		return configureClient(api.NewClient(ac.http))
+	}
+
+	// On a machine where gogchat was never set up, guide the user through
+	// it instead of failing.
+	if firstUse() && canPrompt() {
+		if err := runOnboarding(); err != nil {
+			return nil, err
+		}
+	}
+
+	ac, err := userClient(Cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := configureClient(api.NewClient(ac.http))
+	if err != nil {
+		return nil, err
+	}
+	// When the refresh token is dead, offer to log in again and retry
+	// instead of failing the command.
+	client.Reauthenticate = reauthenticator(ac.clientID, ac.clientSecret, ac.tokenPath, ac.src)
+	return client, nil
+}
+
+// newAccountClient creates an API client for the user login of cfg, e.g. the
+// config of a profile. Unlike newAPIClient it never prompts, neither to set
+// gogchat up nor to log in again, so that it suits commands that run
+// against several accounts.
+func newAccountClient(cfg *config.Config) (*api.Client, error) {
+	httpClientsMu.Lock()
+	ac, err := userClient(cfg)
+	httpClientsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return configureClient(api.NewClient(ac.http))
+}
+
+// userClient returns the authorized HTTP client of the user login of cfg,
+// creating it on first use. The caller holds httpClientsMu.
+func userClient(cfg *config.Config) (*authorizedClient, error) {
+	clientID := cfg.ClientID
+	clientSecret := cfg.ClientSecret
 
 	// Fall back to the built-in defaults when the config has no credentials.
 	if clientID == "" {
@@ -27,23 +124,376 @@ func newAPIClient() (*api.Client, error) {
 		return nil, err
 	}
 
-	tokenPath := Cfg.TokenFile
+	tokenPath := cfg.TokenFile
 	if tokenPath == "" {
 		tokenPath = auth.DefaultTokenPath()
 	}
 
-	token, err := auth.LoadToken(tokenPath)
-	if err != nil {
-		return nil, fmt.Errorf("loading token (run 'gogchat auth login' first): %w", err)
+	key := "user\x00" + tokenPath + "\x00" + clientID
+	ac, ok := httpClients[key]
+	if !ok {
+		token, err := auth.LoadToken(tokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading token (run 'gogchat auth login' first): %w", err)
+		}
+		src := auth.NewSwappableTokenSource(clientID, clientSecret, token)
+		ac = &authorizedClient{
+			http:         auth.SourceHTTPClient(src, getTransport()),
+			src:          src,
+			clientID:     clientID,
+			clientSecret: clientSecret,
+			tokenPath:    tokenPath,
+		}
+		httpClients[key] = ac
 	}
+	return ac, nil
+}
+
+// getTransport returns the HTTP transport shared by all API requests of the
+// process, tuned by the http settings of the config when first used.
+func getTransport() *http.Transport {
+	transportOnce.Do(func() {
+		sharedTransport = api.NewTransport(api.TransportOptions{
+			MaxIdleConns:        Cfg.HTTP.MaxIdleConns,
+			MaxIdleConnsPerHost: Cfg.HTTP.MaxIdleConnsPerHost,
+			IdleConnTimeout:     Cfg.HTTP.IdleConnTimeout,
+			KeepAlive:           Cfg.HTTP.KeepAlive,
+		})
+	})
+	return sharedTransport
+}
+
+// extraHeaders are the --header flags, as 'Name: value'.
+var extraHeaders []string
 
-	httpClient := auth.HTTPClient(clientID, clientSecret, token)
-	client := api.NewClient(httpClient)
+// configureClient applies the global flags and config settings shared by
+// every API client: verbosity, the audit log, User-Agent, and extra request
+// headers.
+func configureClient(client *api.Client) (*api.Client, error) {
 	client.Verbose = viper.GetBool("verbose")
+	if apiVersion != api.DefaultVersion {
+		if err := client.SetVersion(apiVersion); err != nil {
+			return nil, err
+		}
+	}
+	if Cfg.AuditLog != "" {
+		client.AfterRequest = audit.record
+	}
+
+	client.UserAgent = Cfg.UserAgent
+	if client.UserAgent == "" {
+		client.UserAgent = "gogchat/" + Version
+	}
+
+	for _, h := range extraHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --header %q; expected 'Name: value'", h)
+		}
+		if client.Headers == nil {
+			client.Headers = http.Header{}
+		}
+		client.Headers.Add(name, strings.TrimSpace(value))
+	}
+
 	return client, nil
 }
 
+// Retry settings for bulk operations: the total number of retries shared by
+// all requests, the consecutive failures that trip the circuit breaker, and
+// how long to pause once it trips.
+const (
+	bulkRetryBudget      = 20
+	bulkBreakerThreshold = 5
+	bulkBreakerCooldown  = 30 * time.Second
+	// daemonRetryRefill is how often the budget of a long-running command
+	// regains a retry.
+	daemonRetryRefill = time.Minute
+)
+
+// enableBulkRetries attaches a shared retry budget and circuit breaker to
+// client, for commands that issue many requests in one run.
+func enableBulkRetries(client *api.Client) {
+	setRetryBudget(client, api.NewRetryBudget(bulkRetryBudget, bulkBreakerThreshold, bulkBreakerCooldown))
+}
+
+// enableDaemonRetries is enableBulkRetries for commands that run until they
+// are stopped, such as bridges and tail: their budget refills over time, so
+// they still retry after earlier outages used it up.
+func enableDaemonRetries(client *api.Client) {
+	setRetryBudget(client, api.NewRefillingRetryBudget(bulkRetryBudget, bulkBreakerThreshold, bulkBreakerCooldown, daemonRetryRefill))
+}
+
+// setRetryBudget attaches budget to client, warning when its breaker trips.
+func setRetryBudget(client *api.Client, budget *api.RetryBudget) {
+	budget.OnPause = func(d time.Duration) {
+		fmt.Fprintf(os.Stderr, "⚠ The API keeps returning rate-limit or server errors; pausing %s before continuing...\n", d)
+	}
+	client.Retry = budget
+}
+
+// getPageSize returns the --page-size flag when it was given explicitly,
+// otherwise the configured page_size, falling back to the flag default.
+func getPageSize(cmd *cobra.Command) int {
+	pageSize, _ := cmd.Flags().GetInt("page-size")
+	if !cmd.Flags().Changed("page-size") && Cfg.PageSize > 0 {
+		return Cfg.PageSize
+	}
+	return pageSize
+}
+
+// addRenderFlag adds the --render flag to commands that print message text.
+func addRenderFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("render", false, "Render message text as formatted markdown (code blocks, lists, links)")
+}
+
+// shouldRender reports whether message text should be rendered as markdown:
+// the --render flag when given explicitly, otherwise the render_markdown
+// config key.
+func shouldRender(cmd *cobra.Command) bool {
+	render, _ := cmd.Flags().GetBool("render")
+	if !cmd.Flags().Changed("render") {
+		return Cfg.RenderMarkdown
+	}
+	return render
+}
+
+// configureTimeOutput applies the timezone and time_format config keys and
+// the --utc and --relative flags to timestamp formatting. --utc wins over
+// everything else so output can be compared across machines.
+func configureTimeOutput() error {
+	if viper.GetBool("utc") {
+		output.SetTimeStyle(output.TimeRFC3339, time.UTC)
+		return nil
+	}
+
+	style := output.TimeStyle(Cfg.TimeFormat)
+	switch style {
+	case output.TimeShort, output.TimeRelative, output.TimeRFC3339:
+	default:
+		return fmt.Errorf("invalid time_format %q: must be short, relative, or rfc3339", Cfg.TimeFormat)
+	}
+	if viper.GetBool("relative") {
+		style = output.TimeRelative
+	}
+
+	loc := time.Local
+	if Cfg.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(Cfg.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", Cfg.Timezone, err)
+		}
+	}
+	output.SetTimeStyle(style, loc)
+	return nil
+}
+
+// configureTheme applies the theme config section to human-readable
+// output. Colors are only used when stdout is a terminal, NO_COLOR is
+// unset, and the output is not JSON.
+func configureTheme() error {
+	t, err := output.ThemePreset(Cfg.Theme.Preset)
+	if err != nil {
+		return err
+	}
+	if len(Cfg.Theme.Accents) > 0 {
+		t.Accents = nil
+		for _, spec := range Cfg.Theme.Accents {
+			sgr, err := output.ParseColor(spec)
+			if err != nil {
+				return fmt.Errorf("theme.accents: %w", err)
+			}
+			if sgr != "" {
+				t.Accents = append(t.Accents, sgr)
+			}
+		}
+	}
+	for key, c := range map[string]struct {
+		spec string
+		sgr  *string
+	}{
+		"timestamp": {Cfg.Theme.Timestamp, &t.Timestamp},
+		"mention":   {Cfg.Theme.Mention, &t.Mention},
+		"success":   {Cfg.Theme.Success, &t.Success},
+	} {
+		if c.spec == "" {
+			continue
+		}
+		sgr, err := output.ParseColor(c.spec)
+		if err != nil {
+			return fmt.Errorf("theme.%s: %w", key, err)
+		}
+		*c.sgr = sgr
+	}
+
+	color := isTerminal(os.Stdout) && os.Getenv("NO_COLOR") == "" && !viper.GetBool("json")
+	output.SetTheme(t, color)
+	return nil
+}
+
+// applyOutputFormat applies the output config key (or GOGCHAT_OUTPUT)
+// unless --json is given.
+func applyOutputFormat(cmd *cobra.Command) error {
+	if cmd.Flags().Changed("json") {
+		return nil
+	}
+	switch strings.ToLower(Cfg.Output) {
+	case "json":
+		viper.Set("json", true)
+	case "text", "":
+	default:
+		return fmt.Errorf("invalid output %q: must be text or json", Cfg.Output)
+	}
+	return nil
+}
+
+// apiVersion is the Chat API version of the running command, set by
+// applyAPIVersion.
+var apiVersion = api.DefaultVersion
+
+// applyAPIVersion selects the Chat API version of cmd: the --api-version
+// flag, the command's entry in the api_versions config key, or the
+// api_version config key, in that order. Pre-release versions are only
+// allowed with preview_features.
+func applyAPIVersion(cmd *cobra.Command) error {
+	version, _ := cmd.Flags().GetString("api-version")
+	if !cmd.Flags().Changed("api-version") {
+		path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+		version = Cfg.APIVersions[path]
+		if version == "" {
+			version = Cfg.APIVersion
+		}
+	}
+	if version == "" {
+		version = api.DefaultVersion
+	}
+
+	if !api.ValidVersion(version) {
+		return fmt.Errorf("invalid API version %q: expected e.g. v1 or v1beta", version)
+	}
+	if api.PreviewVersion(version) && !Cfg.PreviewFeatures {
+		return fmt.Errorf("API version %s is a preview version; set preview_features: true in the config file to use it", version)
+	}
+	apiVersion = version
+	return nil
+}
+
+// configureColumns limits table output to the --columns flag, or to the
+// command's entry in the columns config key.
+func configureColumns(cmd *cobra.Command) {
+	columns, _ := cmd.Flags().GetStringSlice("columns")
+	if !cmd.Flags().Changed("columns") {
+		path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+		columns = Cfg.Columns[path]
+	}
+	output.SetColumns(columns)
+}
+
+// currentUserName caches the result of currentUser for the process.
+var currentUserName string
+
+// currentUser returns the calling user's Chat resource name (users/{id}).
+// Chat user IDs are the same as People API person IDs, so the ID is taken
+// from people/me.
+func currentUser(ctx context.Context, client *api.Client) (string, error) {
+	if currentUserName != "" {
+		return currentUserName, nil
+	}
+	if viper.GetBool("as_app") {
+		return "", fmt.Errorf("cannot look up the current user with --as-app")
+	}
+
+	raw, err := api.NewPeopleService(client).GetMe(ctx, "metadata")
+	if err != nil {
+		return "", fmt.Errorf("looking up current user: %w", err)
+	}
+	var person struct {
+		ResourceName string `json:"resourceName"`
+	}
+	if err := json.Unmarshal(raw, &person); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+	id := strings.TrimPrefix(person.ResourceName, "people/")
+	if id == "" {
+		return "", fmt.Errorf("looking up current user: no ID in response")
+	}
+
+	currentUserName = "users/" + id
+	return currentUserName, nil
+}
+
+// resolveUser converts a user reference into a users/{id} resource name.
+// It accepts users/{id}, a bare ID, or an email address (optionally as
+// users/{email}); emails are looked up in the Workspace directory because
+// some Chat methods only accept numeric IDs.
+func resolveUser(ctx context.Context, client *api.Client, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	email := strings.TrimPrefix(ref, "users/")
+	if !strings.Contains(email, "@") {
+		return api.NormalizeName(ref, "users/"), nil
+	}
+	key := strings.ToLower(email)
+	var name string
+	if getCache().Get(cache.Users, key, &name) {
+		noteResolved(ref, name)
+		return name, nil
+	}
+
+	raw, err := api.NewPeopleService(client).SearchDirectory(ctx, email, directoryPageSize)
+	if err != nil {
+		return "", directoryError(fmt.Errorf("looking up %s in the directory: %w", email, err))
+	}
+	var resp struct {
+		People []struct {
+			ResourceName   string `json:"resourceName"`
+			EmailAddresses []struct {
+				Value string `json:"value"`
+			} `json:"emailAddresses"`
+		} `json:"people"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+	for _, p := range resp.People {
+		for _, e := range p.EmailAddresses {
+			if strings.EqualFold(e.Value, email) {
+				name := "users/" + strings.TrimPrefix(p.ResourceName, "people/")
+				_ = getCache().Put(cache.Users, key, name)
+				noteResolved(ref, name)
+				return name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no user with email %s found in the directory", email)
+}
+
 // getFormatter returns a Formatter configured from the current CLI flags.
 func getFormatter() *output.Formatter {
 	return output.NewFormatter(viper.GetBool("json"), viper.GetBool("quiet"))
 }
+
+// writeFileAtomic replaces the file at path with data atomically: readers
+// see either the previous or the new content, never a partial one.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,489 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// historySize is the number of commands kept in the command history;
+// older ones are dropped.
+const historySize = 1000
+
+// historyEntry is one command run with gogchat.
+type historyEntry struct {
+	Time time.Time `json:"time"`
+	// Args are the arguments of the command, with the resource names that
+	// were resolved while it ran (see noteResolved).
+	Args  []string `json:"args"`
+	Error string   `json:"error,omitempty"`
+}
+
+// commandLine returns the entry as a shell command line.
+func (h historyEntry) commandLine() string {
+	return "gogchat " + shellJoin(h.Args)
+}
+
+// historySkipped are the commands that are not recorded: those that look
+// at or replay the history, and those run by the shell rather than the
+// user. Commands are named by their path below the root command.
+var historySkipped = map[string]bool{
+	"history":          true,
+	"redo":             true,
+	"readstate prompt": true,
+}
+
+// historySecretFlags are the flags whose values, or the arguments that go
+// with them, must not be written to the history; commands given them are
+// not recorded. --header may carry credentials such as a bearer token, and
+// the text of a message sent with --encrypt-for is meant to be kept secret.
+var historySecretFlags = []string{"client-id", "client-secret", "header", "encrypt-for"}
+
+// resolvedArg is an argument of the running command and the resource name
+// it was resolved to.
+type resolvedArg struct {
+	arg, name string
+}
+
+// resolvedArgs collects the resolved arguments of the running command.
+var resolvedArgs struct {
+	mu   sync.Mutex
+	args []resolvedArg
+}
+
+// noteResolved records that the argument arg of the running command was
+// resolved to the resource name, e.g. an email address to users/{id}, so
+// that the history holds the name. An empty arg records an argument that
+// was not given but filled in, e.g. the default space or picked messages.
+func noteResolved(arg, name string) {
+	if arg == name {
+		return
+	}
+	resolvedArgs.mu.Lock()
+	resolvedArgs.args = append(resolvedArgs.args, resolvedArg{arg, name})
+	resolvedArgs.mu.Unlock()
+}
+
+// historyArgs returns the arguments of the running command with the
+// resolved ones replaced by their resource names.
+func historyArgs() []string {
+	args := slices.Clone(os.Args[1:])
+	resolvedArgs.mu.Lock()
+	defer resolvedArgs.mu.Unlock()
+	for _, r := range resolvedArgs.args {
+		if r.arg == "" {
+			args = append(args, r.name)
+			continue
+		}
+		for i, a := range args {
+			if a == r.arg {
+				args[i] = r.name
+				break
+			}
+			if flag, ok := strings.CutSuffix(a, "="+r.arg); ok && strings.HasPrefix(flag, "-") {
+				args[i] = flag + "=" + r.name
+				break
+			}
+		}
+	}
+	return args
+}
+
+// historyPath returns the location of the command history.
+func historyPath() string {
+	return filepath.Join(config.ConfigDir(), "history.json")
+}
+
+// loadHistory reads the command history, oldest first; a missing history
+// is empty.
+func loadHistory() ([]historyEntry, error) {
+	data, err := os.ReadFile(historyPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	var history []historyEntry
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", historyPath(), err)
+	}
+	return history, nil
+}
+
+// saveHistory writes the command history.
+func saveHistory(history []historyEntry) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(historyPath(), data, 0o600); err != nil {
+		return fmt.Errorf("saving history: %w", err)
+	}
+	return nil
+}
+
+// writeHistory adds cmd to the command history unless history is turned
+// off or cmd is not worth recalling, e.g. help or shell completion.
+// Failing to write the history is reported but does not fail the command.
+func writeHistory(cmd *cobra.Command, cmdErr error) {
+	if Cfg == nil || !Cfg.History || cmd == nil || !cmd.HasParent() || !cmd.Runnable() {
+		return
+	}
+	if cmd.Name() == "help" || strings.HasPrefix(cmd.Name(), "__complete") || cmd.Parent().Name() == "completion" {
+		return
+	}
+	if historySkipped[strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")] {
+		return
+	}
+	for _, name := range historySecretFlags {
+		if cmd.Flags().Changed(name) {
+			return
+		}
+	}
+
+	entry := historyEntry{Time: time.Now().UTC(), Args: historyArgs()}
+	if cmdErr != nil {
+		entry.Error = cmdErr.Error()
+	}
+	history, err := loadHistory()
+	if err == nil {
+		history = append(history, entry)
+		if len(history) > historySize {
+			history = history[len(history)-historySize:]
+		}
+		err = saveHistory(history)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record the command in the history: %v\n", err)
+	}
+}
+
+// NewHistoryCmd creates the top-level "history" command.
+func NewHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List the commands run with gogchat",
+		Long: `List the commands run with gogchat, newest first. The number in the #
+column identifies the command for "redo".
+
+Commands are recorded in ~/.config/gogchat/history.json with the resource
+names they resolved: the default space, messages picked interactively,
+users given by email address, and "me" are recorded as the names they
+stood for, so a command runs against the same resources when it is run
+again. The last 1000 commands are kept. Commands given --client-id,
+--client-secret, --header, or --encrypt-for are not recorded; set
+history: false in the config file to record nothing.`,
+		Example: `  gogchat history
+  gogchat history --search "messages send"
+  gogchat history --clear`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			limit, _ := cmd.Flags().GetInt("limit")
+			search, _ := cmd.Flags().GetString("search")
+			failed, _ := cmd.Flags().GetBool("failed")
+			clearAll, _ := cmd.Flags().GetBool("clear")
+
+			if clearAll {
+				if err := os.Remove(historyPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+					return fmt.Errorf("clearing history: %w", err)
+				}
+				f.PrintSuccess("History cleared")
+				return nil
+			}
+
+			history, err := loadHistory()
+			if err != nil {
+				return err
+			}
+
+			type numbered struct {
+				N int `json:"n"`
+				historyEntry
+			}
+			var entries []numbered
+			for i := len(history) - 1; i >= 0 && (limit <= 0 || len(entries) < limit); i-- {
+				h := history[i]
+				if (search != "" && !strings.Contains(strings.ToLower(h.commandLine()), strings.ToLower(search))) || (failed && h.Error == "") {
+					continue
+				}
+				entries = append(entries, numbered{N: len(history) - i, historyEntry: h})
+			}
+
+			if f.IsJSON() {
+				return printList(f, entries, "")
+			}
+			if len(entries) == 0 {
+				f.PrintMessage("No commands in the history.")
+				return nil
+			}
+
+			table := output.NewTable("#", "TIME", "STATUS", "COMMAND")
+			for _, e := range entries {
+				status := "ok"
+				if e.Error != "" {
+					status = "failed"
+				}
+				table.AddRow(strconv.Itoa(e.N), output.FormatTime(e.Time.Format(time.RFC3339)),
+					status, output.Truncate(e.commandLine(), 100))
+			}
+			fmt.Print(table.Render())
+			return nil
+		},
+	}
+
+	cmd.Flags().Int("limit", 20, "Maximum number of commands to list (0 for all)")
+	cmd.Flags().String("search", "", "Only list commands containing this text")
+	cmd.Flags().Bool("failed", false, "Only list commands that failed")
+	cmd.Flags().Bool("clear", false, "Delete the history")
+
+	return cmd
+}
+
+// redoExitError is the exit status of a command run again by "redo",
+// which has already reported its own error.
+type redoExitError struct {
+	code int
+}
+
+func (e *redoExitError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
+
+// NewRedoCmd creates the top-level "redo" command.
+func NewRedoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "redo [N] [-- ARGS...]",
+		Short: "Run a command from the history again",
+		Long: `Run command N of "history" again; without N, the last command. The
+command line is shown before it runs, and the command runs as a new
+gogchat process with the same arguments, so it asks for confirmation and
+honors read-only mode as before. Input that was piped to the command is
+not recorded and must be piped again.
+
+Arguments after -- are appended, which overrides flags given before, e.g.
+"redo 3 -- --text 'fixed typo'". With --edit, the command line is opened in
+$VISUAL or $EDITOR to be changed first; with --print, it is only shown.`,
+		Example: `  gogchat redo
+  gogchat redo 4
+  gogchat redo 2 -- --space spaces/CCCCDDDDeeee
+  gogchat redo 2 --edit
+  gogchat redo 7 --print`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 0 {
+				dash = len(args)
+			}
+			if dash > 1 {
+				return fmt.Errorf("redo takes at most one command number; put extra arguments after --")
+			}
+			return nil
+		},
+		RunE: runRedo,
+	}
+
+	cmd.Flags().Bool("edit", false, "Edit the command line in $VISUAL or $EDITOR before running it")
+	cmd.Flags().Bool("print", false, "Print the command line instead of running it")
+	disablePager(cmd)
+
+	return cmd
+}
+
+func runRedo(cmd *cobra.Command, args []string) error {
+	edit, _ := cmd.Flags().GetBool("edit")
+	printOnly, _ := cmd.Flags().GetBool("print")
+
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		return fmt.Errorf("the history is empty")
+	}
+	n, extra := 1, args
+	if dash := cmd.ArgsLenAtDash(); dash != 0 && len(args) > 0 {
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 || n > len(history) {
+			return fmt.Errorf("no command %s in the history; see \"gogchat history\"", args[0])
+		}
+		extra = args[1:]
+	}
+	redo := append(slices.Clone(history[len(history)-n].Args), extra...)
+
+	if edit {
+		if err := requireInteractive("editing the command", "pass extra arguments after -- instead"); err != nil {
+			return err
+		}
+		if redo, err = editCommandLine(redo); err != nil {
+			return err
+		}
+	}
+	line := "gogchat " + shellJoin(redo)
+	if printOnly {
+		fmt.Println(line)
+		return nil
+	}
+	fmt.Fprintln(os.Stderr, line)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding the gogchat executable: %w", err)
+	}
+	c := exec.Command(exe, redo...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// Ctrl-C reaches the command, which stops on its own.
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &redoExitError{code: max(exitErr.ExitCode(), 1)}
+		}
+		return fmt.Errorf("running %s: %w", exe, err)
+	}
+	return nil
+}
+
+// editCommandLine opens args as a command line in the user's editor and
+// returns the arguments of the edited line. An empty line cancels.
+func editCommandLine(args []string) ([]string, error) {
+	file, err := os.CreateTemp("", "gogchat-redo-*.sh")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	content := "# Edit the command and save; delete it to cancel.\ngogchat " + shellJoin(args) + "\n"
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may come with arguments, e.g. "code --wait".
+	editorArgs, err := shellSplit(editor)
+	if err != nil || len(editorArgs) == 0 {
+		return nil, fmt.Errorf("invalid editor %q", editor)
+	}
+	c := exec.Command(editorArgs[0], append(editorArgs[1:], file.Name())...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return nil, fmt.Errorf("running %s: %w", editor, err)
+	}
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			lines = append(lines, line)
+		}
+	}
+	edited, err := shellSplit(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	if len(edited) > 0 && (edited[0] == "gogchat" || filepath.Base(edited[0]) == filepath.Base(os.Args[0])) {
+		edited = edited[1:]
+	}
+	if len(edited) == 0 {
+		return nil, fmt.Errorf("redo cancelled")
+	}
+	return edited, nil
+}
+
+// shellJoin quotes args for a POSIX shell, leaving plain words as they are.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		plain := a != "" && !strings.ContainsFunc(a, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_./:=@,+%", r)
+		})
+		if plain {
+			quoted[i] = a
+		} else {
+			quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellSplit splits a command line into arguments the way a POSIX shell
+// does, with single and double quotes, backslash escapes, and backslash
+// line continuations. Variables and globs are not expanded.
+func shellSplit(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			if runes[i] != '\n' {
+				arg.WriteRune(runes[i])
+				inArg = true
+			}
+		case r == '\'':
+			end := slices.Index(runes[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in %q", line)
+			}
+			arg.WriteString(string(runes[i+1 : i+1+end]))
+			i += end + 1
+			inArg = true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				arg.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated quote in %q", line)
+			}
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewImportCmd creates the top-level "import" command for migrating
+// message history into a space created in import mode.
+func NewImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Migrate history into a space in import mode",
+		Long: `Migrate message history from another system into Google Chat.
+
+A space created in import mode (import begin) is hidden from users while
+its historical messages and memberships are created with their original
+times. It must be completed (import complete) before it expires, 90 days
+after creation; otherwise Chat deletes it. Import mode is only available
+to Chat apps with domain-wide delegation (see --as-app).`,
+	}
+
+	cmd.AddCommand(
+		newImportBeginCmd(),
+		newImportStatusCmd(),
+		newImportCompleteCmd(),
+	)
+
+	return cmd
+}
+
+// newImportBeginCmd creates the "import begin" subcommand.
+func newImportBeginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "begin DISPLAY_NAME",
+		Short: "Create a space in import mode",
+		Long: `Create a space in import mode to migrate history into. Use --create-time
+to give the space the creation time it had in the source system.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runImportBegin,
+	}
+
+	flags := cmd.Flags()
+	flags.String("space-type", "SPACE", "Space type (SPACE or GROUP_CHAT)")
+	flags.String("description", "", "Description for the space")
+	flags.String("create-time", "", `Original creation time of the space (e.g. "2019-03-01", RFC 3339)`)
+	flags.String("request-id", "", "Unique request ID for idempotency")
+	addBodyFlag(cmd)
+
+	return cmd
+}
+
+func runImportBegin(cmd *cobra.Command, args []string) error {
+	spaceType, _ := cmd.Flags().GetString("space-type")
+	description, _ := cmd.Flags().GetString("description")
+	requestID, _ := cmd.Flags().GetString("request-id")
+	createTime, err := parseTimeFlag(cmd, "create-time")
+	if err != nil {
+		return err
+	}
+
+	space, err := readBody(cmd)
+	if err != nil {
+		return err
+	}
+	if space == nil {
+		space = map[string]interface{}{
+			"displayName": args[0],
+			"spaceType":   spaceType,
+		}
+		if description != "" {
+			space["spaceDetails"] = map[string]interface{}{"description": description}
+		}
+		if !createTime.IsZero() {
+			space["createTime"] = formatFilterTime(createTime)
+		}
+	}
+	space["importMode"] = true
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+
+	raw, err := api.NewSpacesService(client).Create(cmd.Context(), space, requestID)
+	if err != nil {
+		return fmt.Errorf("creating space in import mode: %w", err)
+	}
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+
+	var sp map[string]interface{}
+	if err := json.Unmarshal(raw, &sp); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	name := spaceMapStr(sp, "name")
+	f.PrintSuccess(fmt.Sprintf("Space created in import mode: %s", name))
+	printSpaceDetail(sp)
+	if expire := spaceMapStr(sp, "importModeExpireTime"); expire != "" {
+		fmt.Printf("%-20s %s\n", "Import Expires:", output.FormatTime(expire))
+	}
+	f.PrintMessage(fmt.Sprintf(`Import messages and members, then run "gogchat import complete %s".`, name))
+	return nil
+}
+
+// importStatus describes a space for "import status" and "import complete".
+type importStatus struct {
+	Space       string `json:"space"`
+	DisplayName string `json:"displayName"`
+	Importing   bool   `json:"importing"`
+	ExpireTime  string `json:"expireTime,omitempty"`
+	// Messages is the message count as text; see countSpaceMessages.
+	Messages string `json:"messages"`
+}
+
+// getImportStatus looks up the import state and message count of space.
+func getImportStatus(cmd *cobra.Command, client *api.Client, space string) (importStatus, error) {
+	space = api.NormalizeName(space, "spaces/")
+	admin, _ := cmd.Flags().GetBool("admin")
+	raw, err := api.NewSpacesService(client).Get(cmd.Context(), space, admin)
+	if err != nil {
+		return importStatus{}, fmt.Errorf("looking up space %s: %w", space, err)
+	}
+
+	var sp struct {
+		DisplayName          string `json:"displayName"`
+		ImportMode           bool   `json:"importMode"`
+		ImportModeExpireTime string `json:"importModeExpireTime"`
+	}
+	if err := json.Unmarshal(raw, &sp); err != nil {
+		return importStatus{}, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return importStatus{
+		Space:       space,
+		DisplayName: sp.DisplayName,
+		Importing:   sp.ImportMode,
+		ExpireTime:  sp.ImportModeExpireTime,
+		Messages:    countSpaceMessages(cmd.Context(), client, space),
+	}, nil
+}
+
+// lines describes s for human-readable output.
+func (s importStatus) lines() []string {
+	state := "complete"
+	if s.Importing {
+		state = "importing"
+	}
+	lines := []string{
+		fmt.Sprintf("Space:        %s", s.Space),
+		fmt.Sprintf("Display Name: %s", s.DisplayName),
+		fmt.Sprintf("State:        %s", state),
+	}
+	if s.Importing && s.ExpireTime != "" {
+		expires := output.FormatTime(s.ExpireTime)
+		if t, err := time.Parse(time.RFC3339, s.ExpireTime); err == nil {
+			expires += fmt.Sprintf(" (in %d days)", int(time.Until(t).Hours()/24))
+		}
+		lines = append(lines, fmt.Sprintf("Expires:      %s", expires))
+	}
+	return append(lines, fmt.Sprintf("Messages:     %s", s.Messages))
+}
+
+// newImportStatusCmd creates the "import status" subcommand.
+func newImportStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status SPACE",
+		Short: "Show whether a space is still being imported",
+		Long:  "Show whether a space is still in import mode, when import mode expires, and how many messages it has so far.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAPIClient()
+			if err != nil {
+				return err
+			}
+			f := getFormatter()
+
+			status, err := getImportStatus(cmd, client, args[0])
+			if err != nil {
+				return err
+			}
+			if f.IsJSON() {
+				return f.Print(status)
+			}
+			for _, line := range status.lines() {
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+}
+
+// newImportCompleteCmd creates the "import complete" subcommand.
+func newImportCompleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "complete SPACE",
+		Short: "Complete the import of a space",
+		Long: `Complete the import of a space, making it and its history visible to its
+members. This cannot be undone: no more historical messages can be
+imported afterwards.
+
+The space is checked first: it must still be in import mode, and if it has
+no messages the prompt warns that nothing seems to have been imported.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runImportComplete,
+	}
+
+	addConfirmFlags(cmd)
+
+	return cmd
+}
+
+func runImportComplete(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+
+	status, err := getImportStatus(cmd, client, args[0])
+	if err != nil {
+		return err
+	}
+	if !status.Importing {
+		return fmt.Errorf("space %s is not in import mode", status.Space)
+	}
+
+	if !skipConfirm(cmd) {
+		question := "Complete the import of this space?"
+		if status.Messages == "0" {
+			question = "The space has no messages; nothing seems to have been imported. Complete the import anyway?"
+		}
+		ok, err := confirm(question, status.lines())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			f.PrintMessage("Cancelled.")
+			return nil
+		}
+	} else if status.Messages == "0" {
+		f.PrintError(fmt.Sprintf("⚠ Space %s has no messages; completing the import anyway.", status.Space))
+	}
+
+	raw, err := api.NewSpacesService(client).CompleteImport(cmd.Context(), status.Space)
+	if err != nil {
+		return fmt.Errorf("completing import: %w", err)
+	}
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+	f.PrintSuccess(fmt.Sprintf("Import completed for space: %s (%s messages)", status.Space, status.Messages))
+	return nil
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+)
+
+// newSpacesRequestJoinCmd creates the "spaces request-join" subcommand.
+func newSpacesRequestJoinCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "request-join SPACE",
+		Short: "Ask the managers of a space to add you",
+		Long: `Ask to be added to a space you cannot join yourself, e.g. a restricted
+one. Every space manager gets a direct message with the request, your note,
+and the command that approves it:
+
+  gogchat members add SPACE --user users/{your ID}
+
+A manager denies the request by not running it. Finding the managers needs
+permission to list the space's members, so the space must at least be
+discoverable to you (or use --as-app with an app that is a member).
+
+With --as-app, the app sends the managers a card with Approve and Deny
+buttons instead, for the user given with --user. The clicks are handled by
+"gogchat bot serve" running as the app's HTTP endpoint, which adds the user
+to the space on approval and marks the card approved or denied. The app
+must be a member of the space and have a direct message with each manager.`,
+		Example: `  gogchat spaces request-join spaces/AAAABBBBcccc --note "Joining the incident review"
+  gogchat spaces request-join spaces/AAAABBBBcccc --as-app --user alice@example.com`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSpacesRequestJoin,
+	}
+
+	cmd.Flags().String("note", "", "Reason for the request, shown to the managers")
+	cmd.Flags().String("user", "", "With --as-app, the user to request membership for (email or users/ID)")
+
+	return cmd
+}
+
+func runSpacesRequestJoin(cmd *cobra.Command, args []string) error {
+	note, _ := cmd.Flags().GetString("note")
+	user, _ := cmd.Flags().GetString("user")
+	asApp := viper.GetBool("as_app")
+	if asApp && user == "" {
+		return fmt.Errorf("--user is required with --as-app")
+	}
+	if !asApp && user != "" {
+		return fmt.Errorf("--user can only be used with --as-app; without it the request is for you")
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+	ctx := cmd.Context()
+	space := api.NormalizeName(args[0], "spaces/")
+
+	if asApp {
+		user, err = resolveUser(ctx, client, user)
+	} else {
+		user, err = currentUser(ctx, client)
+	}
+	if err != nil {
+		return err
+	}
+
+	displayName := space
+	if raw, err := api.NewSpacesService(client).Get(ctx, space, false); err == nil {
+		if name := jsonField(raw, "displayName"); name != "" {
+			displayName = name
+		}
+	}
+
+	managers, err := spaceManagers(ctx, client, space)
+	if err != nil {
+		return err
+	}
+	if len(managers) == 0 {
+		return fmt.Errorf("%s has no managers to ask", space)
+	}
+	if managers[user] {
+		return fmt.Errorf("%s already manages %s", user, space)
+	}
+
+	req := joinRequest{Space: space, DisplayName: displayName, User: user, Note: note}
+	body := req.text()
+	if asApp {
+		body = req.card("")
+	}
+
+	names := make([]string, 0, len(managers))
+	for name := range managers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	enableBulkRetries(client)
+	summary := runBulk(ctx, f, names, func(ctx context.Context, manager string) (string, error) {
+		dm, err := directMessageSpace(ctx, client, manager)
+		if err != nil {
+			return "", err
+		}
+		_, err = api.NewMessagesService(client).Create(ctx, dm, body, "", "", "", "")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Asked %s", manager), nil
+	})
+	return finishBulk(f, summary, "managers")
+}
+
+// joinRequest is a request to be added to a space.
+type joinRequest struct {
+	Space       string
+	DisplayName string
+	User        string
+	Note        string
+}
+
+// joinRequestFunctions are the functions of the buttons of join request
+// cards, as handled by "bot serve".
+const (
+	joinRequestApprove = "joinRequest.approve"
+	joinRequestDeny    = "joinRequest.deny"
+)
+
+// text renders r as a text message with the command that approves it, for
+// user authentication, which cannot post cards.
+func (r joinRequest) text() map[string]interface{} {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*Join request for %s*\n", r.DisplayName)
+	fmt.Fprintf(&b, "<%s> asks to be added to %s.\n", r.User, r.Space)
+	if r.Note != "" {
+		fmt.Fprintf(&b, "> %s\n", r.Note)
+	}
+	fmt.Fprintf(&b, "To approve, run:\n```\ngogchat members add %s --user %s\n```", r.Space, r.User)
+	return map[string]interface{}{"text": b.String()}
+}
+
+// card renders r as a cardsV2 message. Without a status, the card has
+// Approve and Deny buttons; with one, e.g. after a click, it shows the
+// status instead.
+func (r joinRequest) card(status string) map[string]interface{} {
+	widgets := []interface{}{
+		map[string]interface{}{"decoratedText": map[string]interface{}{
+			"topLabel": "Requested by",
+			"text":     r.User,
+		}},
+	}
+	if r.Note != "" {
+		widgets = append(widgets, map[string]interface{}{"textParagraph": map[string]interface{}{"text": html.EscapeString(r.Note)}})
+	}
+	if status != "" {
+		widgets = append(widgets, map[string]interface{}{"textParagraph": map[string]interface{}{"text": "<b>" + html.EscapeString(status) + "</b>"}})
+	} else {
+		// The buttons carry the request, so that the click can be handled
+		// without any state kept by gogchat.
+		params := []interface{}{
+			map[string]interface{}{"key": "space", "value": r.Space},
+			map[string]interface{}{"key": "displayName", "value": r.DisplayName},
+			map[string]interface{}{"key": "user", "value": r.User},
+			map[string]interface{}{"key": "note", "value": r.Note},
+		}
+		button := func(text, function string) map[string]interface{} {
+			return map[string]interface{}{"text": text, "onClick": map[string]interface{}{
+				"action": map[string]interface{}{"function": function, "parameters": params},
+			}}
+		}
+		widgets = append(widgets, map[string]interface{}{"buttonList": map[string]interface{}{"buttons": []interface{}{
+			button("Approve", joinRequestApprove),
+			button("Deny", joinRequestDeny),
+		}}})
+	}
+
+	return map[string]interface{}{
+		"text": fmt.Sprintf("Join request for %s", r.DisplayName),
+		"cardsV2": []interface{}{map[string]interface{}{
+			"cardId": "joinRequest",
+			"card": map[string]interface{}{
+				"header": map[string]interface{}{
+					"title":    "Join request for " + r.DisplayName,
+					"subtitle": r.Space,
+				},
+				"sections": []interface{}{map[string]interface{}{"widgets": widgets}},
+			},
+		}},
+	}
+}
+
+// spaceManagers returns the users who manage space, as a set of
+// users/{id} names.
+func spaceManagers(ctx context.Context, client *api.Client, space string) (map[string]bool, error) {
+	svc := api.NewMembersService(client)
+	managers := map[string]bool{}
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, space, 1000, pageToken, `role = "ROLE_MANAGER" AND member.type = "HUMAN"`, false, false, false)
+		if err != nil {
+			return nil, fmt.Errorf("listing the managers of %s: %w", space, err)
+		}
+		var resp struct {
+			Memberships []struct {
+				Member struct {
+					Name string `json:"name"`
+				} `json:"member"`
+			} `json:"memberships"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, m := range resp.Memberships {
+			if m.Member.Name != "" {
+				managers[m.Member.Name] = true
+			}
+		}
+		if resp.NextPageToken == "" {
+			return managers, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
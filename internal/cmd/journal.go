@@ -0,0 +1,441 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// syslogPriorities are the syslog severities by name, most severe first.
+var syslogPriorities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// parsePriority converts a syslog severity name or number into its level.
+func parsePriority(s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < len(syslogPriorities) {
+		return n, nil
+	}
+	switch s = strings.ToLower(s); s {
+	case "error":
+		s = "err"
+	case "warn":
+		s = "warning"
+	}
+	for i, name := range syslogPriorities {
+		if name == s {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid priority %q (use %s, or 0-7)", s, strings.Join(syslogPriorities, ", "))
+}
+
+// journalEntry is one log entry read by "bridge journal".
+type journalEntry struct {
+	// unit is the systemd unit or, for syslog, the program name.
+	unit     string
+	host     string
+	priority int
+	message  string
+	time     time.Time
+}
+
+// newBridgeJournalCmd creates the "bridge journal" subcommand.
+func newBridgeJournalCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "journal",
+		Short: "Post log entries from the systemd journal or syslog",
+		Long: `Follow the systemd journal, or receive syslog messages on a socket, and
+post the matching entries to a space.
+
+By default the journal is followed with journalctl, so the entries of
+--unit (repeatable; all units if not given) at --priority or more severe
+are posted. Only entries written after the bridge starts are posted.
+
+With --syslog ADDRESS, syslog messages (RFC 3164 or RFC 5424) are received
+on a socket instead: udp://HOST:PORT, tcp://HOST:PORT, or unix:///PATH for a
+datagram socket. --unit then matches the program name (APP-NAME or TAG),
+with or without a .service suffix. Point rsyslog or syslog-ng at the
+address, e.g. *.err @127.0.0.1:5514 in rsyslog.conf.
+
+Each unit gets a thread of its own. Entries of a unit that arrive within
+--burst-window are posted as one message, at most --max-lines of them
+verbatim; at most --rate messages are posted per minute, and entries that
+arrive while the limit is reached are posted together once it allows.`,
+		Example: `  gogchat bridge journal --unit myservice --priority err --space spaces/AAAABBBBcccc
+  gogchat bridge journal --unit nginx --unit postgresql --rate 2 --burst-window 1m
+  gogchat bridge journal --syslog udp://127.0.0.1:5514 --priority warning --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: runBridgeJournal,
+	}
+
+	flags := cmd.Flags()
+	flags.StringArray("unit", nil, "Only post entries of this unit (repeatable)")
+	flags.String("priority", "err", "Only post entries of this priority or more severe (emerg, alert, crit, err, warning, notice, info, debug)")
+	flags.String("syslog", "", "Receive syslog messages on this address instead of following the journal")
+	flags.Duration("burst-window", 10*time.Second, "Collapse entries of a unit that arrive within this period into one message")
+	flags.Int("max-lines", 20, "Maximum number of entries shown in one message; the rest are counted")
+	addBridgeFlags(cmd)
+
+	return cmd
+}
+
+func runBridgeJournal(cmd *cobra.Command, args []string) error {
+	units, _ := cmd.Flags().GetStringArray("unit")
+	priorityFlag, _ := cmd.Flags().GetString("priority")
+	syslogAddr, _ := cmd.Flags().GetString("syslog")
+
+	priority, err := parsePriority(priorityFlag)
+	if err != nil {
+		return err
+	}
+	poster, err := newBridgePoster(cmd, "journal:")
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	entries := make(chan journalEntry)
+	errs := make(chan error, 1)
+	// journalctl selects the units itself, including systemd's messages
+	// about them; syslog messages are matched here.
+	var source string
+	var match []string
+	if syslogAddr != "" {
+		source, match = syslogAddr, units
+		err = listenSyslog(ctx, syslogAddr, entries, errs)
+	} else {
+		source = "the journal"
+		err = followJournal(ctx, units, priority, entries, errs)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Forwarding %s to %s (Ctrl-C to stop)...\n", source, poster.space)
+
+	events := make(chan bridgeEvent)
+	var sourceErr error
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case e := <-entries:
+				if e.priority > priority || !matchUnit(e.unit, match) {
+					continue
+				}
+				select {
+				case events <- journalEvent(e):
+				case <-ctx.Done():
+					return
+				}
+			case sourceErr = <-errs:
+				return
+			}
+		}
+	}()
+	poster.run(ctx, events)
+	for range events {
+	}
+	return sourceErr
+}
+
+// matchUnit reports whether unit is one of units, ignoring a .service
+// suffix; no units match everything.
+func matchUnit(unit string, units []string) bool {
+	if len(units) == 0 {
+		return true
+	}
+	unit = strings.TrimSuffix(unit, ".service")
+	for _, u := range units {
+		if strings.TrimSuffix(u, ".service") == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// journalEvent converts an entry into the event posted for it.
+func journalEvent(e journalEntry) bridgeEvent {
+	unit := e.unit
+	if unit == "" {
+		unit = "unknown"
+	}
+	title := unit
+	if e.host != "" {
+		title += " on " + e.host
+	}
+	at := e.time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	line := fmt.Sprintf("%s %s %s", at.Local().Format("15:04:05"), syslogPriorities[e.priority], e.message)
+	return bridgeEvent{key: e.host + "/" + unit, title: title, line: line}
+}
+
+// followJournal runs journalctl to follow the journal and sends its new
+// entries to entries. When journalctl ends, the reason goes to errs.
+func followJournal(ctx context.Context, units []string, priority int, entries chan<- journalEntry, errs chan<- error) error {
+	path, err := exec.LookPath("journalctl")
+	if err != nil {
+		return fmt.Errorf("following the journal needs journalctl on your PATH; use --syslog to receive syslog messages instead")
+	}
+	args := []string{"--follow", "--lines=0", "--output=json", "--priority=" + strconv.Itoa(priority)}
+	for _, u := range units {
+		args = append(args, "--unit="+u)
+	}
+	c := exec.CommandContext(ctx, path, args...)
+	c.Stderr = os.Stderr
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("starting journalctl: %w", err)
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			e, ok := parseJournalJSON(scanner.Bytes())
+			if !ok {
+				continue
+			}
+			select {
+			case entries <- e:
+			case <-ctx.Done():
+			}
+		}
+		err := c.Wait()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = io.EOF
+		}
+		errs <- fmt.Errorf("journalctl stopped: %w", err)
+	}()
+	return nil
+}
+
+// parseJournalJSON parses one line of journalctl --output=json.
+func parseJournalJSON(line []byte) (journalEntry, bool) {
+	var raw struct {
+		Message    json.RawMessage `json:"MESSAGE"`
+		Priority   string          `json:"PRIORITY"`
+		About      string          `json:"UNIT"`
+		Unit       string          `json:"_SYSTEMD_UNIT"`
+		Identifier string          `json:"SYSLOG_IDENTIFIER"`
+		Hostname   string          `json:"_HOSTNAME"`
+		Realtime   string          `json:"__REALTIME_TIMESTAMP"`
+	}
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return journalEntry{}, false
+	}
+
+	// systemd's own messages about a unit (e.g. that it failed) carry
+	// the unit in UNIT.
+	e := journalEntry{unit: raw.About, host: raw.Hostname, priority: 6}
+	if e.unit == "" {
+		e.unit = raw.Unit
+	}
+	if e.unit == "" {
+		e.unit = raw.Identifier
+	}
+	if p, err := strconv.Atoi(raw.Priority); err == nil && p >= 0 && p < len(syslogPriorities) {
+		e.priority = p
+	}
+	if usec, err := strconv.ParseInt(raw.Realtime, 10, 64); err == nil {
+		e.time = time.UnixMicro(usec)
+	}
+	// Messages that are not valid UTF-8 are written as arrays of bytes.
+	if json.Unmarshal(raw.Message, &e.message) != nil {
+		var b []byte
+		var ints []int
+		if json.Unmarshal(raw.Message, &ints) == nil {
+			for _, n := range ints {
+				b = append(b, byte(n))
+			}
+		}
+		e.message = strings.ToValidUTF8(string(b), "?")
+	}
+	return e, true
+}
+
+// listenSyslog receives syslog messages on addr and sends them to entries.
+// When receiving fails, the reason goes to errs.
+func listenSyslog(ctx context.Context, addr string, entries chan<- journalEntry, errs chan<- error) error {
+	u, err := url.Parse(addr)
+	if err != nil || (u.Scheme != "unix" && u.Host == "") {
+		return fmt.Errorf("invalid --syslog address %q (use udp://HOST:PORT, tcp://HOST:PORT, or unix:///PATH)", addr)
+	}
+
+	send := func(msg string) {
+		e, ok := parseSyslog(msg)
+		if !ok {
+			return
+		}
+		select {
+		case entries <- e:
+		case <-ctx.Done():
+		}
+	}
+
+	switch u.Scheme {
+	case "udp", "unix":
+		var conn net.PacketConn
+		if u.Scheme == "udp" {
+			conn, err = net.ListenPacket("udp", u.Host)
+		} else {
+			conn, err = net.ListenPacket("unixgram", u.Path)
+		}
+		if err != nil {
+			return fmt.Errorf("listening on %s: %w", addr, err)
+		}
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+			if u.Scheme == "unix" {
+				os.Remove(u.Path)
+			}
+		}()
+		go func() {
+			buf := make([]byte, 64*1024)
+			for {
+				n, _, err := conn.ReadFrom(buf)
+				if err != nil {
+					if ctx.Err() == nil {
+						errs <- fmt.Errorf("receiving syslog messages: %w", err)
+					}
+					return
+				}
+				send(string(buf[:n]))
+			}
+		}()
+	case "tcp":
+		ln, err := net.Listen("tcp", u.Host)
+		if err != nil {
+			return fmt.Errorf("listening on %s: %w", addr, err)
+		}
+		go func() {
+			<-ctx.Done()
+			ln.Close()
+		}()
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					if ctx.Err() == nil {
+						errs <- fmt.Errorf("receiving syslog messages: %w", err)
+					}
+					return
+				}
+				// Messages are framed by newlines (RFC 6587
+				// non-transparent framing).
+				go func() {
+					defer conn.Close()
+					scanner := bufio.NewScanner(conn)
+					for scanner.Scan() {
+						send(scanner.Text())
+					}
+				}()
+			}
+		}()
+	default:
+		return fmt.Errorf("invalid --syslog address %q (use udp://HOST:PORT, tcp://HOST:PORT, or unix:///PATH)", addr)
+	}
+	return nil
+}
+
+// parseSyslog parses an RFC 5424 or RFC 3164 syslog message.
+func parseSyslog(msg string) (journalEntry, bool) {
+	msg = strings.TrimRight(msg, "\r\n\x00")
+	if !strings.HasPrefix(msg, "<") {
+		return journalEntry{}, false
+	}
+	end := strings.IndexByte(msg, '>')
+	if end < 2 || end > 4 {
+		return journalEntry{}, false
+	}
+	pri, err := strconv.Atoi(msg[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return journalEntry{}, false
+	}
+	e := journalEntry{priority: pri % 8}
+	rest := msg[end+1:]
+
+	// RFC 5424: VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+	if v, after, ok := strings.Cut(rest, " "); ok && v == "1" {
+		fields := strings.SplitN(after, " ", 6)
+		if len(fields) < 6 {
+			return journalEntry{}, false
+		}
+		nilValue := func(s string) string {
+			if s == "-" {
+				return ""
+			}
+			return s
+		}
+		e.time, _ = time.Parse(time.RFC3339Nano, fields[0])
+		e.host = nilValue(fields[1])
+		e.unit = nilValue(fields[2])
+		e.message = skipStructuredData(fields[5])
+		return e, true
+	}
+
+	// RFC 3164: TIMESTAMP HOSTNAME TAG: MSG, where TIMESTAMP is e.g.
+	// "Jan  2 15:04:05". Local senders often leave out the hostname.
+	if len(rest) > 16 && rest[15] == ' ' {
+		if t, err := time.ParseInLocation(time.Stamp, rest[:15], time.Local); err == nil {
+			now := time.Now()
+			e.time = t.AddDate(now.Year(), 0, 0)
+			rest = rest[16:]
+			if host, after, ok := strings.Cut(rest, " "); ok && !strings.ContainsAny(host, ":[") {
+				e.host = host
+				rest = after
+			}
+		}
+	}
+	if tag, after, ok := strings.Cut(rest, ": "); ok && !strings.Contains(tag, " ") {
+		tag, _, _ = strings.Cut(tag, "[")
+		e.unit = tag
+		rest = after
+	}
+	e.message = rest
+	return e, true
+}
+
+// skipStructuredData returns the MSG after the STRUCTURED-DATA of an
+// RFC 5424 message.
+func skipStructuredData(s string) string {
+	if strings.HasPrefix(s, "-") {
+		return strings.TrimPrefix(strings.TrimPrefix(s, "-"), " ")
+	}
+	inValue := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && inValue:
+			i++
+		case c == '"':
+			inValue = !inValue
+		case c == ']' && !inValue && (i+1 == len(s) || s[i+1] == ' '):
+			return strings.TrimPrefix(s[i+1:], " ")
+		}
+	}
+	return s
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// addFetchAllFlag registers --all on a command that pages through the
+// messages of a space, to lift limits.max_fetch.
+func addFetchAllFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("all", false, "Fetch every matching message, ignoring limits.max_fetch")
+}
+
+// fetchLimit returns the number of messages cmd may fetch from one space,
+// or 0 for no limit.
+func fetchLimit(cmd *cobra.Command) int {
+	if all, _ := cmd.Flags().GetBool("all"); all || Cfg == nil {
+		return 0
+	}
+	return max(Cfg.Limits.MaxFetch, 0)
+}
+
+// warnFetchLimit tells the user that only the first n messages of space
+// were fetched.
+func warnFetchLimit(space string, n int) {
+	fmt.Fprintf(os.Stderr, "⚠ Stopped after %d messages in %s (limits.max_fetch); pass --all to fetch them all.\n", n, space)
+}
+
+// checkDeleteLimit refuses to delete n messages without --yes if that is
+// more than limits.max_delete, so that a mistyped filter cannot be waved
+// through at the prompt.
+func checkDeleteLimit(cmd *cobra.Command, n int) error {
+	if skipConfirm(cmd) || Cfg == nil || Cfg.Limits.MaxDelete <= 0 || n <= Cfg.Limits.MaxDelete {
+		return nil
+	}
+	return fmt.Errorf("refusing to delete %d messages, more than limits.max_delete (%d); pass --yes to delete them anyway",
+		n, Cfg.Limits.MaxDelete)
+}
+
+// confirmLargeUploads asks before uploading files larger than
+// limits.upload_warn_mb, unless --yes is given. Files that cannot be read
+// are left for the upload itself to report.
+func confirmLargeUploads(cmd *cobra.Command, paths []string) (bool, error) {
+	if skipConfirm(cmd) || Cfg == nil || Cfg.Limits.UploadWarnMB <= 0 {
+		return true, nil
+	}
+	var large []string
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && info.Size() > int64(Cfg.Limits.UploadWarnMB)<<20 {
+			large = append(large, fmt.Sprintf("%s (%d MB)", path, info.Size()>>20))
+		}
+	}
+	if len(large) == 0 {
+		return true, nil
+	}
+	return confirm(fmt.Sprintf("Upload files larger than %d MB (limits.upload_warn_mb)?", Cfg.Limits.UploadWarnMB), large)
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -25,18 +28,31 @@ func NewMediaCmd() *cobra.Command {
 	cmd.AddCommand(
 		newMediaUploadCmd(),
 		newMediaDownloadCmd(),
+		newMediaStatCmd(),
 	)
 
 	return cmd
 }
 
+// chatUploadLimit is the largest file the Chat API accepts as an attachment.
+const chatUploadLimit = 200 << 20
+
 // newMediaUploadCmd creates the "media upload" subcommand.
 func newMediaUploadCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "upload SPACE",
-		Short: "Upload a file to a space",
-		Long:  "Upload a file as an attachment to the specified Google Chat space. SPACE is the space resource name (spaces/{space}) or just the space ID.",
-		Args:  cobra.ExactArgs(1),
+		Short: "Upload files to a space",
+		Long: `Upload one or more files as attachments to the specified Google Chat space. SPACE is the space resource name (spaces/{space}) or just the space ID. Repeat --file to upload several files in parallel (see --concurrency).
+
+Chat rejects attachments larger than 200 MB. With --as-drive, such files are
+uploaded to your Google Drive instead and a message with the Drive file
+attached is posted to the space (with --text as its text, or the file name).
+Smaller files are uploaded to Chat as usual. --as-drive needs the drive.file
+scope, which is not requested by default; log in with --scopes drive.file.
+
+Files larger than limits.upload_warn_mb (default 50 MB) are listed and must
+be confirmed before anything is uploaded, unless --yes is given.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
 			if err != nil {
@@ -45,19 +61,84 @@ func newMediaUploadCmd() *cobra.Command {
 			formatter := getFormatter()
 			svc := api.NewMediaService(client)
 
-			parent := args[0]
-			filePath, _ := cmd.Flags().GetString("file")
+			parent := api.NormalizeName(args[0], "spaces/")
+			filePaths, _ := cmd.Flags().GetStringArray("file")
+			asDrive, _ := cmd.Flags().GetBool("as-drive")
+			text, _ := cmd.Flags().GetString("text")
 
-			// Validate that the file exists before uploading.
-			info, err := os.Stat(filePath)
-			if err != nil {
-				if os.IsNotExist(err) {
-					return fmt.Errorf("file not found: %s", filePath)
+			if text != "" && !asDrive {
+				return fmt.Errorf("--text requires --as-drive")
+			}
+			if asDrive {
+				if err := requireFeatureScopes(cmd.Context(), "media upload --as-drive"); err != nil {
+					return err
+				}
+			}
+			if ok, err := confirmLargeUploads(cmd, filePaths); err != nil || !ok {
+				if err == nil {
+					formatter.PrintMessage("Cancelled.")
 				}
-				return fmt.Errorf("checking file %s: %w", filePath, err)
+				return err
 			}
-			if info.IsDir() {
-				return fmt.Errorf("%s is a directory, not a file", filePath)
+
+			if len(filePaths) > 1 {
+				enableBulkRetries(client)
+				summary := runBulk(cmd.Context(), formatter, filePaths, func(ctx context.Context, filePath string) (string, error) {
+					info, err := checkUploadFile(filePath)
+					if err != nil {
+						return "", err
+					}
+					if info.Size() > chatUploadLimit {
+						if !asDrive {
+							return "", uploadLimitError(filePath, info)
+						}
+						file, msg, err := uploadViaDrive(ctx, client, parent, filePath, text)
+						if err != nil {
+							return "", err
+						}
+						return fmt.Sprintf("Uploaded %s to Drive (%s) and posted %s", filePath, jsonField(file, "id"), jsonField(msg, "name")), nil
+					}
+					raw, err := svc.Upload(ctx, parent, filePath)
+					if err != nil {
+						return "", err
+					}
+					var result struct {
+						AttachmentDataRef struct {
+							ResourceName string `json:"resourceName"`
+						} `json:"attachmentDataRef"`
+					}
+					_ = json.Unmarshal(raw, &result)
+					return fmt.Sprintf("Uploaded %s (%s)", filePath, result.AttachmentDataRef.ResourceName), nil
+				})
+				return finishBulk(formatter, summary, "files")
+			}
+
+			filePath := filePaths[0]
+			info, err := checkUploadFile(filePath)
+			if err != nil {
+				return err
+			}
+
+			if info.Size() > chatUploadLimit {
+				if !asDrive {
+					return uploadLimitError(filePath, info)
+				}
+				file, msg, err := uploadViaDrive(cmd.Context(), client, parent, filePath, text)
+				if err != nil {
+					return err
+				}
+				if formatter.IsJSON() {
+					return formatter.Print(map[string]interface{}{
+						"driveFile": file,
+						"message":   msg,
+					})
+				}
+				formatter.PrintSuccess(fmt.Sprintf("Uploaded %s to Drive and posted it to %s", filePath, parent))
+				fmt.Printf("Drive File:    %s\n", jsonField(file, "id"))
+				fmt.Printf("Link:          %s\n", jsonField(file, "webViewLink"))
+				fmt.Printf("Message:       %s\n", jsonField(msg, "name"))
+				fmt.Printf("File Size:     %d bytes\n", info.Size())
+				return nil
 			}
 
 			raw, err := svc.Upload(cmd.Context(), parent, filePath)
@@ -90,7 +171,10 @@ func newMediaUploadCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().String("file", "", "Path to the file to upload (required)")
+	cmd.Flags().StringArray("file", nil, "Path to the file to upload (required, repeatable)")
+	cmd.Flags().Bool("as-drive", false, "Upload files over the 200 MB Chat limit to Google Drive and post them as Drive attachments")
+	cmd.Flags().String("text", "", "Text of the message posted for Drive uploads (requires --as-drive)")
+	addConfirmFlags(cmd)
 	_ = cmd.MarkFlagRequired("file")
 
 	return cmd
@@ -99,10 +183,21 @@ func newMediaUploadCmd() *cobra.Command {
 // newMediaDownloadCmd creates the "media download" subcommand.
 func newMediaDownloadCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "download RESOURCE",
-		Short: "Download a media resource",
-		Long:  "Download media content by resource name and save it to a local file. RESOURCE is the full media resource name.",
-		Args:  cobra.ExactArgs(1),
+		Use:   "download RESOURCE...",
+		Short: "Download media resources",
+		Long: `Download media content and save it to a local file. RESOURCE is an attachment
+name (spaces/{space}/messages/{message}/attachments/{attachment}), whose media
+resource is looked up first, or a media resource name as found in an
+attachment's attachmentDataRef.
+
+Without --output, the file is named after the Content-Disposition header of
+the download, else the attachment's original file name, else the resource
+name. The name is sanitized so it cannot leave the current directory.
+Existing files are never overwritten unless --force is given.
+
+When several resources are given they are downloaded in parallel (see
+--concurrency) and --output names a directory.`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
 			if err != nil {
@@ -111,52 +206,315 @@ func newMediaDownloadCmd() *cobra.Command {
 			formatter := getFormatter()
 			svc := api.NewMediaService(client)
 
-			resourceName := args[0]
 			outputPath, _ := cmd.Flags().GetString("output")
+			force, _ := cmd.Flags().GetBool("force")
 
-			// Derive the output file name if not specified.
-			if outputPath == "" {
-				outputPath = deriveOutputFilename(resourceName)
-			}
-
-			body, contentType, err := svc.Download(cmd.Context(), resourceName)
-			if err != nil {
-				return fmt.Errorf("downloading media: %w", err)
+			if len(args) > 1 {
+				if outputPath != "" {
+					if err := os.MkdirAll(outputPath, 0o755); err != nil {
+						return fmt.Errorf("creating output directory %s: %w", outputPath, err)
+					}
+				}
+				enableBulkRetries(client)
+				summary := runBulk(cmd.Context(), formatter, args, func(ctx context.Context, arg string) (string, error) {
+					src, err := resolveMediaSource(ctx, client, arg)
+					if err != nil {
+						return "", err
+					}
+					path, written, _, err := downloadMedia(ctx, svc, src, outputPath, "", force)
+					if err != nil {
+						return "", err
+					}
+					return fmt.Sprintf("Downloaded to %s (%d bytes)", path, written), nil
+				})
+				return finishBulk(formatter, summary, "downloads")
 			}
-			defer body.Close()
 
-			// Create the output file.
-			outFile, err := os.Create(outputPath)
+			src, err := resolveMediaSource(cmd.Context(), client, args[0])
 			if err != nil {
-				return fmt.Errorf("creating output file %s: %w", outputPath, err)
+				return err
 			}
-			defer outFile.Close()
 
-			written, err := io.Copy(outFile, body)
+			path, written, info, err := downloadMedia(cmd.Context(), svc, src, "", outputPath, force)
 			if err != nil {
-				return fmt.Errorf("writing to file %s: %w", outputPath, err)
+				return err
 			}
 
 			if formatter.IsJSON() {
 				result := map[string]interface{}{
-					"outputFile":  outputPath,
+					"outputFile":  path,
 					"size":        written,
-					"contentType": contentType,
+					"contentType": info.ContentType,
 				}
 				return formatter.Print(result)
 			}
 
-			formatter.PrintSuccess(fmt.Sprintf("Downloaded to %s (%d bytes, %s)", outputPath, written, contentType))
+			formatter.PrintSuccess(fmt.Sprintf("Downloaded to %s (%d bytes, %s)", path, written, info.ContentType))
 
 			return nil
 		},
 	}
 
-	cmd.Flags().StringP("output", "o", "", "Output file path (defaults to derived name from resource)")
+	cmd.Flags().StringP("output", "o", "", "Output file path, or directory when downloading several resources (defaults to the media's file name)")
+	cmd.Flags().Bool("force", false, "Overwrite existing files")
 
 	return cmd
 }
 
+// newMediaStatCmd creates the "media stat" subcommand.
+func newMediaStatCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stat RESOURCE",
+		Short: "Show the size and type of media without downloading it",
+		Long: `Show the content type, size, and file name of a media resource without
+downloading it, e.g. to decide in a script whether a download is worth it.
+RESOURCE is an attachment name or a media resource name, as for
+"media download". Only the first byte of the content is requested.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAPIClient()
+			if err != nil {
+				return err
+			}
+			formatter := getFormatter()
+
+			src, err := resolveMediaSource(cmd.Context(), client, args[0])
+			if err != nil {
+				return err
+			}
+			info, err := api.NewMediaService(client).Stat(cmd.Context(), src.resourceName)
+			if err != nil {
+				return fmt.Errorf("getting media info: %w", err)
+			}
+			if info.FileName == "" {
+				info.FileName = src.fileName
+			}
+
+			if formatter.IsJSON() {
+				return formatter.Print(map[string]interface{}{
+					"resourceName": src.resourceName,
+					"contentType":  info.ContentType,
+					"size":         info.Size,
+					"fileName":     info.FileName,
+				})
+			}
+
+			size := "unknown"
+			if info.Size >= 0 {
+				size = fmt.Sprintf("%d bytes (%s)", info.Size, formatByteSize(info.Size))
+			}
+			fmt.Printf("Resource Name: %s\n", src.resourceName)
+			fmt.Printf("File Name:     %s\n", info.FileName)
+			fmt.Printf("Content Type:  %s\n", info.ContentType)
+			fmt.Printf("Size:          %s\n", size)
+			return nil
+		},
+	}
+}
+
+// formatByteSize formats n bytes with a binary unit, e.g. "1.5 GiB".
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// checkUploadFile validates that filePath exists and is a regular file
+// before uploading it.
+func checkUploadFile(filePath string) (os.FileInfo, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file not found: %s", filePath)
+		}
+		return nil, fmt.Errorf("checking file %s: %w", filePath, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory, not a file", filePath)
+	}
+	return info, nil
+}
+
+// uploadLimitError reports a file that is too large for a Chat attachment.
+func uploadLimitError(filePath string, info os.FileInfo) error {
+	return fmt.Errorf("%s is %d MB, over the %d MB Chat attachment limit; add --as-drive to upload it through Google Drive",
+		filePath, info.Size()>>20, chatUploadLimit>>20)
+}
+
+// uploadViaDrive uploads filePath to the caller's Drive and posts a message
+// in space with the Drive file attached. It returns the Drive file metadata
+// and the created message.
+func uploadViaDrive(ctx context.Context, client *api.Client, space, filePath, text string) (json.RawMessage, json.RawMessage, error) {
+	file, err := api.NewDriveService(client).Upload(ctx, filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("uploading %s to Drive: %w", filePath, err)
+	}
+
+	fileID := jsonField(file, "id")
+	if text == "" {
+		text = filepath.Base(filePath)
+	}
+	body := map[string]interface{}{
+		"text": text,
+		"attachment": []interface{}{
+			map[string]interface{}{
+				"driveDataRef": map[string]interface{}{
+					"driveFileId": fileID,
+				},
+			},
+		},
+	}
+
+	msg, err := api.NewMessagesService(client).Create(ctx, space, body, "", "", "", "")
+	if err != nil {
+		return file, nil, fmt.Errorf("posting Drive file %s to %s: %w", fileID, space, err)
+	}
+	return file, msg, nil
+}
+
+// jsonField returns the top-level string field key of raw, or "" if it is
+// missing.
+func jsonField(raw json.RawMessage, key string) string {
+	var m map[string]interface{}
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return ""
+	}
+	v, _ := m[key].(string)
+	return v
+}
+
+// mediaSource is a media resource to download and the file name to save it
+// under if the download suggests none.
+type mediaSource struct {
+	resourceName string
+	fileName     string
+}
+
+// isAttachmentName reports whether name is an attachment resource name,
+// spaces/{space}/messages/{message}/attachments/{attachment}.
+func isAttachmentName(name string) bool {
+	parts := strings.Split(name, "/")
+	return len(parts) == 6 && parts[0] == "spaces" && parts[2] == "messages" && parts[4] == "attachments"
+}
+
+// resolveMediaSource returns the media resource of arg. An attachment name
+// is looked up to find its media resource and original file name; anything
+// else is taken as a media resource name.
+func resolveMediaSource(ctx context.Context, client *api.Client, arg string) (mediaSource, error) {
+	if !isAttachmentName(arg) {
+		return mediaSource{resourceName: arg, fileName: deriveOutputFilename(arg)}, nil
+	}
+
+	raw, err := api.NewAttachmentsService(client).Get(ctx, arg)
+	if err != nil {
+		return mediaSource{}, fmt.Errorf("getting attachment %s: %w", arg, err)
+	}
+	var attachment struct {
+		ContentName       string `json:"contentName"`
+		AttachmentDataRef struct {
+			ResourceName string `json:"resourceName"`
+		} `json:"attachmentDataRef"`
+		DriveDataRef struct {
+			DriveFileID string `json:"driveFileId"`
+		} `json:"driveDataRef"`
+	}
+	if err := json.Unmarshal(raw, &attachment); err != nil {
+		return mediaSource{}, fmt.Errorf("parsing attachment %s: %w", arg, err)
+	}
+	if attachment.AttachmentDataRef.ResourceName == "" {
+		if attachment.DriveDataRef.DriveFileID != "" {
+			return mediaSource{}, fmt.Errorf("attachment %s is the Google Drive file %s; download it from Drive", arg, attachment.DriveDataRef.DriveFileID)
+		}
+		return mediaSource{}, fmt.Errorf("attachment %s has no media to download", arg)
+	}
+
+	src := mediaSource{
+		resourceName: attachment.AttachmentDataRef.ResourceName,
+		fileName:     attachment.ContentName,
+	}
+	if src.fileName == "" {
+		src.fileName = deriveOutputFilename(arg)
+	}
+	return src, nil
+}
+
+// downloadMedia downloads the media of src to outputPath or, if that is
+// empty, to a file in dir named after the download (see newMediaDownloadCmd).
+// Existing files are only replaced with force. It returns the path written,
+// the number of bytes written, and the media's metadata.
+func downloadMedia(ctx context.Context, svc *api.MediaService, src mediaSource, dir, outputPath string, force bool) (string, int64, api.MediaInfo, error) {
+	body, info, err := svc.Download(ctx, src.resourceName)
+	if err != nil {
+		return "", 0, info, fmt.Errorf("downloading media: %w", err)
+	}
+	defer body.Close()
+
+	path := outputPath
+	if path == "" {
+		name := info.FileName
+		if name == "" {
+			name = src.fileName
+		}
+		path = filepath.Join(dir, sanitizeFileName(name))
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	outFile, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return "", 0, info, fmt.Errorf("%s already exists; use --force to overwrite it", path)
+	}
+	if err != nil {
+		return "", 0, info, fmt.Errorf("creating output file %s: %w", path, err)
+	}
+
+	written, err := io.Copy(outFile, body)
+	if cerr := outFile.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		// Do not leave a truncated file behind.
+		_ = os.Remove(path)
+		return "", 0, info, fmt.Errorf("writing to file %s: %w", path, err)
+	}
+	return path, written, info, nil
+}
+
+// sanitizeFileName makes a file name suggested by the server safe to create
+// in the current directory: directories are dropped, and control characters
+// and characters that are invalid on common file systems are replaced.
+func sanitizeFileName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if len(name) > 255 {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:255-len(ext)], "") + ext
+	}
+	if name == "" {
+		return "download"
+	}
+	return name
+}
+
 // deriveOutputFilename attempts to extract a reasonable filename from a
 // resource name. If no meaningful name can be derived, it falls back to
 // "download".
@@ -1,14 +1,16 @@
 package cmd
 
 import (
-	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/cipher-shad0w/gogchat/internal/api"
 	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/cipher-shad0w/gogchat/internal/undo"
 	"github.com/spf13/cobra"
 )
 
@@ -28,6 +30,8 @@ func NewMembersCmd() *cobra.Command {
 		newMembersAddCmd(),
 		newMembersUpdateCmd(),
 		newMembersRemoveCmd(),
+		newMembersExpiringCmd(),
+		newMembersExpireCmd(),
 	)
 
 	return cmd
@@ -49,7 +53,7 @@ func newMembersListCmd() *cobra.Command {
 			svc := api.NewMembersService(client)
 
 			space := args[0]
-			pageSize, _ := cmd.Flags().GetInt("page-size")
+			pageSize := getPageSize(cmd)
 			pageToken, _ := cmd.Flags().GetString("page-token")
 			filter, _ := cmd.Flags().GetString("filter")
 			showInvited, _ := cmd.Flags().GetBool("show-invited")
@@ -67,7 +71,7 @@ func newMembersListCmd() *cobra.Command {
 			}
 
 			if f.IsJSON() {
-				return f.PrintRaw(result)
+				return printListPage(f, result, "memberships")
 			}
 
 			return printMembersList(f, result)
@@ -112,10 +116,7 @@ func membersListAll(cmd *cobra.Command, svc *api.MembersService, f *output.Forma
 	}
 
 	if f.IsJSON() {
-		combined := map[string]interface{}{
-			"memberships": allMemberships,
-		}
-		return f.Print(combined)
+		return printList(f, allMemberships, "")
 	}
 
 	// Build a synthetic response for the human-readable printer.
@@ -203,12 +204,29 @@ func formatMemberState(state interface{}) string {
 	}
 }
 
+// resolveMemberName normalizes a membership name, replacing the "me" member
+// ID (spaces/{space}/members/me) with the calling user's ID.
+func resolveMemberName(ctx context.Context, client *api.Client, arg string) (string, error) {
+	name := api.NormalizeName(arg, "spaces/")
+	space, member, ok := strings.Cut(name, "/members/")
+	if !ok || (member != "me" && member != "users/me") {
+		return name, nil
+	}
+	user, err := currentUser(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	name = space + "/members/" + strings.TrimPrefix(user, "users/")
+	noteResolved(arg, name)
+	return name, nil
+}
+
 // newMembersGetCmd creates the "members get" subcommand.
 func newMembersGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get MEMBER",
 		Short: "Get details of a space member",
-		Long:  "Get detailed information about a member. MEMBER is the full resource name (e.g. spaces/XXXX/members/YYYY).",
+		Long:  "Get detailed information about a member. MEMBER is the full resource name (e.g. spaces/XXXX/members/YYYY); use spaces/XXXX/members/me for your own membership.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
@@ -218,7 +236,10 @@ func newMembersGetCmd() *cobra.Command {
 			f := getFormatter()
 			svc := api.NewMembersService(client)
 
-			name := args[0]
+			name, err := resolveMemberName(cmd.Context(), client, args[0])
+			if err != nil {
+				return err
+			}
 			admin, _ := cmd.Flags().GetBool("admin")
 
 			result, err := svc.Get(cmd.Context(), name, admin)
@@ -290,9 +311,15 @@ func printMemberDetail(raw json.RawMessage) error {
 func newMembersAddCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "add SPACE",
-		Short: "Add a member to a space",
-		Long:  "Add a user as a member to a Google Chat space. SPACE can be a space ID or full resource name (spaces/XXXX).",
-		Args:  cobra.ExactArgs(1),
+		Short: "Add members to a space",
+		Long: `Add one or more users as members to a Google Chat space. SPACE can be a space ID or full resource name (spaces/XXXX). Repeat --user to add several users in parallel (see --concurrency); with http.batch, their requests are batched into few round trips.
+
+With --expires, the memberships are temporary, e.g. for incident war
+rooms: they are recorded locally and removed by "members expire" once the
+period is over. See "members expiring" for the recorded memberships.`,
+		Example: `  gogchat members add spaces/AAAABBBBcccc --user alice@example.com
+  gogchat members add spaces/AAAABBBBcccc --email oncall@example.com --expires 7d`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
 			if err != nil {
@@ -302,45 +329,97 @@ func newMembersAddCmd() *cobra.Command {
 			svc := api.NewMembersService(client)
 
 			space := args[0]
-			user, _ := cmd.Flags().GetString("user")
+			users, _ := cmd.Flags().GetStringArray("user")
+			emails, _ := cmd.Flags().GetStringArray("email")
+			users = append(users, emails...)
 			role, _ := cmd.Flags().GetString("role")
 			admin, _ := cmd.Flags().GetBool("admin")
 
-			membership := map[string]interface{}{
-				"member": map[string]interface{}{
-					"name": user,
-					"type": "HUMAN",
-				},
-				"role": role,
+			var expires time.Time
+			if s, _ := cmd.Flags().GetString("expires"); s != "" {
+				if expires, err = parseExpiry(s, time.Now()); err != nil {
+					return err
+				}
+			}
+
+			membership, err := readBody(cmd)
+			if err != nil {
+				return err
+			}
+
+			if membership == nil {
+				if len(users) == 0 {
+					return fmt.Errorf(`required flag(s) "user" not set (or pass the full payload with --body)`)
+				}
+
+				if len(users) > 1 {
+					enableBulkRetries(client)
+					summary := runBulkBatched(cmd.Context(), f, client, users, func(user string) api.BatchRequest {
+						return svc.CreateRequest(space, newHumanMembership(user, role), admin)
+					}, func(user string, resp json.RawMessage) (string, error) {
+						if !expires.IsZero() {
+							recordExpiry(user, resp, expires)
+						}
+						return fmt.Sprintf("Added %s to space %s", user, space), nil
+					})
+					return finishBulk(f, summary, "members")
+				}
+
+				membership = newHumanMembership(users[0], role)
 			}
 
 			result, err := svc.Create(cmd.Context(), space, membership, admin)
 			if err != nil {
 				return fmt.Errorf("adding member: %w", err)
 			}
+			if !expires.IsZero() {
+				user := ""
+				if len(users) > 0 {
+					user = users[0]
+				}
+				recordExpiry(user, result, expires)
+			}
 
 			if f.IsJSON() {
 				return f.PrintRaw(result)
 			}
 
 			f.PrintSuccess(fmt.Sprintf("Member added to space %s", space))
+			if !expires.IsZero() {
+				f.PrintMessage(fmt.Sprintf("Expires:     %s", output.FormatTime(expires.Format(time.RFC3339))))
+			}
 			return printMemberDetail(result)
 		},
 	}
 
-	cmd.Flags().String("user", "", "User resource name (e.g. users/123456)")
+	cmd.Flags().StringArray("user", nil, "User resource name (e.g. users/123456, repeatable)")
+	_ = cmd.RegisterFlagCompletionFunc("user", completeDirectoryUsers)
+	cmd.Flags().StringArray("email", nil, "User email address (repeatable; same as --user)")
+	_ = cmd.RegisterFlagCompletionFunc("email", completeDirectoryUsers)
 	cmd.Flags().String("role", "ROLE_MEMBER", "Member role (ROLE_MEMBER or ROLE_MANAGER)")
-	_ = cmd.MarkFlagRequired("user")
+	cmd.Flags().String("expires", "", `Remove the memberships after this period (e.g. "7d", "12h") or at this time`)
+	addBodyFlag(cmd)
 
 	return cmd
 }
 
+// newHumanMembership builds the membership body for adding a human user.
+func newHumanMembership(user, role string) map[string]interface{} {
+	return map[string]interface{}{
+		"member": map[string]interface{}{
+			"name": api.NormalizeUser(user),
+			"type": "HUMAN",
+		},
+		"role": role,
+	}
+}
+
 // newMembersUpdateCmd creates the "members update" subcommand.
 func newMembersUpdateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "update MEMBER",
 		Short: "Update a space member",
-		Long:  "Update a member's role in a Google Chat space. MEMBER is the full resource name (e.g. spaces/XXXX/members/YYYY).",
+		Long:  "Update a member's role in a Google Chat space. MEMBER is the full resource name (e.g. spaces/XXXX/members/YYYY); use spaces/XXXX/members/me for your own membership.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
@@ -350,13 +429,30 @@ func newMembersUpdateCmd() *cobra.Command {
 			f := getFormatter()
 			svc := api.NewMembersService(client)
 
-			name := args[0]
+			name, err := resolveMemberName(cmd.Context(), client, args[0])
+			if err != nil {
+				return err
+			}
 			role, _ := cmd.Flags().GetString("role")
-			updateMask, _ := cmd.Flags().GetString("update-mask")
 			admin, _ := cmd.Flags().GetBool("admin")
 
-			membership := map[string]interface{}{
-				"role": role,
+			membership, err := readBody(cmd)
+			if err != nil {
+				return err
+			}
+
+			if membership == nil {
+				if err := requireFlags(cmd, "role"); err != nil {
+					return err
+				}
+				membership = map[string]interface{}{
+					"role": role,
+				}
+			}
+
+			updateMask, err := resolveUpdateMask(cmd, membership)
+			if err != nil {
+				return err
 			}
 
 			result, err := svc.Patch(cmd.Context(), name, membership, updateMask, admin)
@@ -374,8 +470,8 @@ func newMembersUpdateCmd() *cobra.Command {
 	}
 
 	cmd.Flags().String("role", "", "Member role (ROLE_MEMBER or ROLE_MANAGER)")
-	cmd.Flags().String("update-mask", "role", "Fields to update (comma-separated)")
-	_ = cmd.MarkFlagRequired("role")
+	addBodyFlag(cmd)
+	addMaskFlag(cmd)
 
 	return cmd
 }
@@ -383,10 +479,11 @@ func newMembersUpdateCmd() *cobra.Command {
 // newMembersRemoveCmd creates the "members remove" subcommand.
 func newMembersRemoveCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "remove MEMBER",
-		Short: "Remove a member from a space",
-		Long:  "Remove a member from a Google Chat space. MEMBER is the full resource name (e.g. spaces/XXXX/members/YYYY).",
-		Args:  cobra.ExactArgs(1),
+		Use:     "remove MEMBER",
+		Aliases: []string{"delete"},
+		Short:   "Remove a member from a space",
+		Long:    "Remove a member from a Google Chat space. MEMBER is the full resource name (e.g. spaces/XXXX/members/YYYY); use spaces/XXXX/members/me for your own membership.",
+		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
 			if err != nil {
@@ -395,28 +492,38 @@ func newMembersRemoveCmd() *cobra.Command {
 			f := getFormatter()
 			svc := api.NewMembersService(client)
 
-			name := args[0]
+			name, err := resolveMemberName(cmd.Context(), client, args[0])
+			if err != nil {
+				return err
+			}
 			admin, _ := cmd.Flags().GetBool("admin")
-			force, _ := cmd.Flags().GetBool("force")
+			if err := newSpaceGuard(cmd, client).check(cmd.Context(), name); err != nil {
+				return err
+			}
 
-			if !force {
-				fmt.Fprintf(os.Stderr, "Remove member %s? [y/N]: ", name)
-				reader := bufio.NewReader(os.Stdin)
-				answer, err := reader.ReadString('\n')
+			if !skipConfirm(cmd) {
+				summary, err := summarizeMembership(cmd.Context(), client, name, admin)
+				if err != nil {
+					return err
+				}
+				ok, err := confirm("Remove this member from the space?", summary)
 				if err != nil {
-					return fmt.Errorf("reading confirmation: %w", err)
+					return err
 				}
-				answer = strings.TrimSpace(answer)
-				if answer != "y" && answer != "Y" {
+				if !ok {
 					fmt.Fprintln(os.Stderr, "Cancelled.")
 					return nil
 				}
 			}
 
+			recorder := newUndoRecorder(cmd)
+			saved := recorder.snapshot(cmd.Context(), client, undo.KindMembership, name, admin)
 			result, err := svc.Delete(cmd.Context(), name, admin)
 			if err != nil {
 				return fmt.Errorf("removing member: %w", err)
 			}
+			recorder.add(undo.KindMembership, name, saved)
+			recorder.save()
 
 			if f.IsJSON() {
 				return f.PrintRaw(result)
@@ -427,7 +534,8 @@ func newMembersRemoveCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().Bool("force", false, "Skip confirmation prompt")
+	addConfirmFlags(cmd)
+	addProtectionFlag(cmd)
 
 	return cmd
 }
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// allUsersMention is the user named by an @all mention.
+const allUsersMention = "users/all"
+
+// NewMentionsCmd creates the top-level "mentions" command.
+func NewMentionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mentions",
+		Short: "List recent messages that mention you",
+		Long: `List the messages that @-mention you across your spaces since --since,
+newest first, with the space, sender, text, and a link to each message.
+
+Chat has no mention feed, so every message in the period is scanned for
+mention annotations of your user ID: this reads all your spaces unless
+--spaces narrows them down. --include-all also lists @all mentions. At most
+limits.max_fetch messages (default 5000) are read per space unless --all
+is given.`,
+		Args: cobra.NoArgs,
+		RunE: runMentions,
+	}
+
+	cmd.Flags().String("since", "7d", `Only include messages created after this time (e.g. "7d", "last monday", RFC 3339)`)
+	cmd.Flags().StringSlice("spaces", nil, "Spaces to scan (comma-separated; default all your spaces)")
+	cmd.Flags().Bool("include-all", false, "Also list @all mentions")
+	addFetchAllFlag(cmd)
+
+	return cmd
+}
+
+// mention is a message that mentions the caller.
+type mention struct {
+	Space       string `json:"space"`
+	DisplayName string `json:"displayName"`
+	Message     string `json:"message"`
+	Sender      string `json:"sender"`
+	Text        string `json:"text"`
+	CreateTime  string `json:"createTime"`
+	Link        string `json:"link"`
+}
+
+func runMentions(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	enableBulkRetries(client)
+	f := getFormatter()
+	ctx := cmd.Context()
+
+	spaceArgs, _ := cmd.Flags().GetStringSlice("spaces")
+	includeAll, _ := cmd.Flags().GetBool("include-all")
+	since, err := parseTimeFlag(cmd, "since")
+	if err != nil {
+		return err
+	}
+	if since.IsZero() {
+		return fmt.Errorf("--since is required")
+	}
+
+	me, err := currentUser(ctx, client)
+	if err != nil {
+		return err
+	}
+	names, err := digestSpaces(ctx, client, spaceArgs)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	mentions := []mention{}
+	svc := api.NewMessagesService(client)
+	filter := fmt.Sprintf("createTime > %q", formatFilterTime(since))
+	quiet := output.NewFormatter(false, true)
+	limit := fetchLimit(cmd)
+	summary := runBulk(ctx, quiet, names.order, func(ctx context.Context, space string) (string, error) {
+		raws, more, err := listMessagesUpTo(ctx, svc, space, filter, "", limit)
+		if err != nil {
+			return "", err
+		}
+		if more {
+			warnFetchLimit(space, limit)
+		}
+		displayName := names.display[space]
+		if displayName == "" {
+			displayName = space
+		}
+
+		for _, raw := range raws {
+			var msg digestMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				continue
+			}
+			if !msg.mentions(me) && !(includeAll && msg.mentions(allUsersMention)) {
+				continue
+			}
+			mu.Lock()
+			mentions = append(mentions, mention{
+				Space:       space,
+				DisplayName: displayName,
+				Message:     msg.Name,
+				Sender:      msg.senderName(),
+				Text:        msg.Text,
+				CreateTime:  msg.CreateTime,
+				Link:        messageLink(msg.Name, msg.Thread.Name),
+			})
+			mu.Unlock()
+		}
+		return space, nil
+	})
+	if summary.Succeeded == 0 && summary.Total > 0 {
+		return fmt.Errorf("could not read any space: %s", summary.firstError())
+	}
+
+	sort.SliceStable(mentions, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339Nano, mentions[i].CreateTime)
+		tj, _ := time.Parse(time.RFC3339Nano, mentions[j].CreateTime)
+		return ti.After(tj)
+	})
+
+	if f.IsJSON() {
+		return printList(f, mentions, "")
+	}
+	if len(mentions) == 0 {
+		f.PrintMessage(fmt.Sprintf("No mentions since %s.", output.FormatTime(formatFilterTime(since))))
+		return nil
+	}
+	for _, m := range mentions {
+		fmt.Printf("%s  %s  %s: %s\n", output.FormatTime(m.CreateTime), m.DisplayName, m.Sender, snippet(m.Text))
+		fmt.Printf("  %s\n", m.Link)
+	}
+	return nil
+}
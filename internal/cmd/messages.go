@@ -1,16 +1,19 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
 	"github.com/cipher-shad0w/gogchat/internal/api"
 	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/cipher-shad0w/gogchat/internal/undo"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 // NewMessagesCmd returns the top-level "messages" command with all subcommands.
@@ -19,7 +22,7 @@ func NewMessagesCmd() *cobra.Command {
 		Use:     "messages",
 		Aliases: []string{"msg"},
 		Short:   "Manage messages in Google Chat spaces",
-		Long:    "List, get, send, update, replace, and delete messages in Google Chat spaces.",
+		Long:    "List, get, send, update, replace, delete, and tail messages in Google Chat spaces.",
 	}
 
 	cmd.AddCommand(
@@ -29,6 +32,8 @@ func NewMessagesCmd() *cobra.Command {
 		newMessagesUpdateCmd(),
 		newMessagesDeleteCmd(),
 		newMessagesReplaceCmd(),
+		newMessagesTailCmd(),
+		newMessagesDecryptCmd(),
 	)
 
 	return cmd
@@ -51,9 +56,12 @@ func newMessagesListCmd() *cobra.Command {
 	flags.Int("page-size", 25, "Maximum number of messages to return per page")
 	flags.String("page-token", "", "Token for retrieving the next page of results")
 	flags.String("filter", "", "Filter expression for messages")
-	flags.String("order-by", "", "Order results (e.g. 'createTime desc')")
 	flags.Bool("show-deleted", false, "Include deleted messages in results")
 	flags.Bool("all", false, "Auto-paginate through all results")
+	addTimeRangeFlags(cmd)
+	addSortFlags(cmd, messageSortFields)
+	addRenderFlag(cmd)
+	flags.String("translate", "", "Show message text translated into this language (e.g. en) beside the original")
 
 	return cmd
 }
@@ -68,12 +76,28 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
 	parent := args[0]
-	pageSize, _ := cmd.Flags().GetInt("page-size")
+	pageSize := getPageSize(cmd)
 	pageToken, _ := cmd.Flags().GetString("page-token")
 	filter, _ := cmd.Flags().GetString("filter")
-	orderBy, _ := cmd.Flags().GetString("order-by")
 	showDeleted, _ := cmd.Flags().GetBool("show-deleted")
 	all, _ := cmd.Flags().GetBool("all")
+	translateTo, _ := cmd.Flags().GetString("translate")
+
+	var translator *api.TranslateService
+	if translateTo != "" {
+		if translator, err = newTranslateService(client); err != nil {
+			return err
+		}
+	}
+
+	filter, err = timeRangeFilter(cmd, filter, "createTime")
+	if err != nil {
+		return err
+	}
+	orderBy, err := resolveOrderBy(cmd, messageSortFields)
+	if err != nil {
+		return err
+	}
 
 	// Collect all pages when --all is set, otherwise fetch a single page.
 	var allMessages []json.RawMessage
@@ -84,10 +108,6 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("listing messages: %w", err)
 		}
 
-		if f.IsJSON() && !all {
-			return f.PrintRaw(raw)
-		}
-
 		var resp struct {
 			Messages      []json.RawMessage `json:"messages"`
 			NextPageToken string            `json:"nextPageToken"`
@@ -105,11 +125,20 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 		pageToken = resp.NextPageToken
 	}
 
-	// JSON mode with --all: emit aggregated result.
+	var translations []translation
+	if translator != nil {
+		if translations, err = translateMessages(ctx, translator, allMessages, translateTo); err != nil {
+			return err
+		}
+	}
+
 	if f.IsJSON() {
-		return f.Print(map[string]interface{}{
-			"messages": allMessages,
-		})
+		if translator != nil {
+			if allMessages, err = withTranslations(allMessages, translations); err != nil {
+				return err
+			}
+		}
+		return printList(f, allMessages, pageToken)
 	}
 
 	if len(allMessages) == 0 {
@@ -117,9 +146,13 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	render := shouldRender(cmd)
 	table := output.NewTable("NAME", "SENDER", "TEXT", "CREATE_TIME")
+	if translator != nil {
+		table = output.NewTable("NAME", "SENDER", "TEXT", "TRANSLATION", "CREATE_TIME")
+	}
 
-	for _, raw := range allMessages {
+	for i, raw := range allMessages {
 		var msg struct {
 			Name       string `json:"name"`
 			Text       string `json:"text"`
@@ -138,6 +171,28 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 			sender = msg.Sender.Name
 		}
 
+		// Rendered markdown spans several lines, so it is printed as a
+		// transcript instead of a table row.
+		if render {
+			f.PrintMessage(fmt.Sprintf("%s  %s  (%s)", output.FormatTime(msg.CreateTime), sender, msg.Name))
+			f.PrintMessage(output.RenderMarkdown(msg.Text))
+			if t := shownTranslation(translations, i); t != "" {
+				f.PrintMessage(fmt.Sprintf("[%s] %s", translateTo, t))
+			}
+			f.PrintMessage("")
+			continue
+		}
+
+		if translator != nil {
+			table.AddRow(
+				msg.Name,
+				sender,
+				output.Truncate(msg.Text, 40),
+				output.Truncate(shownTranslation(translations, i), 40),
+				output.FormatTime(msg.CreateTime),
+			)
+			continue
+		}
 		table.AddRow(
 			msg.Name,
 			sender,
@@ -146,7 +201,9 @@ func runMessagesList(cmd *cobra.Command, args []string) error {
 		)
 	}
 
-	f.PrintMessage(table.Render())
+	if !render {
+		f.PrintMessage(table.Render())
+	}
 	return nil
 }
 
@@ -163,6 +220,8 @@ func newMessagesGetCmd() *cobra.Command {
 		RunE:  runMessagesGet,
 	}
 
+	addRenderFlag(cmd)
+
 	return cmd
 }
 
@@ -207,10 +266,16 @@ func runMessagesGet(cmd *cobra.Command, args []string) error {
 
 	f.PrintMessage(fmt.Sprintf("Name:             %s", msg.Name))
 	f.PrintMessage(fmt.Sprintf("Sender:           %s", sender))
-	f.PrintMessage(fmt.Sprintf("Text:             %s", msg.Text))
+	if !shouldRender(cmd) {
+		f.PrintMessage(fmt.Sprintf("Text:             %s", msg.Text))
+	}
 	f.PrintMessage(fmt.Sprintf("Create Time:      %s", output.FormatTime(msg.CreateTime)))
 	f.PrintMessage(fmt.Sprintf("Last Update Time: %s", output.FormatTime(msg.LastUpdateTime)))
 	f.PrintMessage(fmt.Sprintf("Thread Name:      %s", msg.Thread.Name))
+	if shouldRender(cmd) {
+		f.PrintMessage("Text:")
+		f.PrintMessage(output.RenderMarkdown(msg.Text))
+	}
 
 	return nil
 }
@@ -221,20 +286,64 @@ func runMessagesGet(cmd *cobra.Command, args []string) error {
 
 func newMessagesSendCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "send SPACE",
-		Short: "Send a message to a space",
-		Long:  "Send a new message to a Google Chat space. SPACE can be a space ID or full resource name.",
-		Args:  cobra.ExactArgs(1),
-		RunE:  runMessagesSend,
+		Use:     "send SPACE",
+		Aliases: []string{"create"},
+		Short:   "Send a message to a space",
+		Long: `Send a new message to a Google Chat space. SPACE can be a space ID or full resource name.
+
+Use --accessory-button LABEL=URL (repeatable) to attach accessory buttons
+that open a link. Accessory widgets are only accepted for messages sent
+with app authentication.
+
+Use --private-to together with --as-app to send a private message that
+only the given user can see.
+
+Use --drive-file (repeatable) to attach Google Drive files by ID or URL.
+Add --drive-share-members to give the human members of the space read
+access to the attached files, so that they can open them. Access is granted
+to each member by email address, looked up in the directory; members added
+through a Google Group are not included. Sharing needs the drive scope (or
+drive.file for files that gogchat uploaded); log in with --scopes drive.
+
+Use --translate-to LANG[,LANG...] to follow the message with a translated
+copy per language, posted as replies in the message's thread. Translation
+uses the Cloud Translation API with the translation_api_key config key.
+
+With --dedup-window (or the dedup_window config key), a message identical
+to one already sent to the same space (and thread key) within the window
+is skipped, e.g. when an alerting pipeline retries. Skipping is not an
+error.
+
+The thread a --thread-key message lands in is remembered (see "threads
+keys list"), and later messages with the same key in the same space are
+sent to it by name. Unless --reply-option is given, a message whose thread
+no longer exists starts a new one.
+
+Use --encrypt-for FILE to share a secret: the text is encrypted locally
+with age (https://age-encryption.org, which must be installed) to the
+recipients listed in FILE, one public key per line, and posted as a code
+block of ciphertext. Recipients read it with "messages decrypt".
+
+Sent messages are logged locally so they can be sent again (see "sent
+list" and "sent resend").`,
+		Args: cobra.ExactArgs(1),
+		RunE: runMessagesSend,
 	}
 
 	flags := cmd.Flags()
 	flags.String("text", "", "Message text content (required)")
-	flags.String("thread-key", "", "Thread key for threading messages")
+	flags.String("thread-key", "", "Thread key for threading messages; the thread is remembered for later messages with the key")
 	flags.String("request-id", "", "Unique request ID for idempotency")
 	flags.String("message-id", "", "Custom message ID")
 	flags.String("reply-option", "", "Reply option (REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD or REPLY_MESSAGE_OR_FAIL)")
-	_ = cmd.MarkFlagRequired("text")
+	flags.StringArray("accessory-button", nil, "Accessory button as LABEL=URL (repeatable, app auth only)")
+	flags.String("private-to", "", "Only show the message to this user (email or users/ID, requires --as-app)")
+	flags.StringArray("drive-file", nil, "Attach a Google Drive file by ID or URL (repeatable)")
+	flags.Bool("drive-share-members", false, "Grant the human members of the space read access to the attached Drive files")
+	flags.StringSlice("translate-to", nil, "Also send a translated copy in these languages (e.g. fr,de) as replies in the thread")
+	flags.String("encrypt-for", "", "Encrypt the text with age to the public keys in this file")
+	addBodyFlag(cmd)
+	addDedupFlag(cmd)
 
 	return cmd
 }
@@ -247,22 +356,93 @@ func runMessagesSend(cmd *cobra.Command, args []string) error {
 	f := getFormatter()
 	svc := api.NewMessagesService(client)
 
-	text, _ := cmd.Flags().GetString("text")
 	threadKey, _ := cmd.Flags().GetString("thread-key")
 	requestID, _ := cmd.Flags().GetString("request-id")
 	messageID, _ := cmd.Flags().GetString("message-id")
 	replyOption, _ := cmd.Flags().GetString("reply-option")
+	translateTo, _ := cmd.Flags().GetStringSlice("translate-to")
+	encryptFor, _ := cmd.Flags().GetString("encrypt-for")
+	if encryptFor != "" && len(translateTo) > 0 {
+		return fmt.Errorf("--translate-to cannot be combined with --encrypt-for")
+	}
 
-	body := map[string]interface{}{
-		"text": text,
+	body, err := readBody(cmd)
+	if err != nil {
+		return err
+	}
+
+	if body == nil {
+		if body, err = buildSendBody(cmd, client, args[0]); err != nil {
+			return err
+		}
 	}
 
-	raw, err := svc.Create(context.Background(), args[0], body, threadKey, requestID, messageID, replyOption)
+	if encryptFor != "" {
+		text, _ := body["text"].(string)
+		if text == "" {
+			return fmt.Errorf("--encrypt-for needs a message with text")
+		}
+		if body["text"], err = encryptText(cmd.Context(), text, expandHome(encryptFor)); err != nil {
+			return err
+		}
+	}
+
+	key := threadKey
+	threadKey, replyOption = applyThreadKey(args[0], key, body, replyOption)
+
+	// Translate before sending so that a translation failure does not
+	// leave the original posted without its copies.
+	var translations []translation
+	if len(translateTo) > 0 {
+		text, _ := body["text"].(string)
+		if text == "" {
+			return fmt.Errorf("--translate-to needs a message with text")
+		}
+		translator, err := newTranslateService(client)
+		if err != nil {
+			return err
+		}
+		for _, lang := range translateTo {
+			t, err := translateTexts(cmd.Context(), translator, []string{text}, lang)
+			if err != nil {
+				return err
+			}
+			translations = append(translations, t[0])
+		}
+	}
+
+	raw, dup, err := postOnce(messageLedger(cmd), args[0], body, threadKey, func() (json.RawMessage, error) {
+		return svc.Create(context.Background(), args[0], body, threadKey, requestID, messageID, replyOption)
+	})
+	if dup == nil {
+		recordSent(args[0], key, body, raw, err)
+	}
 	if err != nil {
 		return fmt.Errorf("sending message: %w", err)
 	}
+	if dup != nil {
+		if f.IsJSON() {
+			return f.Print(dup)
+		}
+		f.PrintMessage(dup.String())
+		return nil
+	}
+	recordThreadKey(args[0], key, raw)
+
+	var copies []json.RawMessage
+	if len(translations) > 0 {
+		if copies, err = sendTranslatedCopies(cmd.Context(), svc, args[0], raw, translations); err != nil {
+			return err
+		}
+	}
 
 	if f.IsJSON() {
+		if len(translations) > 0 {
+			return f.Print(map[string]interface{}{
+				"message":      raw,
+				"translations": copies,
+			})
+		}
 		return f.PrintRaw(raw)
 	}
 
@@ -295,10 +475,238 @@ func runMessagesSend(cmd *cobra.Command, args []string) error {
 	if msg.Thread.Name != "" {
 		f.PrintMessage(fmt.Sprintf("Thread:      %s", msg.Thread.Name))
 	}
+	for i, t := range translations {
+		f.PrintMessage(fmt.Sprintf("Translation: [%s] %s (%s)", t.Language, output.Truncate(t.Text, 60), jsonField(copies[i], "name")))
+	}
+
+	return nil
+}
+
+// buildSendBody builds the message body for "messages send" to space from
+// its flags.
+func buildSendBody(cmd *cobra.Command, client *api.Client, space string) (map[string]interface{}, error) {
+	if err := requireFlags(cmd, "text"); err != nil {
+		return nil, err
+	}
+
+	text, _ := cmd.Flags().GetString("text")
+	accessoryButtons, _ := cmd.Flags().GetStringArray("accessory-button")
+	privateTo, _ := cmd.Flags().GetString("private-to")
+	driveFiles, _ := cmd.Flags().GetStringArray("drive-file")
+	driveShareMembers, _ := cmd.Flags().GetBool("drive-share-members")
+
+	body := map[string]interface{}{
+		"text": text,
+	}
+
+	if len(accessoryButtons) > 0 {
+		widgets, err := buildAccessoryWidgets(accessoryButtons)
+		if err != nil {
+			return nil, err
+		}
+		body["accessoryWidgets"] = widgets
+	}
+
+	if privateTo != "" {
+		if !viper.GetBool("as_app") {
+			return nil, fmt.Errorf("--private-to requires app authentication; add --as-app")
+		}
+		body["privateMessageViewer"] = map[string]interface{}{
+			"name": api.NormalizeName(privateTo, "users/"),
+		}
+	}
+
+	if len(driveFiles) > 0 {
+		attachments, fileIDs, err := buildDriveAttachments(driveFiles)
+		if err != nil {
+			return nil, err
+		}
+		if driveShareMembers {
+			if err := shareDriveFiles(cmd.Context(), client, space, fileIDs); err != nil {
+				return nil, err
+			}
+		}
+		body["attachment"] = attachments
+	} else if driveShareMembers {
+		return nil, fmt.Errorf("--drive-share-members requires at least one --drive-file")
+	}
+
+	return body, nil
+}
+
+// buildAccessoryWidgets converts LABEL=URL pairs into the accessoryWidgets
+// payload: a single button list whose buttons open the given links.
+func buildAccessoryWidgets(specs []string) ([]interface{}, error) {
+	buttons := make([]interface{}, 0, len(specs))
+	for _, spec := range specs {
+		label, link, ok := strings.Cut(spec, "=")
+		label = strings.TrimSpace(label)
+		link = strings.TrimSpace(link)
+		if !ok || label == "" || link == "" {
+			return nil, fmt.Errorf("invalid --accessory-button %q; expected LABEL=URL", spec)
+		}
+		buttons = append(buttons, map[string]interface{}{
+			"text": label,
+			"onClick": map[string]interface{}{
+				"openLink": map[string]interface{}{
+					"url": link,
+				},
+			},
+		})
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"buttonList": map[string]interface{}{
+				"buttons": buttons,
+			},
+		},
+	}, nil
+}
+
+// buildDriveAttachments resolves Drive file references into message
+// attachments and returns them with the file IDs.
+func buildDriveAttachments(refs []string) ([]interface{}, []string, error) {
+	attachments := make([]interface{}, 0, len(refs))
+	fileIDs := make([]string, 0, len(refs))
+
+	for _, ref := range refs {
+		fileID, err := api.ParseDriveFileID(ref)
+		if err != nil {
+			return nil, nil, err
+		}
+		fileIDs = append(fileIDs, fileID)
+		attachments = append(attachments, map[string]interface{}{
+			"driveDataRef": map[string]interface{}{
+				"driveFileId": fileID,
+			},
+		})
+	}
+
+	return attachments, fileIDs, nil
+}
+
+// shareDriveFiles grants the human members of space read access to the
+// Drive files, by the email addresses of the members in the directory.
+// Members whose address cannot be found, or who cannot be granted access
+// (e.g. outside the domain, if the domain forbids it), are reported on
+// stderr and left out.
+func shareDriveFiles(ctx context.Context, client *api.Client, space string, fileIDs []string) error {
+	if err := requireFeatureScopes(ctx, "messages send --drive-share-members"); err != nil {
+		return err
+	}
+	space = api.NormalizeName(space, "spaces/")
+	drive := api.NewDriveService(client)
+
+	// Check that the files can be shared before looking up the members.
+	for _, fileID := range fileIDs {
+		if _, err := drive.Get(ctx, fileID); err != nil {
+			return driveError("sharing", fileID, err)
+		}
+	}
 
+	members, err := humanMembers(ctx, client, space, false)
+	if err != nil {
+		return err
+	}
+	me, _ := currentUser(ctx, client)
+	users := make([]string, 0, len(members))
+	for _, m := range members {
+		if m.Member != me {
+			users = append(users, m.Member)
+		}
+	}
+	emails, err := userEmails(ctx, client, users)
+	if err != nil {
+		return err
+	}
+	if missing := len(users) - len(emails); missing > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ %d %s of %s without an email address in the directory %s not given access.\n",
+			missing, plural(missing, "member", "members"), space, plural(missing, "was", "were"))
+	}
+
+	var grants []string
+	for _, fileID := range fileIDs {
+		for _, email := range emails {
+			grants = append(grants, fileID+" "+email)
+		}
+	}
+	summary := runBulk(ctx, output.NewFormatter(false, true), grants, func(ctx context.Context, grant string) (string, error) {
+		fileID, email, _ := strings.Cut(grant, " ")
+		permission := map[string]interface{}{
+			"type":         "user",
+			"role":         "reader",
+			"emailAddress": email,
+		}
+		if _, err := drive.CreatePermission(ctx, fileID, permission); err != nil {
+			return "", err
+		}
+		return "", nil
+	})
+	if summary.Failed > 0 || summary.Skipped > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ %d of %d Drive %s could not be granted: %s\n",
+			summary.Failed+summary.Skipped, summary.Total, plural(summary.Total, "permission", "permissions"), summary.firstError())
+	}
 	return nil
 }
 
+// userEmails returns the email addresses of users (users/{id}) in the
+// directory, keyed by user. Users without an address are left out.
+func userEmails(ctx context.Context, client *api.Client, users []string) (map[string]string, error) {
+	people := api.NewPeopleService(client)
+	emails := make(map[string]string, len(users))
+	for start := 0; start < len(users); start += 200 {
+		names := make([]string, 0, 200)
+		for _, user := range users[start:min(start+200, len(users))] {
+			names = append(names, "people/"+strings.TrimPrefix(user, "users/"))
+		}
+		raw, err := people.BatchGet(ctx, names, "emailAddresses")
+		if err != nil {
+			return nil, directoryError(fmt.Errorf("looking up member email addresses: %w", err))
+		}
+		var resp struct {
+			Responses []struct {
+				Person struct {
+					ResourceName   string `json:"resourceName"`
+					EmailAddresses []struct {
+						Value    string `json:"value"`
+						Metadata struct {
+							Primary bool `json:"primary"`
+						} `json:"metadata"`
+					} `json:"emailAddresses"`
+				} `json:"person"`
+			} `json:"responses"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, r := range resp.Responses {
+			user := "users/" + strings.TrimPrefix(r.Person.ResourceName, "people/")
+			for _, e := range r.Person.EmailAddresses {
+				if _, ok := emails[user]; !ok || e.Metadata.Primary {
+					emails[user] = e.Value
+				}
+			}
+		}
+	}
+	return emails, nil
+}
+
+// driveError wraps an error of the Drive API about fileID with a hint when
+// the file cannot be reached: the drive.file scope only covers the files
+// that gogchat created, so other files need the drive scope.
+func driveError(action, fileID string, err error) error {
+	err = fmt.Errorf("%s Drive file %s: %w", action, fileID, err)
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || (apiErr.Code != http.StatusForbidden && apiErr.Code != http.StatusNotFound) {
+		return err
+	}
+	return &hintError{err: err, hint: fmt.Sprintf(`The file does not exist, or this login cannot reach it. The drive.file scope
+only covers files that gogchat uploaded (media upload --as-drive); to share
+other files you have access to, log in again with:
+  %s --scopes drive`, loginCommand())}
+}
+
 // ---------------------------------------------------------------------------
 // messages update (PATCH)
 // ---------------------------------------------------------------------------
@@ -314,9 +722,9 @@ func newMessagesUpdateCmd() *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.String("text", "", "New message text (required)")
-	flags.String("update-mask", "text", "Comma-separated list of fields to update")
 	flags.Bool("allow-missing", false, "Allow updating a message that may not exist yet")
-	_ = cmd.MarkFlagRequired("text")
+	addBodyFlag(cmd)
+	addMaskFlag(cmd)
 
 	return cmd
 }
@@ -330,11 +738,25 @@ func runMessagesUpdate(cmd *cobra.Command, args []string) error {
 	svc := api.NewMessagesService(client)
 
 	text, _ := cmd.Flags().GetString("text")
-	updateMask, _ := cmd.Flags().GetString("update-mask")
 	allowMissing, _ := cmd.Flags().GetBool("allow-missing")
 
-	body := map[string]interface{}{
-		"text": text,
+	body, err := readBody(cmd)
+	if err != nil {
+		return err
+	}
+
+	if body == nil {
+		if err := requireFlags(cmd, "text"); err != nil {
+			return err
+		}
+		body = map[string]interface{}{
+			"text": text,
+		}
+	}
+
+	updateMask, err := resolveUpdateMask(cmd, body)
+	if err != nil {
+		return err
 	}
 
 	raw, err := svc.Patch(context.Background(), args[0], body, updateMask, allowMissing)
@@ -369,15 +791,26 @@ func runMessagesUpdate(cmd *cobra.Command, args []string) error {
 
 func newMessagesDeleteCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "delete MESSAGE",
-		Short: "Delete a message",
-		Long:  "Delete a message. MESSAGE must be the full resource name (spaces/{space}/messages/{message}).",
-		Args:  cobra.ExactArgs(1),
-		RunE:  runMessagesDelete,
+		Use:   "delete MESSAGE...",
+		Short: "Delete one or more messages",
+		Long: `Delete one or more messages. Each MESSAGE must be the full resource name (spaces/{space}/messages/{message}).
+
+Without MESSAGE, the latest messages of --space are listed on the terminal
+to pick from by number (e.g. 3 or 1,4-6).
+
+When several messages are given, they are deleted in parallel (see
+--concurrency) and a summary is printed at the end. Rate-limit and server
+errors are retried from a shared budget; after sustained failures the
+command pauses before continuing instead of retrying every message.
+Deleting more messages than limits.max_delete (default 100) requires --yes.`,
+		Args: messageOrSpaceArgs,
+		RunE: runMessagesDelete,
 	}
 
 	flags := cmd.Flags()
-	flags.Bool("force", false, "Skip confirmation prompt")
+	addConfirmFlags(cmd)
+	addProtectionFlag(cmd)
+	addPickFlags(cmd)
 	flags.Bool("force-threads", false, "Also delete threaded replies (API force parameter)")
 
 	return cmd
@@ -391,33 +824,86 @@ func runMessagesDelete(cmd *cobra.Command, args []string) error {
 	f := getFormatter()
 	svc := api.NewMessagesService(client)
 
-	force, _ := cmd.Flags().GetBool("force")
 	forceThreads, _ := cmd.Flags().GetBool("force-threads")
-	name := args[0]
-
-	// Confirmation prompt unless --force is set.
-	if !force {
-		fmt.Fprintf(os.Stderr, "Delete message %s? [y/N] ", name)
-		reader := bufio.NewReader(os.Stdin)
-		answer, _ := reader.ReadString('\n')
-		answer = strings.TrimSpace(strings.ToLower(answer))
-		if answer != "y" && answer != "yes" {
+
+	args, err = messageArgs(cmd, client, args)
+	if errors.Is(err, errNothingPicked) {
+		f.PrintMessage("Cancelled.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	guard := newSpaceGuard(cmd, client)
+	for _, name := range args {
+		if err := guard.check(context.Background(), name); err != nil {
+			return err
+		}
+	}
+	if err := checkDeleteLimit(cmd, len(args)); err != nil {
+		return err
+	}
+
+	// Confirmation prompt unless --yes or --force is set.
+	if !skipConfirm(cmd) {
+		var ok bool
+		if len(args) == 1 {
+			summary, err := summarizeMessage(context.Background(), client, args[0])
+			if err != nil {
+				return err
+			}
+			ok, err = confirm("Delete this message?", summary)
+			if err != nil {
+				return err
+			}
+		} else {
+			rows, err := previewMessages(context.Background(), client, args)
+			if err != nil {
+				return err
+			}
+			ok, err = confirmPreview(fmt.Sprintf("Delete these %d messages?", len(args)),
+				[]string{"MESSAGE", "SENDER", "TIME", "TEXT"}, rows)
+			if err != nil {
+				return err
+			}
+		}
+		if !ok {
 			f.PrintMessage("Cancelled.")
 			return nil
 		}
 	}
 
-	raw, err := svc.Delete(context.Background(), name, forceThreads)
-	if err != nil {
-		return fmt.Errorf("deleting message: %w", err)
-	}
+	recorder := newUndoRecorder(cmd)
+	defer recorder.save()
 
-	if f.IsJSON() {
-		return f.PrintRaw(raw)
+	if len(args) == 1 {
+		saved := recorder.snapshot(context.Background(), client, undo.KindMessage, args[0], false)
+		raw, err := svc.Delete(context.Background(), args[0], forceThreads)
+		if err != nil {
+			return fmt.Errorf("deleting message: %w", err)
+		}
+		recorder.add(undo.KindMessage, args[0], saved)
+
+		if f.IsJSON() {
+			return f.PrintRaw(raw)
+		}
+
+		f.PrintSuccess(fmt.Sprintf("Message %s deleted.", args[0]))
+		return nil
 	}
 
-	f.PrintSuccess(fmt.Sprintf("Message %s deleted.", name))
-	return nil
+	enableBulkRetries(client)
+
+	summary := runBulk(context.Background(), f, args, func(ctx context.Context, name string) (string, error) {
+		saved := recorder.snapshot(ctx, client, undo.KindMessage, name, false)
+		if _, err := svc.Delete(ctx, name, forceThreads); err != nil {
+			return "", err
+		}
+		recorder.add(undo.KindMessage, name, saved)
+		return fmt.Sprintf("Message %s deleted.", name), nil
+	})
+	return finishBulk(f, summary, "messages")
 }
 
 // ---------------------------------------------------------------------------
@@ -435,9 +921,9 @@ func newMessagesReplaceCmd() *cobra.Command {
 
 	flags := cmd.Flags()
 	flags.String("text", "", "New message text (required)")
-	flags.String("update-mask", "", "Comma-separated list of fields to update")
 	flags.Bool("allow-missing", false, "Allow replacing a message that may not exist yet")
-	_ = cmd.MarkFlagRequired("text")
+	addBodyFlag(cmd)
+	addMaskFlag(cmd)
 
 	return cmd
 }
@@ -451,11 +937,25 @@ func runMessagesReplace(cmd *cobra.Command, args []string) error {
 	svc := api.NewMessagesService(client)
 
 	text, _ := cmd.Flags().GetString("text")
-	updateMask, _ := cmd.Flags().GetString("update-mask")
 	allowMissing, _ := cmd.Flags().GetBool("allow-missing")
 
-	body := map[string]interface{}{
-		"text": text,
+	body, err := readBody(cmd)
+	if err != nil {
+		return err
+	}
+
+	if body == nil {
+		if err := requireFlags(cmd, "text"); err != nil {
+			return err
+		}
+		body = map[string]interface{}{
+			"text": text,
+		}
+	}
+
+	updateMask, err := resolveUpdateMask(cmd, body)
+	if err != nil {
+		return err
 	}
 
 	raw, err := svc.Update(context.Background(), args[0], body, updateMask, allowMissing)
@@ -483,3 +983,43 @@ func runMessagesReplace(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// listAllMessages returns the messages in space matching filter, in orderBy
+// order, following all result pages. On error, the messages fetched so far
+// are returned along with it.
+func listAllMessages(ctx context.Context, svc *api.MessagesService, space, filter, orderBy string) ([]json.RawMessage, error) {
+	all, _, err := listMessagesUpTo(ctx, svc, space, filter, orderBy, 0)
+	return all, err
+}
+
+// listMessagesUpTo is listAllMessages, but stops after limit messages
+// (0 for no limit) and reports whether there were more.
+func listMessagesUpTo(ctx context.Context, svc *api.MessagesService, space, filter, orderBy string, limit int) ([]json.RawMessage, bool, error) {
+	var all []json.RawMessage
+	pageToken := ""
+	for {
+		pageSize := 1000
+		if limit > 0 {
+			pageSize = min(pageSize, limit-len(all))
+		}
+		raw, err := svc.List(ctx, space, pageSize, pageToken, filter, orderBy, false)
+		if err != nil {
+			return all, false, err
+		}
+		var resp struct {
+			Messages      []json.RawMessage `json:"messages"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return all, false, fmt.Errorf("parsing response: %w", err)
+		}
+		all = append(all, resp.Messages...)
+		if resp.NextPageToken == "" {
+			return all, false, nil
+		}
+		if limit > 0 && len(all) >= limit {
+			return all, true, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
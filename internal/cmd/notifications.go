@@ -3,7 +3,6 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
-	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -32,7 +31,7 @@ func newNotificationsGetCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get SETTING",
 		Short: "Get notification settings for a space",
-		Long:  "Retrieve the notification setting for a space. SETTING is the full resource name (users/{user}/spaces/{space}/spaceNotificationSetting).",
+		Long:  "Retrieve the notification setting for a space. SETTING is the full resource name (users/{user}/spaces/{space}/spaceNotificationSetting) or just the space (spaces/{space} or its ID), which is taken as users/me.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
@@ -42,7 +41,7 @@ func newNotificationsGetCmd() *cobra.Command {
 			formatter := getFormatter()
 			svc := api.NewNotificationsService(client)
 
-			name := args[0]
+			name := api.UserScopedName(args[0], "spaceNotificationSetting")
 
 			raw, err := svc.Get(cmd.Context(), name)
 			if err != nil {
@@ -79,11 +78,12 @@ func newNotificationsUpdateCmd() *cobra.Command {
 		Use:   "update SETTING",
 		Short: "Update notification settings for a space",
 		Long: `Update the notification setting for a space. SETTING is the full resource name
-(users/{user}/spaces/{space}/spaceNotificationSetting).
+(users/{user}/spaces/{space}/spaceNotificationSetting) or just the space
+(spaces/{space} or its ID), which is taken as users/me.
 
 Provide --notification-setting and/or --mute-setting flags to update. The
-update mask is auto-built from the flags that are set, unless --update-mask
-is explicitly provided.`,
+update mask is auto-built from the fields that are set, unless --mask is
+explicitly provided.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
@@ -93,30 +93,33 @@ is explicitly provided.`,
 			formatter := getFormatter()
 			svc := api.NewNotificationsService(client)
 
-			name := args[0]
+			name := api.UserScopedName(args[0], "spaceNotificationSetting")
 			notificationSetting, _ := cmd.Flags().GetString("notification-setting")
 			muteSetting, _ := cmd.Flags().GetString("mute-setting")
-			updateMask, _ := cmd.Flags().GetString("update-mask")
 
-			body := map[string]interface{}{}
-			var maskParts []string
-
-			if notificationSetting != "" {
-				body["notificationSetting"] = notificationSetting
-				maskParts = append(maskParts, "notificationSetting")
-			}
-			if muteSetting != "" {
-				body["muteSetting"] = muteSetting
-				maskParts = append(maskParts, "muteSetting")
+			body, err := readBody(cmd)
+			if err != nil {
+				return err
 			}
 
-			if len(body) == 0 {
-				return fmt.Errorf("at least one of --notification-setting or --mute-setting must be provided")
+			if body == nil {
+				body = map[string]interface{}{}
+				if notificationSetting != "" {
+					body["notificationSetting"] = notificationSetting
+				}
+				if muteSetting != "" {
+					body["muteSetting"] = muteSetting
+				}
+
+				if len(body) == 0 {
+					return fmt.Errorf("at least one of --notification-setting, --mute-setting, or --body must be provided")
+				}
 			}
 
-			// Auto-build update mask from set flags if not explicitly provided.
-			if updateMask == "" {
-				updateMask = strings.Join(maskParts, ",")
+			// Build the update mask from the fields being set unless --mask is given.
+			updateMask, err := resolveUpdateMask(cmd, body)
+			if err != nil {
+				return err
 			}
 
 			raw, err := svc.Patch(cmd.Context(), name, body, updateMask)
@@ -148,7 +151,8 @@ is explicitly provided.`,
 
 	cmd.Flags().String("notification-setting", "", "Notification setting (e.g. NOTIFICATION_SETTING_ALL, NOTIFICATION_SETTING_NONE)")
 	cmd.Flags().String("mute-setting", "", "Mute setting (e.g. MUTE_SETTING_MUTED, MUTE_SETTING_UNMUTED)")
-	cmd.Flags().String("update-mask", "", "Fields to update (auto-built from flags if not set)")
+	addBodyFlag(cmd)
+	addMaskFlag(cmd)
 
 	return cmd
 }
@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+)
+
+// NewNotifyCmd creates the top-level "notify" command.
+func NewNotifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Post notifications about other tools to a space",
+		Long:  "Post notifications about other tools, such as new git commits, to a space.",
+	}
+
+	cmd.AddCommand(
+		newNotifyGitCmd(),
+	)
+
+	return cmd
+}
+
+// gitCommit is one commit listed by "notify git".
+type gitCommit struct {
+	Hash        string
+	Short       string
+	Author      string
+	AuthorEmail string
+	Subject     string
+}
+
+// gitUpdate is the commits of one ref, e.g. one branch of a push.
+type gitUpdate struct {
+	ref     string
+	commits []gitCommit
+	// more is the number of commits left out past --max-commits.
+	more int
+}
+
+// newNotifyGitCmd creates the "notify git" subcommand.
+func newNotifyGitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "git",
+		Short: "Post new git commits to a space",
+		Long: `Post a summary of git commits to a space: one message listing each
+commit's subject, author, and a link to it.
+
+With --range, the commits of that range are posted. Otherwise the ref
+updates are read from stdin in the format of git's post-receive hook
+("OLD NEW REF" per line), so the command can be used as that hook as is; the commits of all updated refs go into one message, and
+deleted refs are skipped.
+
+Links are made with --link, a Go template with .Hash, .Short, .Author,
+.AuthorEmail, and .Subject. Without it, links to the commit pages are
+derived from the origin remote for GitHub, GitLab, and Bitbucket; other
+commits are listed without links.
+
+Messages go into one thread per repository (thread key git:REPO, see
+"threads keys"), so a channel shared by several repositories stays
+readable.`,
+		Example: `  gogchat notify git --space spaces/AAAABBBBcccc --range HEAD~5..HEAD
+  gogchat notify git --range v1.2.0..v1.3.0 --link 'https://git.example.com/app/commit/{{.Hash}}'
+
+  # hooks/post-receive of a bare repository
+  #!/bin/sh
+  exec gogchat notify git --space spaces/AAAABBBBcccc --name app`,
+		Args: cobra.NoArgs,
+		RunE: runNotifyGit,
+	}
+
+	flags := cmd.Flags()
+	flags.String("space", "", "Space to post to (default: default_space)")
+	flags.String("range", "", "Commit range to post, e.g. HEAD~5..HEAD (default: read ref updates from stdin)")
+	flags.String("repo", ".", "Path of the git repository")
+	flags.String("name", "", "Repository name shown in the message (default: the repository's directory name)")
+	flags.String("link", "", "Go template of commit links, e.g. 'https://example.com/commit/{{.Hash}}'")
+	flags.Int("max-commits", 20, "Maximum number of commits listed per ref; the rest are counted")
+	flags.String("thread-key", "", "Thread key of the message (default: git:REPO)")
+	flags.Bool("dry-run", false, "Print the message instead of posting it")
+
+	return cmd
+}
+
+func runNotifyGit(cmd *cobra.Command, args []string) error {
+	space, _ := cmd.Flags().GetString("space")
+	commitRange, _ := cmd.Flags().GetString("range")
+	repo, _ := cmd.Flags().GetString("repo")
+	name, _ := cmd.Flags().GetString("name")
+	linkFlag, _ := cmd.Flags().GetString("link")
+	maxCommits, _ := cmd.Flags().GetInt("max-commits")
+	threadKey, _ := cmd.Flags().GetString("thread-key")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	f := getFormatter()
+	ctx := cmd.Context()
+
+	if space == "" {
+		space = Cfg.DefaultSpace
+	}
+	if space == "" && !dryRun {
+		return fmt.Errorf("--space is required (or set default_space)")
+	}
+	if maxCommits < 1 {
+		return fmt.Errorf("--max-commits must be at least 1")
+	}
+
+	if name == "" {
+		dir, err := git(ctx, repo, "rev-parse", "--absolute-git-dir")
+		if err != nil {
+			return err
+		}
+		if filepath.Base(dir) == ".git" {
+			dir = filepath.Dir(dir)
+		}
+		name = strings.TrimSuffix(filepath.Base(dir), ".git")
+	}
+	link, err := commitLinkTemplate(ctx, repo, linkFlag)
+	if err != nil {
+		return err
+	}
+
+	var updates []gitUpdate
+	if commitRange != "" {
+		u, err := gitLog(ctx, repo, maxCommits, commitRange)
+		if err != nil {
+			return err
+		}
+		_, end, _ := strings.Cut(commitRange, "..")
+		end = strings.TrimPrefix(end, ".")
+		if end == "" {
+			end = "HEAD"
+		}
+		u.ref, _ = git(ctx, repo, "rev-parse", "--abbrev-ref", end)
+		if u.ref == "" || u.ref == "HEAD" {
+			u.ref = commitRange
+		}
+		updates = append(updates, u)
+	} else {
+		if isTerminal(os.Stdin) {
+			return fmt.Errorf("--range is required unless ref updates (OLD NEW REF) are piped on stdin, as in a post-receive hook")
+		}
+		if updates, err = readRefUpdates(ctx, repo, os.Stdin, maxCommits); err != nil {
+			return err
+		}
+	}
+
+	text, total, err := formatGitUpdates(name, updates, link)
+	if err != nil {
+		return err
+	}
+	if total == 0 {
+		f.PrintMessage("No new commits.")
+		return nil
+	}
+	if dryRun {
+		fmt.Println(text)
+		return nil
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	if threadKey == "" {
+		threadKey = "git:" + name
+	}
+	body := map[string]interface{}{"text": text}
+	key := threadKey
+	threadKey, replyOption := applyThreadKey(space, key, body, "")
+	raw, err := api.NewMessagesService(client).Create(ctx, space, body, threadKey, "", "", replyOption)
+	recordSent(space, key, body, raw, err)
+	if err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	recordThreadKey(space, key, raw)
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+	f.PrintSuccess(fmt.Sprintf("Posted %d %s of %s", total, plural(total, "commit", "commits"), name))
+	f.PrintMessage(fmt.Sprintf("Name:        %s", jsonField(raw, "name")))
+	return nil
+}
+
+// git runs git in repo and returns its trimmed output.
+func git(ctx context.Context, repo string, args ...string) (string, error) {
+	c := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
+	var stdout, stderr bytes.Buffer
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitLog lists the commits of revs, newest first, at most limit of them.
+func gitLog(ctx context.Context, repo string, limit int, revs ...string) (gitUpdate, error) {
+	revs = append(revs, "--")
+	count, err := git(ctx, repo, append([]string{"rev-list", "--count"}, revs...)...)
+	if err != nil {
+		return gitUpdate{}, err
+	}
+	logArgs := []string{"log", "--format=%H%x1f%h%x1f%an%x1f%ae%x1f%s%x1e", fmt.Sprintf("--max-count=%d", limit)}
+	out, err := git(ctx, repo, append(logArgs, revs...)...)
+	if err != nil {
+		return gitUpdate{}, err
+	}
+
+	var u gitUpdate
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.Split(strings.TrimSpace(record), "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		u.commits = append(u.commits, gitCommit{Hash: fields[0], Short: fields[1], Author: fields[2], AuthorEmail: fields[3], Subject: fields[4]})
+	}
+	var n int
+	fmt.Sscan(count, &n)
+	u.more = max(n-len(u.commits), 0)
+	return u, nil
+}
+
+// readRefUpdates reads "OLD NEW REF" lines as passed to git's post-receive
+// hook and lists the new commits of each updated ref.
+func readRefUpdates(ctx context.Context, repo string, r io.Reader, limit int) ([]gitUpdate, error) {
+	var updates []gitUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		oldRev, newRev, ref := fields[0], fields[1], fields[2]
+		if strings.Trim(newRev, "0") == "" {
+			continue // deleted
+		}
+		revs := []string{oldRev + ".." + newRev}
+		if strings.Trim(oldRev, "0") == "" {
+			// A new ref: only the commits no other ref has.
+			revs = []string{newRev, "--not", "--exclude=" + ref, "--all"}
+		}
+		u, err := gitLog(ctx, repo, limit, revs...)
+		if err != nil {
+			return nil, err
+		}
+		u.ref = strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/tags/")
+		updates = append(updates, u)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading ref updates: %w", err)
+	}
+	return updates, nil
+}
+
+// remoteCommitURLs match the origin URLs of well-known hosts, for the
+// default commit links.
+var remoteCommitURLs = []struct {
+	pattern *regexp.Regexp
+	link    string
+}{
+	{regexp.MustCompile(`^(?:https://|ssh://git@|git@)(github\.com|gitlab\.com)[:/](.+?)(?:\.git)?/?$`), "https://%s/%s/commit/{{.Hash}}"},
+	{regexp.MustCompile(`^(?:https://(?:[^@/]+@)?|ssh://git@|git@)(bitbucket\.org)[:/](.+?)(?:\.git)?/?$`), "https://%s/%s/commits/{{.Hash}}"},
+}
+
+// commitLinkTemplate parses the --link template or, without one, derives
+// a template from the origin remote. It returns nil if there is none.
+func commitLinkTemplate(ctx context.Context, repo, source string) (*template.Template, error) {
+	if source == "" {
+		origin, _ := git(ctx, repo, "remote", "get-url", "origin")
+		for _, r := range remoteCommitURLs {
+			if m := r.pattern.FindStringSubmatch(origin); m != nil {
+				source = fmt.Sprintf(r.link, m[1], m[2])
+				break
+			}
+		}
+		if source == "" {
+			return nil, nil
+		}
+	}
+	t, err := template.New("link").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --link: %w", err)
+	}
+	return t, nil
+}
+
+// formatGitUpdates formats the commits of updates as one message and
+// returns it with the number of commits.
+func formatGitUpdates(repo string, updates []gitUpdate, link *template.Template) (string, int, error) {
+	total := 0
+	var b strings.Builder
+	for _, u := range updates {
+		n := len(u.commits) + u.more
+		if n == 0 {
+			continue
+		}
+		total += n
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "*%s* `%s`: %d new %s\n", repo, u.ref, n, plural(n, "commit", "commits"))
+		for _, c := range u.commits {
+			short := "`" + c.Short + "`"
+			if link != nil {
+				var url strings.Builder
+				if err := link.Execute(&url, c); err != nil {
+					return "", 0, fmt.Errorf("invalid --link: %w", err)
+				}
+				short = fmt.Sprintf("<%s|%s>", url.String(), c.Short)
+			}
+			fmt.Fprintf(&b, "• %s %s (%s)\n", short, c.Subject, c.Author)
+		}
+		if u.more > 0 {
+			fmt.Fprintf(&b, "_... and %d more_\n", u.more)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), total, nil
+}
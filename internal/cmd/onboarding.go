@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/term"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/auth"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+)
+
+// setupSteps are the Google Cloud console pages needed to set up an OAuth
+// client for the Chat API, in order.
+var setupSteps = []struct{ step, url string }{
+	{"Create a Google Cloud project", "https://console.cloud.google.com/projectcreate"},
+	{"Enable the Google Chat API", "https://console.cloud.google.com/apis/library/chat.googleapis.com"},
+	{"Configure a Chat app (a name, avatar URL, and description are enough)", "https://console.cloud.google.com/apis/api/chat.googleapis.com/hangouts-chat"},
+	{"Set up the OAuth consent screen (add yourself as a test user)", "https://console.cloud.google.com/auth/overview"},
+	{`Create an OAuth client ID of type "Desktop app"`, "https://console.cloud.google.com/apis/credentials"},
+}
+
+// NewConfigCmd creates the top-level "config" command.
+func NewConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Create the config file",
+	}
+
+	cmd.AddCommand(
+		newConfigInitCmd(),
+	)
+
+	return cmd
+}
+
+// newConfigInitCmd creates the "config init" subcommand.
+func newConfigInitCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a config file with your OAuth client",
+		Long: `Write a config file (~/.config/gogchat/config.yaml, or --config) with the
+client ID and secret of your OAuth client and, optionally, a default space.
+Values that are not given as flags are asked for; in non-interactive mode
+--client-id and --client-secret are required. An existing config file is
+only replaced with --force.
+
+The OAuth client must belong to a Google Cloud project with the Chat API
+enabled and a Chat app configured; run gogchat without arguments on a new
+machine for a guided setup.`,
+		Example: `  gogchat config init
+  gogchat config init --client-id 1234-abc.apps.googleusercontent.com --client-secret GOCSPX-... --default-space spaces/AAAABBBBcccc`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			clientID, _ := cmd.Flags().GetString("client-id")
+			clientSecret, _ := cmd.Flags().GetString("client-secret")
+			defaultSpace, _ := cmd.Flags().GetString("default-space")
+			force, _ := cmd.Flags().GetBool("force")
+
+			path := configFilePath()
+			if _, err := os.Stat(path); err == nil && !force {
+				return fmt.Errorf("%s already exists; pass --force to replace it", path)
+			}
+			if clientID == "" || clientSecret == "" {
+				if err := requireInteractive("asking for the OAuth client", "pass --client-id and --client-secret"); err != nil {
+					return err
+				}
+				var err error
+				if clientID, clientSecret, err = promptCredentials(clientID, clientSecret); err != nil {
+					return err
+				}
+			}
+			if err := writeInitialConfig(path, clientID, clientSecret, defaultSpace); err != nil {
+				return err
+			}
+			getFormatter().PrintSuccess(fmt.Sprintf("Wrote %s", path))
+			return nil
+		},
+	}
+
+	cmd.Flags().String("client-id", "", "OAuth2 client ID")
+	cmd.Flags().String("client-secret", "", "OAuth2 client secret")
+	cmd.Flags().String("default-space", "", "Space used when a command is run without a SPACE argument")
+	cmd.Flags().Bool("force", false, "Replace an existing config file")
+	disablePager(cmd)
+
+	return cmd
+}
+
+// configFilePath returns the config file in use, or where it is created.
+func configFilePath() string {
+	if path := viper.ConfigFileUsed(); path != "" {
+		return path
+	}
+	return filepath.Join(config.ConfigDir(), "config.yaml")
+}
+
+// writeInitialConfig writes a new config file with the OAuth client and,
+// if not empty, the default space.
+func writeInitialConfig(path, clientID, clientSecret, defaultSpace string) error {
+	space := "# default_space: spaces/AAAABBBBcccc"
+	if defaultSpace != "" {
+		space = fmt.Sprintf("default_space: %q", api.NormalizeName(defaultSpace, "spaces/"))
+	}
+	data := fmt.Sprintf(`# gogchat configuration; see "Configuration" in CLI.md for all settings.
+
+# OAuth client of your Google Cloud project
+client_id: %q
+client_secret: %q
+
+# Space used when a command is run without a SPACE argument
+%s
+`, clientID, clientSecret, space)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// promptCredentials asks for the client ID and secret that are still
+// empty. The secret is not echoed.
+func promptCredentials(clientID, clientSecret string) (string, string, error) {
+	for clientID == "" {
+		answer, err := promptLine("Client ID: ")
+		if err != nil {
+			return "", "", err
+		}
+		if answer != "" && !strings.HasSuffix(answer, ".apps.googleusercontent.com") {
+			fmt.Fprintln(os.Stderr, "  A client ID ends in .apps.googleusercontent.com; paste the whole ID.")
+			continue
+		}
+		clientID = answer
+	}
+	for clientSecret == "" {
+		fmt.Fprint(os.Stderr, "Client secret (not shown): ")
+		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", "", fmt.Errorf("reading client secret: %w", err)
+		}
+		clientSecret = strings.TrimSpace(string(secret))
+	}
+	return clientID, clientSecret, nil
+}
+
+// promptLine asks question and returns the trimmed answer.
+func promptLine(question string) (string, error) {
+	fmt.Fprint(os.Stderr, question)
+	answer, err := stdinReader.ReadString('\n')
+	if err != nil && answer == "" {
+		fmt.Fprintln(os.Stderr)
+		return "", fmt.Errorf("no answer received")
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// firstUse reports whether gogchat has never been set up: there is no
+// config file, no OAuth client in the environment, and no token, and no
+// service account is configured.
+func firstUse() bool {
+	if viper.ConfigFileUsed() != "" || Cfg == nil || Cfg.ClientID != "" || Cfg.ServiceAccountFile != "" || viper.GetBool("as_app") {
+		return false
+	}
+	return !auth.TokenExists(tokenPath())
+}
+
+// errSetupCancelled is returned when the user stops the guided setup.
+var errSetupCancelled = errors.New("setup cancelled; run gogchat again to resume, or see \"gogchat config init\" and \"gogchat auth login\"")
+
+// runOnboarding guides a new user through setting up gogchat: it explains
+// what the Chat API needs, offers to open the console pages, writes the
+// config file, and signs in. Cfg is updated so the running command can
+// continue with the new credentials.
+func runOnboarding() error {
+	resume, ok := pausePager()
+	if !ok {
+		return fmt.Errorf("run 'gogchat config init' and 'gogchat auth login' first")
+	}
+	defer resume()
+
+	fmt.Fprintln(os.Stderr, "Welcome to gogchat! No configuration or login was found, so let's set it up.")
+	fmt.Fprintln(os.Stderr)
+
+	clientID, clientSecret := auth.DefaultClientID, auth.DefaultClientSecret
+	if auth.ValidateCredentials(clientID, clientSecret) == nil {
+		fmt.Fprintln(os.Stderr, "This build of gogchat comes with an OAuth client, so you only need to sign in.")
+	} else {
+		fmt.Fprintln(os.Stderr, `The Google Chat API can only be used through a Google Cloud project that
+has a Chat app configured, even when gogchat acts as you. You need an
+OAuth client of such a project:`)
+		fmt.Fprintln(os.Stderr)
+		for i, s := range setupSteps {
+			fmt.Fprintf(os.Stderr, "  %d. %s\n     %s\n", i+1, s.step, s.url)
+		}
+		fmt.Fprintln(os.Stderr)
+
+		open, err := confirm("Open these pages in your browser?", nil)
+		if err != nil {
+			return err
+		}
+		if open {
+			for _, s := range setupSteps {
+				if err := auth.OpenBrowser(s.url); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠ Could not open the browser: %v\n", err)
+					break
+				}
+			}
+		}
+
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "When the OAuth client is created, paste its client ID and secret.")
+		if clientID, clientSecret, err = promptCredentials("", ""); err != nil {
+			return err
+		}
+		path := configFilePath()
+		if err := writeInitialConfig(path, clientID, clientSecret, ""); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "✓ Wrote %s\n", path)
+		Cfg.ClientID, Cfg.ClientSecret = clientID, clientSecret
+	}
+
+	fmt.Fprintln(os.Stderr)
+	yes, err := confirm("Sign in with your Google account in the browser now?", nil)
+	if err != nil {
+		return err
+	}
+	if !yes {
+		return errSetupCancelled
+	}
+	token, err := auth.Login(clientID, clientSecret, loginOptions())
+	if err != nil {
+		return fmt.Errorf("login failed: %w", err)
+	}
+	if err := auth.SaveToken(tokenPath(), token); err != nil {
+		return fmt.Errorf("saving token: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, "✓ gogchat is set up. Try 'gogchat spaces list', and set default_space in")
+	fmt.Fprintf(os.Stderr, "  %s to leave out the space of everyday commands.\n", configFilePath())
+	fmt.Fprintln(os.Stderr)
+	return nil
+}
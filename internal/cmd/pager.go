@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// noPagerAnnotation marks commands whose output must never be paged, such
+// as interactive prompts and commands that stream output indefinitely.
+const noPagerAnnotation = "gogchat/no-pager"
+
+// disablePager opts cmd out of the output pager.
+func disablePager(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[noPagerAnnotation] = "true"
+}
+
+// pagerProcess is the running pager that stdout is piped into.
+type pagerProcess struct {
+	cmd     *exec.Cmd
+	orig    *os.File
+	w       *os.File
+	command *cobra.Command
+}
+
+// activePager is the pager started for the current command, if any.
+var activePager *pagerProcess
+
+// startPager pipes stdout through $GOGCHAT_PAGER, $PAGER, or "less" when
+// stdout is a terminal and paging is enabled. Like git, less is run with
+// -FRX so output that fits on one screen is printed without paging.
+func startPager(cmd *cobra.Command) {
+	if !shouldPage(cmd) {
+		return
+	}
+
+	pager := os.Getenv("GOGCHAT_PAGER")
+	if pager == "" {
+		pager = os.Getenv("PAGER")
+	}
+	if pager == "" {
+		pager = "less"
+	}
+	if pager == "cat" {
+		return
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return
+	}
+
+	c := exec.Command("sh", "-c", pager)
+	c.Stdin = r
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		c.Env = append(c.Env, "LESS=FRX")
+	}
+	if err := c.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return
+	}
+	r.Close()
+
+	activePager = &pagerProcess{cmd: c, orig: os.Stdout, w: w, command: cmd}
+	os.Stdout = w
+}
+
+// stopPager closes the pager's input, waits for the user to quit it, and
+// restores stdout. It is a no-op when no pager is running.
+func stopPager() {
+	p := activePager
+	if p == nil {
+		return
+	}
+	activePager = nil
+
+	p.w.Close()
+	_ = p.cmd.Wait()
+	os.Stdout = p.orig
+}
+
+// pausePager stops the running pager so the terminal can be used for a
+// prompt, and returns a function that starts a new pager for the rest of the
+// output. It returns false if the pager cannot be paused because --copy is
+// capturing its output.
+func pausePager() (func(), bool) {
+	p := activePager
+	if p == nil {
+		return func() {}, true
+	}
+	if activeCapture != nil {
+		return nil, false
+	}
+	stopPager()
+	return func() { startPager(p.command) }, true
+}
+
+// shouldPage reports whether the output of cmd should go through a pager:
+// human-mode output to a terminal, with neither --no-pager nor pager: false
+// in the config, on a command that has not opted out.
+func shouldPage(cmd *cobra.Command) bool {
+	if runtime.GOOS == "windows" || viper.GetBool("no_pager") || !Cfg.Pager || viper.GetBool("json") {
+		return false
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[noPagerAnnotation] == "true" {
+			return false
+		}
+	}
+	if strings.HasPrefix(cmd.Name(), "__complete") {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// isTerminal reports whether f is a character device such as a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// defaultPickCount is the number of recent messages offered by the
+// message picker when --latest is not given.
+const defaultPickCount = 20
+
+// errNothingPicked is returned when the user leaves the message picker
+// without selecting anything.
+var errNothingPicked = errors.New("no message selected")
+
+// addPickFlags registers --space and --latest on a command that picks its
+// target messages interactively when none are given.
+func addPickFlags(cmd *cobra.Command) {
+	cmd.Flags().String("space", "", "Without MESSAGE, pick the messages from this space's latest messages")
+	cmd.Flags().Int("latest", defaultPickCount, "Number of recent messages to pick from")
+}
+
+// messageOrSpaceArgs is the cobra.PositionalArgs of commands with a
+// message picker: MESSAGE may only be left out when --space is given.
+func messageOrSpaceArgs(cmd *cobra.Command, args []string) error {
+	if space, _ := cmd.Flags().GetString("space"); len(args) == 0 && space == "" {
+		return fmt.Errorf("MESSAGE is required (or --space SPACE to pick from its latest messages)")
+	}
+	return nil
+}
+
+// messageArgs returns the messages named in args or, if there are none,
+// the ones the user picks from the latest messages of --space. Picking
+// needs a terminal; if the user cancels, the error is errNothingPicked.
+func messageArgs(cmd *cobra.Command, client *api.Client, args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	space, _ := cmd.Flags().GetString("space")
+	if err := requireInteractive("picking a message", "pass MESSAGE instead"); err != nil {
+		return nil, err
+	}
+	latest, _ := cmd.Flags().GetInt("latest")
+	if latest < 1 {
+		return nil, fmt.Errorf("--latest must be at least 1")
+	}
+	picked, err := pickMessages(cmd.Context(), client, api.NormalizeName(space, "spaces/"), latest)
+	for _, name := range picked {
+		noteResolved("", name)
+	}
+	return picked, err
+}
+
+// pickMessages shows the latest n messages of space, oldest first so that
+// the newest is next to the prompt, and returns the ones the user selects
+// by number.
+func pickMessages(ctx context.Context, client *api.Client, space string, n int) ([]string, error) {
+	raw, err := api.NewMessagesService(client).List(ctx, space, n, "", "", "createTime desc", false)
+	if err != nil {
+		return nil, fmt.Errorf("listing messages: %w", err)
+	}
+	var resp struct {
+		Messages []digestMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	if len(resp.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in %s", space)
+	}
+	messages := resp.Messages
+	slices.Reverse(messages)
+
+	table := output.NewTable("#", "SENDER", "TIME", "TEXT")
+	for i, msg := range messages {
+		firstLine, _, _ := strings.Cut(msg.Text, "\n")
+		table.AddRow(strconv.Itoa(i+1), msg.senderName(), output.FormatTime(msg.CreateTime), output.Truncate(firstLine, 60))
+	}
+	fmt.Fprint(os.Stderr, table.Render())
+
+	for {
+		fmt.Fprintf(os.Stderr, "Select messages [1-%d] (e.g. 3 or 1,4-6), or press Enter to cancel: ", len(messages))
+		answer, err := stdinReader.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			if err != nil {
+				fmt.Fprintln(os.Stderr)
+			}
+			return nil, errNothingPicked
+		}
+
+		picked, perr := parseSelection(answer, len(messages))
+		if perr != nil {
+			if err != nil {
+				return nil, perr
+			}
+			fmt.Fprintf(os.Stderr, "%v\n", perr)
+			continue
+		}
+
+		names := make([]string, len(picked))
+		for i, p := range picked {
+			names[i] = messages[p-1].Name
+		}
+		return names, nil
+	}
+}
+
+// parseSelection parses a list of numbers and ranges such as "1,4-6" into
+// the distinct numbers in order. Each must be between 1 and max.
+func parseSelection(s string, max int) ([]int, error) {
+	var picked []int
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(lo)
+		last := first
+		if err == nil && isRange {
+			last, err = strconv.Atoi(hi)
+		}
+		if err != nil || first < 1 || last > max || first > last {
+			return nil, fmt.Errorf("invalid selection %q (numbers 1-%d)", part, max)
+		}
+		for i := first; i <= last; i++ {
+			if !slices.Contains(picked, i) {
+				picked = append(picked, i)
+			}
+		}
+	}
+	return picked, nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+)
+
+// addProtectionFlag registers --override-protection on a destructive
+// command.
+func addProtectionFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("override-protection", false, "Allow changes to spaces listed in protected_spaces")
+}
+
+// spaceGuard refuses destructive operations on the spaces listed in the
+// protected_spaces config key. Entries may be space resource names, space
+// IDs, or display names (matched case-insensitively). It is safe for use by
+// concurrent bulk workers.
+type spaceGuard struct {
+	client   *api.Client
+	admin    bool
+	override bool
+	names    map[string]bool
+	display  map[string]bool
+
+	mu      sync.Mutex
+	checked map[string]error
+}
+
+// newSpaceGuard creates a guard for cmd from the configured protected spaces.
+func newSpaceGuard(cmd *cobra.Command, client *api.Client) *spaceGuard {
+	override, _ := cmd.Flags().GetBool("override-protection")
+	admin, _ := cmd.Flags().GetBool("admin")
+	g := &spaceGuard{
+		client:   client,
+		admin:    admin,
+		override: override,
+		names:    make(map[string]bool),
+		display:  make(map[string]bool),
+		checked:  make(map[string]error),
+	}
+	for _, entry := range Cfg.ProtectedSpaces {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		g.names[api.NormalizeName(entry, "spaces/")] = true
+		if !strings.HasPrefix(entry, "spaces/") {
+			g.display[strings.ToLower(entry)] = true
+		}
+	}
+	return g
+}
+
+// check returns an error if resource (a space or any resource inside one,
+// e.g. spaces/AAA/messages/BBB) belongs to a protected space.
+func (g *spaceGuard) check(ctx context.Context, resource string) error {
+	if g.override || len(g.names) == 0 {
+		return nil
+	}
+
+	space := spaceOf(resource)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err, ok := g.checked[space]; ok {
+		return err
+	}
+	err := g.lookup(ctx, space)
+	g.checked[space] = err
+	return err
+}
+
+// lookup checks space against the protected names, fetching its display
+// name if any entry could be one.
+func (g *spaceGuard) lookup(ctx context.Context, space string) error {
+	if g.names[space] {
+		return protectedSpaceError(space)
+	}
+	if len(g.display) == 0 {
+		return nil
+	}
+
+	displayName, err := spaceDisplayName(ctx, g.client, space, g.admin)
+	if err != nil {
+		return fmt.Errorf("checking protected_spaces for %s: %w (pass --override-protection to skip the check)", space, err)
+	}
+	if g.display[strings.ToLower(displayName)] {
+		return protectedSpaceError(fmt.Sprintf("%s (%s)", space, displayName))
+	}
+	return nil
+}
+
+// protectedSpaceError is returned for operations on a protected space.
+func protectedSpaceError(space string) error {
+	return fmt.Errorf("space %s is listed in protected_spaces; pass --override-protection to modify it", space)
+}
+
+// spaceOf returns the space resource name that resource belongs to.
+// E.g. spaceOf("spaces/AAA/messages/BBB") → "spaces/AAA"
+func spaceOf(resource string) string {
+	resource = api.NormalizeName(resource, "spaces/")
+	parts := strings.SplitN(resource, "/", 3)
+	if len(parts) < 2 {
+		return resource
+	}
+	return parts[0] + "/" + parts[1]
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"unicode"
 
@@ -9,6 +11,7 @@ import (
 
 	"github.com/cipher-shad0w/gogchat/internal/api"
 	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/cipher-shad0w/gogchat/internal/undo"
 )
 
 // NewReactionsCmd creates the top-level "reactions" command with list, add, and
@@ -45,7 +48,7 @@ func newReactionsListCmd() *cobra.Command {
 			svc := api.NewReactionsService(client)
 
 			parent := args[0]
-			pageSize, _ := cmd.Flags().GetInt("page-size")
+			pageSize := getPageSize(cmd)
 			pageToken, _ := cmd.Flags().GetString("page-token")
 			filter, _ := cmd.Flags().GetString("filter")
 			all, _ := cmd.Flags().GetBool("all")
@@ -61,10 +64,6 @@ func newReactionsListCmd() *cobra.Command {
 					return fmt.Errorf("listing reactions: %w", err)
 				}
 
-				if formatter.IsJSON() && !all {
-					return formatter.PrintRaw(raw)
-				}
-
 				var resp struct {
 					Reactions []json.RawMessage `json:"reactions"`
 					NextPage  string            `json:"nextPageToken"`
@@ -83,8 +82,7 @@ func newReactionsListCmd() *cobra.Command {
 			}
 
 			if formatter.IsJSON() {
-				// --all + --json: emit collected reactions as a JSON array.
-				return formatter.Print(allReactions)
+				return printList(formatter, allReactions, pageToken)
 			}
 
 			if len(allReactions) == 0 {
@@ -160,13 +158,50 @@ func isUnicodeEmoji(s string) bool {
 	return false
 }
 
+// newReactionBody builds the reaction body for emoji. If the emoji looks like
+// unicode (starts with a non-ASCII character), the unicode field is used;
+// otherwise it is treated as a custom emoji UID.
+func newReactionBody(emoji string) map[string]interface{} {
+	if isUnicodeEmoji(emoji) {
+		return map[string]interface{}{
+			"emoji": map[string]interface{}{
+				"unicode": emoji,
+			},
+		}
+	}
+	return map[string]interface{}{
+		"emoji": map[string]interface{}{
+			"customEmoji": map[string]interface{}{
+				"uid": emoji,
+			},
+		},
+	}
+}
+
+// reactionBody is newReactionBody with a custom emoji shortcode such as
+// ":party-parrot:" resolved to the emoji's UID.
+func reactionBody(ctx context.Context, client *api.Client, emoji string) (map[string]interface{}, error) {
+	if isEmojiShortcode(emoji) {
+		uid, err := customEmojiUID(ctx, client, emoji)
+		if err != nil {
+			return nil, err
+		}
+		emoji = uid
+	}
+	return newReactionBody(emoji), nil
+}
+
 // newReactionsAddCmd creates the "reactions add" subcommand.
 func newReactionsAddCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "add MESSAGE",
-		Short: "Add a reaction to a message",
-		Long:  "Add an emoji reaction to the specified message. MESSAGE is the full message resource name (spaces/{space}/messages/{message}).",
-		Args:  cobra.ExactArgs(1),
+		Use:     "add MESSAGE...",
+		Aliases: []string{"create"},
+		Short:   "Add a reaction to messages",
+		Long: `Add an emoji reaction to the specified messages. MESSAGE is the full message resource name (spaces/{space}/messages/{message}). Several messages are reacted to in parallel (see --concurrency); with http.batch, their requests are batched into few round trips.
+
+Without MESSAGE, the latest messages of --space are listed on the terminal
+to pick from by number (e.g. 3 or 1,4-6).`,
+		Args: messageOrSpaceArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
 			if err != nil {
@@ -175,29 +210,43 @@ func newReactionsAddCmd() *cobra.Command {
 			formatter := getFormatter()
 			svc := api.NewReactionsService(client)
 
-			parent := args[0]
 			emoji, _ := cmd.Flags().GetString("emoji")
 
-			// Build the reaction body. If the emoji looks like unicode (starts
-			// with a non-ASCII character), use the unicode field; otherwise treat
-			// it as a custom emoji UID.
-			var body map[string]interface{}
-			if isUnicodeEmoji(emoji) {
-				body = map[string]interface{}{
-					"emoji": map[string]interface{}{
-						"unicode": emoji,
-					},
+			body, err := readBody(cmd)
+			if err != nil {
+				return err
+			}
+
+			if body == nil {
+				if err := requireFlags(cmd, "emoji"); err != nil {
+					return err
 				}
-			} else {
-				body = map[string]interface{}{
-					"emoji": map[string]interface{}{
-						"customEmoji": map[string]interface{}{
-							"uid": emoji,
-						},
-					},
+				body, err = reactionBody(cmd.Context(), client, emoji)
+				if err != nil {
+					return err
 				}
 			}
 
+			args, err = messageArgs(cmd, client, args)
+			if errors.Is(err, errNothingPicked) {
+				formatter.PrintMessage("Cancelled.")
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			parent := args[0]
+
+			if len(args) > 1 {
+				enableBulkRetries(client)
+				summary := runBulkBatched(cmd.Context(), formatter, client, args, func(message string) api.BatchRequest {
+					return svc.CreateRequest(message, body)
+				}, func(message string, _ json.RawMessage) (string, error) {
+					return fmt.Sprintf("Reaction %s added to %s", emoji, message), nil
+				})
+				return finishBulk(formatter, summary, "reactions")
+			}
+
 			raw, err := svc.Create(cmd.Context(), parent, body)
 			if err != nil {
 				return fmt.Errorf("adding reaction: %w", err)
@@ -212,8 +261,9 @@ func newReactionsAddCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().String("emoji", "", "Emoji to react with (unicode emoji like \"👍\" or custom emoji UID)")
-	_ = cmd.MarkFlagRequired("emoji")
+	cmd.Flags().String("emoji", "", "Emoji to react with (unicode emoji like \"👍\", custom emoji shortcode like \":party-parrot:\", or custom emoji UID)")
+	addBodyFlag(cmd)
+	addPickFlags(cmd)
 
 	return cmd
 }
@@ -234,22 +284,29 @@ func newReactionsRemoveCmd() *cobra.Command {
 			svc := api.NewReactionsService(client)
 
 			name := args[0]
-			force, _ := cmd.Flags().GetBool("force")
+			if err := newSpaceGuard(cmd, client).check(cmd.Context(), name); err != nil {
+				return err
+			}
 
-			if !force {
-				fmt.Printf("Remove reaction %s? [y/N]: ", name)
-				var answer string
-				fmt.Scanln(&answer)
-				if answer != "y" && answer != "Y" {
+			if !skipConfirm(cmd) {
+				ok, err := confirm("Remove this reaction?", []string{fmt.Sprintf("Reaction: %s", name)})
+				if err != nil {
+					return err
+				}
+				if !ok {
 					formatter.PrintMessage("Cancelled.")
 					return nil
 				}
 			}
 
+			recorder := newUndoRecorder(cmd)
+			saved := recorder.snapshot(cmd.Context(), client, undo.KindReaction, name, false)
 			raw, err := svc.Delete(cmd.Context(), name)
 			if err != nil {
 				return fmt.Errorf("removing reaction: %w", err)
 			}
+			recorder.add(undo.KindReaction, name, saved)
+			recorder.save()
 
 			if formatter.IsJSON() {
 				return formatter.PrintRaw(raw)
@@ -260,7 +317,8 @@ func newReactionsRemoveCmd() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().Bool("force", false, "Skip confirmation prompt")
+	addConfirmFlags(cmd)
+	addProtectionFlag(cmd)
 
 	return cmd
 }
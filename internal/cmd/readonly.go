@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// readOnlyCommands are the commands allowed when read_only is set: those
+// that only read from Chat or only change local state (tokens, caches,
+// stars, the thread key registry). Commands are named by their path below
+// the root command. Anything not listed is refused, so new commands are
+// treated as mutating until they are added here.
+var readOnlyCommands = map[string]bool{
+	"analytics export":     true,
+	"attachments get":      true,
+	"auth login":           true,
+	"auth logout":          true,
+	"auth rotate":          true,
+	"auth status":          true,
+	"cache clear":          true,
+	"cache stats":          true,
+	"catchup":              true,
+	"config init":          true,
+	"digest":               true,
+	"dlq list":             true,
+	"dlq purge":            true,
+	"emoji dump":           true,
+	"gen completion":       true,
+	"gen man":              true,
+	"emoji get":            true,
+	"emoji list":           true,
+	"emoji stats":          true,
+	"events get":           true,
+	"events list":          true,
+	"events replay":        true,
+	"export":               true,
+	"history":              true,
+	"import status":        true,
+	"media download":       true,
+	"media stat":           true,
+	"members get":          true,
+	"members expiring":     true,
+	"members list":         true,
+	"mentions":             true,
+	"messages decrypt":     true,
+	"messages get":         true,
+	"messages list":        true,
+	"messages tail":        true,
+	"notifications get":    true,
+	"reactions list":       true,
+	"readstate get-space":  true,
+	"readstate get-thread": true,
+	"readstate prompt":     true,
+	"readstate report":     true,
+	"redo":                 true,
+	"schema":               true,
+	"sent list":            true,
+	"spaces diff":          true,
+	"spaces find-dm":       true,
+	"spaces get":           true,
+	"spaces list":          true,
+	"spaces search":        true,
+	"spaces snapshot":      true,
+	"star add":             true,
+	"star list":            true,
+	"star remove":          true,
+	"threads export":       true,
+	"threads keys forget":  true,
+	"threads keys list":    true,
+	"validate":             true,
+	"webhooks add":         true,
+	"webhooks list":        true,
+	"webhooks remove":      true,
+	"webhooks url":         true,
+}
+
+// readOnlyUnsafeFlags are the flags that make an otherwise read-only
+// command change Chat.
+var readOnlyUnsafeFlags = map[string][]string{
+	"catchup":       {"mark-read"},
+	"messages tail": {"rules"},
+}
+
+// checkReadOnly refuses cmd if the selected account is read-only and cmd
+// may change Chat. It runs before any request is sent.
+func checkReadOnly(cmd *cobra.Command) error {
+	if Cfg == nil || !Cfg.ReadOnly {
+		return nil
+	}
+
+	// Cobra's own help and completion commands, and the root command,
+	// which only shows help or the first-use setup.
+	if !cmd.HasParent() || cmd.Name() == "help" || strings.HasPrefix(cmd.Name(), "__complete") ||
+		(cmd.HasParent() && cmd.Parent().Name() == "completion") {
+		return nil
+	}
+
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	who := "this account is"
+	if account := viper.GetString("account"); account != "" {
+		who = fmt.Sprintf("account %q is", account)
+	}
+	if !readOnlyCommands[path] {
+		return fmt.Errorf("%q can change Chat, but %s read-only (read_only in the config file)", cmd.CommandPath(), who)
+	}
+	for _, name := range readOnlyUnsafeFlags[path] {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s changes Chat, but %s read-only (read_only in the config file)", name, who)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/config"
+)
+
+func TestCheckReadOnly(t *testing.T) {
+	saved := Cfg
+	t.Cleanup(func() { Cfg = saved })
+
+	tests := []struct {
+		name     string
+		readOnly bool
+		account  string
+		args     []string
+		// flag, if set, is given to the command.
+		flag    string
+		wantErr string
+	}{
+		{"not read-only", false, "", []string{"messages", "send"}, "", ""},
+		{"reading", true, "", []string{"messages", "list"}, "", ""},
+		{"local state", true, "", []string{"star", "add"}, "", ""},
+		{"mutating", true, "", []string{"messages", "send"}, "", `"gogchat messages send" can change Chat, but this account is read-only`},
+		{"mutating as a profile", true, "work", []string{"spaces", "delete"}, "", `but account "work" is read-only`},
+		{"unsafe flag", true, "", []string{"catchup"}, "mark-read", "--mark-read changes Chat"},
+		{"safe flag", true, "", []string{"catchup"}, "limit", ""},
+		{"root", true, "", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Cfg = &config.Config{ReadOnly: tt.readOnly}
+			viper.Set("account", tt.account)
+			t.Cleanup(func() { viper.Set("account", "") })
+
+			cmd, _, err := rootCmd.Find(tt.args)
+			if err != nil {
+				t.Fatalf("finding %v: %v", tt.args, err)
+			}
+			if tt.flag != "" {
+				fl := cmd.Flags().Lookup(tt.flag)
+				if fl == nil {
+					t.Fatalf("%s has no --%s", cmd.CommandPath(), tt.flag)
+				}
+				fl.Changed = true
+				t.Cleanup(func() { fl.Changed = false })
+			}
+
+			err = checkReadOnly(cmd)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("checkReadOnly() error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("checkReadOnly() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadOnlyCommandsExist(t *testing.T) {
+	for path := range readOnlyCommands {
+		cmd, _, err := rootCmd.Find(strings.Fields(path))
+		if err != nil || strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name()+" ") != path {
+			t.Errorf("readOnlyCommands lists %q, which is not a command", path)
+		}
+	}
+}
@@ -1,12 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
 	"github.com/cipher-shad0w/gogchat/internal/output"
 )
 
@@ -23,6 +33,8 @@ func NewReadStateCmd() *cobra.Command {
 		newReadStateGetSpaceCmd(),
 		newReadStateUpdateSpaceCmd(),
 		newReadStateGetThreadCmd(),
+		newReadStatePromptCmd(),
+		newReadStateReportCmd(),
 	)
 
 	return cmd
@@ -33,7 +45,7 @@ func newReadStateGetSpaceCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get-space READSTATE",
 		Short: "Get the read state of a space",
-		Long:  "Retrieve the read state of a space for the calling user. READSTATE is the full resource name (users/{user}/spaces/{space}/spaceReadState).",
+		Long:  "Retrieve the read state of a space for the calling user. READSTATE is the full resource name (users/{user}/spaces/{space}/spaceReadState) or just the space (spaces/{space} or its ID), which is taken as users/me.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
@@ -43,7 +55,7 @@ func newReadStateGetSpaceCmd() *cobra.Command {
 			formatter := getFormatter()
 			svc := api.NewReadStateService(client)
 
-			name := args[0]
+			name := api.UserScopedName(args[0], "spaceReadState")
 
 			raw, err := svc.GetSpaceReadState(cmd.Context(), name)
 			if err != nil {
@@ -77,7 +89,7 @@ func newReadStateUpdateSpaceCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "update-space READSTATE",
 		Short: "Update the read state of a space",
-		Long:  "Update the read state of a space for the calling user. READSTATE is the full resource name (users/{user}/spaces/{space}/spaceReadState).",
+		Long:  "Update the read state of a space for the calling user. READSTATE is the full resource name (users/{user}/spaces/{space}/spaceReadState) or just the space (spaces/{space} or its ID), which is taken as users/me.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
@@ -87,12 +99,26 @@ func newReadStateUpdateSpaceCmd() *cobra.Command {
 			formatter := getFormatter()
 			svc := api.NewReadStateService(client)
 
-			name := args[0]
+			name := api.UserScopedName(args[0], "spaceReadState")
 			lastReadTime, _ := cmd.Flags().GetString("last-read-time")
-			updateMask, _ := cmd.Flags().GetString("update-mask")
 
-			body := map[string]interface{}{
-				"lastReadTime": lastReadTime,
+			body, err := readBody(cmd)
+			if err != nil {
+				return err
+			}
+
+			if body == nil {
+				if err := requireFlags(cmd, "last-read-time"); err != nil {
+					return err
+				}
+				body = map[string]interface{}{
+					"lastReadTime": lastReadTime,
+				}
+			}
+
+			updateMask, err := resolveUpdateMask(cmd, body)
+			if err != nil {
+				return err
 			}
 
 			raw, err := svc.UpdateSpaceReadState(cmd.Context(), name, body, updateMask)
@@ -121,8 +147,8 @@ func newReadStateUpdateSpaceCmd() *cobra.Command {
 	}
 
 	cmd.Flags().String("last-read-time", "", "Last read time in RFC3339 format (required)")
-	_ = cmd.MarkFlagRequired("last-read-time")
-	cmd.Flags().String("update-mask", "lastReadTime", "Fields to update (comma-separated)")
+	addBodyFlag(cmd)
+	addMaskFlag(cmd)
 
 	return cmd
 }
@@ -132,7 +158,7 @@ func newReadStateGetThreadCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get-thread THREADREADSTATE",
 		Short: "Get the read state of a thread",
-		Long:  "Retrieve the read state of a thread for the calling user. THREADREADSTATE is the full resource name (users/{user}/spaces/{space}/threads/{thread}/threadReadState).",
+		Long:  "Retrieve the read state of a thread for the calling user. THREADREADSTATE is the full resource name (users/{user}/spaces/{space}/threads/{thread}/threadReadState) or just the thread (spaces/{space}/threads/{thread}), which is taken as users/me.",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			client, err := newAPIClient()
@@ -142,7 +168,7 @@ func newReadStateGetThreadCmd() *cobra.Command {
 			formatter := getFormatter()
 			svc := api.NewReadStateService(client)
 
-			name := args[0]
+			name := api.UserScopedName(args[0], "threadReadState")
 
 			raw, err := svc.GetThreadReadState(cmd.Context(), name)
 			if err != nil {
@@ -170,3 +196,215 @@ func newReadStateGetThreadCmd() *cobra.Command {
 
 	return cmd
 }
+
+// unreadPerSpaceLimit caps the unread messages counted per space so that a
+// refresh stays cheap; the prompt shows "99+" past the total limit.
+const unreadPerSpaceLimit = 100
+
+// unreadCache is the cached unread count shown by "readstate prompt".
+type unreadCache struct {
+	Count     int       `json:"count"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	RefreshAt time.Time `json:"refreshAttemptedAt"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// unreadCachePath returns the location of the unread count cache.
+func unreadCachePath() string {
+	return filepath.Join(config.ConfigDir(), "unread.json")
+}
+
+// loadUnreadCache reads the unread count cache; a missing or unreadable
+// cache is returned as empty.
+func loadUnreadCache() unreadCache {
+	var c unreadCache
+	data, err := os.ReadFile(unreadCachePath())
+	if err == nil {
+		_ = json.Unmarshal(data, &c)
+	}
+	return c
+}
+
+// saveUnreadCache writes the unread count cache.
+func saveUnreadCache(c unreadCache) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(unreadCachePath(), data, 0o600)
+}
+
+// newReadStatePromptCmd creates the "readstate prompt" subcommand.
+func newReadStatePromptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "prompt",
+		Short: "Print a compact unread indicator for shell prompts",
+		Long: `Print a compact unread indicator (e.g. "✉3") for embedding in PS1 or
+starship. The count comes from a local cache and is printed immediately; the
+network is never used in the foreground. When the cache is older than
+--max-staleness (or the prompt_max_staleness config key), a refresh is started
+in the background and the next prompt shows the new count.
+
+Nothing is printed when there are no unread messages, unless --show-zero is
+given.`,
+		Args: cobra.NoArgs,
+		RunE: runReadStatePrompt,
+	}
+
+	flags := cmd.Flags()
+	flags.Duration("max-staleness", 0, "Maximum age of the cached count before refreshing in the background (default from config, 5m)")
+	flags.String("format", "✉%s", "Indicator format; %s is replaced by the count")
+	flags.Bool("show-zero", false, "Print the indicator even when there are no unread messages")
+	flags.Bool("refresh", false, "Recompute the unread count now, blocking until done")
+	disablePager(cmd)
+
+	return cmd
+}
+
+func runReadStatePrompt(cmd *cobra.Command, args []string) error {
+	maxStaleness, _ := cmd.Flags().GetDuration("max-staleness")
+	if !cmd.Flags().Changed("max-staleness") {
+		maxStaleness = Cfg.PromptMaxStaleness
+	}
+	format, _ := cmd.Flags().GetString("format")
+	showZero, _ := cmd.Flags().GetBool("show-zero")
+	refresh, _ := cmd.Flags().GetBool("refresh")
+
+	cache := loadUnreadCache()
+
+	if refresh {
+		cache = refreshUnreadCache(cmd.Context(), cache)
+		if cache.LastError != "" {
+			return fmt.Errorf("refreshing unread count: %s", cache.LastError)
+		}
+	} else if time.Since(cache.UpdatedAt) > maxStaleness && time.Since(cache.RefreshAt) > time.Minute {
+		// Mark the refresh as started so concurrent prompts do not each
+		// spawn one, and failing refreshes are retried at most once a
+		// minute, then refresh in a detached process.
+		cache.RefreshAt = time.Now()
+		_ = saveUnreadCache(cache)
+		startBackgroundRefresh()
+	}
+
+	if cache.UpdatedAt.IsZero() || (cache.Count == 0 && !showZero) {
+		return nil
+	}
+
+	count := strconv.Itoa(cache.Count)
+	if cache.Count > 99 {
+		count = "99+"
+	}
+	fmt.Println(strings.ReplaceAll(format, "%s", count))
+	return nil
+}
+
+// startBackgroundRefresh runs "gogchat readstate prompt --refresh" as a
+// detached process so the shell prompt never waits on the network.
+func startBackgroundRefresh() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	args := []string{"readstate", "prompt", "--refresh"}
+	if cfgFile := viper.GetString("config"); cfgFile != "" {
+		args = append(args, "--config", cfgFile)
+	}
+
+	c := exec.Command(exe, args...)
+	c.Stdin, c.Stdout, c.Stderr = nil, nil, nil
+	if err := c.Start(); err != nil {
+		return
+	}
+	_ = c.Process.Release()
+}
+
+// refreshUnreadCache recomputes the unread count and saves it. Errors are
+// recorded in the cache rather than returned so the prompt stays quiet.
+func refreshUnreadCache(ctx context.Context, cache unreadCache) unreadCache {
+	cache.RefreshAt = time.Now()
+
+	client, err := newAPIClient()
+	if err == nil {
+		var count int
+		if count, err = countUnread(ctx, client); err == nil {
+			cache.Count = count
+			cache.UpdatedAt = time.Now()
+			cache.LastError = ""
+		}
+	}
+	if err != nil {
+		cache.LastError = err.Error()
+	}
+
+	_ = saveUnreadCache(cache)
+	return cache
+}
+
+// countUnread returns the number of messages created after the caller's
+// last read time, summed over all of the caller's spaces. At most
+// unreadPerSpaceLimit messages are counted per space.
+func countUnread(ctx context.Context, client *api.Client) (int, error) {
+	spaces, err := listAllSpaces(ctx, client, "")
+	if err != nil {
+		return 0, err
+	}
+
+	names := make([]string, 0, len(spaces))
+	for _, raw := range spaces {
+		var sp struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(raw, &sp) == nil && sp.Name != "" {
+			names = append(names, sp.Name)
+		}
+	}
+
+	var mu sync.Mutex
+	total := 0
+	quiet := output.NewFormatter(false, true)
+	summary := runBulk(ctx, quiet, names, func(ctx context.Context, space string) (string, error) {
+		n, err := countUnreadInSpace(ctx, client, space)
+		if err != nil {
+			return "", err
+		}
+		mu.Lock()
+		total += n
+		mu.Unlock()
+		return space, nil
+	})
+	if summary.Succeeded == 0 && summary.Total > 0 {
+		return 0, fmt.Errorf("could not read any space: %s", summary.firstError())
+	}
+	return total, nil
+}
+
+// countUnreadInSpace returns the number of messages in space created after
+// the caller's last read time, up to unreadPerSpaceLimit.
+func countUnreadInSpace(ctx context.Context, client *api.Client, space string) (int, error) {
+	raw, err := api.NewReadStateService(client).GetSpaceReadState(ctx, api.UserScopedName(space, "spaceReadState"))
+	if err != nil {
+		return 0, err
+	}
+	var state struct {
+		LastReadTime string `json:"lastReadTime"`
+	}
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return 0, fmt.Errorf("parsing response: %w", err)
+	}
+
+	filter := ""
+	if state.LastReadTime != "" {
+		filter = fmt.Sprintf("createTime > %q", state.LastReadTime)
+	}
+	raw, err = api.NewMessagesService(client).List(ctx, space, unreadPerSpaceLimit, "", filter, "", false)
+	if err != nil {
+		return 0, err
+	}
+	var resp struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return 0, fmt.Errorf("parsing response: %w", err)
+	}
+	return len(resp.Messages), nil
+}
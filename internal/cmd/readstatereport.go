@@ -0,0 +1,373 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// Statuses of a member in a read report, from the strongest evidence that
+// the member has seen the message to none.
+const (
+	readStatusSender  = "sender"
+	readStatusRead    = "read"
+	readStatusReacted = "reacted"
+	readStatusReplied = "replied"
+	readStatusActive  = "active"
+	readStatusUnread  = "unread"
+	readStatusUnknown = "unknown"
+)
+
+// readReportScanLimit is the maximum number of later messages scanned for
+// replies and activity.
+const readReportScanLimit = 5000
+
+// readReport is the result of "readstate report".
+type readReport struct {
+	Message    string             `json:"message"`
+	CreateTime string             `json:"createTime"`
+	Seen       int                `json:"seen"`
+	Unread     int                `json:"unread"`
+	Unknown    int                `json:"unknown"`
+	Members    []readReportMember `json:"members"`
+	// ReadStates tells whether the read states of members other than the
+	// caller could be read.
+	ReadStates bool `json:"readStates"`
+	// Truncated is set when more than readReportScanLimit messages were
+	// posted after the message, so activity after those was not seen.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// readReportMember is the status of one member in a readReport.
+type readReportMember struct {
+	Member      string `json:"member"`
+	DisplayName string `json:"displayName,omitempty"`
+	Status      string `json:"status"`
+	// Time is the time of the evidence: the last read time, or the time of
+	// the reply or later message.
+	Time string `json:"time,omitempty"`
+}
+
+// newReadStateReportCmd creates the "readstate report" subcommand.
+func newReadStateReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report SPACE --message MESSAGE",
+		Short: "Report which members have plausibly seen a message",
+		Long: `Report which human members of a space have plausibly seen a message, such
+as an announcement, by comparing what is known about each member against the
+time the message was posted:
+
+  sender    posted the message
+  read      their read state (lastReadTime) is at or after the message
+  reacted   reacted to the message
+  replied   replied in the message's thread
+  active    posted in the space after the message
+  unread    their read state is before the message
+  unknown   no evidence either way
+
+Google Chat only shows a user's read state to that user, so for the other
+members the report usually relies on their activity; a member who read the
+message without reacting or posting is reported as unknown. Treat the report
+as a lower bound. With --admin, the members are listed with admin access.
+
+MESSAGE is the message name (spaces/{space}/messages/{message}) or its ID in
+SPACE.`,
+		Example: `  # Who has seen the outage announcement?
+  $ gogchat readstate report AAAABBBBcccc --message spaces/AAAABBBBcccc/messages/xyz.xyz
+
+  # Only the members without evidence, for a follow-up
+  $ gogchat readstate report AAAABBBBcccc --message xyz.xyz --json \
+      | jq -r '.members[] | select(.status == "unknown" or .status == "unread") | .member'`,
+		Args: cobra.ExactArgs(1),
+		RunE: runReadStateReport,
+	}
+
+	cmd.Flags().String("message", "", "The message to report on (required)")
+	_ = cmd.MarkFlagRequired("message")
+
+	return cmd
+}
+
+func runReadStateReport(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+	ctx := cmd.Context()
+
+	space := api.NormalizeName(args[0], "spaces/")
+	message, _ := cmd.Flags().GetString("message")
+	if !strings.Contains(message, "/") {
+		message = space + "/messages/" + message
+	}
+	if !strings.HasPrefix(message, space+"/messages/") {
+		return fmt.Errorf("message %s is not in %s", message, space)
+	}
+
+	raw, err := api.NewMessagesService(client).Get(ctx, message)
+	if err != nil {
+		return fmt.Errorf("getting message: %w", err)
+	}
+	var msg tailMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	posted, err := time.Parse(time.RFC3339Nano, msg.CreateTime)
+	if err != nil {
+		return fmt.Errorf("message %s has no valid create time", message)
+	}
+
+	members, err := humanMembers(ctx, client, space, viper.GetBool("admin"))
+	if err != nil {
+		return err
+	}
+
+	report := &readReport{Message: msg.Name, CreateTime: msg.CreateTime}
+	status := make(map[string]*readReportMember, len(members))
+	for i := range members {
+		status[members[i].Member] = &members[i]
+	}
+	// note records evidence for member unless stronger evidence is known.
+	note := func(member, s, at string) {
+		m, ok := status[member]
+		if ok && readStatusRank(s) < readStatusRank(m.Status) {
+			m.Status, m.Time = s, at
+		}
+	}
+
+	note(msg.Sender.Name, readStatusSender, msg.CreateTime)
+
+	states, others := spaceReadStates(ctx, client, space, members)
+	report.ReadStates = others
+	for member, lastRead := range states {
+		if t, err := time.Parse(time.RFC3339Nano, lastRead); err == nil && !t.Before(posted) {
+			note(member, readStatusRead, lastRead)
+		} else {
+			note(member, readStatusUnread, lastRead)
+		}
+	}
+
+	reactors, err := messageReactors(ctx, client, message)
+	if err != nil {
+		return err
+	}
+	for _, member := range reactors {
+		note(member, readStatusReacted, "")
+	}
+
+	// Messages are listed oldest first, so each member keeps the time of
+	// their first reply or later message.
+	filter := fmt.Sprintf("createTime > %q", msg.CreateTime)
+	later, more, err := listMessagesUpTo(ctx, api.NewMessagesService(client), space, filter, "createTime asc", readReportScanLimit)
+	if err != nil {
+		return fmt.Errorf("listing later messages: %w", err)
+	}
+	report.Truncated = more
+	for _, raw := range later {
+		var m tailMessage
+		if json.Unmarshal(raw, &m) != nil {
+			continue
+		}
+		if m.Thread.Name != "" && m.Thread.Name == msg.Thread.Name {
+			note(m.Sender.Name, readStatusReplied, m.CreateTime)
+		} else {
+			note(m.Sender.Name, readStatusActive, m.CreateTime)
+		}
+	}
+
+	sort.SliceStable(members, func(i, j int) bool {
+		return readStatusRank(members[i].Status) < readStatusRank(members[j].Status)
+	})
+	report.Members = members
+	for _, m := range members {
+		switch m.Status {
+		case readStatusUnread:
+			report.Unread++
+		case readStatusUnknown:
+			report.Unknown++
+		default:
+			report.Seen++
+		}
+	}
+
+	if f.IsJSON() {
+		return f.Print(report)
+	}
+	printReadReport(f, report)
+	return nil
+}
+
+// readStatusRank orders the statuses of a read report, strongest evidence
+// first.
+func readStatusRank(status string) int {
+	for i, s := range []string{readStatusSender, readStatusRead, readStatusReacted, readStatusReplied, readStatusActive, readStatusUnread} {
+		if s == status {
+			return i
+		}
+	}
+	return 6
+}
+
+// humanMembers returns the joined human members of space, sorted by name,
+// with status unknown.
+func humanMembers(ctx context.Context, client *api.Client, space string, admin bool) ([]readReportMember, error) {
+	svc := api.NewMembersService(client)
+	var members []readReportMember
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, space, 1000, pageToken, `member.type = "HUMAN"`, false, false, admin)
+		if err != nil {
+			return nil, fmt.Errorf("listing members: %w", err)
+		}
+		var resp struct {
+			Memberships []struct {
+				State  string `json:"state"`
+				Member struct {
+					Name        string `json:"name"`
+					DisplayName string `json:"displayName"`
+				} `json:"member"`
+			} `json:"memberships"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, m := range resp.Memberships {
+			if m.Member.Name == "" || (m.State != "" && m.State != "JOINED") {
+				continue
+			}
+			members = append(members, readReportMember{
+				Member:      m.Member.Name,
+				DisplayName: m.Member.DisplayName,
+				Status:      readStatusUnknown,
+			})
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Member < members[j].Member })
+	return members, nil
+}
+
+// spaceReadStates returns the last read times in space of the members whose
+// read state can be read, keyed by member, and whether those of members
+// other than the caller could be read. The caller's own read state is
+// always tried; those of the other members are only fetched if the first
+// of them can be read, since Google Chat normally refuses them all.
+func spaceReadStates(ctx context.Context, client *api.Client, space string, members []readReportMember) (map[string]string, bool) {
+	svc := api.NewReadStateService(client)
+	states := map[string]string{}
+	var mu sync.Mutex
+	get := func(ctx context.Context, member string) (string, error) {
+		raw, err := svc.GetSpaceReadState(ctx, member+"/"+space+"/spaceReadState")
+		if err != nil {
+			return "", err
+		}
+		var state struct {
+			LastReadTime string `json:"lastReadTime"`
+		}
+		if err := json.Unmarshal(raw, &state); err != nil {
+			return "", fmt.Errorf("parsing response: %w", err)
+		}
+		mu.Lock()
+		states[member] = state.LastReadTime
+		mu.Unlock()
+		return member, nil
+	}
+
+	me := ""
+	if !viper.GetBool("as_app") {
+		if user, err := currentUser(ctx, client); err == nil {
+			me = user
+			_, _ = get(ctx, me)
+		}
+	}
+
+	var others []string
+	for _, m := range members {
+		if m.Member != me {
+			others = append(others, m.Member)
+		}
+	}
+	if len(others) == 0 {
+		return states, false
+	}
+	if _, err := get(ctx, others[0]); err != nil {
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(os.Stderr, "Read states of other members are not available: %v\n", err)
+		}
+		return states, false
+	}
+	enableBulkRetries(client)
+	runBulk(ctx, output.NewFormatter(false, true), others[1:], get)
+	return states, true
+}
+
+// messageReactors returns the users who reacted to message.
+func messageReactors(ctx context.Context, client *api.Client, message string) ([]string, error) {
+	svc := api.NewReactionsService(client)
+	var users []string
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, message, 200, pageToken, "")
+		if err != nil {
+			return nil, fmt.Errorf("listing reactions: %w", err)
+		}
+		var resp struct {
+			Reactions []struct {
+				User struct {
+					Name string `json:"name"`
+				} `json:"user"`
+			} `json:"reactions"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, r := range resp.Reactions {
+			users = append(users, r.User.Name)
+		}
+		if resp.NextPageToken == "" {
+			return users, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// printReadReport prints report as a table followed by a summary.
+func printReadReport(f *output.Formatter, report *readReport) {
+	if len(report.Members) == 0 {
+		f.PrintMessage("No human members found.")
+		return
+	}
+
+	table := output.NewTable("MEMBER", "DISPLAY_NAME", "STATUS", "TIME")
+	for _, m := range report.Members {
+		table.AddRow(m.Member, m.DisplayName, m.Status, output.FormatTime(m.Time))
+	}
+	fmt.Print(table.Render())
+
+	total := len(report.Members)
+	f.PrintMessage(fmt.Sprintf("\nPlausibly seen by %d of %d %s (%d%%); %d unread, %d unknown.",
+		report.Seen, total, plural(total, "member", "members"), report.Seen*100/total, report.Unread, report.Unknown))
+	if !report.ReadStates && total > 1 {
+		f.PrintMessage("Read states of other members are not visible to this login; their status is based on their activity.")
+	}
+	if report.Truncated {
+		f.PrintMessage(fmt.Sprintf("Only the first %d later messages were scanned for activity.", readReportScanLimit))
+	}
+}
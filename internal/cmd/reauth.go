@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/oauth2"
+
+	"github.com/cipher-shad0w/gogchat/internal/auth"
+)
+
+// reauthState records the outcome of logging in again mid-command, so the
+// user is asked at most once per run even when parallel requests all fail.
+var reauthState struct {
+	mu        sync.Mutex
+	attempted bool
+	token     *oauth2.Token
+}
+
+// canPrompt reports whether the user can answer prompts: they are not
+// disabled and stdin and stderr are both terminals.
+func canPrompt() bool {
+	return !nonInteractive() && isTerminal(os.Stdin) && isTerminal(os.Stderr)
+}
+
+// requireInteractive fails fast in non-interactive mode for a command that
+// cannot run without the user, instead of leaving it waiting for input.
+func requireInteractive(action, hint string) error {
+	if nonInteractive() {
+		return fmt.Errorf("%s needs an interactive terminal, but prompts are disabled (non-interactive mode); %s", action, hint)
+	}
+	return nil
+}
+
+// reauthenticator returns an api.Client Reauthenticate hook that offers to
+// run the login flow when the stored credentials have expired or been
+// revoked; when prompts are not possible it only says how to fix it, once.
+// On success the new token is saved to tokenPath and swapped into
+// src, so the failed request can be retried without re-running the command.
+func reauthenticator(clientID, clientSecret, tokenPath string, src *auth.SwappableTokenSource) func(context.Context) bool {
+	return func(ctx context.Context) bool {
+		reauthState.mu.Lock()
+		defer reauthState.mu.Unlock()
+
+		if reauthState.attempted {
+			if reauthState.token == nil {
+				return false
+			}
+			src.Swap(clientID, clientSecret, reauthState.token)
+			return true
+		}
+		reauthState.attempted = true
+
+		if !canPrompt() {
+			fmt.Fprintln(os.Stderr, "⚠ Your Google session has expired or was revoked; run 'gogchat auth login' in a terminal, or use a service account with --as-app.")
+			return false
+		}
+		resume, ok := pausePager()
+		if !ok {
+			return false
+		}
+		defer resume()
+
+		fmt.Fprintln(os.Stderr, "⚠ Your Google session has expired or was revoked.")
+		yes, err := confirm("Log in again and retry?", nil)
+		if err != nil || !yes {
+			return false
+		}
+
+		token, err := auth.Login(clientID, clientSecret, loginOptions())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "✗ Login failed: %v\n", err)
+			return false
+		}
+		if err := auth.SaveToken(tokenPath, token); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Could not save the new token: %v\n", err)
+		}
+		fmt.Fprintln(os.Stderr, "✓ Logged in again; retrying.")
+
+		reauthState.token = token
+		src.Swap(clientID, clientSecret, token)
+		return true
+	}
+}
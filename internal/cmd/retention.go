@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/cipher-shad0w/gogchat/internal/timeparse"
+	"github.com/cipher-shad0w/gogchat/internal/undo"
+)
+
+// NewRetentionCmd creates the top-level "retention" command.
+func NewRetentionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "retention",
+		Short: "Delete messages older than a retention window",
+	}
+
+	cmd.AddCommand(newRetentionApplyCmd())
+
+	return cmd
+}
+
+// newRetentionApplyCmd creates the "retention apply" subcommand.
+func newRetentionApplyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Delete (or export and delete) messages older than a retention window",
+		Long: `Delete the messages of a space that are older than a retention window,
+optionally archiving them first.
+
+--keep is the retention window: "90d", "12w", "6 months", or "1 year".
+Without --space, the policies listed under retention in the config file
+are applied:
+
+  retention:
+    - space: spaces/AAAABBBBcccc
+      keep: 90d
+      export: ~/chat-archive
+    - space: CCCCDDDDeeee
+      keep: 1 year
+
+With --export DIR (or export in a policy), the expired messages of each
+space are appended to DIR/{space ID}.jsonl, one message per line, and are
+only deleted once the archive has been written.
+
+Messages are deleted newest first, so thread replies go before their
+thread root. A thread root whose replies are still inside the window is
+kept and reported as failed until the replies expire too.
+
+A run deletes at most limits.max_delete (default 100) messages across all
+spaces, even with --yes, starting with the oldest; the rest are reported and
+left for the next run. --max-delete N changes the cap for one run (0 for no
+cap). The messages are listed for confirmation before anything is deleted,
+unless --yes is given, and "gogchat undo" re-posts them.
+
+--dry-run lists what would be deleted without changing anything. --report
+FILE writes the outcome for every space and message as JSON, for
+auditing; deletions are also recorded in the audit_log if configured.
+Exits with status 6 if any message could not be deleted.`,
+		Example: `  gogchat retention apply --space spaces/AAAABBBBcccc --keep 90d --dry-run
+  gogchat retention apply --space spaces/AAAABBBBcccc --keep 90d --export ~/chat-archive --yes
+  gogchat retention apply --yes --report /var/log/gogchat/retention.json`,
+		Args: cobra.NoArgs,
+		RunE: runRetentionApply,
+	}
+
+	flags := cmd.Flags()
+	flags.StringArray("space", nil, "Space to apply the retention window to (repeatable)")
+	flags.String("keep", "", `Retention window, e.g. "90d" or "6 months" (required with --space)`)
+	flags.String("export", "", "Directory to archive expired messages to before deleting them")
+	flags.Bool("dry-run", false, "Show what would be deleted without deleting anything")
+	flags.String("report", "", "Write an audit report of the run as JSON to this file")
+	flags.Int("max-delete", 0, "Delete at most this many messages in this run, 0 for no cap (default: limits.max_delete)")
+	addConfirmFlags(cmd)
+	addProtectionFlag(cmd)
+
+	return cmd
+}
+
+// retentionSpace is the outcome of a retention policy for one space.
+type retentionSpace struct {
+	Space  string `json:"space"`
+	Keep   string `json:"keep"`
+	Cutoff string `json:"cutoff"`
+	// Matched is the number of messages older than Cutoff.
+	Matched int `json:"matched"`
+	// Export is the archive the messages were written to, if any.
+	Export string `json:"export,omitempty"`
+	// Error is why the policy could not be applied to the space at all.
+	Error string `json:"error,omitempty"`
+	// Deferred is the number of matched messages left for a later run by
+	// the cap on deletions.
+	Deferred int `json:"deferred,omitempty"`
+	// Messages lists the messages that would be deleted, on a dry run.
+	Messages []string `json:"messages,omitempty"`
+	bulkSummary
+
+	// names are the messages to delete, newest first, and raws the
+	// messages by name.
+	names []string
+	raws  map[string]json.RawMessage
+}
+
+// retentionReport is the audit report of a retention run.
+type retentionReport struct {
+	Time   string            `json:"time"`
+	DryRun bool              `json:"dryRun"`
+	Spaces []*retentionSpace `json:"spaces"`
+}
+
+func runRetentionApply(cmd *cobra.Command, args []string) error {
+	f := getFormatter()
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	reportFile, _ := cmd.Flags().GetString("report")
+	maxDelete, _ := cmd.Flags().GetInt("max-delete")
+	if !cmd.Flags().Changed("max-delete") && Cfg != nil {
+		maxDelete = Cfg.Limits.MaxDelete
+	}
+	if maxDelete < 0 {
+		return fmt.Errorf("--max-delete must not be negative")
+	}
+
+	policies, err := retentionPolicies(cmd)
+	if err != nil {
+		return err
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	svc := api.NewMessagesService(client)
+	guard := newSpaceGuard(cmd, client)
+	ctx := cmd.Context()
+	now := time.Now()
+
+	report := retentionReport{Time: now.UTC().Format(time.RFC3339), DryRun: dryRun}
+	for _, p := range policies {
+		rs := &retentionSpace{Space: api.NormalizeName(p.Space, "spaces/"), Keep: p.Keep, Export: p.Export}
+		report.Spaces = append(report.Spaces, rs)
+
+		cutoff, err := retentionCutoff(p.Keep, now)
+		if err != nil {
+			return fmt.Errorf("retention policy for %s: %w", rs.Space, err)
+		}
+		rs.Cutoff = formatFilterTime(cutoff)
+
+		if err := guard.check(ctx, rs.Space); err != nil {
+			rs.Error = err.Error()
+			continue
+		}
+
+		filter := fmt.Sprintf("createTime < %q", rs.Cutoff)
+		raws, err := listAllMessages(ctx, svc, rs.Space, filter, "createTime desc")
+		if err != nil {
+			rs.Error = fmt.Sprintf("listing messages: %v", err)
+			continue
+		}
+		rs.raws = make(map[string]json.RawMessage, len(raws))
+		for _, raw := range raws {
+			var msg struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(raw, &msg) == nil && msg.Name != "" {
+				rs.names = append(rs.names, msg.Name)
+				rs.raws[msg.Name] = raw
+			}
+		}
+		rs.Matched = len(rs.names)
+	}
+
+	// Past the cap, the oldest messages go first; the newer ones wait for
+	// the next run.
+	budget := maxDelete
+	for _, rs := range report.Spaces {
+		if maxDelete == 0 || len(rs.names) <= budget {
+			budget -= len(rs.names)
+			continue
+		}
+		rs.Deferred = len(rs.names) - budget
+		rs.names = rs.names[rs.Deferred:]
+		budget = 0
+	}
+
+	if dryRun {
+		for _, rs := range report.Spaces {
+			rs.Messages = rs.names
+		}
+		return finishRetention(f, report, reportFile)
+	}
+
+	var all []string
+	for _, rs := range report.Spaces {
+		if rs.Error == "" {
+			all = append(all, rs.names...)
+		}
+	}
+	if len(all) > 0 && !skipConfirm(cmd) {
+		for _, rs := range report.Spaces {
+			if rs.Error == "" && len(rs.names) > 0 && rs.Export != "" {
+				fmt.Fprintf(os.Stderr, "  %s: exported to %s first\n", rs.Space, rs.Export)
+			}
+		}
+		rows, err := previewMessages(ctx, client, all)
+		if err != nil {
+			return err
+		}
+		ok, err := confirmPreview(fmt.Sprintf("Delete these %d messages?", len(all)),
+			[]string{"MESSAGE", "SENDER", "TIME", "TEXT"}, rows)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			f.PrintMessage("Cancelled.")
+			return nil
+		}
+	}
+
+	enableBulkRetries(client)
+	recorder := newUndoRecorder(cmd)
+	defer recorder.save()
+	quiet := output.NewFormatter(false, true)
+	for _, rs := range report.Spaces {
+		if rs.Error != "" || len(rs.names) == 0 {
+			continue
+		}
+		if rs.Export != "" {
+			raws := make([]json.RawMessage, len(rs.names))
+			for i, name := range rs.names {
+				raws[i] = rs.raws[name]
+			}
+			path, err := exportRetained(rs.Export, rs.Space, raws)
+			if err != nil {
+				rs.Error = err.Error()
+				continue
+			}
+			rs.Export = path
+		}
+		rs.bulkSummary = runBulk(ctx, quiet, rs.names, func(ctx context.Context, name string) (string, error) {
+			if _, err := svc.Delete(ctx, name, false); err != nil {
+				return "", err
+			}
+			// The listed message is its state before the delete.
+			recorder.add(undo.KindMessage, name, rs.raws[name])
+			return name, nil
+		})
+	}
+
+	return finishRetention(f, report, reportFile)
+}
+
+// retentionPolicies returns the policies given by --space and --keep, or
+// else those of the retention config key.
+func retentionPolicies(cmd *cobra.Command) ([]config.RetentionPolicy, error) {
+	spaces, _ := cmd.Flags().GetStringArray("space")
+	keep, _ := cmd.Flags().GetString("keep")
+	export, _ := cmd.Flags().GetString("export")
+
+	if len(spaces) == 0 {
+		if cmd.Flags().Changed("keep") || cmd.Flags().Changed("export") {
+			return nil, fmt.Errorf("--keep and --export require --space")
+		}
+		if len(Cfg.Retention) == 0 {
+			return nil, fmt.Errorf("no --space given and no retention policies in the config file")
+		}
+		policies := make([]config.RetentionPolicy, len(Cfg.Retention))
+		for i, p := range Cfg.Retention {
+			if p.Space == "" || p.Keep == "" {
+				return nil, fmt.Errorf("retention policy %d in the config file needs both space and keep", i+1)
+			}
+			p.Export = expandHome(p.Export)
+			policies[i] = p
+		}
+		return policies, nil
+	}
+
+	if keep == "" {
+		return nil, fmt.Errorf("--keep is required with --space")
+	}
+	policies := make([]config.RetentionPolicy, len(spaces))
+	for i, space := range spaces {
+		policies[i] = config.RetentionPolicy{Space: space, Keep: keep, Export: expandHome(export)}
+	}
+	return policies, nil
+}
+
+// retentionCutoff returns the time before which messages fall outside a
+// retention window of keep, e.g. "90d" or "6 months".
+func retentionCutoff(keep string, now time.Time) (time.Time, error) {
+	t, err := timeparse.Parse(keep, now)
+	if err != nil {
+		// "6 months" reads as a window; timeparse wants "6 months ago".
+		if t, err = timeparse.Parse(keep+" ago", now); err != nil {
+			return time.Time{}, fmt.Errorf("invalid keep %q (try \"90d\" or \"6 months\")", keep)
+		}
+	}
+	if !t.Before(now) {
+		return time.Time{}, fmt.Errorf("keep %q does not reach into the past", keep)
+	}
+	return t, nil
+}
+
+// exportRetained appends messages to dir/{space ID}.jsonl, one compact JSON
+// message per line, and returns the file's path. The file is synced before
+// returning so that nothing is deleted that was not archived.
+func exportRetained(dir, space string, messages []json.RawMessage) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("creating export directory: %w", err)
+	}
+	path := filepath.Join(dir, strings.TrimPrefix(space, "spaces/")+".jsonl")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("opening export file: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	enc := json.NewEncoder(w)
+	for _, raw := range messages {
+		if err := enc.Encode(raw); err != nil {
+			return "", fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	if err := file.Sync(); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	return path, file.Close()
+}
+
+// finishRetention prints the outcome of a retention run, writes the audit
+// report if requested, and returns a bulkError if anything failed.
+func finishRetention(f *output.Formatter, report retentionReport, reportFile string) error {
+	if reportFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(expandHome(reportFile), append(data, '\n'), 0o600); err != nil {
+			return fmt.Errorf("writing report: %w", err)
+		}
+	}
+
+	failedSpaces, failedMessages := 0, 0
+	for _, rs := range report.Spaces {
+		if rs.Error != "" {
+			failedSpaces++
+		}
+		failedMessages += rs.Failed + rs.Skipped
+	}
+
+	if f.IsJSON() {
+		if err := f.Print(report); err != nil {
+			return err
+		}
+	} else {
+		table := output.NewTable("SPACE", "CUTOFF", "MATCHED", "DELETED", "FAILED", "NOTE")
+		for _, rs := range report.Spaces {
+			var notes []string
+			if rs.Error != "" {
+				notes = append(notes, rs.Error)
+			} else if rs.Export != "" && !report.DryRun {
+				notes = append(notes, "exported to "+rs.Export)
+			}
+			if rs.Deferred > 0 {
+				notes = append(notes, fmt.Sprintf("%d left for the next run (limits.max_delete)", rs.Deferred))
+			}
+			note := strings.Join(notes, "; ")
+			deleted, failedHere := fmt.Sprint(rs.Succeeded), fmt.Sprint(rs.Failed+rs.Skipped)
+			if report.DryRun {
+				deleted, failedHere = "-", "-"
+			}
+			table.AddRow(rs.Space, output.FormatTime(rs.Cutoff), fmt.Sprint(rs.Matched), deleted, failedHere, note)
+		}
+		fmt.Print(table.Render())
+		if report.DryRun {
+			f.PrintMessage("Dry run: nothing was deleted.")
+		}
+		for _, rs := range report.Spaces {
+			printBulkFailures(f, rs.bulkSummary)
+		}
+	}
+
+	if failedSpaces+failedMessages > 0 {
+		return &bulkError{fmt.Sprintf("retention: %d spaces could not be processed, %d messages could not be deleted", failedSpaces, failedMessages)}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -65,8 +66,47 @@ the full Chat API from your terminal.`,
 			return fmt.Errorf("loading config: %w", err)
 		}
 		Cfg = cfg
+
+		if err := applyAccount(cmd); err != nil {
+			return err
+		}
+		if err := applyOutputFormat(cmd); err != nil {
+			return err
+		}
+		if err := applyAPIVersion(cmd); err != nil {
+			return err
+		}
+		if err := checkReadOnly(cmd); err != nil {
+			return err
+		}
+		if err := checkScopes(cmd); err != nil {
+			return err
+		}
+		if err := configureTimeOutput(); err != nil {
+			return err
+		}
+		if err := configureTheme(); err != nil {
+			return err
+		}
+		configureColumns(cmd)
+
+		startPager(cmd)
+		if viper.GetBool("copy") {
+			return startCopy()
+		}
 		return nil
 	},
+	// On first use, "gogchat" alone starts the guided setup.
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if firstUse() && canPrompt() {
+			return runOnboarding()
+		}
+		return cmd.Help()
+	},
+	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		defer stopPager()
+		return finishCopy(true)
+	},
 }
 
 func init() {
@@ -75,16 +115,37 @@ func init() {
 
 	pflags.BoolP("json", "j", false, "Output in JSON format")
 	pflags.Bool("admin", false, "Use admin access")
+	pflags.Bool("as-app", false, "Authenticate as the Chat app using a service account")
 	pflags.BoolP("quiet", "q", false, "Suppress non-essential output")
 	pflags.BoolP("verbose", "v", false, "Enable verbose/debug output")
 	pflags.String("config", "", "Path to config file")
+	pflags.String("account", "", `Account (profile) to use; "all" runs read-only commands against every profile`)
+	pflags.StringSlice("profiles", nil, "Run a read-only command against these profiles (comma-separated) and merge the results")
+	pflags.StringArrayVar(&extraHeaders, "header", nil, "Extra HTTP header for API requests as 'Name: value' (repeatable)")
+	pflags.Bool("no-pager", false, "Do not pipe output through a pager")
+	pflags.Bool("utc", false, "Show timestamps as RFC 3339 in UTC")
+	pflags.Bool("relative", false, `Show timestamps relative to now (e.g. "3m ago")`)
+	pflags.Bool("copy", false, "Also copy the command's output to the system clipboard")
+	pflags.StringSlice("columns", nil, "Table columns to show, in order (comma-separated, e.g. name,displayName)")
+	pflags.Int("concurrency", defaultConcurrency, "Number of parallel requests for bulk operations")
+	pflags.Bool("non-interactive", false, "Never prompt; fail with an error where input would be needed (default when stdin is not a terminal)")
+	pflags.String("api-version", "", `Chat API version to send requests to (default "v1"; preview versions need preview_features)`)
 
 	// Bind each flag to Viper so env vars and config file values also work.
+	config.SetupEnv()
 	_ = viper.BindPFlag("json", pflags.Lookup("json"))
 	_ = viper.BindPFlag("admin", pflags.Lookup("admin"))
+	_ = viper.BindPFlag("as_app", pflags.Lookup("as-app"))
 	_ = viper.BindPFlag("quiet", pflags.Lookup("quiet"))
 	_ = viper.BindPFlag("verbose", pflags.Lookup("verbose"))
 	_ = viper.BindPFlag("config", pflags.Lookup("config"))
+	_ = viper.BindPFlag("account", pflags.Lookup("account"))
+	_ = viper.BindPFlag("concurrency", pflags.Lookup("concurrency"))
+	_ = viper.BindPFlag("copy", pflags.Lookup("copy"))
+	_ = viper.BindPFlag("no_pager", pflags.Lookup("no-pager"))
+	_ = viper.BindPFlag("utc", pflags.Lookup("utc"))
+	_ = viper.BindPFlag("relative", pflags.Lookup("relative"))
+	_ = viper.BindPFlag("non_interactive", pflags.Lookup("non-interactive"))
 
 	// Apply custom usage template.
 	rootCmd.SetUsageTemplate(usageTemplate)
@@ -101,14 +162,49 @@ func init() {
 		NewMediaCmd(),
 		NewEventsCmd(),
 		NewReadStateCmd(),
+		NewCatchupCmd(),
+		NewDigestCmd(),
+		NewMentionsCmd(),
+		NewStarCmd(),
+		NewSchemaCmd(),
+		NewValidateCmd(),
 		NewNotificationsCmd(),
+		NewThreadsCmd(),
+		NewBatchCmd(),
+		NewUndoCmd(),
+		NewCacheCmd(),
+		NewRetentionCmd(),
+		NewImportCmd(),
+		NewSentCmd(),
+		NewAnalyticsCmd(),
+		NewDMCmd(),
+		NewWebhooksCmd(),
+		NewBridgeCmd(),
+		NewNotifyCmd(),
+		NewConfigCmd(),
+		NewHistoryCmd(),
+		NewRedoCmd(),
+		NewExportCmd(),
+		NewDLQCmd(),
+		NewGenCmd(),
+		NewBotCmd(),
 	)
+	applyDefaultSpace(rootCmd)
 }
 
 // Execute runs the root command. It is the single entry point called from main.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		printRichError(err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	writeAuditLog(cmd, err)
+	writeHistory(cmd, err)
+	if err != nil {
+		// Restore stdout without copying partial output of a failed command.
+		_ = finishCopy(false)
+		stopPager()
+		var redoErr *redoExitError
+		if !errors.As(err, &redoErr) {
+			printRichError(err)
+		}
+		os.Exit(exitCode(err))
 	}
 }
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/auth"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+)
+
+// rotateBackupSuffix is appended to the config and token files to name
+// the backups kept by "auth rotate".
+const rotateBackupSuffix = ".bak"
+
+// newAuthRotateCmd creates the "auth rotate" subcommand.
+func newAuthRotateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Switch to a new OAuth client and token",
+		Long: `Switch to a new OAuth client, e.g. when the client secret is rotated or
+the client is replaced.
+
+Runs the browser sign-in with the new client, then checks the resulting
+token: it must come with a refresh token, refreshing it must work (which
+proves the client secret), and it must be accepted by the Chat API. Only
+then are the client ID and secret written to the config file (under the
+--account profile if one is selected) and the token file replaced. Both
+files are first written next to the originals and then renamed into
+place, and the previous versions are kept as config.yaml.bak and
+token.json.bak (or the names of your files plus .bak).
+
+If anything goes wrong before the swap, nothing is changed. To go back to
+the previous client afterwards, run "auth rotate --rollback".`,
+		Example: `  gogchat auth rotate --client-id 1234-new.apps.googleusercontent.com --client-secret GOCSPX-new
+  gogchat auth rotate --rollback`,
+		Args: cobra.NoArgs,
+		RunE: runAuthRotate,
+	}
+
+	flags := cmd.Flags()
+	flags.String("client-id", "", "The new OAuth2 client ID")
+	flags.String("client-secret", "", "The new OAuth2 client secret")
+	flags.Int("redirect-port", 0, "Localhost port of the OAuth callback server (default 8085, or the port of --redirect-uri)")
+	flags.String("redirect-uri", "", "Redirect URI registered for the new OAuth client (default http://localhost:{port})")
+	flags.Duration("timeout", auth.DefaultLoginTimeout, "How long to wait for the browser sign-in")
+	flags.Bool("rollback", false, "Restore the config and token files saved by the last rotation")
+	cmd.MarkFlagsMutuallyExclusive("rollback", "client-id")
+	cmd.MarkFlagsMutuallyExclusive("rollback", "client-secret")
+	disablePager(cmd)
+
+	return cmd
+}
+
+func runAuthRotate(cmd *cobra.Command, args []string) error {
+	configPath := viper.ConfigFileUsed()
+	if configPath == "" {
+		configPath = filepath.Join(config.ConfigDir(), "config.yaml")
+	}
+	tokenFile := tokenPath()
+
+	if rollback, _ := cmd.Flags().GetBool("rollback"); rollback {
+		return rollbackRotation(configPath, tokenFile)
+	}
+
+	clientID, _ := cmd.Flags().GetString("client-id")
+	clientSecret, _ := cmd.Flags().GetString("client-secret")
+	if clientID == "" || clientSecret == "" {
+		return fmt.Errorf("--client-id and --client-secret are required")
+	}
+	if err := auth.ValidateCredentials(clientID, clientSecret); err != nil {
+		return err
+	}
+	for _, env := range []string{"GOGCHAT_CLIENT_ID", "GOGCHAT_CLIENT_SECRET"} {
+		if os.Getenv(env) != "" {
+			fmt.Fprintf(os.Stderr, "⚠ %s is set and overrides the config file; update it too.\n", env)
+		}
+	}
+
+	if err := requireInteractive("auth rotate", "run it in a terminal; it signs in with the new client in a browser"); err != nil {
+		return err
+	}
+
+	opts := loginOptions()
+	opts.RedirectPort, _ = cmd.Flags().GetInt("redirect-port")
+	opts.RedirectURI, _ = cmd.Flags().GetString("redirect-uri")
+	if cmd.Flags().Changed("timeout") {
+		opts.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	}
+	token, err := auth.Login(clientID, clientSecret, opts)
+	if err != nil {
+		return fmt.Errorf("login with the new client failed: %w", err)
+	}
+
+	// Check the token before touching any file.
+	if token.RefreshToken == "" {
+		return fmt.Errorf("the new client returned no refresh token; nothing was changed")
+	}
+	expired := *token
+	expired.Expiry = time.Now().Add(-time.Minute)
+	if token, err = auth.RefreshToken(clientID, clientSecret, &expired); err != nil {
+		return fmt.Errorf("the new client secret does not work; nothing was changed: %w", err)
+	}
+	client, err := configureClient(api.NewClient(auth.HTTPClient(clientID, clientSecret, token)))
+	if err != nil {
+		return err
+	}
+	if _, err := api.NewSpacesService(client).List(cmd.Context(), "", 1, ""); err != nil {
+		return fmt.Errorf("the Chat API rejected the new token; nothing was changed: %w", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	newConfig, err := setConfigCredentials(data, viper.GetString("account"), clientID, clientSecret)
+	if err != nil {
+		return fmt.Errorf("updating %s: %w", configPath, err)
+	}
+	newToken, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling token: %w", err)
+	}
+
+	if err := swapFiles(map[string][]byte{configPath: newConfig, tokenFile: newToken}); err != nil {
+		return err
+	}
+
+	fmt.Println("✓ Switched to the new OAuth client")
+	fmt.Printf("  Config: %s (backup: %s)\n", configPath, configPath+rotateBackupSuffix)
+	fmt.Printf("  Token:  %s (backup: %s)\n", tokenFile, tokenFile+rotateBackupSuffix)
+	fmt.Println("  Undo with 'gogchat auth rotate --rollback'")
+	return nil
+}
+
+// setConfigCredentials returns the config file data with client_id and
+// client_secret set, at the top level or in the profile of account.
+// Comments and the other settings are kept.
+func setConfigCredentials(data []byte, account, clientID, clientSecret string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("the config file is not a YAML mapping")
+	}
+
+	target := root
+	if account != "" {
+		target = yamlMapping(yamlMapping(root, "profiles"), account)
+	}
+	yamlSet(target, "client_id", clientID)
+	yamlSet(target, "client_secret", clientSecret)
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// yamlMapping returns the mapping under key in m, adding it if needed.
+func yamlMapping(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			if v := m.Content[i+1]; v.Kind == yaml.MappingNode {
+				return v
+			}
+			m.Content[i+1] = &yaml.Node{Kind: yaml.MappingNode}
+			return m.Content[i+1]
+		}
+	}
+	v := &yaml.Node{Kind: yaml.MappingNode}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+	return v
+}
+
+// yamlSet sets key in the mapping m to the string value.
+func yamlSet(m *yaml.Node, key, value string) {
+	v := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			v.LineComment = m.Content[i+1].LineComment
+			m.Content[i+1] = v
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+}
+
+// swapFiles replaces each file with its new contents. The new contents are
+// written next to the files first and the current files are backed up;
+// then they are renamed into place. If a rename fails, the files already
+// replaced are restored from their backups.
+func swapFiles(files map[string][]byte) error {
+	temps := map[string]string{}
+	defer func() {
+		for _, tmp := range temps {
+			os.Remove(tmp)
+		}
+	}()
+	for path, data := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".new-*")
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+		temps[path] = tmp.Name()
+		_, err = tmp.Write(data)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), 0o600)
+		}
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+
+	existed := map[string]bool{}
+	for path := range files {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			os.Remove(path + rotateBackupSuffix)
+			continue
+		}
+		if err == nil {
+			err = os.WriteFile(path+rotateBackupSuffix, data, 0o600)
+		}
+		if err != nil {
+			return fmt.Errorf("backing up %s: %w", path, err)
+		}
+		existed[path] = true
+	}
+
+	var swapped []string
+	for path := range files {
+		if err := os.Rename(temps[path], path); err != nil {
+			for _, done := range swapped {
+				if existed[done] {
+					_ = copyFile(done+rotateBackupSuffix, done)
+				} else {
+					os.Remove(done)
+				}
+			}
+			return fmt.Errorf("replacing %s (nothing was changed): %w", path, err)
+		}
+		delete(temps, path)
+		swapped = append(swapped, path)
+	}
+	return nil
+}
+
+// copyFile copies src to dst with owner-only permissions.
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0o600)
+}
+
+// rollbackRotation restores the config and token files from the backups
+// of the last rotation.
+func rollbackRotation(configPath, tokenFile string) error {
+	restore := map[string][]byte{}
+	for _, path := range []string{configPath, tokenFile} {
+		data, err := os.ReadFile(path + rotateBackupSuffix)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no backup %s to roll back to", path+rotateBackupSuffix)
+		}
+		if err != nil {
+			return fmt.Errorf("reading backup: %w", err)
+		}
+		restore[path] = data
+	}
+
+	// Swapping keeps the rotated files as backups, so a rollback can
+	// itself be undone with another rollback.
+	if err := swapFiles(restore); err != nil {
+		return err
+	}
+	fmt.Println("✓ Restored the previous OAuth client and token")
+	fmt.Printf("  Config: %s\n", configPath)
+	fmt.Printf("  Token:  %s\n", tokenFile)
+	return nil
+}
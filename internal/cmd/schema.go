@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/cipher-shad0w/gogchat/internal/schema"
+)
+
+// NewSchemaCmd creates the top-level "schema" command.
+func NewSchemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema [RESOURCE]",
+		Short: "Print the JSON Schema of an API resource",
+		Long: `Print the JSON Schema (draft 2020-12) of a Google Chat resource, for
+editor validation and completion of --body payloads and card files. Without
+RESOURCE, the available resources are listed.
+
+The schemas cover the writable fields and the output fields commonly read
+back. Output-only fields are marked readOnly, and additional properties are
+allowed so that newer API fields do not fail validation.`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: schema.Resources(),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+
+			if len(args) == 0 {
+				if f.IsJSON() {
+					return printList(f, schema.Resources(), "")
+				}
+				table := output.NewTable("RESOURCE", "DESCRIPTION")
+				for _, name := range schema.Resources() {
+					table.AddRow(name, schema.Description(name))
+				}
+				f.PrintMessage(table.Render())
+				return nil
+			}
+
+			s, err := schema.For(args[0])
+			if err != nil {
+				return err
+			}
+			return output.PrintJSON(s)
+		},
+	}
+
+	return cmd
+}
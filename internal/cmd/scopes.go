@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/auth"
+	"github.com/cipher-shad0w/gogchat/internal/cache"
+)
+
+// commandScopes are the commands that need a scope outside the default
+// login scopes, named by their path below the root command. Any one of the
+// listed scopes (short names) is enough; the first is suggested for logging
+// in, so the narrowest comes first.
+var commandScopes = map[string][]string{
+	"import begin":           {"chat.import"},
+	"import complete":        {"chat.import"},
+	"spaces complete-import": {"chat.import"},
+	"spaces delete":          {"chat.delete", "chat.import"},
+	"spaces search":          {"chat.admin.spaces.readonly", "chat.admin.spaces"},
+}
+
+// adminCommandScopes are the scopes that commands need with --admin, in
+// the form of commandScopes. They replace the entry of commandScopes.
+var adminCommandScopes = map[string][]string{
+	"members add":      {"chat.admin.memberships"},
+	"members expire":   {"chat.admin.memberships"},
+	"members expiring": {"chat.admin.memberships.readonly", "chat.admin.memberships"},
+	"members get":      {"chat.admin.memberships.readonly", "chat.admin.memberships"},
+	"members list":     {"chat.admin.memberships.readonly", "chat.admin.memberships"},
+	"members remove":   {"chat.admin.memberships"},
+	"members update":   {"chat.admin.memberships"},
+	"readstate report": {"chat.admin.memberships.readonly", "chat.admin.memberships"},
+	"spaces delete":    {"chat.admin.delete"},
+	"spaces get":       {"chat.admin.spaces.readonly", "chat.admin.spaces"},
+	"spaces update":    {"chat.admin.spaces"},
+}
+
+// featureScopes are the flags of commands that need a scope outside the
+// default login scopes, in the form of commandScopes. They are checked with
+// requireFeatureScopes when the flag is used, so that the scope is only
+// asked of the users who need it.
+var featureScopes = map[string][]string{
+	"media upload --as-drive":             {"drive.file", "drive"},
+	"messages send --drive-share-members": {"drive", "drive.file"},
+}
+
+// directoryScope lets gogchat search the Workspace directory, to find
+// users by email address and the email addresses of users. It is not a
+// default login scope, so that only the users of these lookups are asked
+// for it.
+const directoryScope = "directory.readonly"
+
+// directoryError adds to an error of a directory lookup how to get
+// directoryScope, if the lookup was refused.
+func directoryError(err error) error {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusForbidden {
+		return err
+	}
+	return &hintError{err: err, hint: fmt.Sprintf(`Looking up people in the Workspace directory needs the %s scope,
+which the default login does not request. Log in again with:
+  %s --scopes %s`, directoryScope, loginCommand(), directoryScope)}
+}
+
+// scopeCheckTimeout bounds the tokeninfo request of a scope check, which
+// is skipped when it fails.
+const scopeCheckTimeout = 10 * time.Second
+
+// requiredScopes returns the scopes of which the command at path needs
+// one, or nil if the default login scopes are enough.
+func requiredScopes(path string, admin bool) []string {
+	if admin {
+		if scopes, ok := adminCommandScopes[path]; ok {
+			return scopes
+		}
+	}
+	return commandScopes[path]
+}
+
+// hasAnyScope reports whether granted holds one of the short scope names.
+func hasAnyScope(granted []string, scopes []string) bool {
+	for _, s := range scopes {
+		if slices.Contains(granted, auth.ScopeURL(s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkScopes refuses cmd before it sends a request if it needs a scope
+// the login was not granted, so that the user learns which scope to log in
+// with instead of getting a 403 afterwards. It does nothing for commands
+// that work with the default scopes, with --as-app, and when the granted
+// scopes cannot be determined.
+func checkScopes(cmd *cobra.Command) error {
+	if !cmd.HasParent() || viper.GetBool("as_app") || fanningOut(cmd) {
+		return nil
+	}
+	path := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	admin, _ := cmd.Flags().GetBool("admin")
+	needed := requiredScopes(path, admin)
+	if len(needed) == 0 {
+		return nil
+	}
+
+	granted, err := grantedScopes(context.Background())
+	if err != nil {
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(os.Stderr, "⚠ Could not check the scopes of the login: %v\n", err)
+		}
+		return nil
+	}
+	if hasAnyScope(granted, needed) {
+		return nil
+	}
+	return missingScopeError(cmd.CommandPath(), needed)
+}
+
+// requireFeatureScopes refuses feature, a key of featureScopes, if the
+// login was not granted one of its scopes, like checkScopes does for whole
+// commands. It does nothing with --as-app and when the granted scopes
+// cannot be determined.
+func requireFeatureScopes(ctx context.Context, feature string) error {
+	needed := featureScopes[feature]
+	if len(needed) == 0 || viper.GetBool("as_app") {
+		return nil
+	}
+	granted, err := grantedScopes(ctx)
+	if err != nil {
+		if viper.GetBool("verbose") {
+			fmt.Fprintf(os.Stderr, "⚠ Could not check the scopes of the login: %v\n", err)
+		}
+		return nil
+	}
+	if hasAnyScope(granted, needed) {
+		return nil
+	}
+	return missingScopeError("gogchat "+feature, needed)
+}
+
+// fanningOut reports whether cmd runs against several accounts, whose
+// logins are not checked.
+func fanningOut(cmd *cobra.Command) bool {
+	profiles, _ := cmd.Flags().GetStringSlice("profiles")
+	return len(profiles) > 0 || viper.GetString("account") == allAccounts
+}
+
+// missingScopeError tells which scope command needs and how to log in
+// with it.
+func missingScopeError(command string, needed []string) error {
+	what := "scope " + needed[0]
+	if len(needed) > 1 {
+		what = "scope " + strings.Join(needed, " or ")
+	}
+	return fmt.Errorf("%q requires %s, which this login was not granted; log in again with: %s --scopes %s",
+		command, what, loginCommand(), needed[0])
+}
+
+// loginCommand returns the command that logs in to the selected account.
+func loginCommand() string {
+	if account := viper.GetString("account"); account != "" {
+		return fmt.Sprintf("gogchat --account %s auth login", account)
+	}
+	return "gogchat auth login"
+}
+
+// grantedScopes returns the scopes (full URLs) granted to the stored
+// login. They are looked up with Google's tokeninfo endpoint and cached per
+// refresh token.
+func grantedScopes(ctx context.Context) ([]string, error) {
+	httpClientsMu.Lock()
+	ac, err := userClient(Cfg)
+	httpClientsMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	token, err := ac.src.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	id := token.RefreshToken
+	if id == "" {
+		id = token.AccessToken
+	}
+	sum := sha256.Sum256([]byte(id))
+	key := hex.EncodeToString(sum[:16])
+
+	var scopes []string
+	if getCache().Get(cache.Scopes, key, &scopes) {
+		return scopes, nil
+	}
+	scopes, err = auth.TokenScopes(ctx, &http.Client{Transport: getTransport(), Timeout: scopeCheckTimeout}, token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(scopes)
+	_ = getCache().Put(cache.Scopes, key, scopes)
+	return scopes, nil
+}
+
+// unavailableCommands returns the commands (and --admin variants) that
+// need a scope missing from granted, each with the scopes it needs.
+func unavailableCommands(granted []string) map[string][]string {
+	missing := map[string][]string{}
+	for path, scopes := range commandScopes {
+		if !hasAnyScope(granted, scopes) {
+			missing[path] = scopes
+		}
+	}
+	for path, scopes := range adminCommandScopes {
+		if !hasAnyScope(granted, scopes) {
+			missing[path+" --admin"] = scopes
+		}
+	}
+	for feature, scopes := range featureScopes {
+		if !hasAnyScope(granted, scopes) {
+			missing[feature] = scopes
+		}
+	}
+	return missing
+}
+
+// printScopeStatus prints the scopes of the stored login for "auth
+// status": default scopes that were not granted, extra scopes that were,
+// and the commands that cannot run.
+func printScopeStatus() {
+	granted, err := grantedScopes(context.Background())
+	if err != nil {
+		fmt.Printf("  Scopes: unknown (%v)\n", err)
+		return
+	}
+
+	var missing, extra []string
+	for _, s := range auth.Scopes {
+		if !slices.Contains(granted, s) {
+			missing = append(missing, auth.ShortScope(s))
+		}
+	}
+	for _, s := range granted {
+		if !slices.Contains(auth.Scopes, s) {
+			extra = append(extra, auth.ShortScope(s))
+		}
+	}
+	fmt.Printf("  Scopes: %d granted\n", len(granted))
+	if len(missing) > 0 {
+		fmt.Printf("  Default scopes not granted: %s\n", strings.Join(missing, ", "))
+	}
+	if len(extra) > 0 {
+		fmt.Printf("  Extra scopes: %s\n", strings.Join(extra, ", "))
+	}
+
+	unavailable := unavailableCommands(granted)
+	if len(unavailable) == 0 {
+		return
+	}
+	commands := make([]string, 0, len(unavailable))
+	width := 0
+	for command := range unavailable {
+		commands = append(commands, command)
+		width = max(width, len(command))
+	}
+	sort.Strings(commands)
+	fmt.Printf("  Unavailable commands (log in again with %s --scopes SCOPE):\n", loginCommand())
+	for _, command := range commands {
+		fmt.Printf("    %-*s  needs %s\n", width, command, strings.Join(unavailable[command], " or "))
+	}
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// sentLogSize is the number of sends kept in the sent-message log; older
+// ones are dropped.
+const sentLogSize = 200
+
+// sentMessage is one message sent (or attempted) by gogchat.
+type sentMessage struct {
+	Space     string                 `json:"space"`
+	Message   string                 `json:"message,omitempty"`
+	ThreadKey string                 `json:"threadKey,omitempty"`
+	Body      map[string]interface{} `json:"body"`
+	SentAt    time.Time              `json:"sentAt"`
+	Error     string                 `json:"error,omitempty"`
+}
+
+// text returns the message text, or a placeholder for card-only messages.
+func (s sentMessage) text() string {
+	if text, _ := s.Body["text"].(string); text != "" {
+		return text
+	}
+	return "(no text)"
+}
+
+// sentMu serialises updates of the log by concurrent batch operations.
+var sentMu sync.Mutex
+
+// sentPath returns the location of the sent-message log.
+func sentPath() string {
+	return filepath.Join(config.ConfigDir(), "sent.json")
+}
+
+// loadSent reads the sent-message log, oldest first; a missing log is
+// empty.
+func loadSent() ([]sentMessage, error) {
+	data, err := os.ReadFile(sentPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading sent messages: %w", err)
+	}
+	var sent []sentMessage
+	if err := json.Unmarshal(data, &sent); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", sentPath(), err)
+	}
+	return sent, nil
+}
+
+// saveSent writes the sent-message log.
+func saveSent(sent []sentMessage) error {
+	data, err := json.MarshalIndent(sent, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.ConfigDir(), 0o700); err != nil {
+		return fmt.Errorf("saving sent messages: %w", err)
+	}
+	if err := os.WriteFile(sentPath(), data, 0o600); err != nil {
+		return fmt.Errorf("saving sent messages: %w", err)
+	}
+	return nil
+}
+
+// recordSent adds the message with body sent to space with thread key key
+// to the log: raw is the created message, or sendErr why sending failed.
+// Failures to write the log are reported but do not fail the send.
+func recordSent(space, key string, body map[string]interface{}, raw json.RawMessage, sendErr error) {
+	entry := sentMessage{
+		Space:     api.NormalizeName(space, "spaces/"),
+		ThreadKey: key,
+		Body:      body,
+		SentAt:    time.Now().UTC(),
+	}
+	if sendErr != nil {
+		entry.Error = sendErr.Error()
+	} else {
+		entry.Message = jsonField(raw, "name")
+	}
+
+	sentMu.Lock()
+	defer sentMu.Unlock()
+	sent, err := loadSent()
+	if err == nil {
+		sent = append(sent, entry)
+		if len(sent) > sentLogSize {
+			sent = sent[len(sent)-sentLogSize:]
+		}
+		err = saveSent(sent)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record the sent message: %v\n", err)
+	}
+}
+
+// NewSentCmd creates the top-level "sent" command with list and resend
+// subcommands.
+func NewSentCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sent",
+		Short: "Show and resend messages sent with gogchat",
+		Long: `Show and resend the messages sent with gogchat.
+
+Every message sent by "messages send", "dm broadcast", or a messages.send
+operation of "batch run" is logged locally in ~/.config/gogchat/sent.json,
+including sends that failed, so that an accidentally deleted announcement
+or a failed broadcast can be re-issued quickly. The last 200 sends are kept.`,
+	}
+
+	cmd.AddCommand(
+		newSentListCmd(),
+		newSentResendCmd(),
+	)
+
+	return cmd
+}
+
+// newSentListCmd creates the "sent list" subcommand.
+func newSentListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List recently sent messages",
+		Long:  "List the messages sent with gogchat, newest first. The number in the # column identifies the message for \"sent resend\".",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			limit, _ := cmd.Flags().GetInt("limit")
+			space, _ := cmd.Flags().GetString("space")
+			failed, _ := cmd.Flags().GetBool("failed")
+
+			sent, err := loadSent()
+			if err != nil {
+				return err
+			}
+			if space != "" {
+				space = api.NormalizeName(space, "spaces/")
+			}
+
+			type numbered struct {
+				N int `json:"n"`
+				sentMessage
+			}
+			var entries []numbered
+			for i := len(sent) - 1; i >= 0 && (limit <= 0 || len(entries) < limit); i-- {
+				s := sent[i]
+				if (space != "" && s.Space != space) || (failed && s.Error == "") {
+					continue
+				}
+				entries = append(entries, numbered{N: len(sent) - i, sentMessage: s})
+			}
+
+			if f.IsJSON() {
+				return printList(f, entries, "")
+			}
+			if len(entries) == 0 {
+				f.PrintMessage("No sent messages.")
+				return nil
+			}
+
+			table := output.NewTable("#", "TIME", "SPACE", "STATUS", "TEXT")
+			for _, e := range entries {
+				status := "sent"
+				if e.Error != "" {
+					status = "failed"
+				}
+				table.AddRow(strconv.Itoa(e.N), output.FormatTime(e.SentAt.Format(time.RFC3339)),
+					e.Space, status, output.Truncate(e.text(), 60))
+			}
+			fmt.Print(table.Render())
+			return nil
+		},
+	}
+
+	cmd.Flags().Int("limit", 20, "Maximum number of messages to list (0 for all)")
+	cmd.Flags().String("space", "", "Only list messages sent to this space")
+	cmd.Flags().Bool("failed", false, "Only list sends that failed")
+
+	return cmd
+}
+
+// newSentResendCmd creates the "sent resend" subcommand.
+func newSentResendCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resend N",
+		Short: "Send a logged message again",
+		Long: `Send message N of "sent list" again, with the same text, cards, and
+attachments. It goes to the space it was first sent to, into the same
+thread, unless --to names another space.`,
+		Example: `  gogchat sent list
+  gogchat sent resend 1
+  gogchat sent resend 3 --to spaces/AAAABBBBcccc`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSentResend,
+	}
+
+	cmd.Flags().String("to", "", "Send to this space instead of the original one")
+
+	return cmd
+}
+
+func runSentResend(cmd *cobra.Command, args []string) error {
+	to, _ := cmd.Flags().GetString("to")
+
+	sent, err := loadSent()
+	if err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 || n > len(sent) {
+		return fmt.Errorf("no sent message %s; see \"gogchat sent list\"", args[0])
+	}
+	orig := sent[len(sent)-n]
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+
+	space, key := orig.Space, orig.ThreadKey
+	body := orig.Body
+	if to != "" && api.NormalizeName(to, "spaces/") != orig.Space {
+		// Threads belong to their space, so start afresh in the other one.
+		space, key = api.NormalizeName(to, "spaces/"), ""
+		delete(body, "thread")
+	}
+
+	threadKey, replyOption := applyThreadKey(space, key, body, "")
+	raw, err := api.NewMessagesService(client).Create(cmd.Context(), space, body, threadKey, "", "", replyOption)
+	recordSent(space, key, body, raw, err)
+	if err != nil {
+		return fmt.Errorf("resending message: %w", err)
+	}
+	recordThreadKey(space, key, raw)
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+	f.PrintSuccess(fmt.Sprintf("Message resent to %s", space))
+	f.PrintMessage(fmt.Sprintf("Name:        %s", jsonField(raw, "name")))
+	return nil
+}
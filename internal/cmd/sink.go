@@ -0,0 +1,583 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/config"
+)
+
+// Sink types of the sinks config.
+const (
+	sinkFile    = "file"
+	sinkWebhook = "webhook"
+	sinkS3      = "s3"
+)
+
+// Limits of the batching sinks.
+const (
+	// sinkRequestTimeout bounds each request of a webhook or s3 sink.
+	sinkRequestTimeout = 30 * time.Second
+	// sinkMaxPendingBatches is the number of batches a batching sink holds
+	// while its destination fails; older records are dropped past it.
+	sinkMaxPendingBatches = 10
+)
+
+// sink is a destination of the records of a long-running command, given
+// with --sink. Records are JSON values, written as JSON Lines; write and
+// close are safe for concurrent use.
+type sink interface {
+	write(record []byte) error
+	// close sends what is pending and releases the sink.
+	close() error
+}
+
+// addSinkFlag registers --sink on a command that writes records.
+func addSinkFlag(cmd *cobra.Command, what string) {
+	cmd.Flags().StringArray("sink", nil, "Also write "+what+" to this sink of the config file (repeatable)")
+	_ = cmd.RegisterFlagCompletionFunc("sink", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names := make([]string, 0, len(Cfg.Sinks))
+		for name := range Cfg.Sinks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// sinkSet is the sinks given with --sink. A nil sinkSet has no sinks.
+type sinkSet struct {
+	names []string
+	sinks []sink
+}
+
+// openSinks opens the sinks given with --sink.
+func openSinks(cmd *cobra.Command) (*sinkSet, error) {
+	names, _ := cmd.Flags().GetStringArray("sink")
+	if len(names) == 0 {
+		return nil, nil
+	}
+	s := &sinkSet{}
+	for _, name := range names {
+		cfg, ok := Cfg.Sinks[strings.ToLower(name)]
+		if !ok {
+			s.close()
+			return nil, fmt.Errorf("unknown sink %q; add it under sinks in the config file", name)
+		}
+		k, err := newSink(cfg)
+		if err != nil {
+			s.close()
+			return nil, fmt.Errorf("sink %s: %w", name, err)
+		}
+		s.names = append(s.names, name)
+		s.sinks = append(s.sinks, k)
+	}
+	return s, nil
+}
+
+// write writes record, compacted to one line, to every sink. Failures are
+// reported and do not stop the command.
+func (s *sinkSet) write(record []byte) {
+	if s == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, record); err != nil {
+		return
+	}
+	for i, k := range s.sinks {
+		if err := k.write(buf.Bytes()); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Writing to sink %s failed: %v\n", s.names[i], err)
+		}
+	}
+}
+
+// close closes every sink, reporting failures to send what was pending.
+func (s *sinkSet) close() {
+	if s == nil {
+		return
+	}
+	for i, k := range s.sinks {
+		if err := k.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Closing sink %s failed: %v\n", s.names[i], err)
+		}
+	}
+}
+
+// newSink creates the sink of cfg.
+func newSink(cfg config.SinkConfig) (sink, error) {
+	switch cfg.Type {
+	case sinkFile:
+		return newFileSink(cfg)
+	case sinkWebhook:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("url is required")
+		}
+		w := &webhookSink{url: cfg.URL, headers: cfg.Headers}
+		return newBatchSink(w.send, cfg.BatchSize, 100, cfg.FlushInterval, 10*time.Second), nil
+	case sinkS3:
+		u, err := newS3Uploader(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return newBatchSink(u.send, cfg.BatchSize, 1000, cfg.FlushInterval, time.Minute), nil
+	case "":
+		return nil, fmt.Errorf("type is required (file, webhook, or s3)")
+	}
+	return nil, fmt.Errorf("unknown type %q; use file, webhook, or s3", cfg.Type)
+}
+
+// fileSink appends records to a file, which is rotated once it reaches
+// maxSize: the file becomes path.1, path.1 becomes path.2, and so on,
+// keeping maxFiles of them.
+type fileSink struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// newFileSink opens the file of cfg for appending.
+func newFileSink(cfg config.SinkConfig) (*fileSink, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+	s := &fileSink{path: expandHome(cfg.Path), maxSize: int64(cfg.MaxSizeMB) << 20, maxFiles: cfg.MaxFiles}
+	if s.maxSize <= 0 {
+		s.maxSize = 100 << 20
+	}
+	if s.maxFiles <= 0 {
+		s.maxFiles = 5
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// open opens the current file. The caller holds s.mu, or owns s.
+func (s *fileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file, s.size = file, info.Size()
+	return nil
+}
+
+func (s *fileSink) write(record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("%s is closed", s.path)
+	}
+	if s.size > 0 && s.size+int64(len(record))+1 > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("rotating %s: %w", s.path, err)
+		}
+	}
+	n, err := s.file.Write(append(record, '\n'))
+	s.size += int64(n)
+	return err
+}
+
+// rotate shifts the rotated files and starts a new current file. The
+// caller holds s.mu.
+func (s *fileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+	_ = os.Remove(fmt.Sprintf("%s.%d", s.path, s.maxFiles))
+	for i := s.maxFiles - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", s.path, i), fmt.Sprintf("%s.%d", s.path, i+1))
+	}
+	if err := os.Rename(s.path, s.path+".1"); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// batchSink collects records and hands them to send in batches of size
+// records, or after they waited for interval. Batches that cannot be sent
+// are kept and sent again with the next one, up to sinkMaxPendingBatches.
+type batchSink struct {
+	send     func(ctx context.Context, records [][]byte) error
+	size     int
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending [][]byte
+	// head counts the records ever removed from the front of pending.
+	head    int
+	dropped int
+	// sending serialises sends, so that records arrive in order.
+	sending sync.Mutex
+	// wake asks run to flush now, as a full batch is pending.
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newBatchSink creates a batchSink, using the defaults for a size or
+// interval of zero, and starts its timer.
+func newBatchSink(send func(ctx context.Context, records [][]byte) error, size, defaultSize int, interval, defaultInterval time.Duration) *batchSink {
+	if size <= 0 {
+		size = defaultSize
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	s := &batchSink{
+		send: send, size: size, interval: interval,
+		wake: make(chan struct{}, 1), stop: make(chan struct{}), done: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// run flushes the pending records every interval, and when write asks for
+// it, until the sink is closed. After a failed flush, requests of write are
+// ignored until the next interval, so that a sink that is down is not
+// tried again for every record.
+func (s *batchSink) run() {
+	defer close(s.done)
+	tick := time.NewTicker(s.interval)
+	defer tick.Stop()
+	failing := false
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+			if failing {
+				continue
+			}
+		case <-tick.C:
+		}
+		err := s.flush()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Sending to sink failed, will retry: %v\n", err)
+		}
+		failing = err != nil
+	}
+}
+
+// write adds record to the pending records, dropping the oldest ones once
+// sinkMaxPendingBatches batches are pending. A full batch is sent by run,
+// so that writing does not wait for the destination.
+func (s *batchSink) write(record []byte) error {
+	s.mu.Lock()
+	s.pending = append(s.pending, bytes.Clone(record))
+	if over := len(s.pending) - sinkMaxPendingBatches*s.size; over > 0 {
+		s.pending = s.pending[over:]
+		s.head += over
+		s.dropped += over
+	}
+	full := len(s.pending) >= s.size
+	s.mu.Unlock()
+	if full {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// flush sends the pending records, in batches of at most s.size.
+func (s *batchSink) flush() error {
+	s.sending.Lock()
+	defer s.sending.Unlock()
+	for {
+		s.mu.Lock()
+		if s.dropped > 0 {
+			fmt.Fprintf(os.Stderr, "⚠ Sink is failing; dropped %d %s\n", s.dropped, plural(s.dropped, "record", "records"))
+			s.dropped = 0
+		}
+		batch := s.pending[:min(len(s.pending), s.size)]
+		start := s.head
+		s.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), sinkRequestTimeout)
+		err := s.send(ctx, batch)
+		cancel()
+		if err != nil {
+			return err
+		}
+
+		// Records may have been dropped from the front meanwhile; only
+		// those still pending are removed.
+		s.mu.Lock()
+		if sent := start + len(batch) - s.head; sent > 0 {
+			s.pending = s.pending[sent:]
+			s.head += sent
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *batchSink) close() error {
+	close(s.stop)
+	<-s.done
+	return s.flush()
+}
+
+// sinkHTTPClient returns the client of the webhook and s3 sinks.
+func sinkHTTPClient() *http.Client {
+	return &http.Client{Transport: getTransport(), Timeout: sinkRequestTimeout}
+}
+
+// sinkBody joins records into a JSON Lines body.
+func sinkBody(records [][]byte) []byte {
+	var body bytes.Buffer
+	for _, r := range records {
+		body.Write(r)
+		body.WriteByte('\n')
+	}
+	return body.Bytes()
+}
+
+// checkSinkResponse returns an error for a non-2xx response.
+func checkSinkResponse(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// webhookSink posts batches of records as JSON Lines to a URL.
+type webhookSink struct {
+	url     string
+	headers map[string]string
+}
+
+func (w *webhookSink) send(ctx context.Context, records [][]byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(sinkBody(records)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := sinkHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkSinkResponse(resp)
+}
+
+// s3Uploader uploads batches of records as JSON Lines objects to an
+// S3-compatible bucket, signing requests with AWS Signature Version 4.
+type s3Uploader struct {
+	bucket    string
+	prefix    string
+	region    string
+	endpoint  *url.URL
+	keyID     string
+	secret    string
+	session   string
+	pathStyle bool
+
+	mu  sync.Mutex
+	seq int
+}
+
+// newS3Uploader creates the uploader of cfg.
+func newS3Uploader(cfg config.SinkConfig) (*s3Uploader, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+	u := &s3Uploader{
+		bucket: cfg.Bucket,
+		prefix: cfg.Prefix,
+		region: firstNonEmpty(cfg.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		keyID:  firstNonEmpty(cfg.AccessKeyID, os.Getenv("AWS_ACCESS_KEY_ID")),
+		secret: firstNonEmpty(cfg.SecretAccessKey, os.Getenv("AWS_SECRET_ACCESS_KEY")),
+	}
+	if cfg.AccessKeyID == "" {
+		u.session = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if u.keyID == "" || u.secret == "" {
+		return nil, fmt.Errorf("access_key_id and secret_access_key are required (or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		// Virtual-hosted-style requests, which AWS prefers.
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", u.bucket, u.region)
+	} else {
+		u.pathStyle = true
+	}
+	parsed, err := url.Parse(strings.TrimRight(endpoint, "/"))
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint %q", cfg.Endpoint)
+	}
+	u.endpoint = parsed
+	return u, nil
+}
+
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// objectName returns the name of the next object, e.g.
+// PREFIX2026/03/01/100005-4242-1.jsonl: by upload time, process, and
+// sequence, so that concurrent runs do not overwrite each other.
+func (u *s3Uploader) objectName(now time.Time) string {
+	u.mu.Lock()
+	u.seq++
+	seq := u.seq
+	u.mu.Unlock()
+	return fmt.Sprintf("%s%s-%d-%d.jsonl", u.prefix, now.Format("2006/01/02/150405"), os.Getpid(), seq)
+}
+
+func (u *s3Uploader) send(ctx context.Context, records [][]byte) error {
+	now := time.Now().UTC()
+	body := sinkBody(records)
+	object := u.objectName(now)
+
+	path := "/" + s3Escape(object)
+	if u.pathStyle {
+		path = "/" + s3Escape(u.bucket) + path
+	}
+	target := *u.endpoint
+	target.Path = u.endpoint.Path + path
+	target.RawPath = u.endpoint.EscapedPath() + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	u.sign(req, body, now)
+
+	resp, err := sinkHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkSinkResponse(resp); err != nil {
+		return fmt.Errorf("uploading %s: %w", object, err)
+	}
+	return nil
+}
+
+// sign adds the AWS Signature Version 4 headers for body to req.
+func (u *s3Uploader) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payload := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(payload[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if u.session != "" {
+		req.Header.Set("X-Amz-Security-Token", u.session)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	scope := date + "/" + u.region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := []byte("AWS4" + u.secret)
+	for _, part := range []string{date, u.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.keyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape escapes an object name for an S3 request path: everything but
+// unreserved characters and slashes is percent-encoded.
+func s3Escape(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,389 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// snapshotVolatileFields are space fields left out of snapshots because they
+// change without anyone reconfiguring the space.
+var snapshotVolatileFields = []string{"lastActiveTime", "membershipCount"}
+
+// spaceSnapshot is the settings and membership of a space at one point in
+// time, as written by "spaces snapshot".
+type spaceSnapshot struct {
+	Space    string                 `json:"space"`
+	TakenAt  string                 `json:"takenAt"`
+	Settings map[string]interface{} `json:"settings"`
+	Members  []snapshotMember       `json:"members"`
+}
+
+// snapshotMember is one membership in a snapshot.
+type snapshotMember struct {
+	Member      string `json:"member"`
+	DisplayName string `json:"displayName,omitempty"`
+	Role        string `json:"role"`
+	State       string `json:"state"`
+}
+
+// settingChange is a space setting that differs from the snapshot. Old or
+// New is nil when the setting was added or removed.
+type settingChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// memberChange is a membership whose role or state differs from the
+// snapshot.
+type memberChange struct {
+	Member   string `json:"member"`
+	OldRole  string `json:"oldRole"`
+	NewRole  string `json:"newRole"`
+	OldState string `json:"oldState"`
+	NewState string `json:"newState"`
+}
+
+// snapshotDiff is the drift of a space from a snapshot.
+type snapshotDiff struct {
+	Space          string           `json:"space"`
+	SnapshotTime   string           `json:"snapshotTime"`
+	Settings       []settingChange  `json:"settings"`
+	MembersAdded   []snapshotMember `json:"membersAdded"`
+	MembersRemoved []snapshotMember `json:"membersRemoved"`
+	MembersChanged []memberChange   `json:"membersChanged"`
+}
+
+// count returns the total number of differences.
+func (d *snapshotDiff) count() int {
+	return len(d.Settings) + len(d.MembersAdded) + len(d.MembersRemoved) + len(d.MembersChanged)
+}
+
+// ---------------------------------------------------------------------------
+// spaces snapshot
+// ---------------------------------------------------------------------------
+
+func newSpacesSnapshotCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot SPACE",
+		Short: "Save a space's settings and membership as JSON",
+		Long: `Capture the settings and membership (including invited members and groups)
+of a space as JSON, for later comparison with "spaces diff". SPACE can be a
+space ID or full resource name (spaces/XXXX).
+
+Fields that change on their own, such as the last activity time and member
+counts, are left out.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSpacesSnapshot,
+	}
+
+	cmd.Flags().StringP("output", "o", "", "Write the snapshot to this file instead of stdout")
+	cmd.Flags().Bool("admin", false, "Use admin access")
+
+	return cmd
+}
+
+func runSpacesSnapshot(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	admin, _ := cmd.Flags().GetBool("admin")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	snap, err := takeSpaceSnapshot(cmd.Context(), client, api.NormalizeName(args[0], "spaces/"), admin)
+	if err != nil {
+		return err
+	}
+
+	if outputPath == "" {
+		return output.PrintJSON(snap)
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling snapshot: %w", err)
+	}
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	getFormatter().PrintSuccess(fmt.Sprintf("Saved snapshot of %s (%d members) to %s", snap.Space, len(snap.Members), outputPath))
+	return nil
+}
+
+// takeSpaceSnapshot fetches the settings and all memberships of space.
+func takeSpaceSnapshot(ctx context.Context, client *api.Client, space string, admin bool) (*spaceSnapshot, error) {
+	raw, err := api.NewSpacesService(client).Get(ctx, space, admin)
+	if err != nil {
+		return nil, fmt.Errorf("getting space: %w", err)
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(raw, &settings); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	for _, field := range snapshotVolatileFields {
+		delete(settings, field)
+	}
+
+	svc := api.NewMembersService(client)
+	var members []snapshotMember
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, space, 1000, pageToken, "", true, true, admin)
+		if err != nil {
+			return nil, fmt.Errorf("listing members: %w", err)
+		}
+		var page struct {
+			Memberships []struct {
+				Role   string `json:"role"`
+				State  string `json:"state"`
+				Member struct {
+					Name        string `json:"name"`
+					DisplayName string `json:"displayName"`
+				} `json:"member"`
+				GroupMember struct {
+					Name string `json:"name"`
+				} `json:"groupMember"`
+			} `json:"memberships"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, m := range page.Memberships {
+			member := m.Member.Name
+			if member == "" {
+				member = m.GroupMember.Name
+			}
+			members = append(members, snapshotMember{
+				Member:      member,
+				DisplayName: m.Member.DisplayName,
+				Role:        m.Role,
+				State:       m.State,
+			})
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Member < members[j].Member })
+
+	return &spaceSnapshot{
+		Space:    space,
+		TakenAt:  time.Now().UTC().Format(time.RFC3339),
+		Settings: settings,
+		Members:  members,
+	}, nil
+}
+
+// ---------------------------------------------------------------------------
+// spaces diff
+// ---------------------------------------------------------------------------
+
+func newSpacesDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff SPACE SNAPSHOT",
+		Short: "Show how a space changed since a snapshot",
+		Long: `Compare the current settings and membership of a space with a snapshot
+written by "spaces snapshot", and list settings that changed and members
+that were added, removed, or changed role or state.
+
+Exits with status 1 if the space has drifted from the snapshot, so the
+command can be used in periodic compliance checks.`,
+		Args: cobra.ExactArgs(2),
+		RunE: runSpacesDiff,
+	}
+
+	cmd.Flags().Bool("admin", false, "Use admin access")
+
+	return cmd
+}
+
+func runSpacesDiff(cmd *cobra.Command, args []string) error {
+	space := api.NormalizeName(args[0], "spaces/")
+	data, err := os.ReadFile(args[1])
+	if err != nil {
+		return fmt.Errorf("reading snapshot: %w", err)
+	}
+	var old spaceSnapshot
+	if err := json.Unmarshal(data, &old); err != nil {
+		return fmt.Errorf("parsing snapshot %s: %w", args[1], err)
+	}
+	if old.Space != space {
+		return fmt.Errorf("snapshot %s is of %s, not %s", args[1], old.Space, space)
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	admin, _ := cmd.Flags().GetBool("admin")
+
+	current, err := takeSpaceSnapshot(cmd.Context(), client, space, admin)
+	if err != nil {
+		return err
+	}
+
+	diff := diffSnapshots(&old, current)
+	f := getFormatter()
+	if f.IsJSON() {
+		if err := f.Print(diff); err != nil {
+			return err
+		}
+	} else {
+		printSnapshotDiff(f, diff)
+	}
+
+	if n := diff.count(); n > 0 {
+		return fmt.Errorf("%s has %d change(s) since the snapshot of %s", space, n, output.FormatTime(old.TakenAt))
+	}
+	return nil
+}
+
+// diffSnapshots compares two snapshots of the same space.
+func diffSnapshots(old, current *spaceSnapshot) *snapshotDiff {
+	diff := &snapshotDiff{
+		Space:          current.Space,
+		SnapshotTime:   old.TakenAt,
+		Settings:       []settingChange{},
+		MembersAdded:   []snapshotMember{},
+		MembersRemoved: []snapshotMember{},
+		MembersChanged: []memberChange{},
+	}
+
+	oldSettings := map[string]interface{}{}
+	flattenSettings("", old.Settings, oldSettings)
+	newSettings := map[string]interface{}{}
+	flattenSettings("", current.Settings, newSettings)
+
+	for field, oldValue := range oldSettings {
+		newValue, ok := newSettings[field]
+		if !ok {
+			diff.Settings = append(diff.Settings, settingChange{Field: field, Old: oldValue})
+			continue
+		}
+		if fmt.Sprint(oldValue) != fmt.Sprint(newValue) {
+			diff.Settings = append(diff.Settings, settingChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	for field, newValue := range newSettings {
+		if _, ok := oldSettings[field]; !ok {
+			diff.Settings = append(diff.Settings, settingChange{Field: field, New: newValue})
+		}
+	}
+	sort.Slice(diff.Settings, func(i, j int) bool { return diff.Settings[i].Field < diff.Settings[j].Field })
+
+	oldMembers := make(map[string]snapshotMember, len(old.Members))
+	for _, m := range old.Members {
+		oldMembers[m.Member] = m
+	}
+	newMembers := make(map[string]bool, len(current.Members))
+	for _, m := range current.Members {
+		newMembers[m.Member] = true
+		prev, ok := oldMembers[m.Member]
+		switch {
+		case !ok:
+			diff.MembersAdded = append(diff.MembersAdded, m)
+		case prev.Role != m.Role || prev.State != m.State:
+			diff.MembersChanged = append(diff.MembersChanged, memberChange{
+				Member:   m.Member,
+				OldRole:  prev.Role,
+				NewRole:  m.Role,
+				OldState: prev.State,
+				NewState: m.State,
+			})
+		}
+	}
+	for _, m := range old.Members {
+		if !newMembers[m.Member] {
+			diff.MembersRemoved = append(diff.MembersRemoved, m)
+		}
+	}
+
+	return diff
+}
+
+// flattenSettings flattens nested settings into dot-separated field paths,
+// e.g. spaceDetails.description, so that changes are reported per field.
+func flattenSettings(prefix string, settings map[string]interface{}, out map[string]interface{}) {
+	for key, value := range settings {
+		field := key
+		if prefix != "" {
+			field = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenSettings(field, nested, out)
+			continue
+		}
+		out[field] = value
+	}
+}
+
+// printSnapshotDiff prints the drift of a space in a diff-like format.
+func printSnapshotDiff(f *output.Formatter, diff *snapshotDiff) {
+	if diff.count() == 0 {
+		f.PrintSuccess(fmt.Sprintf("%s matches the snapshot of %s", diff.Space, output.FormatTime(diff.SnapshotTime)))
+		return
+	}
+
+	fmt.Printf("Changes to %s since %s:\n", diff.Space, output.FormatTime(diff.SnapshotTime))
+	if len(diff.Settings) > 0 {
+		fmt.Println("\nSettings:")
+		for _, c := range diff.Settings {
+			switch {
+			case c.Old == nil:
+				fmt.Printf("  + %s: %s\n", c.Field, snapshotValue(c.New))
+			case c.New == nil:
+				fmt.Printf("  - %s: %s\n", c.Field, snapshotValue(c.Old))
+			default:
+				fmt.Printf("  ~ %s: %s → %s\n", c.Field, snapshotValue(c.Old), snapshotValue(c.New))
+			}
+		}
+	}
+
+	if len(diff.MembersAdded)+len(diff.MembersRemoved)+len(diff.MembersChanged) > 0 {
+		fmt.Println("\nMembers:")
+		for _, m := range diff.MembersAdded {
+			fmt.Printf("  + %s: %s, %s\n", snapshotMemberLabel(m), m.Role, m.State)
+		}
+		for _, m := range diff.MembersRemoved {
+			fmt.Printf("  - %s: %s, %s\n", snapshotMemberLabel(m), m.Role, m.State)
+		}
+		for _, c := range diff.MembersChanged {
+			fmt.Printf("  ~ %s: %s, %s → %s, %s\n", c.Member,
+				c.OldRole, c.OldState,
+				c.NewRole, c.NewState)
+		}
+	}
+}
+
+// snapshotValue formats a setting value for display.
+func snapshotValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// snapshotMemberLabel returns the member's resource name with its display
+// name, if known.
+func snapshotMemberLabel(m snapshotMember) string {
+	if m.DisplayName != "" {
+		return fmt.Sprintf("%s (%s)", m.Member, m.DisplayName)
+	}
+	return m.Member
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// spaceExpandFields are the --expand values of "spaces list".
+var spaceExpandFields = []string{"members", "lastMessage", "unread"}
+
+// spaceExpansion is what --expand fetched for one space. Fields that were
+// not expanded are nil.
+type spaceExpansion struct {
+	MemberCount *int              `json:"memberCount,omitempty"`
+	LastMessage *spaceLastMessage `json:"lastMessage,omitempty"`
+	// UnreadCount is the number of messages after the caller's last read
+	// time, counted up to unreadPerSpaceLimit.
+	UnreadCount *int `json:"unreadCount,omitempty"`
+}
+
+// spaceLastMessage is the preview of the latest message of a space.
+type spaceLastMessage struct {
+	Name       string `json:"name"`
+	Sender     string `json:"sender"`
+	Text       string `json:"text"`
+	CreateTime string `json:"createTime"`
+}
+
+// spaceExpansions is the result of --expand for a list of spaces.
+type spaceExpansions struct {
+	// fields are the expanded fields, in the order given.
+	fields []string
+	spaces map[string]*spaceExpansion
+}
+
+// parseSpaceExpand checks the --expand values, which match the field names
+// regardless of case, dashes, and underscores.
+func parseSpaceExpand(values []string) ([]string, error) {
+	var fields []string
+	for _, v := range values {
+		key := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(v)))
+		found := ""
+		for _, field := range spaceExpandFields {
+			if strings.ToLower(field) == key {
+				found = field
+				break
+			}
+		}
+		if found == "" {
+			return nil, fmt.Errorf("invalid --expand %q; valid values: %s", v, strings.Join(spaceExpandFields, ", "))
+		}
+		if !slices.Contains(fields, found) {
+			fields = append(fields, found)
+		}
+	}
+	if slices.Contains(fields, "unread") && viper.GetBool("as_app") {
+		return nil, fmt.Errorf("--expand unread needs user authentication; read state is not available with --as-app")
+	}
+	return fields, nil
+}
+
+// expandSpaces fetches the fields for each of the spaces, in parallel on
+// the bulk worker pool. Spaces that could not be expanded are reported and
+// left without values.
+func expandSpaces(ctx context.Context, client *api.Client, fields, spaces []string) *spaceExpansions {
+	x := &spaceExpansions{fields: fields, spaces: map[string]*spaceExpansion{}}
+	if len(fields) == 0 {
+		return x
+	}
+
+	var mu sync.Mutex
+	quiet := output.NewFormatter(false, true)
+	summary := runBulk(ctx, quiet, spaces, func(ctx context.Context, space string) (string, error) {
+		var e spaceExpansion
+		for _, field := range fields {
+			var err error
+			switch field {
+			case "members":
+				var n int
+				n, err = countSpaceMembers(ctx, client, space)
+				e.MemberCount = &n
+			case "lastMessage":
+				e.LastMessage, err = lastSpaceMessage(ctx, client, space)
+			case "unread":
+				var n int
+				n, err = countUnreadInSpace(ctx, client, space)
+				e.UnreadCount = &n
+			}
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", space, err)
+			}
+		}
+		mu.Lock()
+		x.spaces[space] = &e
+		mu.Unlock()
+		return space, nil
+	})
+	if failed := summary.Failed + summary.Skipped; failed > 0 {
+		fmt.Fprintf(os.Stderr, "⚠ Could not expand %d of %d %s: %s\n", failed, summary.Total, plural(summary.Total, "space", "spaces"), summary.firstError())
+	}
+	return x
+}
+
+// countSpaceMembers returns the number of members of space, following all
+// result pages.
+func countSpaceMembers(ctx context.Context, client *api.Client, space string) (int, error) {
+	svc := api.NewMembersService(client)
+	count, pageToken := 0, ""
+	for {
+		raw, err := svc.List(ctx, space, 1000, pageToken, "", false, false, false)
+		if err != nil {
+			return 0, fmt.Errorf("listing members: %w", err)
+		}
+		var resp struct {
+			Memberships   []json.RawMessage `json:"memberships"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return 0, fmt.Errorf("parsing response: %w", err)
+		}
+		count += len(resp.Memberships)
+		if resp.NextPageToken == "" {
+			return count, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// lastSpaceMessage returns the latest message of space, or nil if it has
+// none.
+func lastSpaceMessage(ctx context.Context, client *api.Client, space string) (*spaceLastMessage, error) {
+	raw, err := api.NewMessagesService(client).List(ctx, space, 1, "", "", "createTime desc", false)
+	if err != nil {
+		return nil, fmt.Errorf("listing messages: %w", err)
+	}
+	var resp struct {
+		Messages []digestMessage `json:"messages"`
+	}
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	if len(resp.Messages) == 0 {
+		return nil, nil
+	}
+	m := resp.Messages[0]
+	return &spaceLastMessage{Name: m.Name, Sender: m.senderName(), Text: m.Text, CreateTime: m.CreateTime}, nil
+}
+
+// headers returns the table headers of the expanded fields.
+func (x *spaceExpansions) headers() []string {
+	var headers []string
+	for _, field := range x.fields {
+		switch field {
+		case "members":
+			headers = append(headers, "MEMBERS")
+		case "lastMessage":
+			headers = append(headers, "LAST_MESSAGE")
+		case "unread":
+			headers = append(headers, "UNREAD")
+		}
+	}
+	return headers
+}
+
+// cells returns the table cells of the expanded fields of space; they are
+// empty if the space could not be expanded.
+func (x *spaceExpansions) cells(space string) []string {
+	cells := make([]string, len(x.fields))
+	e := x.spaces[space]
+	if e == nil {
+		return cells
+	}
+	for i, field := range x.fields {
+		switch {
+		case field == "members" && e.MemberCount != nil:
+			cells[i] = strconv.Itoa(*e.MemberCount)
+		case field == "lastMessage" && e.LastMessage != nil:
+			text := strings.Join(strings.Fields(e.LastMessage.Text), " ")
+			cells[i] = output.Truncate(fmt.Sprintf("%s: %s", e.LastMessage.Sender, text), 50)
+		case field == "unread" && e.UnreadCount != nil:
+			cells[i] = strconv.Itoa(*e.UnreadCount)
+			if *e.UnreadCount >= unreadPerSpaceLimit {
+				cells[i] += "+"
+			}
+		}
+	}
+	return cells
+}
+
+// annotate adds the expansion of each space to its JSON object as
+// "expanded".
+func (x *spaceExpansions) annotate(spaces []json.RawMessage) {
+	for i, raw := range spaces {
+		var sp map[string]interface{}
+		if err := json.Unmarshal(raw, &sp); err != nil {
+			continue
+		}
+		e := x.spaces[spaceMapStr(sp, "name")]
+		if e == nil {
+			continue
+		}
+		sp["expanded"] = e
+		if data, err := json.Marshal(sp); err == nil {
+			spaces[i] = data
+		}
+	}
+}
@@ -1,17 +1,28 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 
 	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/cache"
 	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/cipher-shad0w/gogchat/internal/undo"
 )
 
 // NewSpacesCmd creates the top-level "spaces" command with all subcommands.
@@ -29,10 +40,17 @@ func NewSpacesCmd() *cobra.Command {
 		newSpacesCreateCmd(),
 		newSpacesUpdateCmd(),
 		newSpacesDeleteCmd(),
+		newSpacesJoinCmd(),
+		newSpacesRequestJoinCmd(),
+		newSpacesLeaveCmd(),
 		newSpacesSearchCmd(),
 		newSpacesSetupCmd(),
 		newSpacesFindDMCmd(),
 		newSpacesCompleteImportCmd(),
+		newSpacesHistoryCmd(),
+		newSpacesAccessCmd(),
+		newSpacesSnapshotCmd(),
+		newSpacesDiffCmd(),
 	)
 
 	return cmd
@@ -46,14 +64,43 @@ func newSpacesListCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List spaces the caller is a member of",
-		Long:  "List all Google Chat spaces the authenticated user is a member of.",
-		RunE:  runSpacesList,
+		Long: `List all Google Chat spaces the authenticated user is a member of.
+
+The API returns spaces in no particular order. In human output, --sort-by
+orders them by last-active or create-time (most recent first), display-name,
+or members (most first), and --group-by type prints a table per space type.
+--reverse flips the order. Only the fetched page is sorted, so combine these
+with --all to order every space.
+
+--expand fetches more about each space with extra requests, run in
+parallel (see --concurrency): members counts all members, lastMessage
+previews the latest message, and unread counts the messages after your
+last read time (up to 100). The values are added as columns, or as an
+"expanded" object of each space in JSON output.`,
+		Example: `  gogchat spaces list --sort-by last-active
+  gogchat spaces list --expand members,lastMessage
+  gogchat spaces list --all --expand unread --json`,
+		RunE: runSpacesList,
 	}
 
 	cmd.Flags().String("filter", "", "Filter spaces (e.g. spaceType = \"SPACE\")")
 	cmd.Flags().Int("page-size", 100, "Maximum number of spaces to return per page")
 	cmd.Flags().String("page-token", "", "Page token for pagination")
 	cmd.Flags().Bool("all", false, "Automatically paginate through all results")
+	cmd.Flags().String("sort-by", "", "Sort by "+strings.Join(spaceListSortFields, "|")+" (human output)")
+	cmd.Flags().Bool("reverse", false, "Reverse the --sort-by order")
+	cmd.Flags().String("group-by", "", "Group by type (human output)")
+	cmd.Flags().StringSlice("expand", nil, "Fetch more about each space: "+strings.Join(spaceExpandFields, ",")+" (comma-separated)")
+	// Accept --sort as shorthand for --sort-by.
+	cmd.Flags().SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "sort" {
+			name = "sort-by"
+		}
+		return pflag.NormalizedName(name)
+	})
+	_ = cmd.RegisterFlagCompletionFunc("sort-by", cobra.FixedCompletions(spaceListSortFields, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("group-by", cobra.FixedCompletions([]string{"type"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = cmd.RegisterFlagCompletionFunc("expand", cobra.FixedCompletions(spaceExpandFields, cobra.ShellCompDirectiveNoFileComp))
 
 	return cmd
 }
@@ -69,9 +116,27 @@ func runSpacesList(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
 	filter, _ := cmd.Flags().GetString("filter")
-	pageSize, _ := cmd.Flags().GetInt("page-size")
+	pageSize := getPageSize(cmd)
 	pageToken, _ := cmd.Flags().GetString("page-token")
 	all, _ := cmd.Flags().GetBool("all")
+	sortBy, _ := cmd.Flags().GetString("sort-by")
+	reverse, _ := cmd.Flags().GetBool("reverse")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	expandValues, _ := cmd.Flags().GetStringSlice("expand")
+
+	if sortBy != "" && !slices.Contains(spaceListSortFields, sortBy) {
+		return fmt.Errorf("invalid --sort-by %q; valid values: %s", sortBy, strings.Join(spaceListSortFields, ", "))
+	}
+	if reverse && sortBy == "" {
+		return fmt.Errorf("--reverse requires --sort-by")
+	}
+	if groupBy != "" && groupBy != "type" {
+		return fmt.Errorf("invalid --group-by %q; only type is supported", groupBy)
+	}
+	expandFields, err := parseSpaceExpand(expandValues)
+	if err != nil {
+		return err
+	}
 
 	// When --all is set we collect every page into a single slice.
 	var allSpaces []json.RawMessage
@@ -82,10 +147,6 @@ func runSpacesList(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("listing spaces: %w", err)
 		}
 
-		if f.IsJSON() && !all {
-			return f.PrintRaw(raw)
-		}
-
 		var resp struct {
 			Spaces        []json.RawMessage `json:"spaces"`
 			NextPageToken string            `json:"nextPageToken"`
@@ -103,11 +164,24 @@ func runSpacesList(cmd *cobra.Command, args []string) error {
 		pageToken = resp.NextPageToken
 	}
 
-	// JSON mode with --all: emit aggregated result.
+	var spaces []map[string]interface{}
+	var names []string
+	for _, raw := range allSpaces {
+		var sp map[string]interface{}
+		if err := json.Unmarshal(raw, &sp); err != nil {
+			continue
+		}
+		spaces = append(spaces, sp)
+		names = append(names, spaceMapStr(sp, "name"))
+	}
+	if len(expandFields) > 0 {
+		enableBulkRetries(client)
+	}
+	expanded := expandSpaces(ctx, client, expandFields, names)
+
 	if f.IsJSON() {
-		return f.Print(map[string]interface{}{
-			"spaces": allSpaces,
-		})
+		expanded.annotate(allSpaces)
+		return printList(f, allSpaces, pageToken)
 	}
 
 	if len(allSpaces) == 0 {
@@ -115,32 +189,135 @@ func runSpacesList(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	table := output.NewTable("NAME", "DISPLAY_NAME", "TYPE", "MEMBER_COUNT", "CREATE_TIME")
+	if sortBy != "" {
+		sortSpaces(spaces, sortBy, reverse)
+	}
 
-	for _, raw := range allSpaces {
-		var sp map[string]interface{}
-		if err := json.Unmarshal(raw, &sp); err != nil {
-			continue
+	if groupBy == "" {
+		fmt.Print(spacesTable(spaces, expanded).Render())
+	} else {
+		for i, group := range groupSpacesByType(spaces) {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s (%d)\n", group.name, len(group.spaces))
+			fmt.Print(spacesTable(group.spaces, expanded).Render())
 		}
-		name := spaceMapStr(sp, "name")
-		displayName := spaceMapStr(sp, "displayName")
-		spaceType := spaceMapStr(sp, "spaceType")
+	}
+
+	if !all && pageToken != "" {
+		f.PrintMessage(fmt.Sprintf("\nMore results available. Use --page-token %s to see the next page, or use --all to fetch everything.", pageToken))
+	}
+
+	return nil
+}
+
+// spaceListSortFields are the --sort-by values of "spaces list".
+var spaceListSortFields = []string{"last-active", "create-time", "display-name", "members"}
+
+// spaceTypeOrder is the order in which --group-by type prints space types;
+// any other types follow alphabetically.
+var spaceTypeOrder = []string{"SPACE", "GROUP_CHAT", "DIRECT_MESSAGE"}
+
+// spacesTable renders spaces as the "spaces list" table, with a column
+// for each field of expanded.
+func spacesTable(spaces []map[string]interface{}, expanded *spaceExpansions) *output.Table {
+	headers := []string{"NAME", "DISPLAY_NAME", "TYPE", "MEMBER_COUNT", "LAST_ACTIVE", "CREATE_TIME"}
+	table := output.NewTable(append(headers, expanded.headers()...)...)
+	for _, sp := range spaces {
 		memberCount := ""
 		if mc, ok := sp["membershipCount"]; ok {
 			memberCount = fmt.Sprintf("%v", mc)
 		}
-		createTime := output.FormatTime(spaceMapStr(sp, "createTime"))
+		row := []string{
+			spaceMapStr(sp, "name"),
+			spaceMapStr(sp, "displayName"),
+			spaceMapStr(sp, "spaceType"),
+			memberCount,
+			output.FormatTime(spaceMapStr(sp, "lastActiveTime")),
+			output.FormatTime(spaceMapStr(sp, "createTime")),
+		}
+		table.AddRow(append(row, expanded.cells(spaceMapStr(sp, "name"))...)...)
+	}
+	return table
+}
 
-		table.AddRow(name, displayName, spaceType, memberCount, createTime)
+// sortSpaces sorts spaces by a --sort-by field: times most recent first,
+// member counts largest first, and display names alphabetically. Spaces
+// without the field go last. reverse flips the order.
+func sortSpaces(spaces []map[string]interface{}, sortBy string, reverse bool) {
+	key := func(sp map[string]interface{}) (string, bool) {
+		switch sortBy {
+		case "last-active", "create-time":
+			field := "lastActiveTime"
+			if sortBy == "create-time" {
+				field = "createTime"
+			}
+			t, err := time.Parse(time.RFC3339Nano, spaceMapStr(sp, field))
+			if err != nil {
+				return "", false
+			}
+			// Invert the time so that ascending order is most recent first.
+			return fmt.Sprintf("%020d", math.MaxInt64-t.UnixNano()), true
+		case "members":
+			n, err := strconv.Atoi(spaceExtractNested(sp, "membershipCount.joinedDirectHumanUserCount"))
+			if err != nil {
+				return "", false
+			}
+			return fmt.Sprintf("%020d", math.MaxInt64-int64(n)), true
+		default:
+			name := strings.ToLower(spaceMapStr(sp, "displayName"))
+			return name, name != ""
+		}
 	}
 
-	fmt.Print(table.Render())
+	sort.SliceStable(spaces, func(i, j int) bool {
+		ki, oki := key(spaces[i])
+		kj, okj := key(spaces[j])
+		if oki != okj {
+			return oki
+		}
+		if reverse {
+			return ki > kj
+		}
+		return ki < kj
+	})
+}
 
-	if !all && pageToken != "" {
-		f.PrintMessage(fmt.Sprintf("\nMore results available. Use --page-token %s to see the next page, or use --all to fetch everything.", pageToken))
+// spaceGroup is the spaces of one type for --group-by type.
+type spaceGroup struct {
+	name   string
+	spaces []map[string]interface{}
+}
+
+// groupSpacesByType splits spaces by spaceType, keeping their order within
+// each group.
+func groupSpacesByType(spaces []map[string]interface{}) []spaceGroup {
+	byType := map[string][]map[string]interface{}{}
+	for _, sp := range spaces {
+		t := spaceMapStr(sp, "spaceType")
+		if t == "" {
+			t = "UNKNOWN"
+		}
+		byType[t] = append(byType[t], sp)
 	}
 
-	return nil
+	var groups []spaceGroup
+	for _, t := range spaceTypeOrder {
+		if len(byType[t]) > 0 {
+			groups = append(groups, spaceGroup{t, byType[t]})
+			delete(byType, t)
+		}
+	}
+	rest := make([]string, 0, len(byType))
+	for t := range byType {
+		rest = append(rest, t)
+	}
+	sort.Strings(rest)
+	for _, t := range rest {
+		groups = append(groups, spaceGroup{t, byType[t]})
+	}
+	return groups
 }
 
 // ---------------------------------------------------------------------------
@@ -235,8 +412,7 @@ func newSpacesCreateCmd() *cobra.Command {
 	cmd.Flags().String("space-type", "SPACE", "Space type (SPACE, GROUP_CHAT, DIRECT_MESSAGE)")
 	cmd.Flags().String("description", "", "Description for the space")
 	cmd.Flags().String("request-id", "", "Unique request ID for idempotency")
-
-	_ = cmd.MarkFlagRequired("display-name")
+	addBodyFlag(cmd)
 
 	return cmd
 }
@@ -256,14 +432,25 @@ func runSpacesCreate(cmd *cobra.Command, args []string) error {
 	description, _ := cmd.Flags().GetString("description")
 	requestID, _ := cmd.Flags().GetString("request-id")
 
-	space := map[string]interface{}{
-		"displayName": displayName,
-		"spaceType":   spaceType,
+	space, err := readBody(cmd)
+	if err != nil {
+		return err
 	}
 
-	if description != "" {
-		space["spaceDetails"] = map[string]interface{}{
-			"description": description,
+	if space == nil {
+		if err := requireFlags(cmd, "display-name"); err != nil {
+			return err
+		}
+
+		space = map[string]interface{}{
+			"displayName": displayName,
+			"spaceType":   spaceType,
+		}
+
+		if description != "" {
+			space["spaceDetails"] = map[string]interface{}{
+				"description": description,
+			}
 		}
 	}
 
@@ -294,7 +481,7 @@ func newSpacesUpdateCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "update SPACE",
 		Short: "Update an existing space",
-		Long:  "Update fields of an existing Google Chat space. SPACE can be a space ID or full resource name (spaces/XXXX).",
+		Long:  "Update fields of an existing Google Chat space. SPACE can be a space ID or full resource name (spaces/XXXX). The update mask is computed from the fields being set unless --mask is given.",
 		Args:  cobra.ExactArgs(1),
 		RunE:  runSpacesUpdate,
 	}
@@ -302,8 +489,9 @@ func newSpacesUpdateCmd() *cobra.Command {
 	cmd.Flags().String("display-name", "", "New display name")
 	cmd.Flags().String("description", "", "New description")
 	cmd.Flags().String("history-state", "", "History state (HISTORY_ON or HISTORY_OFF)")
-	cmd.Flags().String("update-mask", "", "Comma-separated field mask (auto-detected if not set)")
 	cmd.Flags().Bool("admin", false, "Use admin access")
+	addBodyFlag(cmd)
+	addMaskFlag(cmd)
 
 	return cmd
 }
@@ -319,40 +507,40 @@ func runSpacesUpdate(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
 	admin, _ := cmd.Flags().GetBool("admin")
-	updateMask, _ := cmd.Flags().GetString("update-mask")
+
+	body, err := readBody(cmd)
+	if err != nil {
+		return err
+	}
 
 	space := map[string]interface{}{}
-	var maskParts []string
+	if body != nil {
+		space = body
+	}
 
-	if cmd.Flags().Changed("display-name") {
+	if body == nil && cmd.Flags().Changed("display-name") {
 		displayName, _ := cmd.Flags().GetString("display-name")
 		space["displayName"] = displayName
-		maskParts = append(maskParts, "displayName")
 	}
 
-	if cmd.Flags().Changed("description") {
+	if body == nil && cmd.Flags().Changed("description") {
 		description, _ := cmd.Flags().GetString("description")
 		if _, ok := space["spaceDetails"]; !ok {
 			space["spaceDetails"] = map[string]interface{}{}
 		}
 		details := space["spaceDetails"].(map[string]interface{})
 		details["description"] = description
-		maskParts = append(maskParts, "spaceDetails.description")
 	}
 
-	if cmd.Flags().Changed("history-state") {
+	if body == nil && cmd.Flags().Changed("history-state") {
 		historyState, _ := cmd.Flags().GetString("history-state")
 		space["spaceHistoryState"] = historyState
-		maskParts = append(maskParts, "spaceHistoryState")
 	}
 
-	// Auto-build update mask from changed flags if not explicitly provided.
-	if updateMask == "" && len(maskParts) > 0 {
-		updateMask = strings.Join(maskParts, ",")
-	}
-
-	if updateMask == "" {
-		return fmt.Errorf("no fields to update; use --display-name, --description, --history-state, or --update-mask")
+	// Build the update mask from the fields being set unless --mask is given.
+	updateMask, err := resolveUpdateMask(cmd, space)
+	if err != nil {
+		return err
 	}
 
 	raw, err := svc.Patch(ctx, args[0], space, updateMask, admin)
@@ -388,7 +576,8 @@ func newSpacesDeleteCmd() *cobra.Command {
 	}
 
 	cmd.Flags().Bool("admin", false, "Use admin access")
-	cmd.Flags().Bool("force", false, "Skip confirmation prompt")
+	addConfirmFlags(cmd)
+	addProtectionFlag(cmd)
 
 	return cmd
 }
@@ -404,25 +593,36 @@ func runSpacesDelete(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
 	admin, _ := cmd.Flags().GetBool("admin")
-	force, _ := cmd.Flags().GetBool("force")
 
 	spaceName := api.NormalizeName(args[0], "spaces/")
 
-	if !force {
-		fmt.Printf("Are you sure you want to delete space %s? [y/N]: ", spaceName)
-		reader := bufio.NewReader(os.Stdin)
-		answer, _ := reader.ReadString('\n')
-		answer = strings.TrimSpace(answer)
-		if answer != "y" && answer != "Y" {
-			fmt.Println("Delete cancelled.")
+	if err := newSpaceGuard(cmd, client).check(ctx, spaceName); err != nil {
+		return err
+	}
+
+	if !skipConfirm(cmd) {
+		summary, err := summarizeSpace(ctx, client, spaceName, admin)
+		if err != nil {
+			return err
+		}
+		ok, err := confirm("Delete this space and all of its messages?", summary)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			f.PrintMessage("Delete cancelled.")
 			return nil
 		}
 	}
 
+	recorder := newUndoRecorder(cmd)
+	saved := recorder.snapshot(ctx, client, undo.KindSpace, spaceName, admin)
 	raw, err := svc.Delete(ctx, spaceName, admin)
 	if err != nil {
 		return fmt.Errorf("deleting space: %w", err)
 	}
+	recorder.add(undo.KindSpace, spaceName, saved)
+	recorder.save()
 
 	if f.IsJSON() {
 		return f.PrintRaw(raw)
@@ -432,6 +632,125 @@ func runSpacesDelete(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// ---------------------------------------------------------------------------
+// spaces join
+// ---------------------------------------------------------------------------
+
+func newSpacesJoinCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "join SPACE",
+		Short: "Join a space",
+		Long:  "Add yourself as a member of a Google Chat space. This works for spaces that are discoverable to you. SPACE can be a space ID or full resource name (spaces/XXXX).",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runSpacesJoin,
+	}
+
+	return cmd
+}
+
+func runSpacesJoin(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	f := getFormatter()
+	spaceName := api.NormalizeName(args[0], "spaces/")
+
+	raw, err := api.NewMembersService(client).Create(cmd.Context(), spaceName, newHumanMembership("users/me", "ROLE_MEMBER"), false)
+	if err != nil {
+		return fmt.Errorf("joining space: %w", err)
+	}
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+
+	f.PrintSuccess(fmt.Sprintf("Joined %s", spaceName))
+	f.PrintMessage(fmt.Sprintf("Membership: %s", jsonField(raw, "name")))
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// spaces leave
+// ---------------------------------------------------------------------------
+
+func newSpacesLeaveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "leave SPACE",
+		Short: "Leave a space",
+		Long:  "Remove your own membership from a Google Chat space, looking up the membership resource name for you. SPACE can be a space ID or full resource name (spaces/XXXX).",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runSpacesLeave,
+	}
+
+	addConfirmFlags(cmd)
+
+	return cmd
+}
+
+func runSpacesLeave(cmd *cobra.Command, args []string) error {
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	f := getFormatter()
+	svc := api.NewMembersService(client)
+	ctx := cmd.Context()
+	spaceName := api.NormalizeName(args[0], "spaces/")
+
+	// A Chat app leaves through its "app" membership alias.
+	member := "app"
+	if !viper.GetBool("as_app") {
+		user, err := currentUser(ctx, client)
+		if err != nil {
+			return err
+		}
+		member = strings.TrimPrefix(user, "users/")
+	}
+	name := spaceName + "/members/" + member
+
+	if _, err := svc.Get(ctx, name, false); err != nil {
+		var apiErr *api.APIError
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return fmt.Errorf("you are not a member of %s", spaceName)
+		}
+		return fmt.Errorf("looking up membership: %w", err)
+	}
+
+	if !skipConfirm(cmd) {
+		summary, err := summarizeSpace(ctx, client, spaceName, false)
+		if err != nil {
+			return err
+		}
+		ok, err := confirm("Leave this space?", summary)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			f.PrintMessage("Leave cancelled.")
+			return nil
+		}
+	}
+
+	recorder := newUndoRecorder(cmd)
+	saved := recorder.snapshot(ctx, client, undo.KindMembership, name, false)
+	raw, err := svc.Delete(ctx, name, false)
+	if err != nil {
+		return fmt.Errorf("leaving space: %w", err)
+	}
+	recorder.add(undo.KindMembership, name, saved)
+	recorder.save()
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+
+	f.PrintSuccess(fmt.Sprintf("Left %s", spaceName))
+	return nil
+}
+
 // ---------------------------------------------------------------------------
 // spaces search
 // ---------------------------------------------------------------------------
@@ -440,19 +759,90 @@ func newSpacesSearchCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "search",
 		Short: "Search for spaces (admin)",
-		Long:  "Search for Google Chat spaces visible to the caller. Requires admin access.",
-		RunE:  runSpacesSearch,
-	}
+		Long: `Search for Google Chat spaces in your organization. Requires admin access.
+
+Instead of writing the admin search query by hand, combine the builder flags
+(--display-name, --created-after, --last-active-after, --external-user-allowed,
+--member-count, ...). Without --query, the customer and space type clauses
+the API requires are added for you; with --query, the builder clauses are
+ANDed to it. Pass -v to print the assembled query.`,
+		RunE: runSpacesSearch,
+	}
+
+	flags := cmd.Flags()
+	flags.String("query", "", "Raw search query (optional when builder flags are used)")
+	flags.Int("page-size", 100, "Maximum number of spaces per page")
+	flags.String("page-token", "", "Page token for pagination")
+	flags.Bool("admin", true, "Use admin access (default true for search)")
+	flags.String("display-name", "", "Spaces whose display name contains this text")
+	flags.String("created-after", "", `Spaces created after this time (e.g. "30 days ago", RFC 3339)`)
+	flags.String("created-before", "", "Spaces created before this time")
+	flags.String("last-active-after", "", "Spaces with activity after this time")
+	flags.String("last-active-before", "", "Spaces with no activity since this time")
+	flags.Bool("external-user-allowed", false, "Spaces that allow (true) or block (false) external users")
+	flags.String("member-count", "", "Joined human member count, e.g. '>50', '<=3', or '10'")
+	flags.String("history-state", "", "Spaces with this history state (HISTORY_ON or HISTORY_OFF)")
+	addTimeRangeFlags(cmd)
+	addSortFlags(cmd, spaceSearchSortFields)
 
-	cmd.Flags().String("query", "", "Search query (required)")
-	cmd.Flags().Int("page-size", 100, "Maximum number of spaces per page")
-	cmd.Flags().String("page-token", "", "Page token for pagination")
-	cmd.Flags().String("order-by", "", "Order results (e.g. \"membershipCount desc\")")
-	cmd.Flags().Bool("admin", true, "Use admin access (default true for search)")
+	return cmd
+}
 
-	_ = cmd.MarkFlagRequired("query")
+// spaceSearchTimeFlags maps the time flags of "spaces search" to the query
+// clause each one adds.
+var spaceSearchTimeFlags = []struct{ flag, clause string }{
+	{"created-after", "create_time > %q"},
+	{"created-before", "create_time < %q"},
+	{"last-active-after", "last_active_time > %q"},
+	{"last-active-before", "last_active_time < %q"},
+}
 
-	return cmd
+// memberCountPattern matches a --member-count comparison such as ">50".
+var memberCountPattern = regexp.MustCompile(`^(>=|<=|>|<|=)?\s*(\d+)$`)
+
+// buildSpaceSearchQuery assembles the admin search query from --query and
+// the builder flags.
+func buildSpaceSearchQuery(cmd *cobra.Command) (string, error) {
+	flags := cmd.Flags()
+	query, _ := flags.GetString("query")
+
+	var clauses []string
+	if query == "" {
+		clauses = append(clauses, `customer = "customers/my_customer"`, `space_type = "SPACE"`)
+	}
+
+	if name, _ := flags.GetString("display-name"); name != "" {
+		clauses = append(clauses, fmt.Sprintf("display_name:%q", name))
+	}
+	for _, tf := range spaceSearchTimeFlags {
+		t, err := parseTimeFlag(cmd, tf.flag)
+		if err != nil {
+			return "", err
+		}
+		if !t.IsZero() {
+			clauses = append(clauses, fmt.Sprintf(tf.clause, formatFilterTime(t)))
+		}
+	}
+	if flags.Changed("external-user-allowed") {
+		allowed, _ := flags.GetBool("external-user-allowed")
+		clauses = append(clauses, fmt.Sprintf("external_user_allowed = %t", allowed))
+	}
+	if count, _ := flags.GetString("member-count"); count != "" {
+		m := memberCountPattern.FindStringSubmatch(strings.TrimSpace(count))
+		if m == nil {
+			return "", fmt.Errorf("invalid --member-count %q; expected a number with an optional >, >=, <, <=, or = prefix", count)
+		}
+		op := m[1]
+		if op == "" {
+			op = "="
+		}
+		clauses = append(clauses, fmt.Sprintf("membership_count.joined_direct_human_user_count %s %s", op, m[2]))
+	}
+	if state, _ := flags.GetString("history-state"); state != "" {
+		clauses = append(clauses, fmt.Sprintf("space_history_state = %q", strings.ToUpper(state)))
+	}
+
+	return timeRangeFilter(cmd, andFilter(query, clauses...), "create_time")
 }
 
 func runSpacesSearch(cmd *cobra.Command, args []string) error {
@@ -465,19 +855,30 @@ func runSpacesSearch(cmd *cobra.Command, args []string) error {
 	svc := api.NewSpacesService(client)
 	ctx := context.Background()
 
-	query, _ := cmd.Flags().GetString("query")
-	pageSize, _ := cmd.Flags().GetInt("page-size")
+	pageSize := getPageSize(cmd)
 	pageToken, _ := cmd.Flags().GetString("page-token")
-	orderBy, _ := cmd.Flags().GetString("order-by")
 	admin, _ := cmd.Flags().GetBool("admin")
 
+	orderBy, err := resolveOrderBy(cmd, spaceSearchSortFields)
+	if err != nil {
+		return err
+	}
+
+	query, err := buildSpaceSearchQuery(cmd)
+	if err != nil {
+		return err
+	}
+	if viper.GetBool("verbose") {
+		fmt.Fprintf(os.Stderr, "Query: %s\n", query)
+	}
+
 	raw, err := svc.Search(ctx, query, pageSize, pageToken, orderBy, admin)
 	if err != nil {
 		return fmt.Errorf("searching spaces: %w", err)
 	}
 
 	if f.IsJSON() {
-		return f.PrintRaw(raw)
+		return printListPage(f, raw, "spaces")
 	}
 
 	var resp struct {
@@ -536,6 +937,7 @@ func newSpacesSetupCmd() *cobra.Command {
 	cmd.Flags().String("display-name", "", "Display name for the space")
 	cmd.Flags().String("space-type", "SPACE", "Space type (SPACE, GROUP_CHAT, DIRECT_MESSAGE)")
 	cmd.Flags().StringSlice("members", nil, "User resource names to add (e.g. users/12345)")
+	addBodyFlag(cmd)
 
 	return cmd
 }
@@ -554,28 +956,35 @@ func runSpacesSetup(cmd *cobra.Command, args []string) error {
 	spaceType, _ := cmd.Flags().GetString("space-type")
 	members, _ := cmd.Flags().GetStringSlice("members")
 
-	space := map[string]interface{}{
-		"spaceType": spaceType,
-	}
-	if displayName != "" {
-		space["displayName"] = displayName
+	request, err := readBody(cmd)
+	if err != nil {
+		return err
 	}
 
-	request := map[string]interface{}{
-		"space": space,
-	}
+	if request == nil {
+		space := map[string]interface{}{
+			"spaceType": spaceType,
+		}
+		if displayName != "" {
+			space["displayName"] = displayName
+		}
 
-	if len(members) > 0 {
-		memberships := make([]map[string]interface{}, 0, len(members))
-		for _, m := range members {
-			memberships = append(memberships, map[string]interface{}{
-				"member": map[string]interface{}{
-					"name": m,
-					"type": "HUMAN",
-				},
-			})
+		request = map[string]interface{}{
+			"space": space,
+		}
+
+		if len(members) > 0 {
+			memberships := make([]map[string]interface{}, 0, len(members))
+			for _, m := range members {
+				memberships = append(memberships, map[string]interface{}{
+					"member": map[string]interface{}{
+						"name": m,
+						"type": "HUMAN",
+					},
+				})
+			}
+			request["memberships"] = memberships
 		}
-		request["memberships"] = memberships
 	}
 
 	raw, err := svc.Setup(ctx, request)
@@ -603,15 +1012,22 @@ func runSpacesSetup(cmd *cobra.Command, args []string) error {
 
 func newSpacesFindDMCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "find-dm",
+		Use:   "find-dm [USER]",
 		Short: "Find a direct message space with a user",
-		Long:  "Find an existing direct message space between the authenticated user and the specified user.",
+		Long:  "Find an existing direct message space between the authenticated user and the specified user. USER (or --user) is an email address, which is resolved through the Workspace directory, or a user resource name (users/12345).",
+		Args:  cobra.MaximumNArgs(1),
 		RunE:  runSpacesFindDM,
 	}
 
-	cmd.Flags().String("user", "", "User resource name (e.g. users/12345) (required)")
+	cmd.Flags().String("user", "", "User email or resource name (e.g. alice@example.com or users/12345)")
 
-	_ = cmd.MarkFlagRequired("user")
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeDirectoryUsers(cmd, args, toComplete)
+	}
+	_ = cmd.RegisterFlagCompletionFunc("user", completeDirectoryUsers)
 
 	return cmd
 }
@@ -627,6 +1043,19 @@ func runSpacesFindDM(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
 
 	user, _ := cmd.Flags().GetString("user")
+	switch {
+	case len(args) == 1 && user != "":
+		return fmt.Errorf("give the user either as an argument or with --user, not both")
+	case len(args) == 1:
+		user = args[0]
+	case user == "":
+		return fmt.Errorf("a user is required: gogchat spaces find-dm alice@example.com")
+	}
+
+	user, err = resolveUser(ctx, client, user)
+	if err != nil {
+		return err
+	}
 
 	raw, err := svc.FindDirectMessage(ctx, user)
 	if err != nil {
@@ -654,7 +1083,7 @@ func newSpacesCompleteImportCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "complete-import SPACE",
 		Short: "Complete the import process for a space",
-		Long:  "Complete the import process for a Google Chat space, making it visible to users and allowing new messages.",
+		Long:  "Complete the import process for a Google Chat space, making it visible to users and allowing new messages. \"import complete\" does the same after checking the space first.",
 		Args:  cobra.ExactArgs(1),
 		RunE:  runSpacesCompleteImport,
 	}
@@ -690,6 +1119,218 @@ func runSpacesCompleteImport(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// ---------------------------------------------------------------------------
+// spaces history
+// ---------------------------------------------------------------------------
+
+func newSpacesHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:       "history SPACE on|off",
+		Short:     "Turn message history on or off for a space",
+		Long:      "Toggle the history setting of a Google Chat space. SPACE can be a space ID or full resource name (spaces/XXXX).",
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: []string{"on", "off"},
+		RunE:      runSpacesHistory,
+	}
+
+	cmd.Flags().Bool("admin", false, "Use admin access")
+
+	return cmd
+}
+
+func runSpacesHistory(cmd *cobra.Command, args []string) error {
+	var historyState string
+	switch strings.ToLower(args[1]) {
+	case "on":
+		historyState = "HISTORY_ON"
+	case "off":
+		historyState = "HISTORY_OFF"
+	default:
+		return fmt.Errorf("invalid history setting %q; use 'on' or 'off'", args[1])
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	f := getFormatter()
+	svc := api.NewSpacesService(client)
+	ctx := context.Background()
+
+	admin, _ := cmd.Flags().GetBool("admin")
+
+	space := map[string]interface{}{
+		"spaceHistoryState": historyState,
+	}
+
+	raw, err := svc.Patch(ctx, args[0], space, "spaceHistoryState", admin)
+	if err != nil {
+		return fmt.Errorf("updating space history: %w", err)
+	}
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+
+	var sp map[string]interface{}
+	if err := json.Unmarshal(raw, &sp); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+
+	f.PrintSuccess(fmt.Sprintf("History turned %s for space: %s", strings.ToLower(args[1]), spaceMapStr(sp, "name")))
+	printSpaceDetail(sp)
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// spaces access
+// ---------------------------------------------------------------------------
+
+// spacePermissionSettings lists the permissionSettings fields that can be
+// changed with --permission.
+var spacePermissionSettings = []string{
+	"manageMembersAndGroups",
+	"modifySpaceDetails",
+	"toggleHistory",
+	"useAtMentionAll",
+	"manageApps",
+	"manageWebhooks",
+	"postMessages",
+	"replyMessages",
+}
+
+func newSpacesAccessCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "access SPACE",
+		Short: "Change who can find and use a space",
+		Long: `Change the access and permission settings of a Google Chat space.
+SPACE can be a space ID or full resource name (spaces/XXXX).
+
+Use --discoverable to make the space discoverable by a target audience
+(audiences/default is everyone in the organization), or --restricted to
+limit it to invited members only.
+
+Use --permission SETTING=managers|members (repeatable) to change who may
+perform an action. SETTING is one of: ` + strings.Join(spacePermissionSettings, ", ") + `.
+
+The update mask is built from the flags that are set.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runSpacesAccess,
+	}
+
+	cmd.Flags().Bool("discoverable", false, "Make the space discoverable by --audience")
+	cmd.Flags().String("audience", "audiences/default", "Target audience for a discoverable space")
+	cmd.Flags().Bool("restricted", false, "Restrict the space to invited members only")
+	cmd.Flags().StringArray("permission", nil, "Permission setting as SETTING=managers|members (repeatable)")
+	cmd.Flags().Bool("admin", false, "Use admin access")
+
+	cmd.MarkFlagsMutuallyExclusive("discoverable", "restricted")
+
+	return cmd
+}
+
+func runSpacesAccess(cmd *cobra.Command, args []string) error {
+	discoverable, _ := cmd.Flags().GetBool("discoverable")
+	audience, _ := cmd.Flags().GetString("audience")
+	restricted, _ := cmd.Flags().GetBool("restricted")
+	permissions, _ := cmd.Flags().GetStringArray("permission")
+	admin, _ := cmd.Flags().GetBool("admin")
+
+	space := map[string]interface{}{}
+	var maskParts []string
+
+	switch {
+	case discoverable:
+		space["accessSettings"] = map[string]interface{}{
+			"audience": api.NormalizeName(audience, "audiences/"),
+		}
+		maskParts = append(maskParts, "accessSettings.audience")
+	case restricted:
+		// An empty audience in the mask clears it, leaving the space
+		// accessible to invited members only.
+		space["accessSettings"] = map[string]interface{}{}
+		maskParts = append(maskParts, "accessSettings.audience")
+	}
+
+	if len(permissions) > 0 {
+		settings := map[string]interface{}{}
+		for _, p := range permissions {
+			key, value, ok := strings.Cut(p, "=")
+			if !ok {
+				return fmt.Errorf("invalid --permission %q; expected SETTING=managers|members", p)
+			}
+			if !spaceIsPermissionSetting(key) {
+				return fmt.Errorf("unknown permission setting %q; valid settings: %s", key, strings.Join(spacePermissionSettings, ", "))
+			}
+
+			var membersAllowed bool
+			switch strings.ToLower(value) {
+			case "managers":
+				membersAllowed = false
+			case "members":
+				membersAllowed = true
+			default:
+				return fmt.Errorf("invalid value %q for %s; use 'managers' or 'members'", value, key)
+			}
+
+			settings[key] = map[string]interface{}{
+				"managersAllowed": true,
+				"membersAllowed":  membersAllowed,
+			}
+			maskParts = append(maskParts, "permissionSettings."+key)
+		}
+		space["permissionSettings"] = settings
+	}
+
+	if len(maskParts) == 0 {
+		return fmt.Errorf("no settings to change; use --discoverable, --restricted, or --permission")
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+
+	f := getFormatter()
+	svc := api.NewSpacesService(client)
+	ctx := context.Background()
+
+	raw, err := svc.Patch(ctx, args[0], space, strings.Join(maskParts, ","), admin)
+	if err != nil {
+		return fmt.Errorf("updating space access: %w", err)
+	}
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+
+	var sp map[string]interface{}
+	if err := json.Unmarshal(raw, &sp); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+
+	f.PrintSuccess(fmt.Sprintf("Access settings updated for space: %s", spaceMapStr(sp, "name")))
+	printSpaceDetail(sp)
+	if access := spaceExtractNested(sp, "accessSettings.accessState"); access != "" {
+		fmt.Printf("%-20s %s\n", "Access State:", access)
+	}
+	if audience := spaceExtractNested(sp, "accessSettings.audience"); audience != "" {
+		fmt.Printf("%-20s %s\n", "Audience:", audience)
+	}
+	return nil
+}
+
+// spaceIsPermissionSetting reports whether key is a known permissionSettings field.
+func spaceIsPermissionSetting(key string) bool {
+	for _, s := range spacePermissionSettings {
+		if s == key {
+			return true
+		}
+	}
+	return false
+}
+
 // ---------------------------------------------------------------------------
 // helpers (spaces-specific)
 // ---------------------------------------------------------------------------
@@ -725,3 +1366,70 @@ func spaceExtractNested(m map[string]interface{}, key string) string {
 
 	return spaceMapStr(nestedMap, parts[1])
 }
+
+// listAllSpaces returns every space the caller is a member of that matches
+// filter, following all result pages.
+func listAllSpaces(ctx context.Context, client *api.Client, filter string) ([]json.RawMessage, error) {
+	svc := api.NewSpacesService(client)
+
+	var all []json.RawMessage
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, filter, 1000, pageToken)
+		if err != nil {
+			return nil, fmt.Errorf("listing spaces: %w", err)
+		}
+		var resp struct {
+			Spaces        []json.RawMessage `json:"spaces"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		all = append(all, resp.Spaces...)
+		if resp.NextPageToken == "" {
+			cacheSpaceNames(all)
+			return all, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// spaceDisplayName returns the display name of space, from the lookup
+// cache when possible. Spaces without one (direct messages) have "".
+func spaceDisplayName(ctx context.Context, client *api.Client, space string, admin bool) (string, error) {
+	space = api.NormalizeName(space, "spaces/")
+	var name string
+	if getCache().Get(cache.Spaces, space, &name) {
+		return name, nil
+	}
+
+	raw, err := api.NewSpacesService(client).Get(ctx, space, admin)
+	if err != nil {
+		return "", err
+	}
+	var sp struct {
+		DisplayName string `json:"displayName"`
+	}
+	if err := json.Unmarshal(raw, &sp); err != nil {
+		return "", fmt.Errorf("parsing response: %w", err)
+	}
+	_ = getCache().Put(cache.Spaces, space, sp.DisplayName)
+	return sp.DisplayName, nil
+}
+
+// cacheSpaceNames stores the display names of listed spaces in the lookup
+// cache.
+func cacheSpaceNames(spaces []json.RawMessage) {
+	names := make(map[string]interface{}, len(spaces))
+	for _, raw := range spaces {
+		var sp struct {
+			Name        string `json:"name"`
+			DisplayName string `json:"displayName"`
+		}
+		if json.Unmarshal(raw, &sp) == nil && sp.Name != "" {
+			names[sp.Name] = sp.DisplayName
+		}
+	}
+	_ = getCache().PutAll(cache.Spaces, names)
+}
@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewStarCmd creates the top-level "star" command with add, list, and
+// remove subcommands.
+func NewStarCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "star",
+		Short: "Keep a local list of important messages",
+		Long: `Star important messages to find them again later, like pinning them.
+
+Chat has no pin API, so starred messages are kept locally in
+~/.config/gogchat/stars.json, with a link, a snippet of the text, and an
+optional note. Stars are per machine and only visible to you.`,
+	}
+
+	cmd.AddCommand(
+		newStarAddCmd(),
+		newStarListCmd(),
+		newStarRemoveCmd(),
+	)
+
+	return cmd
+}
+
+// star is one starred message.
+type star struct {
+	Message   string    `json:"message"`
+	Space     string    `json:"space"`
+	Sender    string    `json:"sender"`
+	Text      string    `json:"text"`
+	Link      string    `json:"link"`
+	Note      string    `json:"note,omitempty"`
+	StarredAt time.Time `json:"starredAt"`
+}
+
+// starsPath returns the location of the starred message store.
+func starsPath() string {
+	return filepath.Join(config.ConfigDir(), "stars.json")
+}
+
+// loadStars reads the starred messages; a missing store is empty.
+func loadStars() ([]star, error) {
+	data, err := os.ReadFile(starsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading stars: %w", err)
+	}
+	var stars []star
+	if err := json.Unmarshal(data, &stars); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", starsPath(), err)
+	}
+	return stars, nil
+}
+
+// saveStars writes the starred messages.
+func saveStars(stars []star) error {
+	data, err := json.MarshalIndent(stars, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.ConfigDir(), 0o700); err != nil {
+		return fmt.Errorf("saving stars: %w", err)
+	}
+	if err := os.WriteFile(starsPath(), data, 0o600); err != nil {
+		return fmt.Errorf("saving stars: %w", err)
+	}
+	return nil
+}
+
+// newStarAddCmd creates the "star add" subcommand.
+func newStarAddCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add MESSAGE",
+		Short: "Star a message",
+		Long:  "Star a message (spaces/{space}/messages/{message}). The message is fetched to check that it exists and to save a snippet of its text. Starring a message again updates its note.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newAPIClient()
+			if err != nil {
+				return err
+			}
+			f := getFormatter()
+			note, _ := cmd.Flags().GetString("note")
+
+			raw, err := api.NewMessagesService(client).Get(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("getting message: %w", err)
+			}
+			var msg digestMessage
+			if err := json.Unmarshal(raw, &msg); err != nil {
+				return fmt.Errorf("parsing response: %w", err)
+			}
+
+			stars, err := loadStars()
+			if err != nil {
+				return err
+			}
+			s := star{
+				Message:   msg.Name,
+				Space:     spaceOf(msg.Name),
+				Sender:    msg.senderName(),
+				Text:      snippet(msg.Text),
+				Link:      messageLink(msg.Name, msg.Thread.Name),
+				Note:      note,
+				StarredAt: time.Now().UTC(),
+			}
+			replaced := false
+			for i := range stars {
+				if stars[i].Message == s.Message {
+					s.StarredAt = stars[i].StarredAt
+					stars[i] = s
+					replaced = true
+				}
+			}
+			if !replaced {
+				stars = append(stars, s)
+			}
+			if err := saveStars(stars); err != nil {
+				return err
+			}
+
+			if f.IsJSON() {
+				return f.Print(s)
+			}
+			if replaced {
+				f.PrintSuccess(fmt.Sprintf("Updated star on %s", s.Message))
+			} else {
+				f.PrintSuccess(fmt.Sprintf("Starred %s", s.Message))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().String("note", "", "Note to keep with the star")
+
+	return cmd
+}
+
+// newStarListCmd creates the "star list" subcommand.
+func newStarListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list [SPACE]",
+		Short: "List starred messages",
+		Long:  "List starred messages, grouped by space in the order they were starred. With SPACE, only that space's stars are listed.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			stars, err := loadStars()
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				space := api.NormalizeName(args[0], "spaces/")
+				var kept []star
+				for _, s := range stars {
+					if s.Space == space {
+						kept = append(kept, s)
+					}
+				}
+				stars = kept
+			}
+
+			if f.IsJSON() {
+				return printList(f, stars, "")
+			}
+			if len(stars) == 0 {
+				f.PrintMessage("No starred messages.")
+				return nil
+			}
+
+			var spaces []string
+			bySpace := map[string][]star{}
+			for _, s := range stars {
+				if _, ok := bySpace[s.Space]; !ok {
+					spaces = append(spaces, s.Space)
+				}
+				bySpace[s.Space] = append(bySpace[s.Space], s)
+			}
+			for i, space := range spaces {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Println(space)
+				for _, s := range bySpace[space] {
+					fmt.Printf("  ★ %s: %s\n", s.Sender, quoteSnippet(s.Text))
+					if s.Note != "" {
+						fmt.Printf("    Note: %s\n", s.Note)
+					}
+					fmt.Printf("    %s  (%s, starred %s)\n", s.Link, s.Message, output.FormatTime(s.StarredAt.Format(time.RFC3339)))
+				}
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newStarRemoveCmd creates the "star remove" subcommand.
+func newStarRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove MESSAGE...",
+		Short: "Remove stars from messages",
+		Long:  "Remove the stars from one or more messages. Only the local star is removed; the messages are not changed.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			stars, err := loadStars()
+			if err != nil {
+				return err
+			}
+
+			remove := map[string]bool{}
+			for _, arg := range args {
+				remove[strings.TrimSpace(arg)] = true
+			}
+			kept := stars[:0]
+			removed := 0
+			for _, s := range stars {
+				if remove[s.Message] {
+					removed++
+					continue
+				}
+				kept = append(kept, s)
+			}
+			if removed == 0 {
+				return fmt.Errorf("no starred message matches %s", strings.Join(args, ", "))
+			}
+			if err := saveStars(kept); err != nil {
+				return err
+			}
+
+			f.PrintSuccess(fmt.Sprintf("Removed %d star(s).", removed))
+			return nil
+		},
+	}
+}
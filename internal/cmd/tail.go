@@ -0,0 +1,592 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// ---------------------------------------------------------------------------
+// messages tail
+// ---------------------------------------------------------------------------
+
+// tailMessage holds the message fields printed by "messages tail" and passed
+// to --exec handlers.
+type tailMessage struct {
+	Name       string `json:"name"`
+	Text       string `json:"text"`
+	CreateTime string `json:"createTime"`
+	Sender     struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+		Type        string `json:"type"`
+	} `json:"sender"`
+	Thread struct {
+		Name string `json:"name"`
+	} `json:"thread"`
+	Space struct {
+		Name string `json:"name"`
+	} `json:"space"`
+	Annotations []struct {
+		Type       string `json:"type"`
+		StartIndex int    `json:"startIndex"`
+		Length     int    `json:"length"`
+	} `json:"annotations"`
+}
+
+// highlightedText returns the message text with its @-mentions highlighted
+// in the theme's mention color.
+func (m tailMessage) highlightedText() string {
+	text := []rune(m.Text)
+	var b strings.Builder
+	pos := 0
+	for _, a := range m.Annotations {
+		end := a.StartIndex + a.Length
+		if a.Type != "USER_MENTION" || a.StartIndex < pos || end > len(text) || a.Length == 0 || text[a.StartIndex] != '@' {
+			continue
+		}
+		b.WriteString(string(text[pos:a.StartIndex]))
+		b.WriteString(output.Mention(string(text[a.StartIndex:end])))
+		pos = end
+	}
+	b.WriteString(string(text[pos:]))
+	return b.String()
+}
+
+func newMessagesTailCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tail SPACE... | --all-spaces",
+		Short: "Follow new messages in one or more spaces",
+		Long: `Poll spaces for new messages and print them as they arrive, until
+interrupted with Ctrl-C.
+
+Several spaces can be watched at once, or every space you are a member of
+with --all-spaces (the space list is read when tailing starts). Their
+messages are interleaved in creation order, each prefixed with the space
+name, colored per space on a terminal unless NO_COLOR is set. The spaces are
+polled a few at a time and at most 5 requests per second overall, sharing one
+retry budget, so large watch lists stay within the API's rate limits; with
+many spaces a poll round can take longer than --interval.
+
+With --exec, the given command is run through the shell for each new message,
+with the message JSON on stdin and these environment variables set:
+
+  GOGCHAT_MESSAGE       Message resource name
+  GOGCHAT_SPACE         Space resource name
+  GOGCHAT_THREAD        Thread resource name
+  GOGCHAT_SENDER        Sender resource name (users/...)
+  GOGCHAT_SENDER_NAME   Sender display name
+  GOGCHAT_TEXT          Message text
+  GOGCHAT_CREATE_TIME   Message creation time (RFC 3339)
+
+A failing handler is reported but does not stop tailing.
+
+With --digest, --exec runs at once only for messages that @-mention you;
+the other messages of each space are collected and handed to the command
+together, at most once per --digest period per space, to cut down on
+alerts. For such a digest the message JSON on stdin is an array of the
+messages, GOGCHAT_TEXT is a summary such as "5 new messages from Jane Doe
+and John Roe", GOGCHAT_DIGEST_COUNT is the number of messages, and
+GOGCHAT_SPACE_NAME is the space's display name. GOGCHAT_MESSAGE,
+GOGCHAT_THREAD, and the sender variables are empty. Pending messages are
+handed over when tailing stops. For desktop notifications:
+
+  gogchat messages tail --all-spaces --digest 15m \
+    --exec 'notify-send "${GOGCHAT_SPACE_NAME:-$GOGCHAT_SENDER_NAME}" "$GOGCHAT_TEXT"'
+
+With --rules, new messages are matched against declared auto-response rules
+and the reply of the first matching rule is posted in the message's thread:
+
+  rules:
+    - when: "contains:oncall"
+      reply: "Current oncall is {{env ONCALL}}"
+      cooldown: 10m
+    - when: "regex:(?i)^deploy status$"
+      reply: "Hi {{.SenderName}}, see https://deploy.example.com"
+
+Conditions are contains:TEXT, equals:TEXT, regex:PATTERN, and from:USER.
+Replies are Go templates with .Text, .Sender, .SenderName, .Space, .Thread,
+.Message, and env. Messages from bots and the responder's own replies never
+trigger rules.
+
+With --sink NAME, new messages are also written to that sink of the config
+file (see sinks): a rotating JSON Lines file, an HTTP endpoint, or an
+S3-compatible bucket such as Cloud Storage. --sink can be repeated.
+
+With --write-latest, the newest --write-count messages are kept in a JSON
+file, newest first, for status pages and kiosks that show live Chat
+content without calling the API. The file is written when tailing starts
+and replaced atomically whenever new messages arrive:
+
+  {
+    "version": 1,
+    "updatedAt": "2026-03-01T10:00:05Z",
+    "spaces": [{"name": "spaces/AAAABBBBcccc", "label": "AAAABBBBcccc"}],
+    "messages": [{"name": "...", "space": "...", "spaceLabel": "...",
+      "thread": "...", "senderName": "users/...", "senderDisplayName": "...",
+      "text": "...", "createTime": "..."}]
+  }`,
+		Example: `  gogchat messages tail spaces/AAAABBBBcccc
+  gogchat messages tail --all-spaces --digest 15m --exec ./notify.sh
+  gogchat messages tail spaces/AAAABBBBcccc --write-latest /var/www/status.json --write-count 5 --quiet`,
+		RunE: runMessagesTail,
+	}
+
+	flags := cmd.Flags()
+	flags.Bool("all-spaces", false, "Watch every space you are a member of")
+	flags.Duration("interval", 5*time.Second, "How often to poll for new messages")
+	flags.String("exec", "", "Shell command to run for each new message")
+	flags.Duration("digest", 0, "With --exec, batch messages that do not mention you per space over this period")
+	flags.String("rules", "", "YAML file of auto-response rules to apply to new messages")
+	flags.String("write-latest", "", "Keep the newest messages in this JSON file")
+	flags.Int("write-count", 1, "Number of messages kept in the --write-latest file")
+	addSinkFlag(cmd, "new messages")
+	addRenderFlag(cmd)
+	disablePager(cmd)
+
+	return cmd
+}
+
+// Polling limits for "messages tail": the number of spaces polled at once
+// and the overall request rate shared by all of them.
+const (
+	tailPollConcurrency   = 4
+	tailRequestsPerSecond = 5
+)
+
+// tailTarget is one space watched by "messages tail".
+type tailTarget struct {
+	space string
+	// label prefixes the space's messages when several spaces are watched.
+	label string
+	// since is the creation time of the last message seen in the space.
+	since string
+}
+
+// tailEntry is a new message found by one poll round.
+type tailEntry struct {
+	target *tailTarget
+	raw    json.RawMessage
+	msg    tailMessage
+	at     time.Time
+}
+
+func runMessagesTail(cmd *cobra.Command, args []string) error {
+	allSpaces, _ := cmd.Flags().GetBool("all-spaces")
+	switch {
+	case allSpaces && len(args) > 0:
+		return fmt.Errorf("--all-spaces cannot be combined with SPACE arguments")
+	case !allSpaces && len(args) == 0:
+		return fmt.Errorf("requires at least one SPACE, or --all-spaces")
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	enableDaemonRetries(client)
+	f := getFormatter()
+	svc := api.NewMessagesService(client)
+
+	interval, _ := cmd.Flags().GetDuration("interval")
+	handler, _ := cmd.Flags().GetString("exec")
+	rulesPath, _ := cmd.Flags().GetString("rules")
+	digest, _ := cmd.Flags().GetDuration("digest")
+	latestPath, _ := cmd.Flags().GetString("write-latest")
+	latestCount, _ := cmd.Flags().GetInt("write-count")
+	render := shouldRender(cmd)
+	if interval < time.Second {
+		return fmt.Errorf("--interval must be at least 1s")
+	}
+	if digest != 0 && handler == "" {
+		return fmt.Errorf("--digest requires --exec")
+	}
+	if digest < 0 || (digest > 0 && digest < interval) {
+		return fmt.Errorf("--digest must be at least --interval")
+	}
+	if latestCount < 1 {
+		return fmt.Errorf("--write-count must be at least 1")
+	}
+
+	sinks, err := openSinks(cmd)
+	if err != nil {
+		return err
+	}
+	defer sinks.close()
+
+	var auto *responder
+	if rulesPath != "" {
+		if auto, err = loadResponder(rulesPath); err != nil {
+			return err
+		}
+		if auto.gov, err = getPostGovernor(); err != nil {
+			return err
+		}
+		defer auto.gov.printSummary()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	limiter := time.NewTicker(time.Second / tailRequestsPerSecond)
+	defer limiter.Stop()
+
+	targets, err := tailTargets(ctx, client, args, allSpaces)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("you are not a member of any space")
+	}
+
+	// Only messages created after tailing starts are reported.
+	start := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, t := range targets {
+		t.since = start
+	}
+	if !f.IsJSON() {
+		what := targets[0].space
+		if len(targets) > 1 {
+			what = fmt.Sprintf("%d spaces", len(targets))
+		}
+		fmt.Fprintf(os.Stderr, "Tailing %s (Ctrl-C to stop)...\n", what)
+	}
+
+	var latest *latestWriter
+	if latestPath != "" {
+		if latest, err = newLatestWriter(ctx, f, svc, expandHome(latestPath), latestCount, targets); err != nil {
+			return err
+		}
+	}
+
+	var batches *tailBatches
+	if digest > 0 {
+		me, err := currentUser(ctx, client)
+		if err != nil {
+			return fmt.Errorf("looking up your user for --digest: %w", err)
+		}
+		batches = &tailBatches{me: me, period: digest, pending: map[*tailTarget]*tailBatch{}}
+		// Hand over what is pending even when interrupted.
+		defer batches.flush(context.Background(), f, handler, true)
+	}
+
+	for {
+		entries := pollTargets(ctx, f, svc, limiter.C, targets)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		for _, e := range entries {
+			prefix := ""
+			if len(targets) > 1 {
+				prefix = output.Accent(e.target.label, "["+e.target.label+"]")
+			}
+			printTailMessage(f, e.raw, e.msg, render, prefix)
+			sinks.write(e.raw)
+			batched := batches != nil && batches.add(e)
+			if handler != "" && !batched {
+				if err := runTailHandler(ctx, handler, e.raw, e.msg); err != nil {
+					f.PrintError(fmt.Sprintf("⚠ --exec failed for %s: %v", e.msg.Name, err))
+				}
+			}
+			if auto != nil {
+				auto.handle(ctx, f, svc, e.target.space, e.msg)
+			}
+		}
+		if batches != nil {
+			batches.flush(ctx, f, handler, false)
+		}
+		if latest != nil {
+			if err := latest.add(entries); err != nil {
+				f.PrintError(fmt.Sprintf("⚠ --write-latest failed: %v", err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// tailTargets returns the spaces to watch: the given spaces, labelled with
+// their display names, or every space of the caller with allSpaces.
+func tailTargets(ctx context.Context, client *api.Client, args []string, allSpaces bool) ([]*tailTarget, error) {
+	label := func(space, displayName string) string {
+		if displayName != "" {
+			return displayName
+		}
+		return strings.TrimPrefix(space, "spaces/")
+	}
+
+	if allSpaces {
+		raws, err := listAllSpaces(ctx, client, "")
+		if err != nil {
+			return nil, err
+		}
+		var targets []*tailTarget
+		for _, raw := range raws {
+			var sp struct {
+				Name        string `json:"name"`
+				DisplayName string `json:"displayName"`
+			}
+			if json.Unmarshal(raw, &sp) == nil && sp.Name != "" {
+				targets = append(targets, &tailTarget{space: sp.Name, label: label(sp.Name, sp.DisplayName)})
+			}
+		}
+		return targets, nil
+	}
+
+	var targets []*tailTarget
+	seen := map[string]bool{}
+	for _, arg := range args {
+		space := api.NormalizeName(arg, "spaces/")
+		if seen[space] {
+			continue
+		}
+		seen[space] = true
+		targets = append(targets, &tailTarget{space: space, label: label(space, "")})
+	}
+	if len(targets) == 1 {
+		return targets, nil
+	}
+
+	// Label the spaces by display name; a space that cannot be read keeps
+	// its ID and fails visibly on the first poll.
+	for _, t := range targets {
+		if name, err := spaceDisplayName(ctx, client, t.space, false); err == nil {
+			t.label = label(t.space, name)
+		}
+	}
+	return targets, nil
+}
+
+// pollTargets polls every target for new messages, at most
+// tailPollConcurrency at a time and one request per tick, and returns them
+// in creation order. Each target's since is advanced past its messages;
+// failures are reported and the target is retried on the next round.
+func pollTargets(ctx context.Context, f *output.Formatter, svc *api.MessagesService, tick <-chan time.Time, targets []*tailTarget) []tailEntry {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		entries []tailEntry
+	)
+	sem := make(chan struct{}, tailPollConcurrency)
+
+	for _, t := range targets {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			<-sem
+			continue
+		case <-tick:
+		}
+
+		wg.Add(1)
+		go func(t *tailTarget) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			messages, err := pollMessages(ctx, svc, t.space, t.since)
+			if err != nil {
+				if ctx.Err() == nil {
+					f.PrintError(fmt.Sprintf("⚠ Polling %s failed: %v", t.space, err))
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, raw := range messages {
+				var msg tailMessage
+				if err := json.Unmarshal(raw, &msg); err != nil {
+					continue
+				}
+				at, _ := time.Parse(time.RFC3339Nano, msg.CreateTime)
+				entries = append(entries, tailEntry{target: t, raw: raw, msg: msg, at: at})
+				t.since = msg.CreateTime
+			}
+		}(t)
+	}
+	wg.Wait()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].at.Before(entries[j].at)
+	})
+	return entries
+}
+
+// pollMessages returns the messages in space created after since, oldest
+// first.
+func pollMessages(ctx context.Context, svc *api.MessagesService, space, since string) ([]json.RawMessage, error) {
+	return listAllMessages(ctx, svc, space, fmt.Sprintf("createTime > %q", since), "createTime asc")
+}
+
+// printTailMessage prints one message: a compact JSON line in JSON mode,
+// otherwise "time  sender: text" after prefix (if any), with the text
+// rendered as markdown below the header when render is set.
+func printTailMessage(f *output.Formatter, raw json.RawMessage, msg tailMessage, render bool, prefix string) {
+	if f.IsJSON() {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err == nil {
+			fmt.Println(buf.String())
+		}
+		return
+	}
+
+	sender := msg.Sender.DisplayName
+	if sender == "" {
+		sender = msg.Sender.Name
+	}
+	if prefix != "" {
+		prefix += " "
+	}
+	at := output.Timestamp(output.FormatTime(msg.CreateTime))
+	if render {
+		fmt.Printf("%s%s  %s:\n%s\n\n", prefix, at, sender, output.RenderMarkdown(msg.Text))
+		return
+	}
+	fmt.Printf("%s%s  %s: %s\n", prefix, at, sender, msg.highlightedText())
+}
+
+// runTailHandler runs the --exec command for one message. env holds extra
+// NAME=value environment variables.
+func runTailHandler(ctx context.Context, handler string, raw json.RawMessage, msg tailMessage, env ...string) error {
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		c = exec.CommandContext(ctx, "cmd", "/C", handler)
+	} else {
+		c = exec.CommandContext(ctx, "sh", "-c", handler)
+	}
+
+	space := msg.Space.Name
+	if space == "" {
+		space = spaceOf(msg.Name)
+	}
+
+	c.Stdin = bytes.NewReader(raw)
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.Env = append(os.Environ(),
+		"GOGCHAT_MESSAGE="+msg.Name,
+		"GOGCHAT_SPACE="+space,
+		"GOGCHAT_THREAD="+msg.Thread.Name,
+		"GOGCHAT_SENDER="+msg.Sender.Name,
+		"GOGCHAT_SENDER_NAME="+msg.Sender.DisplayName,
+		"GOGCHAT_TEXT="+msg.Text,
+		"GOGCHAT_CREATE_TIME="+msg.CreateTime,
+	)
+	c.Env = append(c.Env, env...)
+	return c.Run()
+}
+
+// tailBatch is the messages of one space pending for its next digest.
+type tailBatch struct {
+	started time.Time
+	entries []tailEntry
+}
+
+// tailBatches collects the messages handed to --exec in digests with
+// --digest. Messages that mention me are not batched.
+type tailBatches struct {
+	me      string
+	period  time.Duration
+	pending map[*tailTarget]*tailBatch
+}
+
+// add batches e for its space's next digest, and reports whether it did;
+// messages that mention the caller are left to be handled at once.
+func (b *tailBatches) add(e tailEntry) bool {
+	var msg digestMessage
+	if err := json.Unmarshal(e.raw, &msg); err == nil && msg.mentions(b.me) {
+		return false
+	}
+	batch := b.pending[e.target]
+	if batch == nil {
+		batch = &tailBatch{started: time.Now()}
+		b.pending[e.target] = batch
+	}
+	batch.entries = append(batch.entries, e)
+	return true
+}
+
+// flush runs handler once for every space whose batch is older than the
+// digest period, or for every pending batch with all.
+func (b *tailBatches) flush(ctx context.Context, f *output.Formatter, handler string, all bool) {
+	var due []*tailTarget
+	for t, batch := range b.pending {
+		if all || time.Since(batch.started) >= b.period {
+			due = append(due, t)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].space < due[j].space })
+
+	for _, t := range due {
+		entries := b.pending[t].entries
+		delete(b.pending, t)
+
+		raws := make([]json.RawMessage, len(entries))
+		var senders []string
+		seen := map[string]bool{}
+		for i, e := range entries {
+			raws[i] = e.raw
+			name := e.msg.Sender.DisplayName
+			if name == "" {
+				name = e.msg.Sender.Name
+			}
+			if !seen[name] {
+				seen[name] = true
+				senders = append(senders, name)
+			}
+		}
+		raw, err := json.Marshal(raws)
+		if err != nil {
+			continue
+		}
+
+		var msg tailMessage
+		msg.Space.Name = t.space
+		msg.CreateTime = entries[len(entries)-1].msg.CreateTime
+		msg.Text = fmt.Sprintf("%d new %s from %s", len(entries), plural(len(entries), "message", "messages"), joinNames(senders, 3))
+		if err := runTailHandler(ctx, handler, raw, msg,
+			"GOGCHAT_SPACE_NAME="+t.label,
+			fmt.Sprintf("GOGCHAT_DIGEST_COUNT=%d", len(entries)),
+		); err != nil {
+			f.PrintError(fmt.Sprintf("⚠ --exec failed for the digest of %s: %v", t.space, err))
+		}
+	}
+}
+
+// joinNames joins names as "A, B and C", naming at most max of them and
+// counting the rest as others.
+func joinNames(names []string, max int) string {
+	if len(names) > max {
+		rest := len(names) - max
+		names = append(names[:max:max], fmt.Sprintf("%d %s", rest, plural(rest, "other", "others")))
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// latestFileVersion is the schema version of the --write-latest file. It
+// only changes when fields are renamed or removed.
+const latestFileVersion = 1
+
+// latestFile is the content of the --write-latest file.
+type latestFile struct {
+	Version   int             `json:"version"`
+	UpdatedAt string          `json:"updatedAt"`
+	Spaces    []latestSpace   `json:"spaces"`
+	Messages  []latestMessage `json:"messages"`
+}
+
+// latestSpace is a space watched by "messages tail".
+type latestSpace struct {
+	Name  string `json:"name"`
+	Label string `json:"label"`
+}
+
+// latestMessage is a message in the --write-latest file.
+type latestMessage struct {
+	Name              string `json:"name"`
+	Space             string `json:"space"`
+	SpaceLabel        string `json:"spaceLabel"`
+	Thread            string `json:"thread"`
+	SenderName        string `json:"senderName"`
+	SenderDisplayName string `json:"senderDisplayName"`
+	Text              string `json:"text"`
+	CreateTime        string `json:"createTime"`
+}
+
+// latestWriter keeps the newest messages of "messages tail" in a file, so
+// that status pages can show them without calling the API.
+type latestWriter struct {
+	path    string
+	count   int
+	targets []*tailTarget
+	// messages are the newest messages, newest first.
+	messages []latestMessage
+}
+
+// newLatestWriter writes the count newest messages of targets to path,
+// so that the file is complete before the first new message arrives.
+// Spaces that cannot be read are reported and left out.
+func newLatestWriter(ctx context.Context, f *output.Formatter, svc *api.MessagesService, path string, count int, targets []*tailTarget) (*latestWriter, error) {
+	w := &latestWriter{path: path, count: count, targets: targets}
+	for _, t := range targets {
+		raw, err := svc.List(ctx, t.space, count, "", "", "createTime desc", false)
+		if err != nil {
+			f.PrintError(fmt.Sprintf("⚠ Reading the latest messages of %s failed: %v", t.space, err))
+			continue
+		}
+		var resp struct {
+			Messages []tailMessage `json:"messages"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, msg := range resp.Messages {
+			w.messages = append(w.messages, newLatestMessage(t, msg))
+		}
+	}
+	sort.SliceStable(w.messages, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339Nano, w.messages[i].CreateTime)
+		tj, _ := time.Parse(time.RFC3339Nano, w.messages[j].CreateTime)
+		return ti.After(tj)
+	})
+	w.trim()
+	if err := w.write(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// newLatestMessage converts msg of target t for the file.
+func newLatestMessage(t *tailTarget, msg tailMessage) latestMessage {
+	return latestMessage{
+		Name:              msg.Name,
+		Space:             t.space,
+		SpaceLabel:        t.label,
+		Thread:            msg.Thread.Name,
+		SenderName:        msg.Sender.Name,
+		SenderDisplayName: msg.Sender.DisplayName,
+		Text:              msg.Text,
+		CreateTime:        msg.CreateTime,
+	}
+}
+
+// trim drops all but the count newest messages.
+func (w *latestWriter) trim() {
+	if len(w.messages) > w.count {
+		w.messages = w.messages[:w.count]
+	}
+}
+
+// add rewrites the file with entries, which are in creation order, as the
+// newest messages.
+func (w *latestWriter) add(entries []tailEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	added := make([]latestMessage, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		added = append(added, newLatestMessage(entries[i].target, entries[i].msg))
+	}
+	w.messages = append(added, w.messages...)
+	w.trim()
+	return w.write()
+}
+
+// write replaces the file atomically. The file is world-readable, for web
+// servers.
+func (w *latestWriter) write() error {
+	content := latestFile{
+		Version:   latestFileVersion,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		Spaces:    make([]latestSpace, len(w.targets)),
+		Messages:  w.messages,
+	}
+	for i, t := range w.targets {
+		content.Spaces[i] = latestSpace{Name: t.space, Label: t.label}
+	}
+	if content.Messages == nil {
+		content.Messages = []latestMessage{}
+	}
+	data, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(w.path, append(data, '\n'), 0o644)
+}
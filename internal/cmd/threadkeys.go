@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// threadKey maps a human-chosen thread key in a space to the thread that
+// was created for it.
+type threadKey struct {
+	Space     string    `json:"space"`
+	Key       string    `json:"key"`
+	Thread    string    `json:"thread"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastUsed  time.Time `json:"lastUsed"`
+}
+
+// threadKeysMu serialises updates of the registry by concurrent batch
+// operations.
+var threadKeysMu sync.Mutex
+
+// threadKeysPath returns the location of the thread key registry.
+func threadKeysPath() string {
+	return filepath.Join(config.ConfigDir(), "thread-keys.json")
+}
+
+// loadThreadKeys reads the thread key registry; a missing registry is empty.
+func loadThreadKeys() ([]threadKey, error) {
+	data, err := os.ReadFile(threadKeysPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading thread keys: %w", err)
+	}
+	var keys []threadKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", threadKeysPath(), err)
+	}
+	return keys, nil
+}
+
+// saveThreadKeys writes the thread key registry.
+func saveThreadKeys(keys []threadKey) error {
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.ConfigDir(), 0o700); err != nil {
+		return fmt.Errorf("saving thread keys: %w", err)
+	}
+	if err := os.WriteFile(threadKeysPath(), data, 0o600); err != nil {
+		return fmt.Errorf("saving thread keys: %w", err)
+	}
+	return nil
+}
+
+// applyThreadKey prepares body to be sent to space with thread key key. If
+// the registry knows the thread created for key, body is pointed at that
+// thread by name and the returned thread key is empty, because the API
+// does not reliably match keys to threads created earlier (e.g. by another
+// app or with user authentication). Otherwise key is returned to be sent
+// as before. In both cases replyOption defaults to falling back to a new
+// thread, whose name recordThreadKey then registers.
+func applyThreadKey(space, key string, body map[string]interface{}, replyOption string) (string, string) {
+	if key == "" {
+		return "", replyOption
+	}
+	if replyOption == "" {
+		replyOption = "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD"
+	}
+	if _, ok := body["thread"]; ok {
+		return key, replyOption
+	}
+
+	threadKeysMu.Lock()
+	keys, err := loadThreadKeys()
+	threadKeysMu.Unlock()
+	if err != nil {
+		return key, replyOption
+	}
+	space = api.NormalizeName(space, "spaces/")
+	for _, k := range keys {
+		if k.Space == space && k.Key == key {
+			body["thread"] = map[string]interface{}{"name": k.Thread}
+			return "", replyOption
+		}
+	}
+	return key, replyOption
+}
+
+// recordThreadKey registers the thread of the message raw, sent to space
+// with thread key key, so that later messages with the same key land in
+// it. Failures are reported but do not fail the send.
+func recordThreadKey(space, key string, raw json.RawMessage) {
+	var msg struct {
+		Thread struct {
+			Name string `json:"name"`
+		} `json:"thread"`
+	}
+	if key == "" || json.Unmarshal(raw, &msg) != nil || msg.Thread.Name == "" {
+		return
+	}
+
+	threadKeysMu.Lock()
+	defer threadKeysMu.Unlock()
+	keys, err := loadThreadKeys()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record thread key %q: %v\n", key, err)
+		return
+	}
+
+	space = api.NormalizeName(space, "spaces/")
+	now := time.Now().UTC()
+	found := false
+	for i := range keys {
+		if keys[i].Space == space && keys[i].Key == key {
+			if keys[i].Thread != msg.Thread.Name {
+				// The old thread is gone; start over with the new one.
+				keys[i].Thread = msg.Thread.Name
+				keys[i].CreatedAt = now
+			}
+			keys[i].LastUsed = now
+			found = true
+		}
+	}
+	if !found {
+		keys = append(keys, threadKey{Space: space, Key: key, Thread: msg.Thread.Name, CreatedAt: now, LastUsed: now})
+	}
+	if err := saveThreadKeys(keys); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not record thread key %q: %v\n", key, err)
+	}
+}
+
+// newThreadsKeysCmd creates the "threads keys" command group.
+func newThreadsKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage the local registry of thread keys",
+		Long: `Manage the local registry of thread keys.
+
+When a message is sent with --thread-key KEY (by "messages send" or a
+messages.send operation of "batch run"), the thread it lands in is
+remembered in ~/.config/gogchat/thread-keys.json. Later messages with the
+same key in the same space are sent to that thread by name, so recurring
+automated posts such as daily reports reliably end up together. If the
+thread has been deleted, a new one is started and registered instead.`,
+	}
+
+	cmd.AddCommand(
+		newThreadsKeysListCmd(),
+		newThreadsKeysForgetCmd(),
+	)
+
+	return cmd
+}
+
+// newThreadsKeysListCmd creates the "threads keys list" subcommand.
+func newThreadsKeysListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [SPACE]",
+		Short: "List registered thread keys",
+		Long:  "List the registered thread keys with their threads. With SPACE, only that space's keys are listed.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			keys, err := loadThreadKeys()
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				space := api.NormalizeName(args[0], "spaces/")
+				var kept []threadKey
+				for _, k := range keys {
+					if k.Space == space {
+						kept = append(kept, k)
+					}
+				}
+				keys = kept
+			}
+
+			if f.IsJSON() {
+				return printList(f, keys, "")
+			}
+			if len(keys) == 0 {
+				f.PrintMessage("No thread keys registered.")
+				return nil
+			}
+
+			table := output.NewTable("SPACE", "KEY", "THREAD", "CREATED", "LAST USED")
+			for _, k := range keys {
+				table.AddRow(k.Space, k.Key, k.Thread,
+					output.FormatTime(k.CreatedAt.Format(time.RFC3339)),
+					output.FormatTime(k.LastUsed.Format(time.RFC3339)))
+			}
+			fmt.Print(table.Render())
+			return nil
+		},
+	}
+}
+
+// newThreadsKeysForgetCmd creates the "threads keys forget" subcommand.
+func newThreadsKeysForgetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "forget SPACE KEY...",
+		Short: "Remove thread keys from the registry",
+		Long:  "Remove thread keys of a space from the registry, so that the next message sent with the key starts a new thread. The threads themselves are not changed.",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			threadKeysMu.Lock()
+			defer threadKeysMu.Unlock()
+			keys, err := loadThreadKeys()
+			if err != nil {
+				return err
+			}
+
+			space := api.NormalizeName(args[0], "spaces/")
+			forget := map[string]bool{}
+			for _, key := range args[1:] {
+				forget[key] = true
+			}
+			kept := keys[:0]
+			removed := 0
+			for _, k := range keys {
+				if k.Space == space && forget[k.Key] {
+					removed++
+					continue
+				}
+				kept = append(kept, k)
+			}
+			if removed == 0 {
+				return fmt.Errorf("no registered thread key in %s matches", space)
+			}
+			if err := saveThreadKeys(kept); err != nil {
+				return err
+			}
+
+			f.PrintSuccess(fmt.Sprintf("Forgot %d thread key(s).", removed))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewThreadsCmd creates the top-level "threads" command with the export
+// and keys subcommands.
+func NewThreadsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "threads",
+		Short: "Work with message threads",
+		Long:  "Export the messages of a Google Chat thread, and manage the local registry of thread keys.",
+	}
+
+	cmd.AddCommand(
+		newThreadsExportCmd(),
+		newThreadsKeysCmd(),
+	)
+
+	return cmd
+}
+
+// exportMessage holds the message fields included in a thread export.
+type exportMessage struct {
+	Name       string `json:"name"`
+	Text       string `json:"text"`
+	CreateTime string `json:"createTime"`
+	Sender     struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"sender"`
+	Attachment []struct {
+		ContentName string `json:"contentName"`
+	} `json:"attachment"`
+}
+
+// newThreadsExportCmd creates the "threads export" subcommand.
+func newThreadsExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export THREAD",
+		Short: "Export all messages in a thread",
+		Long: `Export every message in a thread, oldest first, with a sender and time
+header for each message. THREAD is the thread resource name
+(spaces/{space}/threads/{thread}), as shown by "messages get".
+
+--format md (the default) writes Markdown suitable for pasting into a doc,
+with Chat formatting converted to Markdown; --format text writes plain text.
+With --json, the raw messages are printed instead.
+
+Only the first limits.max_fetch messages (default 5000) are exported unless
+--all is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runThreadsExport,
+	}
+
+	flags := cmd.Flags()
+	flags.String("format", "md", "Export format: md or text")
+	flags.StringP("output", "o", "", "Write the export to this file instead of stdout")
+	addTimeRangeFlags(cmd)
+	addFetchAllFlag(cmd)
+
+	return cmd
+}
+
+func runThreadsExport(cmd *cobra.Command, args []string) error {
+	thread := api.NormalizeName(args[0], "spaces/")
+	if !strings.Contains(thread, "/threads/") {
+		return fmt.Errorf("THREAD must be a thread resource name (spaces/{space}/threads/{thread}), got %q", args[0])
+	}
+	format, _ := cmd.Flags().GetString("format")
+	if format != "md" && format != "text" {
+		return fmt.Errorf("--format must be md or text, got %q", format)
+	}
+	outputPath, _ := cmd.Flags().GetString("output")
+	filter, err := timeRangeFilter(cmd, fmt.Sprintf("thread.name = %s", thread), "createTime")
+	if err != nil {
+		return err
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	f := getFormatter()
+	ctx := cmd.Context()
+	space := spaceOf(thread)
+
+	limit := fetchLimit(cmd)
+	raws, more, err := listMessagesUpTo(ctx, api.NewMessagesService(client), space, filter, "createTime asc", limit)
+	if err != nil {
+		return fmt.Errorf("listing thread messages: %w", err)
+	}
+	if more {
+		warnFetchLimit(thread, limit)
+	}
+	if len(raws) == 0 {
+		return fmt.Errorf("no messages found in %s", thread)
+	}
+
+	if f.IsJSON() {
+		return f.Print(map[string]interface{}{
+			"thread":   thread,
+			"messages": raws,
+		})
+	}
+
+	messages := make([]exportMessage, 0, len(raws))
+	for _, raw := range raws {
+		var msg exportMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return fmt.Errorf("parsing response: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+
+	// The space name makes the export self-describing; fall back to the
+	// resource name if it cannot be fetched.
+	title := space
+	if name, err := spaceDisplayName(ctx, client, space, false); err == nil && name != "" {
+		title = name
+	}
+
+	var export string
+	if format == "md" {
+		export = threadMarkdown(title, thread, messages)
+	} else {
+		export = threadText(title, thread, messages)
+	}
+
+	if outputPath == "" {
+		fmt.Print(export)
+		return nil
+	}
+	if err := os.WriteFile(outputPath, []byte(export), 0o644); err != nil {
+		return fmt.Errorf("writing export: %w", err)
+	}
+	f.PrintSuccess(fmt.Sprintf("Exported %d messages to %s", len(messages), outputPath))
+	return nil
+}
+
+// threadMarkdown renders a thread as Markdown with a heading per message.
+func threadMarkdown(title, thread string, messages []exportMessage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "Thread `%s`, %d messages.\n", thread, len(messages))
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "\n### %s — %s\n\n", exportSender(msg), exportTime(msg.CreateTime))
+		if msg.Text != "" {
+			b.WriteString(output.ChatToMarkdown(msg.Text))
+			b.WriteString("\n")
+		}
+		for _, a := range msg.Attachment {
+			fmt.Fprintf(&b, "\n_Attachment: %s_\n", a.ContentName)
+		}
+	}
+	return b.String()
+}
+
+// threadText renders a thread as plain text with the message bodies
+// indented under their headers.
+func threadText(title, thread string, messages []exportMessage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\nThread %s, %d messages.\n", title, thread, len(messages))
+	for _, msg := range messages {
+		fmt.Fprintf(&b, "\n[%s] %s:\n", exportTime(msg.CreateTime), exportSender(msg))
+		if msg.Text != "" {
+			for _, line := range strings.Split(msg.Text, "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+		for _, a := range msg.Attachment {
+			fmt.Fprintf(&b, "  [attachment: %s]\n", a.ContentName)
+		}
+	}
+	return b.String()
+}
+
+// exportSender returns the sender's display name, or resource name if the
+// API did not include one.
+func exportSender(msg exportMessage) string {
+	if msg.Sender.DisplayName != "" {
+		return msg.Sender.DisplayName
+	}
+	return msg.Sender.Name
+}
+
+// exportTime formats an RFC 3339 timestamp as an absolute time with zone in
+// the configured time zone, since exports are read long after they are
+// written.
+func exportTime(t string) string {
+	parsed, err := time.Parse(time.RFC3339Nano, t)
+	if err != nil {
+		return t
+	}
+	return parsed.In(output.TimeLocation()).Format("2006-01-02 15:04 MST")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/timeparse"
+)
+
+// addTimeRangeFlags adds --since and --until to commands that list
+// time-stamped resources.
+func addTimeRangeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("since", "", `Only include items created after this time (e.g. "2 days ago", "yesterday 9am", RFC 3339)`)
+	cmd.Flags().String("until", "", `Only include items created before this time (same formats as --since)`)
+}
+
+// timeRange parses --since and --until. A bound that was not given is the
+// zero time.
+func timeRange(cmd *cobra.Command) (time.Time, time.Time, error) {
+	since, err := parseTimeFlag(cmd, "since")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	until, err := parseTimeFlag(cmd, "until")
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--since (%s) must be before --until (%s)", formatFilterTime(since), formatFilterTime(until))
+	}
+	return since, until, nil
+}
+
+// parseTimeFlag parses a time flag in any format timeparse accepts. It
+// returns the zero time if the flag is empty.
+func parseTimeFlag(cmd *cobra.Command, name string) (time.Time, error) {
+	value, _ := cmd.Flags().GetString(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := timeparse.Parse(value, time.Now())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s: %w", name, err)
+	}
+	return t, nil
+}
+
+// timeRangeFilter adds comparisons of field against --since and --until to
+// a filter, e.g. for field createTime:
+// createTime > "2026-02-01T09:00:00Z" AND createTime < "2026-02-02T09:00:00Z".
+func timeRangeFilter(cmd *cobra.Command, filter, field string) (string, error) {
+	since, until, err := timeRange(cmd)
+	if err != nil {
+		return "", err
+	}
+	var clauses []string
+	if !since.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("%s > %q", field, formatFilterTime(since)))
+	}
+	if !until.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("%s < %q", field, formatFilterTime(until)))
+	}
+	return andFilter(filter, clauses...), nil
+}
+
+// eventTimeFilter adds start_time and end_time bounds for --since and
+// --until to a space events filter.
+func eventTimeFilter(cmd *cobra.Command, filter string) (string, error) {
+	since, until, err := timeRange(cmd)
+	if err != nil {
+		return "", err
+	}
+	var clauses []string
+	if !since.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("start_time=%q", formatFilterTime(since)))
+	}
+	if !until.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("end_time=%q", formatFilterTime(until)))
+	}
+	return andFilter(filter, clauses...), nil
+}
+
+// andFilter joins filter and clauses with AND, skipping empty parts.
+func andFilter(filter string, clauses ...string) string {
+	parts := make([]string, 0, len(clauses)+1)
+	if filter = strings.TrimSpace(filter); filter != "" {
+		parts = append(parts, filter)
+	}
+	parts = append(parts, clauses...)
+	return strings.Join(parts, " AND ")
+}
+
+// formatFilterTime formats t as RFC 3339 in UTC, as API filters expect.
+func formatFilterTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+)
+
+// translateBatchSize is the maximum number of texts the Cloud Translation
+// API accepts in one request.
+const translateBatchSize = 128
+
+// translation is the translated text of one message.
+type translation struct {
+	Language       string `json:"language"`
+	Text           string `json:"text"`
+	SourceLanguage string `json:"sourceLanguage,omitempty"`
+}
+
+// newTranslateService returns a Cloud Translation client authenticated with
+// the translation_api_key config key.
+func newTranslateService(client *api.Client) (*api.TranslateService, error) {
+	if Cfg.TranslationAPIKey == "" {
+		return nil, fmt.Errorf("translation needs a Google Cloud API key with the Cloud Translation API enabled; set translation_api_key in the config or GOGCHAT_TRANSLATION_API_KEY")
+	}
+	return api.NewTranslateService(client, Cfg.TranslationAPIKey), nil
+}
+
+// translateTexts translates texts into target, in batches the API accepts.
+// Empty texts are not sent and yield empty translations.
+func translateTexts(ctx context.Context, svc *api.TranslateService, texts []string, target string) ([]translation, error) {
+	target = strings.ToLower(strings.TrimSpace(target))
+	results := make([]translation, len(texts))
+
+	var pending []int
+	for i, text := range texts {
+		results[i].Language = target
+		if strings.TrimSpace(text) != "" {
+			pending = append(pending, i)
+		}
+	}
+
+	for start := 0; start < len(pending); start += translateBatchSize {
+		batch := pending[start:min(start+translateBatchSize, len(pending))]
+		q := make([]string, len(batch))
+		for j, i := range batch {
+			q[j] = texts[i]
+		}
+
+		raw, err := svc.Translate(ctx, q, target)
+		if err != nil {
+			return nil, fmt.Errorf("translating to %s: %w", target, err)
+		}
+		var resp struct {
+			Data struct {
+				Translations []struct {
+					TranslatedText         string `json:"translatedText"`
+					DetectedSourceLanguage string `json:"detectedSourceLanguage"`
+				} `json:"translations"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("parsing translation response: %w", err)
+		}
+		if len(resp.Data.Translations) != len(batch) {
+			return nil, fmt.Errorf("translation returned %d results for %d texts", len(resp.Data.Translations), len(batch))
+		}
+		for j, i := range batch {
+			results[i].Text = resp.Data.Translations[j].TranslatedText
+			results[i].SourceLanguage = resp.Data.Translations[j].DetectedSourceLanguage
+		}
+	}
+	return results, nil
+}
+
+// translateMessages translates the text of raw messages into target.
+func translateMessages(ctx context.Context, svc *api.TranslateService, raws []json.RawMessage, target string) ([]translation, error) {
+	texts := make([]string, len(raws))
+	for i, raw := range raws {
+		var msg struct {
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		texts[i] = msg.Text
+	}
+	return translateTexts(ctx, svc, texts, target)
+}
+
+// withTranslations adds a "translation" field to each raw message.
+func withTranslations(raws []json.RawMessage, translations []translation) ([]json.RawMessage, error) {
+	out := make([]json.RawMessage, len(raws))
+	for i, raw := range raws {
+		var msg map[string]interface{}
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		msg["translation"] = translations[i]
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling message: %w", err)
+		}
+		out[i] = data
+	}
+	return out, nil
+}
+
+// shownTranslation returns the translation of message i for display, or ""
+// if there is none or the message is already in the target language.
+func shownTranslation(translations []translation, i int) string {
+	if i >= len(translations) {
+		return ""
+	}
+	t := translations[i]
+	if t.SourceLanguage == t.Language {
+		return ""
+	}
+	return t.Text
+}
+
+// sendTranslatedCopies posts each translation as a reply in the thread of
+// the sent message, prefixed with its language code.
+func sendTranslatedCopies(ctx context.Context, svc *api.MessagesService, space string, sent json.RawMessage, translations []translation) ([]json.RawMessage, error) {
+	var msg struct {
+		Name   string `json:"name"`
+		Thread struct {
+			Name string `json:"name"`
+		} `json:"thread"`
+	}
+	if err := json.Unmarshal(sent, &msg); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	copies := make([]json.RawMessage, 0, len(translations))
+	for _, t := range translations {
+		body := map[string]interface{}{
+			"text": fmt.Sprintf("[%s] %s", t.Language, t.Text),
+		}
+		if msg.Thread.Name != "" {
+			body["thread"] = map[string]interface{}{"name": msg.Thread.Name}
+		}
+		raw, err := svc.Create(ctx, space, body, "", "", "", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+		if err != nil {
+			return copies, fmt.Errorf("sending %s translation (the original was sent as %s): %w", t.Language, msg.Name, err)
+		}
+		copies = append(copies, raw)
+	}
+	return copies, nil
+}
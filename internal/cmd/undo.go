@@ -0,0 +1,279 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+	"github.com/cipher-shad0w/gogchat/internal/undo"
+)
+
+// undoJournal returns the journal of destructive operations stored in the
+// config directory.
+func undoJournal() *undo.Journal {
+	return undo.Open(filepath.Join(config.ConfigDir(), "undo.jsonl"))
+}
+
+// undoRecorder collects the resources deleted by one command so they can be
+// recorded in the undo journal as a single operation. It is safe for use by
+// concurrent bulk workers.
+type undoRecorder struct {
+	op      string
+	command string
+
+	mu      sync.Mutex
+	entries []undo.Entry
+}
+
+// newUndoRecorder creates a recorder for the destructive command cmd.
+func newUndoRecorder(cmd *cobra.Command) *undoRecorder {
+	return &undoRecorder{op: undo.NewOpID(), command: cmd.CommandPath()}
+}
+
+// snapshot fetches the resource about to be deleted. If it cannot be
+// fetched, a warning is printed and nil is returned; the delete still goes
+// ahead but cannot be undone.
+func (r *undoRecorder) snapshot(ctx context.Context, client *api.Client, kind, name string, admin bool) json.RawMessage {
+	raw, err := fetchUndoResource(ctx, client, kind, name, admin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not save %s for undo: %v\n", name, err)
+		return nil
+	}
+	return raw
+}
+
+// add records a deleted resource previously captured with snapshot.
+func (r *undoRecorder) add(kind, name string, resource json.RawMessage) {
+	if resource == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, undo.Entry{
+		Op:       r.op,
+		Time:     time.Now(),
+		Command:  r.command,
+		Kind:     kind,
+		Name:     name,
+		Resource: resource,
+	})
+}
+
+// save writes the recorded resources to the undo journal.
+func (r *undoRecorder) save() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := undoJournal().Record(r.entries...); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠ Could not write undo journal: %v\n", err)
+	}
+}
+
+// fetchUndoResource returns the current state of a resource of the given
+// kind.
+func fetchUndoResource(ctx context.Context, client *api.Client, kind, name string, admin bool) (json.RawMessage, error) {
+	switch kind {
+	case undo.KindMessage:
+		return api.NewMessagesService(client).Get(ctx, name)
+	case undo.KindMembership:
+		return api.NewMembersService(client).Get(ctx, name, admin)
+	case undo.KindSpace:
+		return api.NewSpacesService(client).Get(ctx, name, admin)
+	case undo.KindReaction:
+		return findReaction(ctx, client, name)
+	}
+	return nil, fmt.Errorf("unknown resource kind %q", kind)
+}
+
+// findReaction looks up a reaction by name. The API has no reactions.get,
+// so the reactions of the parent message are listed instead.
+func findReaction(ctx context.Context, client *api.Client, name string) (json.RawMessage, error) {
+	parent, _, ok := strings.Cut(name, "/reactions/")
+	if !ok {
+		return nil, fmt.Errorf("invalid reaction name %q", name)
+	}
+
+	svc := api.NewReactionsService(client)
+	pageToken := ""
+	for {
+		raw, err := svc.List(ctx, parent, 200, pageToken, "")
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			Reactions     []json.RawMessage `json:"reactions"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(raw, &resp); err != nil {
+			return nil, fmt.Errorf("parsing response: %w", err)
+		}
+		for _, r := range resp.Reactions {
+			var reaction struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(r, &reaction) == nil && reaction.Name == name {
+				return r, nil
+			}
+		}
+		if resp.NextPageToken == "" {
+			return nil, fmt.Errorf("reaction %s not found", name)
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// NewUndoCmd creates the top-level "undo" command.
+func NewUndoCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Restore the last deleted resources",
+		Long: `Restore the resources removed by the last destructive command.
+
+Deleted messages, memberships, spaces, and reactions are recorded in a local
+journal (~/.config/gogchat/undo.jsonl). Undo re-creates them where the API
+allows it: messages are re-posted by you with their original text, cards, and
+thread; memberships are re-added with their role; spaces are re-created with
+their settings but without their messages or members; reactions are re-added.
+Restored resources get new resource names.`,
+		Args: cobra.NoArgs,
+		RunE: runUndo,
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show what would be restored without changing anything")
+
+	return cmd
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	f := getFormatter()
+	journal := undoJournal()
+
+	entries, err := journal.Last()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		f.PrintMessage("Nothing to undo.")
+		return nil
+	}
+
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if dryRun {
+		if f.IsJSON() {
+			return f.Print(entries)
+		}
+		f.PrintMessage(fmt.Sprintf("Would restore %d resource(s) deleted by %q at %s:",
+			len(entries), entries[0].Command, output.FormatTime(entries[0].Time.Format(time.RFC3339Nano))))
+		table := output.NewTable("KIND", "NAME")
+		for _, e := range entries {
+			table.AddRow(e.Kind, e.Name)
+		}
+		fmt.Print(table.Render())
+		return nil
+	}
+
+	client, err := newAPIClient()
+	if err != nil {
+		return err
+	}
+	ctx := cmd.Context()
+
+	var restored []string
+	var results []json.RawMessage
+	var failed int
+	for _, e := range entries {
+		raw, err := restoreUndoEntry(ctx, client, e)
+		if err != nil {
+			failed++
+			f.PrintError(fmt.Sprintf("✗ %s: %v", e.Name, err))
+			continue
+		}
+		restored = append(restored, e.Name)
+		results = append(results, raw)
+
+		var created struct {
+			Name string `json:"name"`
+		}
+		_ = json.Unmarshal(raw, &created)
+		if !f.IsJSON() {
+			f.PrintSuccess(fmt.Sprintf("Restored %s %s as %s", e.Kind, e.Name, created.Name))
+		}
+	}
+
+	if err := journal.Remove(entries[0].Op, restored...); err != nil {
+		return err
+	}
+
+	if f.IsJSON() {
+		if err := f.Print(results); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d resources could not be restored; they remain in the undo journal", failed, len(entries))
+	}
+	return nil
+}
+
+// restoreUndoEntry re-creates the resource recorded in e.
+func restoreUndoEntry(ctx context.Context, client *api.Client, e undo.Entry) (json.RawMessage, error) {
+	var res map[string]interface{}
+	if err := json.Unmarshal(e.Resource, &res); err != nil {
+		return nil, fmt.Errorf("parsing saved %s: %w", e.Kind, err)
+	}
+
+	switch e.Kind {
+	case undo.KindMessage:
+		parent, _, _ := strings.Cut(e.Name, "/messages/")
+		body := pickFields(res, "text", "cardsV2", "accessoryWidgets", "thread")
+		replyOption := ""
+		if thread, ok := body["thread"].(map[string]interface{}); ok {
+			body["thread"] = pickFields(thread, "name")
+			replyOption = "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD"
+		}
+		return api.NewMessagesService(client).Create(ctx, parent, body, "", "", "", replyOption)
+
+	case undo.KindMembership:
+		parent, _, _ := strings.Cut(e.Name, "/members/")
+		body := pickFields(res, "role")
+		if member, ok := res["member"].(map[string]interface{}); ok {
+			body["member"] = pickFields(member, "name", "type")
+		} else if group, ok := res["groupMember"].(map[string]interface{}); ok {
+			body["groupMember"] = pickFields(group, "name")
+		}
+		return api.NewMembersService(client).Create(ctx, parent, body, false)
+
+	case undo.KindSpace:
+		if res["spaceType"] == "DIRECT_MESSAGE" {
+			return nil, fmt.Errorf("direct messages cannot be re-created")
+		}
+		body := pickFields(res, "displayName", "spaceType", "spaceDetails", "spaceHistoryState", "externalUserAllowed")
+		return api.NewSpacesService(client).Create(ctx, body, "")
+
+	case undo.KindReaction:
+		parent, _, _ := strings.Cut(e.Name, "/reactions/")
+		return api.NewReactionsService(client).Create(ctx, parent, pickFields(res, "emoji"))
+	}
+	return nil, fmt.Errorf("cannot restore resources of kind %q", e.Kind)
+}
+
+// pickFields returns a copy of m containing only the given keys.
+func pickFields(m map[string]interface{}, keys ...string) map[string]interface{} {
+	out := make(map[string]interface{}, len(keys))
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/cipher-shad0w/gogchat/internal/schema"
+)
+
+// NewValidateCmd creates the top-level "validate" command.
+func NewValidateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate FILE",
+		Short: "Check a message or card payload before sending it",
+		Long: `Check a JSON or YAML payload offline against the schema of its resource
+(see "gogchat schema") and the rules the API enforces on messages and cards:
+required fields, allowed values, text length limits, widgets and onClick
+handlers with exactly one kind set, buttons with a label and an onClick
+action, and unique card IDs. FILE may be - to read stdin.
+
+The resource is detected from the payload's fields unless --resource is
+given. Unknown and output-only fields are reported as warnings. The command
+fails when any error is found, so it can gate scripts and CI.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runValidate,
+	}
+
+	cmd.Flags().String("resource", "", "Resource to validate against: "+strings.Join(schema.Resources(), ", ")+" (detected by default)")
+	_ = cmd.RegisterFlagCompletionFunc("resource", cobra.FixedCompletions(schema.Resources(), cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	f := getFormatter()
+	resource, _ := cmd.Flags().GetString("resource")
+
+	path := args[0]
+	var data []byte
+	var err error
+	if path == "-" {
+		path = "stdin"
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	payload, err := decodePayload(data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if resource == "" {
+		if resource = detectResource(payload); resource == "" {
+			return fmt.Errorf("cannot tell which resource %s is; pass --resource", path)
+		}
+	}
+
+	problems, err := schema.Validate(resource, payload)
+	if err != nil {
+		return err
+	}
+	errorCount := 0
+	for _, p := range problems {
+		if p.Severity == schema.SeverityError {
+			errorCount++
+		}
+	}
+
+	if f.IsJSON() {
+		if problems == nil {
+			problems = []schema.Problem{}
+		}
+		if err := f.Print(map[string]interface{}{
+			"file":     path,
+			"resource": resource,
+			"valid":    errorCount == 0,
+			"problems": problems,
+		}); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range problems {
+			mark := "✗"
+			if p.Severity == schema.SeverityWarning {
+				mark = "⚠"
+			}
+			fmt.Printf("%s %s: %s\n", mark, p.Path, p.Message)
+		}
+		if errorCount == 0 {
+			f.PrintSuccess(fmt.Sprintf("%s is a valid %s", path, resource))
+		}
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("%s has %d error(s)", path, errorCount)
+	}
+	return nil
+}
+
+// decodePayload parses JSON or YAML into the values encoding/json produces,
+// so numbers are float64 whichever format was used.
+func decodePayload(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	normalized, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(normalized, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// detectResource guesses the resource of a payload from its fields.
+func detectResource(v interface{}) string {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	has := func(keys ...string) bool {
+		for _, k := range keys {
+			if _, ok := obj[k]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case has("text", "cardsV2", "attachment", "accessoryWidgets", "thread"):
+		return "message"
+	case has("card", "sections", "header", "cardId"):
+		return "card"
+	case has("spaceType", "spaceDetails", "displayName", "accessSettings", "permissionSettings"):
+		return "space"
+	case has("emojiName", "payload"):
+		return "custom-emoji"
+	case has("member", "groupMember", "role"):
+		return "membership"
+	case has("emoji"):
+		return "reaction"
+	}
+	return ""
+}
@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cipher-shad0w/gogchat/internal/api"
+	"github.com/cipher-shad0w/gogchat/internal/config"
+	"github.com/cipher-shad0w/gogchat/internal/output"
+)
+
+// NewWebhooksCmd creates the top-level "webhooks" command.
+func NewWebhooksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhooks",
+		Short: "Keep and use the incoming webhook URLs of spaces",
+		Long: `Keep the incoming webhook URLs of spaces under a name, and post to them.
+
+The Chat API cannot create, list, or delete incoming webhooks: they are
+managed in the Chat web UI (space name > Apps & integrations > Webhooks),
+and "spaces access --permission manageWebhooks=managers" controls who may
+do so. Once created there, a webhook's URL is added here, e.g. on the
+machine that runs CI, and "webhooks url" or "webhooks send" use it by name.
+To rotate a URL, delete the webhook in Chat, create a new one, and add its
+URL under the same name.
+
+The URLs are secrets and are kept in ~/.config/gogchat/webhooks.json,
+readable only by you.`,
+	}
+
+	cmd.AddCommand(
+		newWebhooksAddCmd(),
+		newWebhooksListCmd(),
+		newWebhooksURLCmd(),
+		newWebhooksRemoveCmd(),
+		newWebhooksSendCmd(),
+	)
+
+	return cmd
+}
+
+// webhook is one saved incoming webhook URL.
+type webhook struct {
+	Name    string    `json:"name"`
+	Space   string    `json:"space"`
+	URL     string    `json:"url"`
+	AddedAt time.Time `json:"addedAt"`
+}
+
+// tokenHint returns the end of the webhook's token, to tell webhooks of
+// the same space apart without showing the secret.
+func (w webhook) tokenHint() string {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return ""
+	}
+	token := u.Query().Get("token")
+	if len(token) <= 4 {
+		return "..."
+	}
+	return "..." + token[len(token)-4:]
+}
+
+// webhooksPath returns the location of the webhook store.
+func webhooksPath() string {
+	return filepath.Join(config.ConfigDir(), "webhooks.json")
+}
+
+// loadWebhooks reads the saved webhooks; a missing store is empty.
+func loadWebhooks() ([]webhook, error) {
+	data, err := os.ReadFile(webhooksPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading webhooks: %w", err)
+	}
+	var hooks []webhook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", webhooksPath(), err)
+	}
+	return hooks, nil
+}
+
+// saveWebhooks writes the saved webhooks.
+func saveWebhooks(hooks []webhook) error {
+	data, err := json.MarshalIndent(hooks, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.ConfigDir(), 0o700); err != nil {
+		return fmt.Errorf("saving webhooks: %w", err)
+	}
+	if err := os.WriteFile(webhooksPath(), data, 0o600); err != nil {
+		return fmt.Errorf("saving webhooks: %w", err)
+	}
+	return nil
+}
+
+// findWebhook returns the saved webhook called name.
+func findWebhook(name string) (webhook, error) {
+	hooks, err := loadWebhooks()
+	if err != nil {
+		return webhook{}, err
+	}
+	for _, w := range hooks {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+	return webhook{}, fmt.Errorf("no webhook %q; see \"gogchat webhooks list\"", name)
+}
+
+// webhookSpace checks that raw is an incoming webhook URL and returns the
+// space it posts to.
+func webhookSpace(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.Host != "chat.googleapis.com" {
+		return "", fmt.Errorf("not an incoming webhook URL: expected https://chat.googleapis.com/v1/spaces/.../messages?key=...&token=...")
+	}
+	space, ok := strings.CutSuffix(strings.TrimPrefix(u.Path, "/v1/"), "/messages")
+	if !ok || !strings.HasPrefix(space, "spaces/") || strings.Count(space, "/") != 1 {
+		return "", fmt.Errorf("not an incoming webhook URL: unexpected path %s", u.Path)
+	}
+	if u.Query().Get("key") == "" || u.Query().Get("token") == "" {
+		return "", fmt.Errorf("not an incoming webhook URL: the key or token parameter is missing")
+	}
+	return space, nil
+}
+
+// newWebhooksAddCmd creates the "webhooks add" subcommand.
+func newWebhooksAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "add NAME URL",
+		Short:   "Save an incoming webhook URL under a name",
+		Long:    "Save the URL of an incoming webhook created in the Chat web UI under NAME. Adding a URL under an existing name replaces it, e.g. after rotating the webhook.",
+		Example: `  gogchat webhooks add ci-alerts "https://chat.googleapis.com/v1/spaces/AAAABBBBcccc/messages?key=...&token=..."`,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			name, rawURL := args[0], args[1]
+			space, err := webhookSpace(rawURL)
+			if err != nil {
+				return err
+			}
+
+			hooks, err := loadWebhooks()
+			if err != nil {
+				return err
+			}
+			w := webhook{Name: name, Space: space, URL: rawURL, AddedAt: time.Now().UTC()}
+			replaced := false
+			for i := range hooks {
+				if hooks[i].Name == name {
+					hooks[i] = w
+					replaced = true
+				}
+			}
+			if !replaced {
+				hooks = append(hooks, w)
+			}
+			if err := saveWebhooks(hooks); err != nil {
+				return err
+			}
+
+			if f.IsJSON() {
+				return f.Print(map[string]interface{}{"name": w.Name, "space": w.Space, "token": w.tokenHint(), "replaced": replaced})
+			}
+			if replaced {
+				f.PrintSuccess(fmt.Sprintf("Replaced the URL of webhook %s (%s)", name, space))
+			} else {
+				f.PrintSuccess(fmt.Sprintf("Added webhook %s (%s)", name, space))
+			}
+			return nil
+		},
+	}
+}
+
+// newWebhooksListCmd creates the "webhooks list" subcommand.
+func newWebhooksListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [SPACE]",
+		Short: "List saved webhooks",
+		Long:  `List the saved webhooks, or only those of SPACE. Only the end of each webhook's token is shown; "webhooks url" prints the full URL.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+
+			hooks, err := loadWebhooks()
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				space := api.NormalizeName(args[0], "spaces/")
+				var kept []webhook
+				for _, w := range hooks {
+					if w.Space == space {
+						kept = append(kept, w)
+					}
+				}
+				hooks = kept
+			}
+			sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].Name < hooks[j].Name })
+
+			if f.IsJSON() {
+				type listed struct {
+					Name    string    `json:"name"`
+					Space   string    `json:"space"`
+					Token   string    `json:"token"`
+					AddedAt time.Time `json:"addedAt"`
+				}
+				items := make([]listed, len(hooks))
+				for i, w := range hooks {
+					items[i] = listed{w.Name, w.Space, w.tokenHint(), w.AddedAt}
+				}
+				return printList(f, items, "")
+			}
+			if len(hooks) == 0 {
+				f.PrintMessage("No webhooks saved.")
+				return nil
+			}
+
+			table := output.NewTable("NAME", "SPACE", "TOKEN", "ADDED")
+			for _, w := range hooks {
+				table.AddRow(w.Name, w.Space, w.tokenHint(), output.FormatTime(w.AddedAt.Format(time.RFC3339)))
+			}
+			fmt.Print(table.Render())
+			return nil
+		},
+	}
+}
+
+// newWebhooksURLCmd creates the "webhooks url" subcommand.
+func newWebhooksURLCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "url NAME",
+		Short:   "Print the URL of a saved webhook",
+		Long:    "Print the full URL of the saved webhook NAME, e.g. to hand it to a CI integration.",
+		Example: `  curl -X POST -H 'Content-Type: application/json' -d '{"text": "build passed"}' "$(gogchat webhooks url ci-alerts)"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w, err := findWebhook(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(w.URL)
+			return nil
+		},
+	}
+}
+
+// newWebhooksRemoveCmd creates the "webhooks remove" subcommand.
+func newWebhooksRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove NAME...",
+		Short: "Forget saved webhooks",
+		Long:  "Forget the saved webhooks. The webhooks themselves keep working until they are deleted in the Chat web UI.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f := getFormatter()
+			hooks, err := loadWebhooks()
+			if err != nil {
+				return err
+			}
+			remove := map[string]bool{}
+			for _, name := range args {
+				remove[name] = true
+			}
+			kept := hooks[:0]
+			for _, w := range hooks {
+				if remove[w.Name] {
+					delete(remove, w.Name)
+					continue
+				}
+				kept = append(kept, w)
+			}
+			for _, name := range args {
+				if remove[name] {
+					return fmt.Errorf("no webhook %q; see \"gogchat webhooks list\"", name)
+				}
+			}
+			if err := saveWebhooks(kept); err != nil {
+				return err
+			}
+			f.PrintSuccess(fmt.Sprintf("Removed %d %s", len(args), plural(len(args), "webhook", "webhooks")))
+			return nil
+		},
+	}
+}
+
+// postWebhook posts the message body through w, into the thread with
+// threadKey if it is not empty, and returns the created message.
+func postWebhook(ctx context.Context, w webhook, body map[string]interface{}, threadKey string) (json.RawMessage, error) {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL of webhook %s: %w", w.Name, err)
+	}
+	if threadKey != "" {
+		q := u.Query()
+		q.Set("threadKey", threadKey)
+		q.Set("messageReplyOption", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+		u.RawQuery = q.Encode()
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("posting to webhook %s: %w", w.Name, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("posting to webhook %s: %s: %s", w.Name, resp.Status, strings.TrimSpace(string(raw)))
+	}
+	return raw, nil
+}
+
+// newWebhooksSendCmd creates the "webhooks send" subcommand.
+func newWebhooksSendCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send NAME",
+		Short: "Post a message through a saved webhook",
+		Long: `Post a message to a space through the saved webhook NAME. The message
+appears as sent by the webhook, and no sign-in is needed.`,
+		Example: `  gogchat webhooks send ci-alerts --text "Build #42 passed"
+  gogchat webhooks send ci-alerts --text "Deploying" --thread-key deploy-42`,
+		Args: cobra.ExactArgs(1),
+		RunE: runWebhooksSend,
+	}
+
+	cmd.Flags().String("text", "", "Message text (required)")
+	cmd.Flags().String("thread-key", "", "Post into the thread with this key, starting it if needed")
+	_ = cmd.MarkFlagRequired("text")
+
+	return cmd
+}
+
+func runWebhooksSend(cmd *cobra.Command, args []string) error {
+	text, _ := cmd.Flags().GetString("text")
+	threadKey, _ := cmd.Flags().GetString("thread-key")
+	f := getFormatter()
+
+	w, err := findWebhook(args[0])
+	if err != nil {
+		return err
+	}
+	raw, err := postWebhook(cmd.Context(), w, map[string]interface{}{"text": text}, threadKey)
+	if err != nil {
+		return err
+	}
+
+	if f.IsJSON() {
+		return f.PrintRaw(raw)
+	}
+	f.PrintSuccess(fmt.Sprintf("Message posted to %s", w.Space))
+	f.PrintMessage(fmt.Sprintf("Name:        %s", jsonField(raw, "name")))
+	return nil
+}
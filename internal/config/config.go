@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -27,6 +30,7 @@ var DefaultScopes = []string{
 	"https://www.googleapis.com/auth/chat.users.readstate",
 	"https://www.googleapis.com/auth/chat.users.readstate.readonly",
 	"https://www.googleapis.com/auth/chat.users.spacesettings",
+	"https://www.googleapis.com/auth/userinfo.profile",
 }
 
 // Config holds the application configuration.
@@ -34,6 +38,332 @@ type Config struct {
 	ClientID     string `mapstructure:"client_id"`
 	ClientSecret string `mapstructure:"client_secret"`
 	TokenFile    string `mapstructure:"token_file"`
+
+	// ServiceAccountFile is the JSON key of the Chat app's service account,
+	// used when commands run with --as-app.
+	ServiceAccountFile string `mapstructure:"service_account_file"`
+
+	// UserAgent overrides the User-Agent header sent with API requests.
+	UserAgent string `mapstructure:"user_agent"`
+
+	// PageSize is the page size used by list commands when --page-size is
+	// not given. Zero keeps each command's built-in default.
+	PageSize int `mapstructure:"page_size"`
+
+	// Concurrency is the number of parallel requests used by bulk
+	// operations when --concurrency is not given.
+	Concurrency int `mapstructure:"concurrency"`
+
+	// ReadOnly refuses every command that could change Chat, e.g. for
+	// dashboards and monitoring scripts sharing an identity.
+	ReadOnly bool `mapstructure:"read_only"`
+
+	// ProtectedSpaces lists spaces (resource names, IDs, or display names)
+	// that destructive commands refuse to touch without
+	// --override-protection.
+	ProtectedSpaces []string `mapstructure:"protected_spaces"`
+
+	// PromptMaxStaleness is how old the cached unread count used by
+	// "readstate prompt" may get before a background refresh is started.
+	PromptMaxStaleness time.Duration `mapstructure:"prompt_max_staleness"`
+
+	// Pager enables piping long human-readable output through $PAGER when
+	// stdout is a terminal.
+	Pager bool `mapstructure:"pager"`
+
+	// RenderMarkdown renders message text as formatted markdown in
+	// human-readable output when --render is not given.
+	RenderMarkdown bool `mapstructure:"render_markdown"`
+
+	// Timezone is the IANA time zone (e.g. "Europe/Berlin") timestamps are
+	// shown in. Empty means the system's local zone.
+	Timezone string `mapstructure:"timezone"`
+
+	// TimeFormat is how timestamps are shown in human-readable output:
+	// "short", "relative", or "rfc3339".
+	TimeFormat string `mapstructure:"time_format"`
+
+	// TranslationAPIKey is the Google Cloud API key used for the Cloud
+	// Translation API by --translate and --translate-to.
+	TranslationAPIKey string `mapstructure:"translation_api_key"`
+
+	// Profiles are named Google accounts selected with --account, each
+	// with its own token (and optionally its own OAuth client).
+	Profiles map[string]Profile `mapstructure:"profiles"`
+
+	// LoginSuccessMessage replaces the text of the page shown in the
+	// browser after signing in, e.g. for organization branding.
+	LoginSuccessMessage string `mapstructure:"login_success_message"`
+
+	// LoginTimeout is how long "auth login" waits for the browser sign-in.
+	LoginTimeout time.Duration `mapstructure:"login_timeout"`
+
+	// Scopes are OAuth2 scopes requested by every login in addition to
+	// the defaults, e.g. chat.admin.spaces.readonly for "spaces search".
+	Scopes []string `mapstructure:"scopes"`
+
+	// DedupWindow is how long a sent message is remembered so that an
+	// identical one sent to the same space is skipped. Zero disables
+	// deduplication.
+	DedupWindow time.Duration `mapstructure:"dedup_window"`
+
+	// DefaultSpace is the space commands such as "messages send" use when
+	// no SPACE argument is given.
+	DefaultSpace string `mapstructure:"default_space"`
+
+	// Output is the default output format: "text" or "json".
+	Output string `mapstructure:"output"`
+
+	// Theme sets the colors of human-readable output.
+	Theme ThemeConfig `mapstructure:"theme"`
+
+	// Columns are the default table columns of commands, by command path
+	// (e.g. "spaces list"), used when --columns is not given.
+	Columns map[string][]string `mapstructure:"columns"`
+
+	// APIVersion is the version of the Chat API that requests are sent to
+	// (e.g. "v1"). Empty means the stable version.
+	APIVersion string `mapstructure:"api_version"`
+
+	// APIVersions pins the API version of single commands, by command path
+	// (e.g. "messages send"), overriding APIVersion.
+	APIVersions map[string]string `mapstructure:"api_versions"`
+
+	// PreviewFeatures allows pre-release API versions such as v1beta.
+	PreviewFeatures bool `mapstructure:"preview_features"`
+
+	// AuditLog is the JSONL file every command that changes Chat is
+	// recorded in. Empty disables the audit log.
+	AuditLog string `mapstructure:"audit_log"`
+
+	// History records the commands run in the local command history, for
+	// "history" and "redo".
+	History bool `mapstructure:"history"`
+
+	// HTTP tunes the connections used for API requests.
+	HTTP HTTPConfig `mapstructure:"http"`
+
+	// Cache sets how long lookup results are cached.
+	Cache CacheConfig `mapstructure:"cache"`
+
+	// Limits guards interactive use against accidentally huge operations.
+	Limits LimitsConfig `mapstructure:"limits"`
+
+	// Posting caps the messages that bridges and auto-responders post.
+	Posting PostingConfig `mapstructure:"posting"`
+
+	// Retention lists the policies applied by "retention apply" when no
+	// --space is given.
+	Retention []RetentionPolicy `mapstructure:"retention"`
+
+	// Sinks are the destinations that long-running commands also write
+	// their records to with --sink NAME, by name.
+	Sinks map[string]SinkConfig `mapstructure:"sinks"`
+}
+
+// SinkConfig is a destination for the records (e.g. messages) of
+// long-running commands, written as JSON Lines. Zero values select the
+// defaults of the sink type.
+type SinkConfig struct {
+	// Type is "file", "webhook", or "s3".
+	Type string `mapstructure:"type"`
+
+	// Path is the file of a file sink.
+	Path string `mapstructure:"path"`
+
+	// MaxSizeMB is the size in MB at which a file sink's file is rotated
+	// (default 100).
+	MaxSizeMB int `mapstructure:"max_size_mb"`
+
+	// MaxFiles is the number of rotated files a file sink keeps besides
+	// the current one (default 5).
+	MaxFiles int `mapstructure:"max_files"`
+
+	// URL is the endpoint a webhook sink posts to.
+	URL string `mapstructure:"url"`
+
+	// Headers are sent with every request of a webhook sink, e.g. an
+	// Authorization header.
+	Headers map[string]string `mapstructure:"headers"`
+
+	// Bucket is the bucket an s3 sink uploads objects to.
+	Bucket string `mapstructure:"bucket"`
+
+	// Prefix is prepended to the object names of an s3 sink.
+	Prefix string `mapstructure:"prefix"`
+
+	// Region is the region of an s3 sink's bucket (default AWS_REGION,
+	// then us-east-1).
+	Region string `mapstructure:"region"`
+
+	// Endpoint is the S3-compatible service of an s3 sink, e.g.
+	// https://storage.googleapis.com for Cloud Storage (default AWS S3).
+	Endpoint string `mapstructure:"endpoint"`
+
+	// AccessKeyID and SecretAccessKey sign the requests of an s3 sink
+	// (default AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY).
+	AccessKeyID     string `mapstructure:"access_key_id"`
+	SecretAccessKey string `mapstructure:"secret_access_key"`
+
+	// BatchSize is the number of records sent per request of a webhook
+	// sink (default 100) or per object of an s3 sink (default 1000).
+	BatchSize int `mapstructure:"batch_size"`
+
+	// FlushInterval is how long records wait for a batch to fill up
+	// before they are sent (default 10s for webhook, 1m for s3).
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// RetentionPolicy is how long the messages of a space are kept.
+type RetentionPolicy struct {
+	// Space is the space resource name or ID.
+	Space string `mapstructure:"space"`
+
+	// Keep is the retention window, e.g. "90d" or "6 months".
+	Keep string `mapstructure:"keep"`
+
+	// Export, if set, is the directory expired messages are archived to
+	// before they are deleted.
+	Export string `mapstructure:"export"`
+}
+
+// CacheConfig holds the time to live of each kind of cached lookup. Zero
+// turns caching off for that kind.
+type CacheConfig struct {
+	// Spaces is how long space display names are cached.
+	Spaces time.Duration `mapstructure:"spaces"`
+
+	// Users is how long the user IDs of email addresses are cached.
+	Users time.Duration `mapstructure:"users"`
+
+	// Directory is how long directory search results, used by shell
+	// completion, are cached.
+	Directory time.Duration `mapstructure:"directory"`
+
+	// Emoji is how long custom emoji shortcodes are cached.
+	Emoji time.Duration `mapstructure:"emoji"`
+
+	// Scopes is how long the scopes granted to a login are cached.
+	Scopes time.Duration `mapstructure:"scopes"`
+}
+
+// ThemeConfig selects a built-in color theme and overrides its colors.
+// Colors are names such as "cyan" or "bright-red", 256-color numbers, or
+// "#rrggbb", optionally with attributes such as "bold"; empty keeps the
+// preset's color and "none" turns it off.
+type ThemeConfig struct {
+	// Preset is the built-in theme: "dark", "light", or "monochrome".
+	Preset string `mapstructure:"preset"`
+
+	// Accents are cycled through for per-space prefixes.
+	Accents []string `mapstructure:"accents"`
+
+	// Timestamp colors message times.
+	Timestamp string `mapstructure:"timestamp"`
+
+	// Mention highlights @-mentions in message text.
+	Mention string `mapstructure:"mention"`
+
+	// Success colors the check mark of success messages.
+	Success string `mapstructure:"success"`
+}
+
+// LimitsConfig holds the guards against accidentally huge operations.
+// Zero turns a guard off.
+type LimitsConfig struct {
+	// MaxFetch is the number of messages a command fetches from a space
+	// by following result pages before it stops, unless --all is given.
+	MaxFetch int `mapstructure:"max_fetch"`
+
+	// MaxDelete is the number of messages a single command deletes
+	// without --yes; larger deletions are refused even if confirmed.
+	MaxDelete int `mapstructure:"max_delete"`
+
+	// UploadWarnMB is the file size in MB above which an upload must be
+	// confirmed (or --yes given).
+	UploadWarnMB int `mapstructure:"upload_warn_mb"`
+}
+
+// PostingConfig holds the limits of the posting governor, which protects
+// spaces from runaway automation. Zero turns a limit off.
+type PostingConfig struct {
+	// MaxPerMinute is the number of messages posted to a space per minute.
+	MaxPerMinute int `mapstructure:"max_per_minute"`
+
+	// MaxPerDay is the number of messages posted to a space per local
+	// calendar day, counted across runs.
+	MaxPerDay int `mapstructure:"max_per_day"`
+
+	// Overflow is what happens to messages past MaxPerMinute: "queue"
+	// delays them until there is room, "drop" discards them.
+	Overflow string `mapstructure:"overflow"`
+}
+
+// HTTPConfig holds the connection settings of the HTTP transport.
+type HTTPConfig struct {
+	// MaxIdleConns is the maximum number of idle keep-alive connections.
+	MaxIdleConns int `mapstructure:"max_idle_conns"`
+
+	// MaxIdleConnsPerHost is the maximum number of idle keep-alive
+	// connections to a single host, e.g. chat.googleapis.com.
+	MaxIdleConnsPerHost int `mapstructure:"max_idle_conns_per_host"`
+
+	// IdleConnTimeout is how long an idle connection is kept for reuse.
+	IdleConnTimeout time.Duration `mapstructure:"idle_conn_timeout"`
+
+	// KeepAlive is the interval of TCP keep-alive probes; negative
+	// disables them.
+	KeepAlive time.Duration `mapstructure:"keep_alive"`
+
+	// Batch combines the requests of bulk operations into batch requests
+	// of up to 50 calls each. Off by default.
+	Batch bool `mapstructure:"batch"`
+}
+
+// Profile holds the settings of one named account. Empty fields fall back
+// to the top-level settings, except TokenFile, which defaults to
+// token-{name}.json in the config directory.
+type Profile struct {
+	TokenFile    string `mapstructure:"token_file"`
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	// ReadOnly, if set, overrides the top-level read_only.
+	ReadOnly *bool `mapstructure:"read_only"`
+}
+
+// WithProfile returns a copy of the configuration with the settings of the
+// named profile applied.
+func (c *Config) WithProfile(name string) (*Config, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown account %q; add it under profiles in the config file", name)
+	}
+
+	cfg := *c
+	cfg.TokenFile = p.TokenFile
+	if cfg.TokenFile == "" {
+		cfg.TokenFile = filepath.Join(ConfigDir(), "token-"+name+".json")
+	}
+	if p.ClientID != "" {
+		cfg.ClientID = p.ClientID
+	}
+	if p.ClientSecret != "" {
+		cfg.ClientSecret = p.ClientSecret
+	}
+	if p.ReadOnly != nil {
+		cfg.ReadOnly = *p.ReadOnly
+	}
+	return &cfg, nil
+}
+
+// ProfileNames returns the names of the configured profiles, sorted.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // ConfigDir returns the path to the gogchat configuration directory
@@ -48,22 +378,75 @@ func ConfigDir() string {
 	return dir
 }
 
+// SetupEnv makes every setting readable from a GOGCHAT_ environment
+// variable, e.g. GOGCHAT_TOKEN_FILE or GOGCHAT_HTTP_BATCH for http.batch,
+// plus the aliases GOGCHAT_PROFILE for the account and GOGCHAT_SPACE for
+// the default space.
+func SetupEnv() {
+	viper.SetEnvPrefix("GOGCHAT")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.AutomaticEnv()
+	_ = viper.BindEnv("account", "GOGCHAT_ACCOUNT", "GOGCHAT_PROFILE")
+	_ = viper.BindEnv("default_space", "GOGCHAT_DEFAULT_SPACE", "GOGCHAT_SPACE")
+}
+
 // Load reads the configuration from the config file, environment variables,
 // and returns a populated Config struct.
 func Load() (*Config, error) {
 	dir := ConfigDir()
 	defaultTokenFile := filepath.Join(dir, "token.json")
 
-	viper.SetConfigName("config")
+	// SetConfigName discards a file chosen with --config, so only search
+	// the config directory when none was given.
+	if viper.ConfigFileUsed() == "" {
+		viper.SetConfigName("config")
+		viper.AddConfigPath(dir)
+	}
 	viper.SetConfigType("yaml")
-	viper.AddConfigPath(dir)
 
-	viper.SetEnvPrefix("GOGCHAT")
-	viper.AutomaticEnv()
+	SetupEnv()
 
 	viper.SetDefault("client_id", "")
 	viper.SetDefault("client_secret", "")
 	viper.SetDefault("token_file", defaultTokenFile)
+	viper.SetDefault("service_account_file", "")
+	viper.SetDefault("user_agent", "")
+	viper.SetDefault("page_size", 0)
+	viper.SetDefault("concurrency", 4)
+	viper.SetDefault("read_only", false)
+	viper.SetDefault("protected_spaces", []string{})
+	viper.SetDefault("prompt_max_staleness", 5*time.Minute)
+	viper.SetDefault("pager", true)
+	viper.SetDefault("render_markdown", false)
+	viper.SetDefault("timezone", "")
+	viper.SetDefault("time_format", "short")
+	viper.SetDefault("translation_api_key", "")
+	viper.SetDefault("login_success_message", "")
+	viper.SetDefault("login_timeout", 5*time.Minute)
+	viper.SetDefault("dedup_window", 0)
+	viper.SetDefault("audit_log", "")
+	viper.SetDefault("history", true)
+	viper.SetDefault("http.max_idle_conns", 100)
+	viper.SetDefault("http.max_idle_conns_per_host", 16)
+	viper.SetDefault("http.idle_conn_timeout", 90*time.Second)
+	viper.SetDefault("http.keep_alive", 30*time.Second)
+	viper.SetDefault("http.batch", false)
+	viper.SetDefault("default_space", "")
+	viper.SetDefault("api_version", "")
+	viper.SetDefault("preview_features", false)
+	viper.SetDefault("output", "text")
+	viper.SetDefault("theme.preset", "dark")
+	viper.SetDefault("cache.spaces", time.Hour)
+	viper.SetDefault("cache.users", 7*24*time.Hour)
+	viper.SetDefault("cache.directory", 24*time.Hour)
+	viper.SetDefault("cache.emoji", 24*time.Hour)
+	viper.SetDefault("cache.scopes", 24*time.Hour)
+	viper.SetDefault("limits.max_fetch", 5000)
+	viper.SetDefault("limits.max_delete", 100)
+	viper.SetDefault("limits.upload_warn_mb", 50)
+	viper.SetDefault("posting.max_per_minute", 0)
+	viper.SetDefault("posting.max_per_day", 0)
+	viper.SetDefault("posting.overflow", "queue")
 
 	// Read the config file; ignore "not found" errors since env vars or
 	// defaults may be sufficient.
@@ -78,5 +461,16 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("unmarshalling config: %w", err)
 	}
 
+	// Profiles without settings ("work: {}") are dropped by Unmarshal but
+	// are valid: they use the default token file for their name.
+	for name := range viper.GetStringMap("profiles") {
+		if _, ok := cfg.Profiles[name]; !ok {
+			if cfg.Profiles == nil {
+				cfg.Profiles = map[string]Profile{}
+			}
+			cfg.Profiles[name] = Profile{}
+		}
+	}
+
 	return &cfg, nil
 }
@@ -0,0 +1,154 @@
+// Package dedup keeps a ledger of the content hashes of recently posted
+// messages, per space, so that a message posted again within a window (e.g.
+// because an upstream alerting pipeline retried) can be skipped.
+//
+// The ledger is a bbolt database with one bucket per space. Like the lookup
+// cache, it is opened for each operation and closed right after, so that
+// concurrent gogchat processes only wait for each other briefly.
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// lockTimeout is how long an operation waits for another process that
+// holds the database.
+const lockTimeout = 5 * time.Second
+
+// Entry records a message posted to a space.
+type Entry struct {
+	// Time is when the message was claimed for posting.
+	Time time.Time `json:"time"`
+	// Message is the resource name of the posted message. It is empty
+	// while the message is still being sent.
+	Message string `json:"message,omitempty"`
+}
+
+// Ledger is an on-disk record of recently posted messages.
+type Ledger struct {
+	path   string
+	window time.Duration
+}
+
+// New returns a ledger stored at path that treats messages as duplicates
+// for window after they were posted.
+func New(path string, window time.Duration) *Ledger {
+	return &Ledger{path: path, window: window}
+}
+
+// Path returns the location of the database.
+func (l *Ledger) Path() string {
+	return l.path
+}
+
+// Hash returns the content hash of a message body sent with threadKey. Map
+// keys are marshaled in sorted order, so equal bodies hash equally.
+func Hash(body map[string]interface{}, threadKey string) (string, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("hashing message: %w", err)
+	}
+	sum := sha256.New()
+	sum.Write([]byte(threadKey))
+	sum.Write([]byte{0})
+	sum.Write(data)
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// Claim reserves hash in space for a message about to be posted. If the
+// same content was claimed within the window, it returns that entry and
+// false, and the message should not be posted. Otherwise the caller must
+// follow up with Record once the message is posted, or Release if posting
+// failed. Entries past the window are pruned from the space.
+func (l *Ledger) Claim(space, hash string) (Entry, bool, error) {
+	var existing Entry
+	claimed := false
+	err := l.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(space))
+		if err != nil {
+			return err
+		}
+		if err := l.prune(b); err != nil {
+			return err
+		}
+		if data := b.Get([]byte(hash)); data != nil && json.Unmarshal(data, &existing) == nil {
+			return nil
+		}
+		claimed = true
+		return put(b, hash, Entry{Time: time.Now()})
+	})
+	if err != nil {
+		return Entry{}, false, err
+	}
+	return existing, claimed, nil
+}
+
+// Record stores the name of the message posted for a claimed hash.
+func (l *Ledger) Record(space, hash, message string) error {
+	return l.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(space))
+		if err != nil {
+			return err
+		}
+		return put(b, hash, Entry{Time: time.Now(), Message: message})
+	})
+}
+
+// Release removes the claim on hash, so that the message can be posted
+// again after a failed attempt.
+func (l *Ledger) Release(space, hash string) error {
+	return l.update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(space))
+		if b == nil {
+			return nil
+		}
+		return b.Delete([]byte(hash))
+	})
+}
+
+// prune removes the entries of b older than the window.
+func (l *Ledger) prune(b *bolt.Bucket) error {
+	var stale [][]byte
+	err := b.ForEach(func(k, v []byte) error {
+		var e Entry
+		if json.Unmarshal(v, &e) != nil || time.Since(e.Time) >= l.window {
+			stale = append(stale, k)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range stale {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// put stores e under key in b.
+func put(b *bolt.Bucket, key string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), data)
+}
+
+// update runs fn in a read-write transaction, creating the database if
+// needed.
+func (l *Ledger) update(fn func(tx *bolt.Tx) error) error {
+	db, err := bolt.Open(l.path, 0o600, &bolt.Options{Timeout: lockTimeout})
+	if err != nil {
+		return fmt.Errorf("opening dedup ledger %s: %w", l.path, err)
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
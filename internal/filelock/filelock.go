@@ -0,0 +1,66 @@
+// Package filelock serialises the updates of a file by several gogchat
+// processes, e.g. a running bridge and "dlq retry". A lock is held on a
+// sidecar file next to the protected one, so that the protected file can
+// still be replaced atomically by renaming.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Timeout is how long Lock waits for another process that holds the lock.
+const Timeout = 10 * time.Second
+
+// pollInterval is how often Lock tries again while the lock is held.
+const pollInterval = 20 * time.Millisecond
+
+// errLocked is returned by tryLock when another process holds the lock.
+var errLocked = errors.New("locked")
+
+// Lock is a held lock on a file.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes the lock of the file at path, waiting up to Timeout for
+// another process that holds it. The lock is released with Unlock, or
+// when the process exits.
+func Acquire(path string) (*Lock, error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o700); err != nil {
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+
+	deadline := time.Now().Add(Timeout)
+	for {
+		err := tryLock(f)
+		if err == nil {
+			return &Lock{f: f}, nil
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			f.Close()
+			if errors.Is(err, errLocked) {
+				return nil, fmt.Errorf("locking %s: another gogchat process holds it", path)
+			}
+			return nil, fmt.Errorf("locking %s: %w", path, err)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// Unlock releases the lock.
+func (l *Lock) Unlock() error {
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -71,7 +71,7 @@ func (f *Formatter) PrintSuccess(msg string) {
 	if f.Quiet {
 		return
 	}
-	fmt.Fprintf(os.Stdout, "✓ %s\n", msg)
+	fmt.Fprintf(os.Stdout, "%s %s\n", colorize(theme.Success, "✓"), msg)
 }
 
 // IsJSON returns true if the formatter is in JSON output mode.
@@ -31,9 +31,39 @@ func PrintRawJSON(raw json.RawMessage) error {
 	return err
 }
 
+// TimeStyle selects how FormatTime renders timestamps.
+type TimeStyle string
+
+const (
+	// TimeShort shows the time, adding the date unless it is today and the
+	// year unless it is this year.
+	TimeShort TimeStyle = "short"
+	// TimeRelative shows how long ago the timestamp was, e.g. "3m ago".
+	TimeRelative TimeStyle = "relative"
+	// TimeRFC3339 shows the full RFC 3339 timestamp.
+	TimeRFC3339 TimeStyle = "rfc3339"
+)
+
+var (
+	timeStyle    = TimeShort
+	timeLocation = time.Local
+)
+
+// SetTimeStyle configures how FormatTime renders timestamps and the time
+// zone they are shown in.
+func SetTimeStyle(style TimeStyle, loc *time.Location) {
+	timeStyle = style
+	timeLocation = loc
+}
+
+// TimeLocation returns the time zone timestamps are displayed in.
+func TimeLocation() *time.Location {
+	return timeLocation
+}
+
 // FormatTime converts a Google API datetime string (RFC 3339) to a
-// human-readable local time format. If parsing fails, the original
-// string is returned unchanged.
+// human-readable time in the configured style and time zone. If parsing
+// fails, the original string is returned unchanged.
 func FormatTime(t string) string {
 	if t == "" {
 		return ""
@@ -48,8 +78,17 @@ func FormatTime(t string) string {
 		}
 	}
 
-	local := parsed.Local()
-	now := time.Now()
+	local := parsed.In(timeLocation)
+	now := time.Now().In(timeLocation)
+
+	switch timeStyle {
+	case TimeRFC3339:
+		return local.Format(time.RFC3339)
+	case TimeRelative:
+		if rel := relativeTime(local, now); rel != "" {
+			return rel
+		}
+	}
 
 	// If it's today, show just the time.
 	if local.Year() == now.Year() && local.YearDay() == now.YearDay() {
@@ -65,6 +104,34 @@ func FormatTime(t string) string {
 	return local.Format("Jan 2, 2006 3:04 PM")
 }
 
+// relativeTime describes t relative to now, e.g. "just now", "3m ago",
+// "2h ago", "5d ago", or "in 10m". It returns "" for times more than 30
+// days away, which read better as dates.
+func relativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := " ago"
+	prefix := ""
+	if d < 0 {
+		d = -d
+		prefix, suffix = "in ", ""
+	}
+
+	var amount string
+	switch {
+	case d < 45*time.Second:
+		return "just now"
+	case d < time.Hour:
+		amount = fmt.Sprintf("%dm", int((d + 30*time.Second).Minutes()))
+	case d < 24*time.Hour:
+		amount = fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		amount = fmt.Sprintf("%dd", int(d.Hours()/24))
+	default:
+		return ""
+	}
+	return prefix + amount + suffix
+}
+
 // Truncate truncates a string to maxLen characters, appending "..." if truncated.
 // If maxLen is less than or equal to 3, the string is truncated to maxLen without ellipsis.
 func Truncate(s string, maxLen int) string {
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/glamour"
+	"golang.org/x/term"
+)
+
+// defaultRenderWidth is the word-wrap width used when the terminal width
+// cannot be determined.
+const defaultRenderWidth = 80
+
+var (
+	// chatLink matches Chat's <url|label> link syntax.
+	chatLink = regexp.MustCompile(`<(https?://[^|>\s]+)\|([^>]+)>`)
+	// chatBold matches Chat's *bold* syntax.
+	chatBold = regexp.MustCompile(`(^|[\s(])\*([^*\n]+)\*`)
+	// chatStrike matches Chat's ~strikethrough~ syntax.
+	chatStrike = regexp.MustCompile(`(^|[\s(])~([^~\n]+)~`)
+)
+
+// ChatToMarkdown converts Google Chat's message formatting (*bold*,
+// ~strike~, <url|label>) into CommonMark. Italics (_x_), inline code, and
+// fenced code blocks already use the same syntax. Text inside code is left
+// untouched.
+func ChatToMarkdown(text string) string {
+	lines := strings.Split(text, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		// Split on backticks so inline code spans are not rewritten.
+		parts := strings.Split(line, "`")
+		for j := 0; j < len(parts); j += 2 {
+			p := chatLink.ReplaceAllString(parts[j], "[$2]($1)")
+			p = chatBold.ReplaceAllString(p, "$1**$2**")
+			p = chatStrike.ReplaceAllString(p, "$1~~$2~~")
+			parts[j] = p
+		}
+		lines[i] = strings.Join(parts, "`")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderMarkdown renders a Chat message body for the terminal, formatting
+// code blocks, lists, and links, in the theme's markdown style. Colors are
+// omitted when NO_COLOR is set. If rendering fails, the text is returned
+// unchanged.
+func RenderMarkdown(text string) string {
+	style := theme.Markdown
+	if style == "" || os.Getenv("NO_COLOR") != "" {
+		style = "notty"
+	}
+
+	r, err := glamour.NewTermRenderer(
+		glamour.WithStandardStyle(style),
+		glamour.WithWordWrap(renderWidth()),
+	)
+	if err != nil {
+		return text
+	}
+	out, err := r.Render(ChatToMarkdown(text))
+	if err != nil {
+		return text
+	}
+
+	// Glamour pads every line to the wrap width; drop the padding so
+	// copied or piped output stays clean.
+	lines := strings.Split(strings.Trim(out, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderWidth returns the terminal width for word wrapping. Stderr is
+// checked because stdout may be redirected to a pager.
+func renderWidth() int {
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	if w, _, err := term.GetSize(int(os.Stderr.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return defaultRenderWidth
+}
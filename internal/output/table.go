@@ -1,6 +1,8 @@
 package output
 
 import (
+	"fmt"
+	"os"
 	"strings"
 )
 
@@ -11,6 +13,24 @@ const (
 	columnPadding = 2
 )
 
+// selectedColumns are the columns tables are limited to; empty means all.
+var selectedColumns []string
+
+// warnedColumns are the unknown selected columns already warned about.
+var warnedColumns = map[string]bool{}
+
+// SetColumns limits the columns rendered by every table to those named, in
+// the given order. Names match headers regardless of case, underscores,
+// and dashes, so "memberCount" selects MEMBER_COUNT.
+func SetColumns(names []string) {
+	selectedColumns = names
+}
+
+// columnKey normalizes a column name or header for matching.
+func columnKey(s string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
 // Table formats data into aligned columns for human-readable output.
 type Table struct {
 	Headers []string
@@ -32,20 +52,21 @@ func (t *Table) AddRow(values ...string) *Table {
 
 // Render returns the table as a formatted, aligned string with header underlines.
 func (t *Table) Render() string {
-	if len(t.Headers) == 0 {
+	headers, rows := t.selected()
+	if len(headers) == 0 {
 		return ""
 	}
 
-	numCols := len(t.Headers)
+	numCols := len(headers)
 
 	// Calculate max width per column, capped at maxColumnWidth.
 	widths := make([]int, numCols)
-	for i, h := range t.Headers {
+	for i, h := range headers {
 		if len(h) > widths[i] {
 			widths[i] = len(h)
 		}
 	}
-	for _, row := range t.Rows {
+	for _, row := range rows {
 		for i := 0; i < numCols && i < len(row); i++ {
 			val := Truncate(row[i], maxColumnWidth)
 			if len(val) > widths[i] {
@@ -65,7 +86,7 @@ func (t *Table) Render() string {
 	var b strings.Builder
 
 	// Print headers in UPPERCASE.
-	for i, h := range t.Headers {
+	for i, h := range headers {
 		upper := strings.ToUpper(h)
 		if i > 0 {
 			b.WriteString(pad)
@@ -87,7 +108,7 @@ func (t *Table) Render() string {
 	b.WriteString("\n")
 
 	// Print rows.
-	for _, row := range t.Rows {
+	for _, row := range rows {
 		for i := 0; i < numCols; i++ {
 			if i > 0 {
 				b.WriteString(pad)
@@ -106,3 +127,50 @@ func (t *Table) Render() string {
 
 	return b.String()
 }
+
+// selected returns the headers and rows limited to the columns chosen with
+// SetColumns. Unknown columns are skipped with a warning; a table with
+// none of the chosen columns is shown in full.
+func (t *Table) selected() ([]string, [][]string) {
+	if len(selectedColumns) == 0 {
+		return t.Headers, t.Rows
+	}
+
+	var idx []int
+	for _, name := range selectedColumns {
+		found := false
+		for i, h := range t.Headers {
+			if columnKey(h) == columnKey(name) {
+				idx = append(idx, i)
+				found = true
+				break
+			}
+		}
+		if !found && !warnedColumns[name] {
+			warnedColumns[name] = true
+			available := make([]string, len(t.Headers))
+			for i, h := range t.Headers {
+				available[i] = strings.ToLower(h)
+			}
+			fmt.Fprintf(os.Stderr, "⚠ Unknown column %q (available: %s)\n", name, strings.Join(available, ", "))
+		}
+	}
+	if len(idx) == 0 {
+		return t.Headers, t.Rows
+	}
+
+	headers := make([]string, len(idx))
+	for i, c := range idx {
+		headers[i] = t.Headers[c]
+	}
+	rows := make([][]string, len(t.Rows))
+	for r, row := range t.Rows {
+		rows[r] = make([]string, len(idx))
+		for i, c := range idx {
+			if c < len(row) {
+				rows[r][i] = row[c]
+			}
+		}
+	}
+	return headers, rows
+}
@@ -0,0 +1,158 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Theme holds the colors of human-readable output as ANSI SGR parameters
+// (e.g. "1;33"); an empty color leaves the text unstyled.
+type Theme struct {
+	// Accents are cycled through for per-space prefixes in "messages tail".
+	Accents []string
+	// Timestamp colors message times.
+	Timestamp string
+	// Mention highlights @-mentions in message text.
+	Mention string
+	// Success colors the check mark of success messages.
+	Success string
+	// Markdown is the glamour style used to render markdown: "dark",
+	// "light", or "notty".
+	Markdown string
+}
+
+// themePresets are the built-in themes, selected with theme.preset.
+var themePresets = map[string]Theme{
+	"dark": {
+		Accents:   []string{"36", "33", "35", "32", "34", "31", "96", "93", "95", "92"},
+		Timestamp: "90",
+		Mention:   "1;33",
+		Success:   "32",
+		Markdown:  "dark",
+	},
+	"light": {
+		Accents:   []string{"34", "35", "31", "32", "36", "33"},
+		Timestamp: "90",
+		Mention:   "1;35",
+		Success:   "32",
+		Markdown:  "light",
+	},
+	"monochrome": {
+		Accents:  []string{"1"},
+		Mention:  "1;4",
+		Markdown: "notty",
+	},
+}
+
+var (
+	theme        = themePresets["dark"]
+	colorEnabled = false
+)
+
+// ThemePreset returns the built-in theme called name.
+func ThemePreset(name string) (Theme, error) {
+	t, ok := themePresets[name]
+	if !ok {
+		names := make([]string, 0, len(themePresets))
+		for n := range themePresets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Theme{}, fmt.Errorf("unknown theme preset %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	t.Accents = append([]string(nil), t.Accents...)
+	return t, nil
+}
+
+// SetTheme sets the theme of human-readable output. Colors are only
+// written when color is true, e.g. when stdout is a terminal.
+func SetTheme(t Theme, color bool) {
+	theme = t
+	colorEnabled = color
+}
+
+// colorNames maps color names to their SGR foreground parameters.
+var colorNames = map[string]string{
+	"black": "30", "red": "31", "green": "32", "yellow": "33",
+	"blue": "34", "magenta": "35", "cyan": "36", "white": "37",
+	"gray": "90", "grey": "90",
+	"bright-red": "91", "bright-green": "92", "bright-yellow": "93",
+	"bright-blue": "94", "bright-magenta": "95", "bright-cyan": "96", "bright-white": "97",
+}
+
+// attributeNames maps text attributes to their SGR parameters.
+var attributeNames = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4",
+}
+
+// ParseColor converts a color spec into SGR parameters. A spec is a
+// space-separated list of attributes (bold, dim, italic, underline) and at
+// most one color: a name such as "cyan" or "bright-red", a 256-color
+// number, or "#rrggbb". "none" or "" means unstyled.
+func ParseColor(spec string) (string, error) {
+	var params []string
+	color := false
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if word == "none" {
+			continue
+		}
+		if p, ok := attributeNames[word]; ok {
+			params = append(params, p)
+			continue
+		}
+		if color {
+			return "", fmt.Errorf("invalid color %q: more than one color", spec)
+		}
+		color = true
+		if p, ok := colorNames[word]; ok {
+			params = append(params, p)
+		} else if n, err := strconv.Atoi(word); err == nil && n >= 0 && n <= 255 {
+			params = append(params, "38;5;"+word)
+		} else if rgb, ok := strings.CutPrefix(word, "#"); ok && len(rgb) == 6 {
+			v, err := strconv.ParseUint(rgb, 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid color %q", spec)
+			}
+			params = append(params, fmt.Sprintf("38;2;%d;%d;%d", v>>16, v>>8&0xff, v&0xff))
+		} else {
+			return "", fmt.Errorf("invalid color %q (use a name such as cyan, a number 0-255, or #rrggbb)", spec)
+		}
+	}
+	return strings.Join(params, ";"), nil
+}
+
+// colorize wraps s in the SGR parameters sgr when colors are enabled.
+func colorize(sgr, s string) string {
+	if !colorEnabled || sgr == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + sgr + "m" + s + "\x1b[0m"
+}
+
+// Accent colors s with the theme's accent for key, which stays the same
+// for the same key.
+func Accent(key, s string) string {
+	if len(theme.Accents) == 0 {
+		return s
+	}
+	h := 0
+	for _, c := range key {
+		h = h*31 + int(c)
+	}
+	if h < 0 {
+		h = -h
+	}
+	return colorize(theme.Accents[h%len(theme.Accents)], s)
+}
+
+// Timestamp colors a formatted time.
+func Timestamp(s string) string {
+	return colorize(theme.Timestamp, s)
+}
+
+// Mention highlights an @-mention.
+func Mention(s string) string {
+	return colorize(theme.Mention, s)
+}
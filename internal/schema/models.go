@@ -0,0 +1,388 @@
+package schema
+
+// The types below model the Google Chat API resources accepted by --body.
+// Field tags besides json drive the generated schema:
+//
+//	desc       description of the field
+//	enum       comma-separated allowed values
+//	readonly   "true" for output-only fields
+//	required   "true" for fields the API requires
+//	maxLength  maximum length of a string
+//
+// Only the writable fields and the output fields commonly read back are
+// modelled; the schemas allow additional properties so newer API fields do
+// not fail validation.
+
+// Space is a Chat space (spaces.* request and response bodies).
+type Space struct {
+	Name                string              `json:"name" desc:"Resource name, spaces/{space}" readonly:"true"`
+	DisplayName         string              `json:"displayName" desc:"Display name; required when creating a space of type SPACE" maxLength:"128"`
+	SpaceType           string              `json:"spaceType" desc:"Type of space" enum:"SPACE,GROUP_CHAT,DIRECT_MESSAGE"`
+	SpaceThreadingState string              `json:"spaceThreadingState" desc:"Threading state of the space" enum:"THREADED_MESSAGES,GROUPED_MESSAGES,UNTHREADED_MESSAGES" readonly:"true"`
+	SpaceDetails        *SpaceDetails       `json:"spaceDetails" desc:"Description and guidelines of the space"`
+	SpaceHistoryState   string              `json:"spaceHistoryState" desc:"Message history state" enum:"HISTORY_OFF,HISTORY_ON"`
+	ExternalUserAllowed bool                `json:"externalUserAllowed" desc:"Whether users outside the organization can join"`
+	ImportMode          bool                `json:"importMode" desc:"Whether the space is created in import mode"`
+	AccessSettings      *AccessSettings     `json:"accessSettings" desc:"Who can discover and join the space"`
+	PermissionSettings  *PermissionSettings `json:"permissionSettings" desc:"Who can perform which actions in the space"`
+	SpaceURI            string              `json:"spaceUri" desc:"Link to the space in Chat" readonly:"true"`
+	CreateTime          string              `json:"createTime" desc:"Creation time (RFC 3339); settable only in import mode"`
+	LastActiveTime      string              `json:"lastActiveTime" desc:"Time of the last message" readonly:"true"`
+}
+
+// SpaceDetails holds the description and rules of a space.
+type SpaceDetails struct {
+	Description string `json:"description" desc:"Description of the space" maxLength:"150"`
+	Guidelines  string `json:"guidelines" desc:"Rules, expectations, and etiquette" maxLength:"5000"`
+}
+
+// AccessSettings controls the discoverability of a space.
+type AccessSettings struct {
+	AccessState string `json:"accessState" desc:"Whether the space is discoverable" enum:"PRIVATE,DISCOVERABLE" readonly:"true"`
+	Audience    string `json:"audience" desc:"Target audience that can discover the space, audiences/{audience}"`
+}
+
+// PermissionSettings controls who may manage a space.
+type PermissionSettings struct {
+	ManageMembersAndGroups *PermissionSetting `json:"manageMembersAndGroups"`
+	ModifySpaceDetails     *PermissionSetting `json:"modifySpaceDetails"`
+	ToggleHistory          *PermissionSetting `json:"toggleHistory"`
+	UseAtMentionAll        *PermissionSetting `json:"useAtMentionAll"`
+	ManageApps             *PermissionSetting `json:"manageApps"`
+	ManageWebhooks         *PermissionSetting `json:"manageWebhooks"`
+	PostMessages           *PermissionSetting `json:"postMessages"`
+	ReplyMessages          *PermissionSetting `json:"replyMessages"`
+}
+
+// PermissionSetting says which roles hold a permission.
+type PermissionSetting struct {
+	ManagersAllowed bool `json:"managersAllowed" desc:"Whether space managers have the permission"`
+	MembersAllowed  bool `json:"membersAllowed" desc:"Whether members have the permission"`
+}
+
+// Message is a Chat message (messages.* request and response bodies).
+type Message struct {
+	Name                 string         `json:"name" desc:"Resource name, spaces/{space}/messages/{message}" readonly:"true"`
+	Sender               *User          `json:"sender" desc:"Author of the message" readonly:"true"`
+	CreateTime           string         `json:"createTime" desc:"Creation time (RFC 3339); settable only in import mode"`
+	LastUpdateTime       string         `json:"lastUpdateTime" readonly:"true"`
+	Text                 string         `json:"text" desc:"Plain-text body, with Chat formatting (*bold*, _italic_, <users/{id}> mentions)" maxLength:"4096"`
+	FormattedText        string         `json:"formattedText" desc:"Text with formatting markup" readonly:"true"`
+	CardsV2              []CardWithID   `json:"cardsV2" desc:"Cards shown with the message; apps only"`
+	Thread               *Thread        `json:"thread" desc:"Thread the message belongs to"`
+	FallbackText         string         `json:"fallbackText" desc:"Plain-text description of the cards, used in notifications"`
+	ActionResponse       *ActionResp    `json:"actionResponse" desc:"Response to an interaction; apps only"`
+	AccessoryWidgets     []Accessory    `json:"accessoryWidgets" desc:"Widgets shown below the text and cards; apps only"`
+	PrivateMessageViewer *User          `json:"privateMessageViewer" desc:"User who alone can see the message; apps only"`
+	Attachment           []Attachment   `json:"attachment" desc:"Uploaded or Drive attachments"`
+	EmojiReactionSums    []ReactionStat `json:"emojiReactionSummaries" readonly:"true"`
+	ClientAssignedID     string         `json:"clientAssignedMessageId" desc:"Custom ID, must start with client-"`
+}
+
+// User is a Chat user or app.
+type User struct {
+	Name        string `json:"name" desc:"Resource name, users/{user}"`
+	DisplayName string `json:"displayName" readonly:"true"`
+	Type        string `json:"type" enum:"HUMAN,BOT"`
+}
+
+// Thread identifies the thread of a message.
+type Thread struct {
+	Name      string `json:"name" desc:"Resource name, spaces/{space}/threads/{thread}"`
+	ThreadKey string `json:"threadKey" desc:"App-defined key to create or reply to a thread"`
+}
+
+// ActionResp is the response of an app to an interaction.
+type ActionResp struct {
+	Type string `json:"type" enum:"NEW_MESSAGE,UPDATE_MESSAGE,UPDATE_USER_MESSAGE_CARDS,REQUEST_CONFIG,DIALOG,UPDATE_WIDGET"`
+	URL  string `json:"url" desc:"URL for REQUEST_CONFIG"`
+}
+
+// Accessory is an accessory widget of a message.
+type Accessory struct {
+	ButtonList *ButtonList `json:"buttonList"`
+}
+
+// Attachment is a file attached to a message.
+type Attachment struct {
+	Name              string `json:"name" readonly:"true"`
+	ContentName       string `json:"contentName" readonly:"true"`
+	ContentType       string `json:"contentType" readonly:"true"`
+	AttachmentDataRef *struct {
+		ResourceName          string `json:"resourceName" desc:"Resource name returned by media upload"`
+		AttachmentUploadToken string `json:"attachmentUploadToken" desc:"Upload token returned by media upload"`
+	} `json:"attachmentDataRef"`
+	DriveDataRef *struct {
+		DriveFileID string `json:"driveFileId" desc:"ID of the Drive file"`
+	} `json:"driveDataRef"`
+}
+
+// ReactionStat counts the reactions of one emoji on a message.
+type ReactionStat struct {
+	Emoji         *Emoji `json:"emoji"`
+	ReactionCount int    `json:"reactionCount"`
+}
+
+// Membership is a member of a space (members.* request and response bodies).
+type Membership struct {
+	Name        string `json:"name" desc:"Resource name, spaces/{space}/members/{member}" readonly:"true"`
+	State       string `json:"state" enum:"JOINED,INVITED,NOT_A_MEMBER" readonly:"true"`
+	Role        string `json:"role" desc:"Role in the space" enum:"ROLE_MEMBER,ROLE_MANAGER"`
+	Member      *User  `json:"member" desc:"The user or app; set either member or groupMember"`
+	GroupMember *struct {
+		Name string `json:"name" desc:"Google Group, groups/{group}"`
+	} `json:"groupMember" desc:"The Google Group; set either member or groupMember"`
+	CreateTime string `json:"createTime" desc:"Time the member joined; settable only in import mode"`
+	DeleteTime string `json:"deleteTime" desc:"Time the member left; settable only in import mode"`
+}
+
+// Reaction is an emoji reaction to a message.
+type Reaction struct {
+	Name  string `json:"name" desc:"Resource name, spaces/{space}/messages/{message}/reactions/{reaction}" readonly:"true"`
+	User  *User  `json:"user" readonly:"true"`
+	Emoji *Emoji `json:"emoji" desc:"The emoji; set either unicode or customEmoji" required:"true"`
+}
+
+// Emoji is a Unicode or custom emoji.
+type Emoji struct {
+	Unicode     string `json:"unicode" desc:"Unicode emoji, e.g. 👍"`
+	CustomEmoji *struct {
+		UID string `json:"uid" desc:"ID of the custom emoji"`
+	} `json:"customEmoji"`
+}
+
+// CustomEmoji is an organization's custom emoji (emoji create).
+type CustomEmoji struct {
+	Name              string `json:"name" readonly:"true"`
+	UID               string `json:"uid" readonly:"true"`
+	EmojiName         string `json:"emojiName" desc:"Name surrounded by colons, e.g. :party-parrot:" required:"true" maxLength:"64"`
+	TemporaryImageURI string `json:"temporaryImageUri" readonly:"true"`
+	Payload           *struct {
+		FileContent string `json:"fileContent" desc:"Base64-encoded image, at most 256 KB" required:"true"`
+		Filename    string `json:"filename" desc:"File name ending in .png, .jpg, or .gif" required:"true"`
+	} `json:"payload" desc:"Image of the emoji; input only" required:"true"`
+}
+
+// CardWithID is one entry of a message's cardsV2.
+type CardWithID struct {
+	CardID string `json:"cardId" desc:"Identifier of the card, unique within the message" required:"true"`
+	Card   *Card  `json:"card" required:"true"`
+}
+
+// Card is a card shown in a message or dialog.
+type Card struct {
+	Header              *CardHeader  `json:"header"`
+	Sections            []Section    `json:"sections"`
+	SectionDividerStyle string       `json:"sectionDividerStyle" enum:"SOLID_DIVIDER,NO_DIVIDER"`
+	CardActions         []CardAction `json:"cardActions"`
+	Name                string       `json:"name"`
+	FixedFooter         *struct {
+		PrimaryButton   *Button `json:"primaryButton"`
+		SecondaryButton *Button `json:"secondaryButton"`
+	} `json:"fixedFooter"`
+	DisplayStyle   string      `json:"displayStyle" enum:"PEEK,REPLACE"`
+	PeekCardHeader *CardHeader `json:"peekCardHeader"`
+}
+
+// CardHeader is the header of a card.
+type CardHeader struct {
+	Title        string `json:"title" required:"true" maxLength:"200"`
+	Subtitle     string `json:"subtitle" maxLength:"200"`
+	ImageType    string `json:"imageType" enum:"SQUARE,CIRCLE"`
+	ImageURL     string `json:"imageUrl"`
+	ImageAltText string `json:"imageAltText"`
+}
+
+// Section groups the widgets of a card.
+type Section struct {
+	Header                    string   `json:"header"`
+	Widgets                   []Widget `json:"widgets"`
+	Collapsible               bool     `json:"collapsible"`
+	UncollapsibleWidgetsCount int      `json:"uncollapsibleWidgetsCount"`
+}
+
+// Widget is one element of a card section. Exactly one widget field must be
+// set.
+type Widget struct {
+	TextParagraph *struct {
+		Text     string `json:"text" required:"true" maxLength:"4096"`
+		MaxLines int    `json:"maxLines"`
+	} `json:"textParagraph"`
+	Image *struct {
+		ImageURL string   `json:"imageUrl" required:"true"`
+		OnClick  *OnClick `json:"onClick"`
+		AltText  string   `json:"altText"`
+	} `json:"image"`
+	DecoratedText  *DecoratedText  `json:"decoratedText"`
+	ButtonList     *ButtonList     `json:"buttonList"`
+	TextInput      *TextInput      `json:"textInput"`
+	SelectionInput *SelectionInput `json:"selectionInput"`
+	DateTimePicker *struct {
+		Name           string  `json:"name" required:"true"`
+		Label          string  `json:"label"`
+		Type           string  `json:"type" enum:"DATE_AND_TIME,DATE_ONLY,TIME_ONLY"`
+		ValueMsEpoch   string  `json:"valueMsEpoch"`
+		OnChangeAction *Action `json:"onChangeAction"`
+	} `json:"dateTimePicker"`
+	Divider *struct{} `json:"divider"`
+	Grid    *struct {
+		Title       string     `json:"title"`
+		Items       []GridItem `json:"items"`
+		ColumnCount int        `json:"columnCount"`
+		OnClick     *OnClick   `json:"onClick"`
+	} `json:"grid"`
+	Columns *struct {
+		ColumnItems []Column `json:"columnItems"`
+	} `json:"columns"`
+	ChipList *struct {
+		Layout string `json:"layout" enum:"WRAPPED,HORIZONTAL_SCROLLABLE"`
+		Chips  []Chip `json:"chips"`
+	} `json:"chipList"`
+	HorizontalAlignment string `json:"horizontalAlignment" enum:"START,CENTER,END"`
+}
+
+// DecoratedText is a text widget with optional icons and a button or switch.
+type DecoratedText struct {
+	StartIcon     *Icon          `json:"startIcon"`
+	TopLabel      string         `json:"topLabel"`
+	Text          string         `json:"text" required:"true" maxLength:"4096"`
+	WrapText      bool           `json:"wrapText"`
+	BottomLabel   string         `json:"bottomLabel"`
+	OnClick       *OnClick       `json:"onClick"`
+	Button        *Button        `json:"button"`
+	SwitchControl *SwitchControl `json:"switchControl"`
+	EndIcon       *Icon          `json:"endIcon"`
+}
+
+// ButtonList is a row of buttons.
+type ButtonList struct {
+	Buttons []Button `json:"buttons" required:"true"`
+}
+
+// Button is a text or icon button.
+type Button struct {
+	Text     string   `json:"text" maxLength:"200"`
+	Icon     *Icon    `json:"icon"`
+	Color    *Color   `json:"color"`
+	OnClick  *OnClick `json:"onClick" desc:"What happens when the button is clicked" required:"true"`
+	Disabled bool     `json:"disabled"`
+	AltText  string   `json:"altText"`
+	Type     string   `json:"type" enum:"OUTLINED,FILLED,FILLED_TONAL,BORDERLESS"`
+}
+
+// Color is an RGBA color with components from 0 to 1.
+type Color struct {
+	Red   float64 `json:"red"`
+	Green float64 `json:"green"`
+	Blue  float64 `json:"blue"`
+	Alpha float64 `json:"alpha"`
+}
+
+// Icon is a built-in, Material, or custom icon.
+type Icon struct {
+	KnownIcon    string `json:"knownIcon" desc:"Built-in icon, e.g. STAR or EMAIL"`
+	IconURL      string `json:"iconUrl"`
+	MaterialIcon *struct {
+		Name string `json:"name" required:"true"`
+		Fill bool   `json:"fill"`
+	} `json:"materialIcon"`
+	AltText   string `json:"altText"`
+	ImageType string `json:"imageType" enum:"SQUARE,CIRCLE"`
+}
+
+// OnClick is the effect of clicking a widget. Exactly one field must be set.
+type OnClick struct {
+	Action                *Action   `json:"action" desc:"Run an app function"`
+	OpenLink              *OpenLink `json:"openLink" desc:"Open a URL"`
+	OpenDynamicLinkAction *Action   `json:"openDynamicLinkAction"`
+	Card                  *Card     `json:"card" desc:"Push a new card"`
+}
+
+// OpenLink opens a URL.
+type OpenLink struct {
+	URL     string `json:"url" required:"true"`
+	OpenAs  string `json:"openAs" enum:"FULL_SIZE,OVERLAY"`
+	OnClose string `json:"onClose" enum:"NOTHING,RELOAD"`
+}
+
+// Action runs a function of the app.
+type Action struct {
+	Function      string            `json:"function" required:"true"`
+	Parameters    []ActionParameter `json:"parameters"`
+	LoadIndicator string            `json:"loadIndicator" enum:"SPINNER,NONE"`
+	PersistValues bool              `json:"persistValues"`
+	Interaction   string            `json:"interaction" enum:"INTERACTION_UNSPECIFIED,OPEN_DIALOG"`
+}
+
+// ActionParameter is a key/value passed to an action function.
+type ActionParameter struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// SwitchControl is a switch or checkbox in a decorated text.
+type SwitchControl struct {
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Selected       bool    `json:"selected"`
+	OnChangeAction *Action `json:"onChangeAction"`
+	ControlType    string  `json:"controlType" enum:"SWITCH,CHECKBOX,CHECK_BOX"`
+}
+
+// TextInput is a text field.
+type TextInput struct {
+	Name           string  `json:"name" required:"true"`
+	Label          string  `json:"label"`
+	HintText       string  `json:"hintText"`
+	Value          string  `json:"value"`
+	Type           string  `json:"type" enum:"SINGLE_LINE,MULTIPLE_LINE"`
+	OnChangeAction *Action `json:"onChangeAction"`
+}
+
+// SelectionInput is a set of checkboxes, radio buttons, switches, or a
+// dropdown.
+type SelectionInput struct {
+	Name           string          `json:"name" required:"true"`
+	Label          string          `json:"label"`
+	Type           string          `json:"type" enum:"CHECK_BOX,RADIO_BUTTON,SWITCH,DROPDOWN,MULTI_SELECT"`
+	Items          []SelectionItem `json:"items"`
+	OnChangeAction *Action         `json:"onChangeAction"`
+}
+
+// SelectionItem is one choice of a selection input.
+type SelectionItem struct {
+	Text     string `json:"text" required:"true"`
+	Value    string `json:"value" required:"true"`
+	Selected bool   `json:"selected"`
+}
+
+// GridItem is one cell of a grid.
+type GridItem struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+	Layout   string `json:"layout" enum:"TEXT_BELOW,TEXT_ABOVE"`
+}
+
+// Column is one column of a columns widget.
+type Column struct {
+	HorizontalSizeStyle string   `json:"horizontalSizeStyle" enum:"FILL_AVAILABLE_SPACE,FILL_MINIMUM_SPACE"`
+	HorizontalAlignment string   `json:"horizontalAlignment" enum:"START,CENTER,END"`
+	VerticalAlignment   string   `json:"verticalAlignment" enum:"CENTER,TOP,BOTTOM"`
+	Widgets             []Widget `json:"widgets"`
+}
+
+// Chip is a small clickable label.
+type Chip struct {
+	Icon     *Icon    `json:"icon"`
+	Label    string   `json:"label"`
+	OnClick  *OnClick `json:"onClick"`
+	Disabled bool     `json:"disabled"`
+	AltText  string   `json:"altText"`
+}
+
+// CardAction is an item of a card's overflow menu.
+type CardAction struct {
+	ActionLabel string   `json:"actionLabel" required:"true"`
+	OnClick     *OnClick `json:"onClick" required:"true"`
+}
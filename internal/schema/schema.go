@@ -0,0 +1,140 @@
+// Package schema generates JSON Schemas for the Google Chat API resources
+// from the typed models in this package, for editor validation of --body
+// payloads and card files.
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// resource is a resource a schema can be generated for.
+type resource struct {
+	model       interface{}
+	description string
+}
+
+// resources maps the names accepted by "gogchat schema" to their models.
+var resources = map[string]resource{
+	"space":        {Space{}, "A Google Chat space"},
+	"message":      {Message{}, "A Google Chat message"},
+	"membership":   {Membership{}, "A membership of a user, app, or group in a space"},
+	"reaction":     {Reaction{}, "An emoji reaction to a message"},
+	"custom-emoji": {CustomEmoji{}, "A custom emoji of the organization"},
+	"card":         {CardWithID{}, "A card of a message's cardsV2 list"},
+}
+
+// Resources returns the names of the resources with a schema, sorted.
+func Resources() []string {
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Description returns the one-line description of a resource.
+func Description(name string) string {
+	return resources[name].description
+}
+
+// For returns the JSON Schema (draft 2020-12) of the named resource.
+func For(name string) (map[string]interface{}, error) {
+	res, ok := resources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %q; valid resources: %s", name, strings.Join(Resources(), ", "))
+	}
+
+	g := &generator{defs: map[string]interface{}{}}
+	t := reflect.TypeOf(res.model)
+	g.root = t
+	s := g.object(t)
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["title"] = t.Name()
+	s["description"] = res.description
+	if len(g.defs) > 0 {
+		s["$defs"] = g.defs
+	}
+	return s, nil
+}
+
+// generator builds a schema, collecting named nested types in defs so that
+// recursive types (a card inside a button's onClick) terminate.
+type generator struct {
+	root reflect.Type
+	defs map[string]interface{}
+}
+
+// schema returns the schema of a Go type.
+func (g *generator) schema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schema(t.Elem())
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if t == g.root {
+			return map[string]interface{}{"$ref": "#"}
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			// Reserve the name before descending so recursion stops here.
+			g.defs[t.Name()] = nil
+			g.defs[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// object returns the object schema of a struct type from its field tags.
+func (g *generator) object(t reflect.Type) map[string]interface{} {
+	props := map[string]interface{}{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		s := g.schema(field.Type)
+		if desc := field.Tag.Get("desc"); desc != "" {
+			s["description"] = desc
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			s["enum"] = strings.Split(enum, ",")
+		}
+		if field.Tag.Get("readonly") == "true" {
+			s["readOnly"] = true
+		}
+		if n, err := strconv.Atoi(field.Tag.Get("maxLength")); err == nil {
+			s["maxLength"] = n
+		}
+		if field.Tag.Get("required") == "true" {
+			required = append(required, name)
+		}
+		props[name] = s
+	}
+
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
@@ -0,0 +1,377 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity says whether a problem makes the API reject a payload.
+type Severity string
+
+const (
+	// SeverityError marks problems the API rejects or that break the card.
+	SeverityError Severity = "error"
+	// SeverityWarning marks fields that are likely mistakes but accepted.
+	SeverityWarning Severity = "warning"
+)
+
+// Problem is one finding of Validate.
+type Problem struct {
+	Path     string   `json:"path"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// maxMessageBytes is the maximum size of a message body accepted by the API.
+const maxMessageBytes = 32000
+
+// Validate checks a decoded JSON payload against the schema of the named
+// resource and the semantic rules of Chat messages and cards. For "card",
+// both a cardsV2 entry ({"cardId", "card"}) and a bare card are accepted.
+// Problems are sorted by path.
+func Validate(name string, v interface{}) ([]Problem, error) {
+	root, err := For(name)
+	if err != nil {
+		return nil, err
+	}
+
+	vd := &validator{defs: map[string]interface{}{}}
+	if defs, ok := root["$defs"].(map[string]interface{}); ok {
+		vd.defs = defs
+	}
+	vd.root = root
+
+	s := root
+	if obj, ok := v.(map[string]interface{}); ok && name == "card" {
+		if _, wrapped := obj["card"]; !wrapped {
+			s = vd.defs["Card"].(map[string]interface{})
+		}
+	}
+	vd.value("", s, v)
+	vd.top(name, v)
+	vd.semantics("", v)
+
+	sort.SliceStable(vd.problems, func(i, j int) bool {
+		return vd.problems[i].Path < vd.problems[j].Path
+	})
+	return vd.problems, nil
+}
+
+// validator accumulates the problems of one payload.
+type validator struct {
+	root     map[string]interface{}
+	defs     map[string]interface{}
+	problems []Problem
+}
+
+func (vd *validator) errorf(path, format string, args ...interface{}) {
+	vd.problems = append(vd.problems, Problem{Path: displayPath(path), Severity: SeverityError, Message: fmt.Sprintf(format, args...)})
+}
+
+func (vd *validator) warnf(path, format string, args ...interface{}) {
+	vd.problems = append(vd.problems, Problem{Path: displayPath(path), Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)})
+}
+
+// displayPath returns path, or "(root)" for the payload itself.
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
+
+// resolve follows a $ref to its schema.
+func (vd *validator) resolve(s map[string]interface{}) map[string]interface{} {
+	ref, ok := s["$ref"].(string)
+	if !ok {
+		return s
+	}
+	if ref == "#" {
+		return vd.root
+	}
+	def, _ := vd.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	return def
+}
+
+// value checks v against schema s.
+func (vd *validator) value(path string, s map[string]interface{}, v interface{}) {
+	s = vd.resolve(s)
+	if s == nil || v == nil {
+		return
+	}
+
+	switch s["type"] {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			vd.errorf(path, "expected an object, got %s", jsonType(v))
+			return
+		}
+		props, _ := s["properties"].(map[string]interface{})
+		if required, ok := s["required"].([]string); ok {
+			for _, name := range required {
+				if _, ok := obj[name]; !ok {
+					vd.errorf(join(path, name), "required field is missing")
+				}
+			}
+		}
+		for _, name := range sortedKeys(obj) {
+			prop, ok := props[name].(map[string]interface{})
+			if !ok {
+				vd.warnf(join(path, name), "unknown field (typo?)")
+				continue
+			}
+			if prop["readOnly"] == true {
+				vd.warnf(join(path, name), "output-only field; the API ignores it")
+			}
+			vd.value(join(path, name), prop, obj[name])
+		}
+	case "array":
+		list, ok := v.([]interface{})
+		if !ok {
+			vd.errorf(path, "expected an array, got %s", jsonType(v))
+			return
+		}
+		items, _ := s["items"].(map[string]interface{})
+		for i, item := range list {
+			vd.value(fmt.Sprintf("%s[%d]", path, i), items, item)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			vd.errorf(path, "expected a string, got %s", jsonType(v))
+			return
+		}
+		if enum, ok := s["enum"].([]string); ok && !contains(enum, str) {
+			vd.errorf(path, "invalid value %q; expected one of %s", str, strings.Join(enum, ", "))
+		}
+		if max, ok := s["maxLength"].(int); ok {
+			if n := utf8.RuneCountInString(str); n > max {
+				vd.errorf(path, "text is %d characters long; the limit is %d", n, max)
+			}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			vd.errorf(path, "expected true or false, got %s", jsonType(v))
+		}
+	case "integer":
+		if n, ok := v.(float64); !ok || n != float64(int64(n)) {
+			vd.errorf(path, "expected an integer, got %s", jsonType(v))
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			vd.errorf(path, "expected a number, got %s", jsonType(v))
+		}
+	}
+}
+
+// widgetKinds are the fields of a widget of which exactly one must be set.
+var widgetKinds = []string{
+	"textParagraph", "image", "decoratedText", "buttonList", "textInput",
+	"selectionInput", "dateTimePicker", "divider", "grid", "columns", "chipList",
+}
+
+// onClickKinds are the fields of an onClick of which exactly one must be set.
+var onClickKinds = []string{"action", "openLink", "openDynamicLinkAction", "card"}
+
+// semantics applies the rules a schema cannot express, walking the payload
+// by field name: one kind per widget and onClick, buttons with a label,
+// unique card IDs, and links with a URL scheme.
+func (vd *validator) semantics(path string, v interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		for i, item := range v {
+			vd.semantics(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+		return
+	case map[string]interface{}:
+		for _, name := range sortedKeys(v) {
+			child := join(path, name)
+			switch name {
+			case "widgets":
+				vd.eachObject(child, v[name], vd.widget)
+			case "onClick":
+				if obj, ok := v[name].(map[string]interface{}); ok {
+					vd.onClick(child, obj)
+				}
+			case "buttons", "primaryButton", "secondaryButton", "button":
+				vd.eachObject(child, v[name], vd.button)
+			case "sections":
+				if list, ok := v[name].([]interface{}); ok && len(list) == 0 {
+					vd.errorf(child, "a card needs at least one section")
+				}
+			case "cardsV2":
+				vd.cardIDs(child, v[name])
+			}
+			vd.semantics(child, v[name])
+		}
+	}
+}
+
+// top checks the payload as a whole: a message needs content and must fit
+// the size limit, and a card needs sections.
+func (vd *validator) top(name string, v interface{}) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	switch name {
+	case "message":
+		if len(presentKeys(obj, []string{"text", "cardsV2", "attachment"})) == 0 {
+			vd.errorf("", "message has no text, cardsV2, or attachment")
+		}
+		if data, err := json.Marshal(obj); err == nil && len(data) > maxMessageBytes {
+			vd.errorf("", "message is %d bytes; the limit is %d", len(data), maxMessageBytes)
+		}
+	case "card":
+		path := ""
+		if card, ok := obj["card"].(map[string]interface{}); ok {
+			obj, path = card, "card"
+		}
+		if _, ok := obj["sections"]; !ok {
+			vd.errorf(path, "a card needs at least one section")
+		}
+	}
+}
+
+// eachObject calls fn for v if it is an object, or for each object in v if
+// it is an array.
+func (vd *validator) eachObject(path string, v interface{}, fn func(string, map[string]interface{})) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		fn(path, v)
+	case []interface{}:
+		for i, item := range v {
+			if obj, ok := item.(map[string]interface{}); ok {
+				fn(fmt.Sprintf("%s[%d]", path, i), obj)
+			}
+		}
+	}
+}
+
+// widget checks that exactly one kind of widget is set.
+func (vd *validator) widget(path string, w map[string]interface{}) {
+	set := presentKeys(w, widgetKinds)
+	switch {
+	case len(set) == 0:
+		vd.errorf(path, "widget has no content; set one of %s", strings.Join(widgetKinds, ", "))
+	case len(set) > 1:
+		vd.errorf(path, "widget sets %s; a widget holds exactly one kind, put each in its own widget", strings.Join(set, " and "))
+	}
+	if dt, ok := w["decoratedText"].(map[string]interface{}); ok {
+		if _, b := dt["button"]; b {
+			if _, s := dt["switchControl"]; s {
+				vd.errorf(join(path, "decoratedText"), "set either button or switchControl, not both")
+			}
+		}
+	}
+	if si, ok := w["selectionInput"].(map[string]interface{}); ok {
+		if items, _ := si["items"].([]interface{}); len(items) == 0 {
+			vd.warnf(join(path, "selectionInput"), "selection input has no items")
+		}
+	}
+}
+
+// onClick checks that exactly one click effect is set and links have a
+// URL scheme.
+func (vd *validator) onClick(path string, oc map[string]interface{}) {
+	set := presentKeys(oc, onClickKinds)
+	switch {
+	case len(set) == 0:
+		vd.errorf(path, "onClick does nothing; set one of %s", strings.Join(onClickKinds, ", "))
+	case len(set) > 1:
+		vd.errorf(path, "onClick sets %s; set exactly one", strings.Join(set, " and "))
+	}
+	if link, ok := oc["openLink"].(map[string]interface{}); ok {
+		if url, _ := link["url"].(string); url != "" && !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+			vd.warnf(join(join(path, "openLink"), "url"), "link %q has no http:// or https:// scheme", url)
+		}
+	}
+}
+
+// button checks that a button has a label. A missing onClick is reported
+// by the schema.
+func (vd *validator) button(path string, b map[string]interface{}) {
+	_, hasText := b["text"]
+	_, hasIcon := b["icon"]
+	if !hasText && !hasIcon {
+		vd.errorf(path, "button has neither text nor icon")
+	}
+}
+
+// cardIDs checks that the cards of a message have unique IDs.
+func (vd *validator) cardIDs(path string, v interface{}) {
+	list, _ := v.([]interface{})
+	seen := map[string]bool{}
+	for i, item := range list {
+		card, _ := item.(map[string]interface{})
+		id, _ := card["cardId"].(string)
+		if id == "" {
+			continue
+		}
+		if seen[id] {
+			vd.errorf(fmt.Sprintf("%s[%d].cardId", path, i), "duplicate cardId %q", id)
+		}
+		seen[id] = true
+	}
+}
+
+// join appends a field name to a path.
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// presentKeys returns the keys of obj that are in keys.
+func presentKeys(obj map[string]interface{}, keys []string) []string {
+	var set []string
+	for _, k := range keys {
+		if _, ok := obj[k]; ok {
+			set = append(set, k)
+		}
+	}
+	return set
+}
+
+// sortedKeys returns the keys of obj in order, for stable output.
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	}
+	return "null"
+}
@@ -0,0 +1,171 @@
+// Package timeparse parses the human-friendly points in time accepted by
+// --since and --until, such as "2 days ago", "yesterday 9am", or RFC 3339.
+package timeparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// agoPattern matches relative times such as "2 days ago" or "an hour ago".
+	agoPattern = regexp.MustCompile(`^(\d+|an?)\s*([a-z]+)\s+ago$`)
+	// shortPattern matches compact relative times such as "90m" or "3d".
+	shortPattern = regexp.MustCompile(`^(\d+)(s|m|h|d|w)$`)
+	// clockPattern matches a time of day such as "9am", "9:30pm", or "14:00".
+	clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+)
+
+// dateLayouts are the absolute formats accepted besides RFC 3339, in the
+// local time zone.
+var dateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// units maps the accepted unit spellings to their duration. Months and
+// years are handled separately because their length varies.
+var units = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+// Parse converts s into a point in time relative to now. It accepts:
+//
+//   - RFC 3339 ("2026-02-01T09:00:00Z") and local dates ("2026-02-01",
+//     "2026-02-01 09:00")
+//   - relative times ("2 days ago", "an hour ago", "3h", "1w")
+//   - "now", "today", "yesterday", and weekday names ("monday",
+//     "last friday"), optionally followed by a time of day ("9am",
+//     "14:30"); day names without a time mean midnight
+func Parse(s string, now time.Time) (time.Time, error) {
+	in := strings.ToLower(strings.Join(strings.Fields(s), " "))
+	if in == "" {
+		return time.Time{}, fmt.Errorf("empty time")
+	}
+
+	if t, err := time.Parse(time.RFC3339Nano, strings.ToUpper(in)); err == nil {
+		return t, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, in, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if in == "now" {
+		return now, nil
+	}
+	if m := shortPattern.FindStringSubmatch(in); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return now.Add(-time.Duration(n) * units[m[2]]), nil
+	}
+	if m := agoPattern.FindStringSubmatch(in); m != nil {
+		return ago(m[1], m[2], now)
+	}
+
+	day, clock, _ := strings.Cut(in, " ")
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var base time.Time
+	switch day {
+	case "today":
+		base = midnight
+	case "yesterday":
+		base = midnight.AddDate(0, 0, -1)
+	case "last":
+		// "last friday [9am]"
+		day, clock, _ = strings.Cut(clock, " ")
+		fallthrough
+	default:
+		wd, ok := weekday(day)
+		if !ok {
+			return time.Time{}, fmt.Errorf("unrecognized time %q (try \"2 days ago\", \"yesterday 9am\", or 2026-02-01T09:00:00Z)", s)
+		}
+		// The most recent such day strictly before today.
+		diff := (int(now.Weekday()) - int(wd) + 7) % 7
+		if diff == 0 {
+			diff = 7
+		}
+		base = midnight.AddDate(0, 0, -diff)
+	}
+
+	if clock == "" {
+		return base, nil
+	}
+	hour, min, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unrecognized time of day in %q: %w", s, err)
+	}
+	return base.Add(time.Duration(hour)*time.Hour + time.Duration(min)*time.Minute), nil
+}
+
+// ago subtracts count units from now.
+func ago(count, unit string, now time.Time) (time.Time, error) {
+	n := 1
+	if count != "a" && count != "an" {
+		n, _ = strconv.Atoi(count)
+	}
+
+	switch strings.TrimSuffix(unit, "s") {
+	case "month":
+		return now.AddDate(0, -n, 0), nil
+	case "year":
+		return now.AddDate(-n, 0, 0), nil
+	}
+	d, ok := units[unit]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unknown time unit %q", unit)
+	}
+	return now.Add(-time.Duration(n) * d), nil
+}
+
+// parseClock parses a time of day such as "9am", "9:30pm", or "14:00".
+func parseClock(s string) (int, int, error) {
+	m := clockPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, fmt.Errorf("expected a time such as 9am or 14:30, got %q", s)
+	}
+	hour, _ := strconv.Atoi(m[1])
+	min := 0
+	if m[2] != "" {
+		min, _ = strconv.Atoi(m[2])
+	}
+
+	switch m[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, fmt.Errorf("hour %d out of range for %s", hour, m[3])
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	default:
+		if hour > 23 {
+			return 0, 0, fmt.Errorf("hour %d out of range", hour)
+		}
+	}
+	if min > 59 {
+		return 0, 0, fmt.Errorf("minute %d out of range", min)
+	}
+	return hour, min, nil
+}
+
+// weekday parses a full or three-letter weekday name.
+func weekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, true
+		}
+	}
+	return 0, false
+}
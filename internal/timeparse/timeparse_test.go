@@ -0,0 +1,67 @@
+package timeparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// Wednesday, 2026-03-04 15:30 UTC.
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	day := func(d, hour, min int) time.Time {
+		return time.Date(2026, 3, d, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "2026-02-01T09:00:00Z", want: time.Date(2026, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{in: "2026-02-01t09:00:00+01:00", want: time.Date(2026, 2, 1, 8, 0, 0, 0, time.UTC)},
+		{in: "2026-02-01", want: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{in: "2026-02-01 09:15", want: time.Date(2026, 2, 1, 9, 15, 0, 0, time.UTC)},
+		{in: "now", want: now},
+		{in: "  NOW ", want: now},
+		{in: "90m", want: now.Add(-90 * time.Minute)},
+		{in: "3d", want: now.Add(-3 * 24 * time.Hour)},
+		{in: "1w", want: now.Add(-7 * 24 * time.Hour)},
+		{in: "2 days ago", want: now.Add(-2 * 24 * time.Hour)},
+		{in: "an hour ago", want: now.Add(-time.Hour)},
+		{in: "a week ago", want: now.Add(-7 * 24 * time.Hour)},
+		{in: "3 months ago", want: time.Date(2025, 12, 4, 15, 30, 0, 0, time.UTC)},
+		{in: "1 year ago", want: time.Date(2025, 3, 4, 15, 30, 0, 0, time.UTC)},
+		{in: "today", want: day(4, 0, 0)},
+		{in: "yesterday", want: day(3, 0, 0)},
+		{in: "yesterday 9am", want: day(3, 9, 0)},
+		{in: "yesterday 9:30pm", want: day(3, 21, 30)},
+		{in: "today 14:00", want: day(4, 14, 0)},
+		{in: "today 12am", want: day(4, 0, 0)},
+		{in: "monday", want: day(2, 0, 0)},
+		{in: "last friday 9am", want: time.Date(2026, 2, 27, 9, 0, 0, 0, time.UTC)},
+		{in: "wed", want: time.Date(2026, 2, 25, 0, 0, 0, 0, time.UTC)},
+		{in: "", wantErr: true},
+		{in: "soon", wantErr: true},
+		{in: "2 fortnights ago", wantErr: true},
+		{in: "yesterday 25:00", wantErr: true},
+		{in: "yesterday 13pm", wantErr: true},
+		{in: "today 9:75", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %v, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
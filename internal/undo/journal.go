@@ -0,0 +1,165 @@
+// Package undo keeps a local journal of destructive operations so that
+// deleted resources can be re-created later with "gogchat undo".
+package undo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Resource kinds recorded in the journal.
+const (
+	KindMessage    = "message"
+	KindMembership = "membership"
+	KindSpace      = "space"
+	KindReaction   = "reaction"
+)
+
+// maxEntries caps the journal size; the oldest entries are dropped first.
+const maxEntries = 1000
+
+// Entry is a single deleted resource. Entries recorded by the same command
+// share an Op ID and are restored together.
+type Entry struct {
+	Op       string          `json:"op"`
+	Time     time.Time       `json:"time"`
+	Command  string          `json:"command"`
+	Kind     string          `json:"kind"`
+	Name     string          `json:"name"`
+	Resource json.RawMessage `json:"resource"`
+}
+
+// Journal is an append-only JSON Lines file of deleted resources.
+type Journal struct {
+	path string
+}
+
+// Open returns the journal stored at path. The file is created on the first
+// Record call.
+func Open(path string) *Journal {
+	return &Journal{path: path}
+}
+
+// NewOpID returns an ID for a new destructive operation.
+func NewOpID() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// Record appends entries to the journal, dropping the oldest entries once
+// the journal grows beyond its size limit.
+func (j *Journal) Record(entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	all, err := j.entries()
+	if err != nil {
+		return err
+	}
+	all = append(all, entries...)
+	if len(all) > maxEntries {
+		all = all[len(all)-maxEntries:]
+	}
+	return j.write(all)
+}
+
+// Last returns the entries of the most recent operation, or nil if the
+// journal is empty.
+func (j *Journal) Last() ([]Entry, error) {
+	all, err := j.entries()
+	if err != nil || len(all) == 0 {
+		return nil, err
+	}
+
+	op := all[len(all)-1].Op
+	var last []Entry
+	for _, e := range all {
+		if e.Op == op {
+			last = append(last, e)
+		}
+	}
+	return last, nil
+}
+
+// Remove deletes the named entries of operation op from the journal.
+func (j *Journal) Remove(op string, names ...string) error {
+	drop := make(map[string]bool, len(names))
+	for _, name := range names {
+		drop[name] = true
+	}
+
+	all, err := j.entries()
+	if err != nil {
+		return err
+	}
+	kept := all[:0]
+	for _, e := range all {
+		if e.Op == op && drop[e.Name] {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return j.write(kept)
+}
+
+// entries reads every entry in the journal. A missing file is an empty
+// journal.
+func (j *Journal) entries() ([]Entry, error) {
+	data, err := os.ReadFile(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading undo journal %s: %w", j.path, err)
+	}
+
+	var all []Entry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("parsing undo journal %s: %w", j.path, err)
+		}
+		all = append(all, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading undo journal %s: %w", j.path, err)
+	}
+	return all, nil
+}
+
+// write replaces the journal contents with entries. The file holds message
+// contents, so it is only readable by the current user.
+func (j *Journal) write(entries []Entry) error {
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return fmt.Errorf("creating undo journal directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("encoding undo entry: %w", err)
+		}
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("writing undo journal %s: %w", j.path, err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("writing undo journal %s: %w", j.path, err)
+	}
+	return nil
+}